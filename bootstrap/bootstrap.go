@@ -0,0 +1,165 @@
+// Package bootstrap installs the external binaries the library shells out to at pinned versions into a
+// managed bin dir that is prepended to PATH, so tests don't depend on whatever happens to be installed.
+package bootstrap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/flanksource/commons/logger"
+	"github.com/flanksource/deps"
+
+	"github.com/flanksource/commons-test/config"
+)
+
+// Versions are the pinned versions of the tools installed by Ensure, overridden by config Tools
+var Versions = map[string]string{
+	"kubectl": "v1.33.2",
+	"helm":    "v3.18.4",
+	"kind":    "v0.29.0",
+	"jq":      "1.7.1",
+}
+
+// External are tools that cannot be installed and must already be on the PATH
+var External = []string{"docker"}
+
+// Tool is the outcome of ensuring a single binary
+type Tool struct {
+	Name    string
+	Version string
+	Path    string
+	// Status is the deps install status, found for External tools, or missing
+	Status string
+	Error  error
+}
+
+// Result lists every tool checked by Ensure
+type Result struct {
+	BinDir string
+	Tools  []Tool
+}
+
+// Missing returns the tools that could not be installed or found
+func (r Result) Missing() []Tool {
+	var missing []Tool
+	for _, tool := range r.Tools {
+		if tool.Error != nil {
+			missing = append(missing, tool)
+		}
+	}
+	return missing
+}
+
+func (r Result) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "tools in %s:\n", r.BinDir)
+	for _, tool := range r.Tools {
+		fmt.Fprintf(&b, "  %-8s %-10s %s", tool.Name, tool.Version, tool.Status)
+		if tool.Error != nil {
+			fmt.Fprintf(&b, ": %v", tool.Error)
+		} else if tool.Path != "" {
+			fmt.Fprintf(&b, " (%s)", tool.Path)
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// BinDir returns the configured BinDir, defaulting to bin in the CacheDir
+func BinDir() string {
+	c := config.Get()
+	if c.BinDir != "" {
+		return c.BinDir
+	}
+	return filepath.Join(c.CacheDir, "bin")
+}
+
+var pathOnce sync.Once
+
+// Ensure installs the named tools, or every tool in Versions and External when none are named, and prepends
+// BinDir to PATH. It fails with the full result when any tool is missing.
+func Ensure(ctx context.Context, tools ...string) (*Result, error) {
+	if len(tools) == 0 {
+		for name := range Versions {
+			tools = append(tools, name)
+		}
+		sort.Strings(tools)
+		tools = append(tools, External...)
+	}
+
+	binDir := BinDir()
+	if err := os.MkdirAll(binDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create bin dir: %w", err)
+	}
+	pathOnce.Do(func() { prependPath(binDir) })
+
+	result := &Result{BinDir: binDir}
+	for _, name := range tools {
+		result.Tools = append(result.Tools, ensure(ctx, binDir, name))
+	}
+
+	if missing := result.Missing(); len(missing) > 0 {
+		var names []string
+		for _, tool := range missing {
+			names = append(names, tool.Name)
+		}
+		return result, fmt.Errorf("missing %s\n%s", strings.Join(names, ", "), result)
+	}
+	logger.Debugf("%s", result)
+	return result, nil
+}
+
+// MustEnsure calls Ensure and exits when any tool is missing, for use in TestMain or a suite's init
+func MustEnsure(tools ...string) *Result {
+	result, err := Ensure(context.Background(), tools...)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "bootstrap failed: %v\n", err)
+		os.Exit(1)
+	}
+	return result
+}
+
+func ensure(ctx context.Context, binDir, name string) Tool {
+	version, pinned := config.Get().Tools[name]
+	if !pinned {
+		version, pinned = Versions[name]
+	}
+	if !pinned {
+		tool := Tool{Name: name, Status: "found"}
+		if tool.Path, tool.Error = exec.LookPath(name); tool.Error != nil {
+			tool.Status = "missing"
+			tool.Error = errors.New("not found on PATH and cannot be installed")
+		}
+		return tool
+	}
+
+	tool := Tool{Name: name, Version: version}
+	installed, err := deps.InstallWithContext(ctx, name, version, deps.WithBinDir(binDir))
+	if err != nil {
+		tool.Status, tool.Error = "missing", err
+		return tool
+	}
+	tool.Status = string(installed.Status)
+	tool.Path = filepath.Join(binDir, name)
+	if installed.Version.Version != "" {
+		tool.Version = installed.Version.Version
+	}
+	return tool
+}
+
+func prependPath(dir string) {
+	path := os.Getenv("PATH")
+	for _, entry := range filepath.SplitList(path) {
+		if entry == dir {
+			return
+		}
+	}
+	os.Setenv("PATH", dir+string(os.PathListSeparator)+path)
+}
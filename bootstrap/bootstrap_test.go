@@ -0,0 +1,37 @@
+package bootstrap
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/flanksource/commons-test/config"
+)
+
+func TestEnsureExternal(t *testing.T) {
+	t.Setenv("PATH", os.Getenv("PATH"))
+	c := config.Default()
+	c.BinDir = t.TempDir()
+	config.Set(c)
+	defer config.Set(nil)
+
+	result, err := Ensure(context.Background(), "sh", "commons-test-missing-tool")
+	if err == nil {
+		t.Fatal("expected an error for a missing tool")
+	}
+	if !strings.Contains(err.Error(), "missing commons-test-missing-tool") {
+		t.Errorf("expected the missing tool in the error, got %v", err)
+	}
+	if missing := result.Missing(); len(missing) != 1 || missing[0].Name != "commons-test-missing-tool" {
+		t.Errorf("expected only the unknown tool to be missing, got %+v", missing)
+	}
+	if result.Tools[0].Status != "found" {
+		t.Errorf("expected sh to be found, got %s", result.Tools[0].Status)
+	}
+
+	if first := filepath.SplitList(os.Getenv("PATH"))[0]; first != c.BinDir {
+		t.Errorf("expected %s to be prepended to PATH, got %s", c.BinDir, first)
+	}
+}
@@ -0,0 +1,107 @@
+// Package budget gives each setup phase (cluster create, chart install, container readiness) a maximum
+// duration. A phase that exceeds its budget is cancelled and fails with diagnostics collected, instead of
+// running into the overall ginkgo timeout that hides which step was slow.
+package budget
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/flanksource/commons/logger"
+
+	"github.com/flanksource/commons-test/command"
+	"github.com/flanksource/commons-test/config"
+	"github.com/flanksource/commons-test/diagnostics"
+	"github.com/flanksource/commons-test/report"
+)
+
+// ExceededError is returned by Run when a phase runs longer than its budget
+type ExceededError struct {
+	Category string
+	Name     string
+	Budget   time.Duration
+	// Diagnostics is the directory diagnostics were collected into, empty when collection failed
+	Diagnostics string
+}
+
+func (e *ExceededError) Error() string {
+	msg := fmt.Sprintf("%s %s exceeded its budget of %v", e.Category, e.Name, e.Budget)
+	if e.Diagnostics != "" {
+		msg += ", diagnostics written to " + e.Diagnostics
+	}
+	return msg
+}
+
+// errExceeded is the cause of the context passed to fn when the budget runs out
+var errExceeded = errors.New("budget exceeded")
+
+var budgets struct {
+	sync.Mutex
+	overrides map[string]time.Duration
+}
+
+// Set overrides the configured budget of category, 0 removes the limit
+func Set(category string, d time.Duration) {
+	budgets.Lock()
+	defer budgets.Unlock()
+	if budgets.overrides == nil {
+		budgets.overrides = map[string]time.Duration{}
+	}
+	budgets.overrides[category] = d
+}
+
+// Reset removes every override made with Set
+func Reset() {
+	budgets.Lock()
+	defer budgets.Unlock()
+	budgets.overrides = nil
+}
+
+// Get returns the budget of category from Set or the configured Budgets, 0 when unlimited
+func Get(category string) time.Duration {
+	budgets.Lock()
+	d, ok := budgets.overrides[category]
+	budgets.Unlock()
+	if ok {
+		return d
+	}
+	return config.Get().Budget(category)
+}
+
+// Run runs fn as a report step, failing with an ExceededError when it takes longer than the budget of
+// category. The context passed to fn is cancelled when the budget runs out, and Run waits for fn to
+// return before reporting the failure so that a failed step stops changing shared state.
+func Run(ctx context.Context, category, name string, fn func(ctx context.Context) error) error {
+	return report.Track(category, name, func() error {
+		limit := Get(category)
+		if limit <= 0 {
+			return fn(ctx)
+		}
+
+		ctx, cancel := context.WithTimeoutCause(ctx, limit, errExceeded)
+		defer cancel()
+		err := fn(ctx)
+		if errors.Is(context.Cause(ctx), errExceeded) {
+			if err != nil {
+				logger.Debugf("%s %s failed after exceeding its budget: %v", category, name, err)
+			}
+			return exceeded(category, name, limit)
+		}
+		return err
+	})
+}
+
+func exceeded(category, name string, limit time.Duration) error {
+	err := &ExceededError{Category: category, Name: name, Budget: limit}
+	dir := command.ArtifactsDir(filepath.Join(config.Get().ArtifactsDir, "budget"), category+" "+name)
+	if collectErr := diagnostics.Collect(dir); collectErr != nil {
+		logger.Warnf("failed to collect diagnostics for %s %s: %v", category, name, collectErr)
+	} else {
+		err.Diagnostics = dir
+	}
+	return err
+}
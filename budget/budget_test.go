@@ -0,0 +1,47 @@
+package budget
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/flanksource/commons-test/config"
+)
+
+func TestRun(t *testing.T) {
+	c := config.Default()
+	c.ArtifactsDir = t.TempDir()
+	c.Budgets["chart"] = config.Duration(time.Hour)
+	config.Set(c)
+	defer config.Set(nil)
+	defer Reset()
+
+	if err := Run(context.Background(), "chart", "fast", func(context.Context) error { return nil }); err != nil {
+		t.Fatal(err)
+	}
+
+	Set("chart", 10*time.Millisecond)
+	returned := false
+	err := Run(context.Background(), "chart", "slow", func(ctx context.Context) error {
+		<-ctx.Done()
+		time.Sleep(10 * time.Millisecond)
+		returned = true
+		return ctx.Err()
+	})
+	if !returned {
+		t.Error("expected Run to wait for fn to return after cancelling it")
+	}
+
+	var exceeded *ExceededError
+	if !errors.As(err, &exceeded) {
+		t.Fatalf("expected an ExceededError, got %v", err)
+	}
+	if exceeded.Budget != 10*time.Millisecond || exceeded.Diagnostics == "" {
+		t.Errorf("expected the budget and diagnostics dir, got %+v", exceeded)
+	}
+
+	if Get("cluster") != 0 {
+		t.Error("expected phases without a budget to be unlimited")
+	}
+}
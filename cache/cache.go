@@ -0,0 +1,91 @@
+// Package cache remembers across test runs when network work like `helm repo update` or `docker pull`
+// last succeeded, so repeated runs within a TTL can skip it. Each refresh is a stamp file in a local
+// directory whose modification time is the time of the refresh.
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/flanksource/commons-test/config"
+)
+
+// Kinds of cached work
+const (
+	Repository = "repository"
+	Image      = "image"
+)
+
+// Stamps tracks when keys were last refreshed
+type Stamps struct {
+	dir string
+	ttl time.Duration
+}
+
+// New returns stamps stored in dir that stay fresh for ttl, a ttl of 0 disables caching
+func New(dir string, ttl time.Duration) *Stamps {
+	return &Stamps{dir: dir, ttl: ttl}
+}
+
+// Default returns stamps using the configured CacheDir and CacheTTL
+func Default() *Stamps {
+	c := config.Get()
+	return New(c.CacheDir, time.Duration(c.CacheTTL))
+}
+
+// Fresh returns true if key was refreshed within the TTL
+func (s *Stamps) Fresh(kind, key string) bool {
+	if s.ttl <= 0 {
+		return false
+	}
+	info, err := os.Stat(s.path(kind, key))
+	return err == nil && time.Since(info.ModTime()) < s.ttl
+}
+
+// Touch records that key was refreshed now
+func (s *Stamps) Touch(kind, key string) error {
+	path := s.path(kind, key)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create cache dir: %w", err)
+	}
+	if err := os.WriteFile(path, []byte(key), 0644); err != nil {
+		return fmt.Errorf("failed to write cache stamp: %w", err)
+	}
+	return nil
+}
+
+// Do runs fn unless key is fresh, recording the refresh when fn succeeds
+func (s *Stamps) Do(kind, key string, fn func() error) error {
+	if s.Fresh(kind, key) {
+		return nil
+	}
+	if err := fn(); err != nil {
+		return err
+	}
+	return s.Touch(kind, key)
+}
+
+// Invalidate forgets the refresh of key, e.g. after a failure caused by stale data
+func (s *Stamps) Invalidate(kind, key string) error {
+	if err := os.Remove(s.path(kind, key)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove cache stamp: %w", err)
+	}
+	return nil
+}
+
+// Clear forgets every refresh
+func (s *Stamps) Clear() error {
+	if err := os.RemoveAll(s.dir); err != nil {
+		return fmt.Errorf("failed to clear cache dir: %w", err)
+	}
+	return nil
+}
+
+func (s *Stamps) path(kind, key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(s.dir, kind, hex.EncodeToString(sum[:8]))
+}
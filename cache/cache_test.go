@@ -0,0 +1,40 @@
+package cache
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestDo(t *testing.T) {
+	s := New(t.TempDir(), time.Hour)
+
+	runs := 0
+	refresh := func() error { runs++; return nil }
+	for i := 0; i < 3; i++ {
+		if err := s.Do(Image, "postgres:16", refresh); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if runs != 1 {
+		t.Errorf("expected a single refresh within the TTL, got %d", runs)
+	}
+
+	if err := s.Do(Image, "redis:7", func() error { return errors.New("pull failed") }); err == nil {
+		t.Error("expected the error of fn")
+	}
+	if s.Fresh(Image, "redis:7") {
+		t.Error("expected a failed refresh not to be recorded")
+	}
+
+	if err := s.Invalidate(Image, "postgres:16"); err != nil {
+		t.Fatal(err)
+	}
+	if s.Fresh(Image, "postgres:16") {
+		t.Error("expected an invalidated key to be stale")
+	}
+
+	if New(t.TempDir(), 0).Do(Repository, "flanksource", refresh); runs != 2 {
+		t.Error("expected a TTL of 0 to always refresh")
+	}
+}
@@ -0,0 +1,157 @@
+// Package chaos injects failures into workloads on the current kubectl context, so resilience scenarios
+// can be expressed as one-liners against kind clusters. Helpers that leave the cluster degraded return a
+// restore function that undoes the failure.
+package chaos
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/flanksource/clicky"
+	"github.com/flanksource/clicky/exec"
+	"sigs.k8s.io/yaml"
+
+	"github.com/flanksource/commons-test/names"
+)
+
+// PartitionLabel marks the NetworkPolicies created by PartitionNetwork
+const PartitionLabel = "commons-test/chaos-partition"
+
+var kubectl = clicky.Exec("kubectl").AsWrapper()
+
+// KillPod force deletes every pod matching selector in namespace without waiting for graceful shutdown,
+// returning the names of the killed pods
+func KillPod(ctx context.Context, namespace, selector string) ([]string, error) {
+	result, err := run(ctx, "delete", "pod", "-n", namespace, "-l", selector,
+		"--grace-period=0", "--force", "--wait=false", "-o", "name")
+	if err != nil {
+		return nil, fmt.Errorf("failed to kill pods %s in %s: %w", selector, namespace, err)
+	}
+	var pods []string
+	for _, line := range strings.Split(strings.TrimSpace(result), "\n") {
+		if line != "" {
+			pods = append(pods, strings.TrimPrefix(line, "pod/"))
+		}
+	}
+	if len(pods) == 0 {
+		return nil, fmt.Errorf("no pods found with selector %s in %s", selector, namespace)
+	}
+	return pods, nil
+}
+
+// DrainNode cordons the node and evicts its pods, the returned function uncordons it
+func DrainNode(ctx context.Context, name string) (func() error, error) {
+	if _, err := run(ctx, "drain", name, "--ignore-daemonsets", "--delete-emptydir-data", "--force", "--timeout=2m"); err != nil {
+		return nil, fmt.Errorf("failed to drain node %s: %w", name, err)
+	}
+	return func() error {
+		if _, err := run(context.Background(), "uncordon", name); err != nil {
+			return fmt.Errorf("failed to uncordon node %s: %w", name, err)
+		}
+		return nil
+	}, nil
+}
+
+// PartitionNetwork blocks traffic between the pods of two namespaces with NetworkPolicies denying ingress
+// from the other namespace, the returned function deletes them. Requires a CNI enforcing NetworkPolicies,
+// e.g. kindnet in kind v0.24+.
+func PartitionNetwork(ctx context.Context, nsA, nsB string) (func() error, error) {
+	manifest, err := partitionPolicies(nsA, nsB)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.CreateTemp("", names.TempPrefix("chaos-partition")+"*.yaml")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(file.Name())
+	if _, err := file.Write(manifest); err != nil {
+		file.Close()
+		return nil, err
+	}
+	file.Close()
+
+	if _, err := run(ctx, "apply", "-f", file.Name()); err != nil {
+		return nil, fmt.Errorf("failed to partition %s from %s: %w", nsA, nsB, err)
+	}
+
+	return func() error {
+		for _, ns := range []string{nsA, nsB} {
+			if _, err := run(context.Background(), "delete", "networkpolicy", "-n", ns, "-l", PartitionLabel, "--ignore-not-found"); err != nil {
+				return fmt.Errorf("failed to heal partition in %s: %w", ns, err)
+			}
+		}
+		return nil
+	}, nil
+}
+
+// partitionPolicies returns a NetworkPolicy in each namespace allowing ingress from every namespace
+// except the other one
+func partitionPolicies(nsA, nsB string) ([]byte, error) {
+	var docs []string
+	for _, pair := range [][2]string{{nsA, nsB}, {nsB, nsA}} {
+		data, err := yaml.Marshal(map[string]any{
+			"apiVersion": "networking.k8s.io/v1",
+			"kind":       "NetworkPolicy",
+			"metadata": map[string]any{
+				"name":      "chaos-partition-" + pair[1],
+				"namespace": pair[0],
+				"labels":    map[string]string{PartitionLabel: "true"},
+			},
+			"spec": map[string]any{
+				"podSelector": map[string]any{},
+				"policyTypes": []string{"Ingress"},
+				"ingress": []any{map[string]any{
+					"from": []any{map[string]any{
+						"namespaceSelector": map[string]any{
+							"matchExpressions": []any{map[string]any{
+								"key":      "kubernetes.io/metadata.name",
+								"operator": "NotIn",
+								"values":   []string{pair[1]},
+							}},
+						},
+					}},
+				}},
+			},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal network policy: %w", err)
+		}
+		docs = append(docs, string(data))
+	}
+	return []byte(strings.Join(docs, "---\n")), nil
+}
+
+// FillDisk writes a file of size bytes to path inside the pod, e.g. a volume mount, the returned function
+// removes it
+func FillDisk(ctx context.Context, namespace, pod, path string, size int64) (func() error, error) {
+	file := strings.TrimSuffix(path, "/") + "/chaos-fill"
+	script := fmt.Sprintf("head -c %d /dev/zero > %s", size, file)
+	if _, err := run(ctx, "exec", "-n", namespace, pod, "--", "sh", "-c", script); err != nil {
+		return nil, fmt.Errorf("failed to fill %s in %s/%s: %w", path, namespace, pod, err)
+	}
+	return func() error {
+		if _, err := run(context.Background(), "exec", "-n", namespace, pod, "--", "rm", "-f", file); err != nil {
+			return fmt.Errorf("failed to remove %s in %s/%s: %w", file, namespace, pod, err)
+		}
+		return nil
+	}, nil
+}
+
+func run(ctx context.Context, args ...string) (string, error) {
+	argv := []any{exec.WithContext(ctx)}
+	for _, arg := range args {
+		argv = append(argv, arg)
+	}
+	result, err := kubectl(argv...)
+	if err != nil {
+		if result != nil && result.Stderr != "" {
+			return "", fmt.Errorf("%w: %s", err, strings.TrimSpace(result.Stderr))
+		}
+		return "", err
+	}
+	return result.Stdout, nil
+}
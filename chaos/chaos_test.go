@@ -0,0 +1,46 @@
+package chaos
+
+import (
+	"strings"
+	"testing"
+
+	"sigs.k8s.io/yaml"
+)
+
+func TestPartitionPolicies(t *testing.T) {
+	data, err := partitionPolicies("frontend", "backend")
+	if err != nil {
+		t.Fatal(err)
+	}
+	docs := strings.Split(string(data), "---\n")
+	if len(docs) != 2 {
+		t.Fatalf("expected a policy per namespace, got %d", len(docs))
+	}
+
+	var policy struct {
+		Metadata struct {
+			Name      string `json:"name"`
+			Namespace string `json:"namespace"`
+		} `json:"metadata"`
+		Spec struct {
+			Ingress []struct {
+				From []struct {
+					NamespaceSelector struct {
+						MatchExpressions []struct {
+							Values []string `json:"values"`
+						} `json:"matchExpressions"`
+					} `json:"namespaceSelector"`
+				} `json:"from"`
+			} `json:"ingress"`
+		} `json:"spec"`
+	}
+	if err := yaml.Unmarshal([]byte(docs[1]), &policy); err != nil {
+		t.Fatal(err)
+	}
+	if policy.Metadata.Namespace != "backend" || policy.Metadata.Name != "chaos-partition-frontend" {
+		t.Errorf("unexpected metadata %+v", policy.Metadata)
+	}
+	if got := policy.Spec.Ingress[0].From[0].NamespaceSelector.MatchExpressions[0].Values; len(got) != 1 || got[0] != "frontend" {
+		t.Errorf("expected ingress from frontend to be denied, got %v", got)
+	}
+}
@@ -0,0 +1,161 @@
+// Package cleanup tracks the infrastructure created by a test run (clusters, containers, namespaces,
+// temp files) and removes it on normal exit, panic, or SIGINT/SIGTERM.
+package cleanup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/flanksource/commons/logger"
+)
+
+// KeepEnv is the environment variable that keeps every resource after the run, e.g.
+// KEEP_RESOURCES=true to inspect them after a failure
+const KeepEnv = "KEEP_RESOURCES"
+
+// Timeout bounds the cleanup run on a signal or panic
+var Timeout = 2 * time.Minute
+
+// Keep returns true when resources should be left behind
+func Keep() bool {
+	return os.Getenv(KeepEnv) == "true"
+}
+
+type entry struct {
+	id   int
+	name string
+	fn   func(ctx context.Context) error
+}
+
+var registry struct {
+	sync.Mutex
+	next    int
+	entries []entry
+	signals sync.Once
+}
+
+// Register adds fn to the cleanups run by Run, and starts handling SIGINT/SIGTERM. Call the returned
+// func once the resource has been removed normally so it is not removed again.
+func Register(name string, fn func(ctx context.Context) error) (unregister func()) {
+	registry.signals.Do(handleSignals)
+
+	registry.Lock()
+	defer registry.Unlock()
+	registry.next++
+	id := registry.next
+	registry.entries = append(registry.entries, entry{id: id, name: name, fn: fn})
+	return func() {
+		registry.Lock()
+		defer registry.Unlock()
+		for i, e := range registry.entries {
+			if e.id == id {
+				registry.entries = append(registry.entries[:i], registry.entries[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// RemoveFile registers a temp file for removal
+func RemoveFile(path string) (unregister func()) {
+	return Register("remove "+path, func(context.Context) error {
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		return nil
+	})
+}
+
+// Pending returns the names of the registered cleanups, most recent first
+func Pending() []string {
+	registry.Lock()
+	defer registry.Unlock()
+	var names []string
+	for i := len(registry.entries) - 1; i >= 0; i-- {
+		names = append(names, registry.entries[i].name)
+	}
+	return names
+}
+
+// Run runs and removes every registered cleanup in reverse order of registration, returning every
+// failure. Nothing is removed when KEEP_RESOURCES=true.
+func Run(ctx context.Context) error {
+	registry.Lock()
+	entries := registry.entries
+	registry.entries = nil
+	registry.Unlock()
+
+	if Keep() {
+		for _, e := range entries {
+			logger.Infof("Keeping %s (%s=true)", e.name, KeepEnv)
+		}
+		return nil
+	}
+
+	var errs []error
+	for i := len(entries) - 1; i >= 0; i-- {
+		e := entries[i]
+		logger.Infof("Cleaning up %s", e.name)
+		if err := e.fn(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("failed to clean up %s: %w", e.name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Main runs the tests and then the registered cleanups, also when the tests panic, e.g.
+//
+//	func TestMain(m *testing.M) { os.Exit(cleanup.Main(m.Run)) }
+func Main(run func() int) (code int) {
+	defer func() {
+		r := recover()
+		ctx, cancel := context.WithTimeout(context.Background(), Timeout)
+		defer cancel()
+		if err := Run(ctx); err != nil {
+			logger.Errorf("%v", err)
+			if code == 0 {
+				code = 1
+			}
+		}
+		if r != nil {
+			panic(r)
+		}
+	}()
+	return run()
+}
+
+// handleSignals runs the cleanups and exits on SIGINT or SIGTERM, a second signal exits immediately
+func handleSignals() {
+	signals := make(chan os.Signal, 2)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		sig := <-signals
+		logger.Warnf("Received %v, cleaning up (send again to exit immediately)", sig)
+
+		ctx, cancel := context.WithTimeout(context.Background(), Timeout)
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			if err := Run(ctx); err != nil {
+				logger.Errorf("%v", err)
+			}
+		}()
+		select {
+		case <-done:
+		case <-signals:
+		}
+		cancel()
+
+		code := 130
+		if sig == syscall.SIGTERM {
+			code = 143
+		}
+		os.Exit(code)
+	}()
+}
@@ -0,0 +1,248 @@
+// Command commons-test inspects and repairs the environment of commons-test suites, e.g. to debug a
+// broken CI runner interactively:
+//
+//	commons-test dump -run-id k3x9qa -dir artifacts/dump
+//	commons-test cleanup -run-id k3x9qa
+//	commons-test doctor
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/flanksource/commons-test/bootstrap"
+	"github.com/flanksource/commons-test/command"
+	"github.com/flanksource/commons-test/config"
+	"github.com/flanksource/commons-test/diagnostics"
+	"github.com/flanksource/commons-test/names"
+)
+
+const usage = `usage: commons-test <command> [flags]
+
+commands:
+  dump      write the state of every labelled namespace and container to a directory
+  cleanup   remove every container, network, namespace and kind cluster of a run
+  doctor    check that docker, kind, helm and kubectl are available
+`
+
+var runner = command.NewCommandRunner(false)
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	var err error
+	switch args := os.Args[2:]; os.Args[1] {
+	case "dump":
+		err = dump(args)
+	case "cleanup":
+		err = cleanup(args)
+	case "doctor":
+		err = doctor(args)
+	case "help", "-h", "--help":
+		fmt.Print(usage)
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n%s", os.Args[1], usage)
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// selector matches resources of runID, or of every run when runID is empty
+func selector(runID string) string {
+	if runID == "" {
+		return names.RunLabel
+	}
+	return names.RunLabel + "=" + runID
+}
+
+func dump(args []string) error {
+	fs := flag.NewFlagSet("dump", flag.ExitOnError)
+	runID := fs.String("run-id", os.Getenv(names.RunIDEnv), "only dump resources of this run, every run when empty")
+	dir := fs.String("dir", filepath.Join(config.Get().ArtifactsDir, "dump"), "directory to write to")
+	_ = fs.Parse(args)
+
+	// Containers are still dumped without a reachable cluster
+	namespaces, err := labelledNamespaces(*runID)
+	if err != nil {
+		warn(err)
+	}
+	containers, err := labelledContainers(*runID)
+	if err != nil {
+		return err
+	}
+	diagnostics.TrackNamespace(namespaces...)
+	for _, c := range containers {
+		diagnostics.TrackContainer(c)
+	}
+
+	if err := diagnostics.Collect(*dir); err != nil {
+		return fmt.Errorf("failed to dump state: %w", err)
+	}
+	fmt.Printf("Dumped %d namespaces and %d containers to %s\n", len(namespaces), len(containers), *dir)
+	return nil
+}
+
+func cleanup(args []string) error {
+	fs := flag.NewFlagSet("cleanup", flag.ExitOnError)
+	runID := fs.String("run-id", os.Getenv(names.RunIDEnv), "the run to remove resources of")
+	all := fs.Bool("all", false, "remove the containers, networks and namespaces of every run")
+	dryRun := fs.Bool("dry-run", false, "only print what would be removed")
+	_ = fs.Parse(args)
+
+	if *runID == "" && !*all {
+		return fmt.Errorf("either -run-id or -all is required")
+	}
+	if *all {
+		*runID = ""
+	}
+
+	// Every source that can be listed is cleaned up, e.g. containers without a reachable cluster
+	var errs []error
+	containers, err := labelledContainers(*runID)
+	errs = append(errs, err)
+	networks, err := labelledNetworks(*runID)
+	errs = append(errs, err)
+	namespaces, err := labelledNamespaces(*runID)
+	if err != nil {
+		warn(err)
+	}
+	clusters, err := runClusters(*runID)
+	if err != nil {
+		warn(err)
+	}
+
+	remove := func(kind, name string, cmd ...string) {
+		fmt.Printf("Removing %s %s\n", kind, name)
+		if *dryRun {
+			return
+		}
+		if result := runner.RunCommandQuiet(cmd[0], cmd[1:]...); result.Err != nil {
+			errs = append(errs, fmt.Errorf("failed to remove %s %s: %w %s", kind, name, result.Err, strings.TrimSpace(result.Stderr)))
+		}
+	}
+	for _, c := range containers {
+		remove("container", c, "docker", "rm", "-f", "-v", c)
+	}
+	// Networks can only be removed once their containers are
+	for _, n := range networks {
+		remove("network", n, "docker", "network", "rm", n)
+	}
+	for _, ns := range namespaces {
+		remove("namespace", ns, "kubectl", "delete", "namespace", ns, "--wait=false")
+	}
+	for _, cluster := range clusters {
+		remove("kind cluster", cluster, "kind", "delete", "cluster", "--name", cluster)
+	}
+	return errors.Join(errs...)
+}
+
+func warn(err error) {
+	fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+}
+
+func doctor(args []string) error {
+	fs := flag.NewFlagSet("doctor", flag.ExitOnError)
+	install := fs.Bool("install", false, "install missing tools at their pinned versions first")
+	_ = fs.Parse(args)
+
+	if *install {
+		result, err := bootstrap.Ensure(context.Background())
+		if err != nil {
+			return err
+		}
+		fmt.Print(result)
+	}
+
+	checks := []struct {
+		name string
+		args []string
+	}{
+		{"docker", []string{"info", "--format", "{{.ServerVersion}}"}},
+		{"kind", []string{"version"}},
+		{"helm", []string{"version", "--short"}},
+		{"kubectl", []string{"version", "--client"}},
+	}
+
+	var failed []string
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "TOOL\tSTATUS\tVERSION")
+	for _, check := range checks {
+		result := runner.RunCommandQuiet(check.name, check.args...)
+		if result.Err != nil {
+			failed = append(failed, check.name)
+			fmt.Fprintf(w, "%s\tmissing\t%s\n", check.name, firstLine(result.Stderr+result.Err.Error()))
+			continue
+		}
+		fmt.Fprintf(w, "%s\tok\t%s\n", check.name, firstLine(result.Stdout))
+	}
+	w.Flush()
+
+	if len(failed) > 0 {
+		return fmt.Errorf("unavailable: %s", strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+func labelledNamespaces(runID string) ([]string, error) {
+	result := runner.RunCommandQuiet("kubectl", "get", "namespaces", "-l", selector(runID), "-o", "name")
+	if result.Err != nil {
+		return nil, fmt.Errorf("failed to list namespaces: %w %s", result.Err, result.Stderr)
+	}
+	var namespaces []string
+	for _, line := range result.Lines() {
+		namespaces = append(namespaces, strings.TrimPrefix(line, "namespace/"))
+	}
+	return namespaces, nil
+}
+
+func labelledContainers(runID string) ([]string, error) {
+	result := runner.RunCommandQuiet("docker", "ps", "-a", "--filter", "label="+selector(runID), "--format", "{{.Names}}")
+	if result.Err != nil {
+		return nil, fmt.Errorf("failed to list containers: %w %s", result.Err, result.Stderr)
+	}
+	return result.Lines(), nil
+}
+
+func labelledNetworks(runID string) ([]string, error) {
+	result := runner.RunCommandQuiet("docker", "network", "ls", "--filter", "label="+selector(runID), "--format", "{{.Name}}")
+	if result.Err != nil {
+		return nil, fmt.Errorf("failed to list networks: %w %s", result.Err, result.Stderr)
+	}
+	return result.Lines(), nil
+}
+
+// runClusters returns the kind clusters of runID. Kind nodes can't carry the run label, so clusters are
+// matched on the run ID that names.New puts in their name.
+func runClusters(runID string) ([]string, error) {
+	if runID == "" {
+		return nil, nil
+	}
+	result := runner.RunCommandQuiet("kind", "get", "clusters")
+	if result.Err != nil {
+		return nil, fmt.Errorf("failed to list kind clusters: %w %s", result.Err, result.Stderr)
+	}
+	var clusters []string
+	for _, cluster := range result.Lines() {
+		if strings.Contains(cluster, "-"+names.Sanitize(runID)+"-") {
+			clusters = append(clusters, cluster)
+		}
+	}
+	return clusters, nil
+}
+
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(s), "\n")
+	return line
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSelector(t *testing.T) {
+	if got := selector(""); got != "commons-test/run-id" {
+		t.Errorf("expected every run to be matched, got %s", got)
+	}
+	if got := selector("k3x9qa"); got != "commons-test/run-id=k3x9qa" {
+		t.Errorf("expected the run to be matched, got %s", got)
+	}
+}
+
+func TestCleanup(t *testing.T) {
+	// kubectl and kind have no cluster to talk to, docker lists two containers and fails to remove one
+	bin := t.TempDir()
+	calls := filepath.Join(bin, "calls")
+	fake := func(name, script string) {
+		content := "#!/bin/sh\necho \"" + name + " $*\" >> " + calls + "\n" + script
+		if err := os.WriteFile(filepath.Join(bin, name), []byte(content), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	fake("kubectl", "echo 'connection refused' >&2\nexit 1\n")
+	fake("kind", "exit 1\n")
+	fake("docker", `case "$1 $2" in
+"ps -a") echo app; echo db ;;
+"network ls") echo net ;;
+"rm -f") [ "$4" = db ] && echo 'removal failed' >&2 && exit 1 ;;
+esac
+exit 0
+`)
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	err := cleanup([]string{"-run-id", "k3x9qa"})
+	if err == nil || !strings.Contains(err.Error(), "failed to remove container db") {
+		t.Errorf("expected the failed removal to be returned, got %v", err)
+	}
+	if err != nil && strings.Contains(err.Error(), "namespaces") {
+		t.Errorf("expected listing namespaces to only warn, got %v", err)
+	}
+
+	data, err := os.ReadFile(calls)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"docker rm -f -v app", "docker rm -f -v db", "docker network rm net"} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("expected %q to run, got:\n%s", want, data)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package command
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/flanksource/commons-test/logging"
+)
+
+var unsafePathChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// ArtifactsDir returns the folder for a test's command artifacts under root, e.g.
+// ArtifactsDir("artifacts", t.Name()) returns artifacts/TestInstall_with_values
+func ArtifactsDir(root, test string) string {
+	return filepath.Join(root, strings.Trim(unsafePathChars.ReplaceAllString(test, "_"), "_"))
+}
+
+// CommandMetadata is written to metadata.json next to each command's output
+type CommandMetadata struct {
+	Command  string    `json:"command"`
+	Args     []string  `json:"args,omitempty"`
+	Path     string    `json:"path,omitempty"`
+	Dir      string    `json:"dir,omitempty"`
+	ExitCode int       `json:"exit_code"`
+	Error    string    `json:"error,omitempty"`
+	TimedOut bool      `json:"timed_out,omitempty"`
+	Start    time.Time `json:"start"`
+	End      time.Time `json:"end"`
+	Duration string    `json:"duration"`
+}
+
+// artifacts numbers the commands written to dir, shared by every copy of a runner
+type artifacts struct {
+	mu  sync.Mutex
+	dir string
+	n   int
+}
+
+// WithArtifacts returns a copy of the runner that writes the stdout, stderr and metadata of each
+// command to its own numbered folder in dir, e.g. dir/003-kubectl/stdout.log, for CI to upload.
+// Masked values are redacted from every file.
+func (c *Runner) WithArtifacts(dir string) *Runner {
+	r := c.clone()
+	r.artifacts = &artifacts{dir: dir}
+	return r
+}
+
+func (a *artifacts) write(name string, args []string, dir string, result Result) error {
+	a.mu.Lock()
+	a.n++
+	folder := filepath.Join(a.dir, fmt.Sprintf("%03d-%s", a.n, unsafePathChars.ReplaceAllString(filepath.Base(name), "_")))
+	a.mu.Unlock()
+
+	if err := os.MkdirAll(folder, 0755); err != nil {
+		return fmt.Errorf("failed to create artifacts folder: %w", err)
+	}
+	metadata := CommandMetadata{
+		Command:  name,
+		Args:     redactArgs(args),
+		Path:     result.Path,
+		Dir:      dir,
+		ExitCode: result.ExitCode,
+		TimedOut: result.TimedOut,
+		Start:    result.Start,
+		End:      result.End,
+		Duration: result.Duration.String(),
+	}
+	if result.Err != nil {
+		metadata.Error = logging.Redact(result.Err.Error())
+	}
+	data, err := json.MarshalIndent(metadata, "", "  ")
+	if err != nil {
+		return err
+	}
+	for file, content := range map[string][]byte{
+		"stdout.log":    []byte(logging.Redact(result.Stdout)),
+		"stderr.log":    []byte(logging.Redact(result.Stderr)),
+		"metadata.json": data,
+	} {
+		if err := os.WriteFile(filepath.Join(folder, file), content, 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", file, err)
+		}
+	}
+	return nil
+}
+
+// redactArgs returns a copy of args with every masked value replaced by ***
+func redactArgs(args []string) []string {
+	if args == nil {
+		return nil
+	}
+	redacted := make([]string, len(args))
+	for i, arg := range args {
+		redacted[i] = logging.Redact(arg)
+	}
+	return redacted
+}
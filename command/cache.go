@@ -0,0 +1,93 @@
+package command
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Cache stores successful results of idempotent commands, e.g. `kind get clusters` or version checks,
+// so identical invocations within the TTL don't start another process
+type Cache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[string]cacheEntry
+}
+
+type cacheEntry struct {
+	result  Result
+	expires time.Time
+}
+
+// NewCache returns a cache whose results expire after ttl
+func NewCache(ttl time.Duration) *Cache {
+	return &Cache{ttl: ttl, entries: map[string]cacheEntry{}}
+}
+
+// Invalidate removes the cached result of the command, e.g. after `helm repo add` changes the output of `helm repo list`
+func (c *Cache) Invalidate(name string, args ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	prefix := name + "\x00" + strings.Join(args, "\x00") + "\x00\x00"
+	for key := range c.entries {
+		if strings.HasPrefix(key, prefix) {
+			delete(c.entries, key)
+		}
+	}
+}
+
+// Clear removes every cached result
+func (c *Cache) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = map[string]cacheEntry{}
+}
+
+func (c *Cache) get(key string) (Result, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[key]
+	if !ok {
+		return Result{}, false
+	}
+	if time.Now().After(entry.expires) {
+		delete(c.entries, key)
+		return Result{}, false
+	}
+	return entry.result, true
+}
+
+func (c *Cache) put(key string, result Result) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[key] = cacheEntry{result: result, expires: time.Now().Add(c.ttl)}
+}
+
+// WithCache returns a copy of the runner that serves repeated commands from cache. Only successful
+// results are cached, commands reading stdin are always executed. Cached output still reaches the
+// OnStdout/OnStderr callbacks, the tee file, the recording and the artifacts.
+func (c *Runner) WithCache(cache *Cache) *Runner {
+	r := c.clone()
+	r.cache = cache
+	return r
+}
+
+// cacheKey identifies the command and every runner setting that can change its output
+func (c *Runner) cacheKey(name string, args []string) string {
+	var b strings.Builder
+	b.WriteString(name + "\x00" + strings.Join(args, "\x00") + "\x00\x00")
+	b.WriteString(c.dir + "\x00" + string(c.shell) + "\x00")
+	if c.sudo {
+		b.WriteString("sudo")
+	}
+	keys := make([]string, 0, len(c.env))
+	for k := range c.env {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		b.WriteString("\x00" + k + "=" + c.env[k])
+	}
+	return b.String()
+}
@@ -0,0 +1,85 @@
+package command
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCacheReplaysOutput(t *testing.T) {
+	dir := t.TempDir()
+	tee := filepath.Join(dir, "tee.log")
+	recording := Record(filepath.Join(dir, "commands.json"))
+	var stdout, stderr []string
+
+	runner := NewCommandRunner(false).
+		WithDir(dir).
+		WithCache(NewCache(time.Minute)).
+		OnStdout(func(line string) { stdout = append(stdout, line) }).
+		OnStderr(func(line string) { stderr = append(stderr, line) }).
+		TeeTo(tee).
+		WithRecording(recording).
+		WithArtifacts(filepath.Join(dir, "artifacts"))
+
+	script := "echo run >> runs; echo out; echo err >&2"
+	for range 2 {
+		if result := runner.RunCommandQuiet("sh", "-c", script); result.Err != nil || result.Stdout != "out\n" {
+			t.Fatalf("unexpected result: %+v", result)
+		}
+	}
+
+	runs, err := os.ReadFile(filepath.Join(dir, "runs"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(runs) != "run\n" {
+		t.Errorf("expected the command to run once, got %q", runs)
+	}
+	if strings.Join(stdout, ",") != "out,out" || strings.Join(stderr, ",") != "err,err" {
+		t.Errorf("expected callbacks on every call, got stdout %v and stderr %v", stdout, stderr)
+	}
+
+	transcript, err := os.ReadFile(tee)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(string(transcript), "$ sh -c"); n != 2 {
+		t.Errorf("expected 2 commands in the tee file, got %d:\n%s", n, transcript)
+	}
+	if n := strings.Count(string(transcript), "out\n"); n != 2 {
+		t.Errorf("expected the output twice in the tee file, got %d:\n%s", n, transcript)
+	}
+
+	if n := len(recording.Commands()); n != 2 {
+		t.Errorf("expected 2 recorded commands, got %d", n)
+	}
+	for _, folder := range []string{"001-sh", "002-sh"} {
+		data, err := os.ReadFile(filepath.Join(dir, "artifacts", folder, "stdout.log"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != "out\n" {
+			t.Errorf("unexpected stdout artifact in %s: %q", folder, data)
+		}
+	}
+}
+
+func TestCacheSkipsFailures(t *testing.T) {
+	dir := t.TempDir()
+	runner := NewCommandRunner(false).WithDir(dir).WithCache(NewCache(time.Minute))
+
+	for range 2 {
+		if result := runner.RunCommandQuiet("sh", "-c", "echo run >> runs; exit 1"); result.Err == nil {
+			t.Fatal("expected the command to fail")
+		}
+	}
+	runs, err := os.ReadFile(filepath.Join(dir, "runs"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(runs) != "run\nrun\n" {
+		t.Errorf("expected failed commands to run every time, got %q", runs)
+	}
+}
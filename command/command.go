@@ -1,13 +1,22 @@
 package command
 
 import (
-	"bufio"
 	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"log/slog"
+	"os"
 	"os/exec"
+	"runtime"
+	"slices"
 	"strings"
 	"sync"
+	"time"
+
+	"github.com/flanksource/commons-test/logging"
 )
 
 // Result holds the result of a command execution
@@ -16,17 +25,58 @@ type Result struct {
 	Stderr   string
 	ExitCode int
 	Err      error
+	// TimedOut is true when the command was killed because the runner's timeout expired
+	TimedOut bool
+	// Path is the resolved path of the executable
+	Path     string
+	Start    time.Time
+	End      time.Time
+	Duration time.Duration
+}
+
+// JSON unmarshals Stdout into dest
+func (r Result) JSON(dest any) error {
+	if err := json.Unmarshal([]byte(r.Stdout), dest); err != nil {
+		return fmt.Errorf("failed to parse output of %s as JSON: %w", r.Path, err)
+	}
+	return nil
+}
+
+// Lines returns the non-empty lines of Stdout
+func (r Result) Lines() []string {
+	var lines []string
+	for _, line := range strings.Split(r.Stdout, "\n") {
+		if line = strings.TrimRight(line, "\r"); strings.TrimSpace(line) != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
 }
 
 // String returns a formatted string of the command result
 func (r Result) String() string {
-	return fmt.Sprintf("ExitCode: %d\nStdout:\n%s\nStderr:\n%s\nError: %v",
-		r.ExitCode, r.Stdout, r.Stderr, r.Err)
+	return logging.Redact(fmt.Sprintf("ExitCode: %d\nStdout:\n%s\nStderr:\n%s\nError: %v",
+		r.ExitCode, r.Stdout, r.Stderr, r.Err))
 }
 
 // Runner provides command execution with optional colored output
 type Runner struct {
 	ColorOutput bool
+
+	env     map[string]string
+	dir     string
+	stdin   io.Reader
+	timeout time.Duration
+
+	onStdout []func(line string)
+	onStderr []func(line string)
+	teePath  string
+
+	recording *Recording
+	shell     ShellType
+	sudo      bool
+	cache     *Cache
+	artifacts *artifacts
 }
 
 // NewCommandRunner creates a new CommandRunner
@@ -34,69 +84,137 @@ func NewCommandRunner(colorOutput bool) *Runner {
 	return &Runner{ColorOutput: colorOutput}
 }
 
-// RunCommand executes a command and returns the result
-func (c *Runner) RunCommand(name string, args ...string) Result {
-	if c.ColorOutput {
-		fmt.Printf("%s%s>>> Executing: %s %s%s\n", colorBlue, colorBold, name, strings.Join(args, " "), colorReset)
-	}
+// WithEnv returns a copy of the runner that adds key=value to the environment of os.Environ()
+func (c *Runner) WithEnv(key, value string) *Runner {
+	r := c.clone()
+	r.env[key] = value
+	return r
+}
 
-	cmd := exec.Command(name, args...)
+// WithDir returns a copy of the runner that runs commands in dir
+func (c *Runner) WithDir(dir string) *Runner {
+	r := c.clone()
+	r.dir = dir
+	return r
+}
 
-	// Create pipes for stdout and stderr
-	stdoutPipe, err := cmd.StdoutPipe()
-	if err != nil {
-		return Result{
-			Err:      fmt.Errorf("failed to create stdout pipe: %w", err),
-			ExitCode: -1,
-		}
-	}
+// WithTimeout returns a copy of the runner that kills commands running longer than timeout
+func (c *Runner) WithTimeout(timeout time.Duration) *Runner {
+	r := c.clone()
+	r.timeout = timeout
+	return r
+}
 
-	stderrPipe, err := cmd.StderrPipe()
-	if err != nil {
-		return Result{
-			Err:      fmt.Errorf("failed to create stderr pipe: %w", err),
-			ExitCode: -1,
-		}
-	}
+// OnStdout returns a copy of the runner that calls fn with each line of stdout as it is written
+func (c *Runner) OnStdout(fn func(line string)) *Runner {
+	r := c.clone()
+	r.onStdout = append(r.onStdout, fn)
+	return r
+}
 
-	// Buffers to capture output
-	var stdout, stderr bytes.Buffer
+// OnStderr returns a copy of the runner that calls fn with each line of stderr as it is written
+func (c *Runner) OnStderr(fn func(line string)) *Runner {
+	r := c.clone()
+	r.onStderr = append(r.onStderr, fn)
+	return r
+}
 
-	// Start the command
-	if err := cmd.Start(); err != nil {
-		return Result{
-			Err:      fmt.Errorf("failed to start command: %w", err),
-			ExitCode: -1,
-		}
-	}
+// TeeTo returns a copy of the runner that appends each command line and its combined output to path
+func (c *Runner) TeeTo(path string) *Runner {
+	r := c.clone()
+	r.teePath = path
+	return r
+}
 
-	// Stream output in real-time with colors
-	var wg sync.WaitGroup
-	wg.Add(2)
+// WithRecording returns a copy of the runner that records commands to, or replays them from, recording
+func (c *Runner) WithRecording(recording *Recording) *Runner {
+	r := c.clone()
+	r.recording = recording
+	return r
+}
 
-	go c.streamOutput(stdoutPipe, "stdout", colorGray, &stdout, &wg)
-	go c.streamOutput(stderrPipe, "stderr", colorRed, &stderr, &wg)
+// WithStdin returns a copy of the runner that feeds stdin to commands
+func (c *Runner) WithStdin(stdin io.Reader) *Runner {
+	r := c.clone()
+	r.stdin = stdin
+	return r
+}
 
-	// Wait for output streaming to complete
-	wg.Wait()
+// InheritStdin returns a copy of the runner whose commands read from the current process's stdin
+func (c *Runner) InheritStdin() *Runner {
+	return c.WithStdin(os.Stdin)
+}
 
-	// Wait for command to complete
-	err = cmd.Wait()
-	exitCode := 0
-	if exitErr, ok := err.(*exec.ExitError); ok {
-		exitCode = exitErr.ExitCode()
+func (c *Runner) clone() *Runner {
+	r := *c
+	r.env = make(map[string]string, len(c.env))
+	for k, v := range c.env {
+		r.env[k] = v
 	}
+	r.onStdout = slices.Clone(c.onStdout)
+	r.onStderr = slices.Clone(c.onStderr)
+	return &r
+}
 
-	result := Result{
-		Stdout:   stdout.String(),
-		Stderr:   stderr.String(),
-		ExitCode: exitCode,
-		Err:      err,
+// command builds an exec.Cmd with the runner's environment, working directory and stdin that kills
+// its whole process group when ctx is done. The returned context includes the runner's timeout.
+func (c *Runner) command(ctx context.Context, name string, args ...string) (*exec.Cmd, context.Context, context.CancelFunc) {
+	var cancel context.CancelFunc
+	if c.timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+	} else {
+		ctx, cancel = context.WithCancel(ctx)
+	}
+
+	if c.shell != "" {
+		name, args = c.shell.wrap(name, args)
 	}
+	if c.sudo {
+		name, args = c.sudoWrap(name, args)
+	}
+	cmd := exec.CommandContext(ctx, nativePath(name), args...)
+	cmd.Dir = c.dir
+	cmd.Stdin = c.stdin
+	setProcessGroup(cmd)
+	cmd.Cancel = func() error { return killProcessGroup(cmd) }
+	// Don't wait forever for orphaned grandchildren still holding stdout/stderr open
+	cmd.WaitDelay = 5 * time.Second
+	if len(c.env) > 0 {
+		cmd.Env = os.Environ()
+		for k, v := range c.env {
+			cmd.Env = append(cmd.Env, k+"="+v)
+		}
+	}
+	return cmd, ctx, cancel
+}
+
+// RunCommand executes a command and returns the result
+func (c *Runner) RunCommand(name string, args ...string) Result {
+	return c.RunCommandCtx(context.Background(), name, args...)
+}
+
+// RunCommandCtx executes a command, killing it and its children when ctx is done or the runner's timeout expires
+func (c *Runner) RunCommandCtx(ctx context.Context, name string, args ...string) Result {
+	if c.ColorOutput && logging.JSON() {
+		logging.Log(slog.LevelInfo, "command", name, "executing", "command", commandLine(name, args))
+	} else if c.ColorOutput {
+		fmt.Printf("%s%s>>> Executing: %s%s\n", colorBlue, colorBold, commandLine(name, args), colorReset)
+	}
+
+	result := c.run(ctx, true, name, args...)
 
 	// Print exit status
-	if c.ColorOutput {
+	if c.ColorOutput && logging.JSON() {
+		level := slog.LevelInfo
 		if result.Err != nil {
+			level = slog.LevelError
+		}
+		logging.Log(level, "command", name, "completed", "command", commandLine(name, args),
+			"exit_code", result.ExitCode, "timed_out", result.TimedOut, "duration", result.Duration.String())
+	} else if c.ColorOutput {
+		if result.TimedOut {
+			fmt.Printf("%s%s<<< Command timed out after %v%s\n", colorRed, colorBold, c.timeout, colorReset)
+		} else if result.Err != nil {
 			fmt.Printf("%s%s<<< Command failed with exit code %d%s\n", colorRed, colorBold, result.ExitCode, colorReset)
 		} else {
 			fmt.Printf("%s<<< Command completed successfully%s\n", colorGray, colorReset)
@@ -109,57 +227,239 @@ func (c *Runner) RunCommand(name string, args ...string) Result {
 
 // RunCommandQuiet executes a command without output streaming
 func (c *Runner) RunCommandQuiet(name string, args ...string) Result {
-	cmd := exec.Command(name, args...)
+	return c.RunCommandQuietCtx(context.Background(), name, args...)
+}
+
+// RunCommandQuietCtx executes a command without output streaming, killing it when ctx is done or the runner's timeout expires
+func (c *Runner) RunCommandQuietCtx(ctx context.Context, name string, args ...string) Result {
+	return c.run(ctx, false, name, args...)
+}
+
+// run executes the command, capturing its output and passing each line to the registered callbacks,
+// the tee file and, when echo is true, the console
+func (c *Runner) run(ctx context.Context, echo bool, name string, args ...string) Result {
+	if c.recording != nil && c.recording.replay {
+		return c.recording.lookup(name, args)
+	}
+	if c.sudo && runtime.GOOS == "windows" {
+		return Result{Err: errors.New("sudo is not supported on windows"), ExitCode: -1}
+	}
+
+	var cacheKey string
+	var result Result
+	cached := false
+	if c.cache != nil && c.stdin == nil {
+		cacheKey = c.cacheKey(name, args)
+		result, cached = c.cache.get(cacheKey)
+	}
+
+	if cached {
+		result = c.replay(echo, name, args, result)
+	} else {
+		spanCtx, span := startSpan(ctx, name, args)
+		result = c.execute(spanCtx, echo, name, args...)
+		endSpan(span, result)
+		track(name, args, result)
+		if cacheKey != "" && result.Err == nil {
+			c.cache.put(cacheKey, result)
+		}
+	}
+	if c.recording != nil {
+		if err := c.recording.record(name, args, result); err != nil {
+			c.Errorf("failed to record %s: %v", name, err)
+		}
+	}
+	if c.artifacts != nil {
+		if err := c.artifacts.write(name, args, c.dir, result); err != nil {
+			c.Errorf("failed to write artifacts of %s: %v", name, err)
+		}
+	}
+	return result
+}
+
+func (c *Runner) execute(ctx context.Context, echo bool, name string, args ...string) Result {
+	cmd, ctx, cancel := c.command(ctx, name, args...)
+	defer cancel()
+
+	stdoutSinks, stderrSinks, flush, err := c.sinks(echo, name, args)
+	if err != nil {
+		return Result{Err: err, ExitCode: -1}
+	}
 	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
+	cmd.Stdout = io.MultiWriter(append([]io.Writer{&stdout}, stdoutSinks...)...)
+	cmd.Stderr = io.MultiWriter(append([]io.Writer{&stderr}, stderrSinks...)...)
+
+	start := time.Now()
+	err = cmd.Run()
+	end := time.Now()
+	flush()
+	result := c.result(ctx, cmd, name, args, err, stdout.String(), stderr.String())
+	result.Start, result.End, result.Duration = start, end, end.Sub(start)
+	return result
+}
+
+// replay passes the output of a cached result through the same sinks as an executed command, so
+// callbacks, the tee file and the console see it on every call. Stdout is replayed before stderr.
+func (c *Runner) replay(echo bool, name string, args []string, result Result) Result {
+	stdoutSinks, stderrSinks, flush, err := c.sinks(echo, name, args)
+	if err != nil {
+		return Result{Err: err, ExitCode: -1}
+	}
+	_, _ = io.MultiWriter(stdoutSinks...).Write([]byte(result.Stdout))
+	_, _ = io.MultiWriter(stderrSinks...).Write([]byte(result.Stderr))
+	flush()
+	return result
+}
 
-	err := cmd.Run()
+// sinks returns the writers receiving stdout and stderr besides the captured output: the tee file, the
+// line callbacks and the console. flush passes on partial lines and closes the tee file, it must be
+// called once the command finished.
+func (c *Runner) sinks(echo bool, name string, args []string) (stdout, stderr []io.Writer, flush func(), err error) {
+	var tee *os.File
+	if c.teePath != "" {
+		if tee, err = os.OpenFile(c.teePath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644); err != nil {
+			return nil, nil, nil, fmt.Errorf("failed to open tee file: %w", err)
+		}
+		fmt.Fprintf(tee, "$ %s\n", commandLine(name, args))
+	}
+
+	var lineWriters []*lineWriter
+	if tee != nil {
+		// Output is teed a line at a time so secrets split across writes are still masked
+		for _, writers := range []*[]io.Writer{&stdout, &stderr} {
+			w := &lineWriter{onLine: func(line string) { fmt.Fprintln(tee, logging.Redact(line)) }}
+			lineWriters = append(lineWriters, w)
+			*writers = append(*writers, w)
+		}
+	}
+	if echo && c.ColorOutput || len(c.onStdout) > 0 {
+		w := c.lineWriter(name, "stdout", colorGray, echo, c.onStdout)
+		lineWriters = append(lineWriters, w)
+		stdout = append(stdout, w)
+	}
+	if echo && c.ColorOutput || len(c.onStderr) > 0 {
+		w := c.lineWriter(name, "stderr", colorRed, echo, c.onStderr)
+		lineWriters = append(lineWriters, w)
+		stderr = append(stderr, w)
+	}
+
+	flush = func() {
+		for _, w := range lineWriters {
+			w.Flush()
+		}
+		if tee != nil {
+			// Separate the transcripts of consecutive commands
+			fmt.Fprintln(tee)
+			tee.Close()
+		}
+	}
+	return stdout, stderr, flush, nil
+}
+
+// result converts the outcome of cmd, which ran name and args, into a Result, recording whether it was
+// killed by a timeout
+func (c *Runner) result(ctx context.Context, cmd *exec.Cmd, name string, args []string, err error, stdout, stderr string) Result {
 	exitCode := 0
 	if exitErr, ok := err.(*exec.ExitError); ok {
 		exitCode = exitErr.ExitCode()
+	} else if err != nil {
+		exitCode = -1
 	}
 
-	return Result{
-		Stdout:   stdout.String(),
-		Stderr:   stderr.String(),
+	result := Result{
+		Stdout:   stdout,
+		Stderr:   stderr,
 		ExitCode: exitCode,
 		Err:      err,
+		Path:     cmd.Path,
 	}
+	if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		result.TimedOut = true
+		result.Err = fmt.Errorf("%s timed out: %w", cmd.Path, ctx.Err())
+	} else if err != nil && c.sudo && isSudoPasswordPrompt(stderr) {
+		result.Err = fmt.Errorf("failed to run %s: %w", commandLine(name, args), ErrSudoPassword)
+	}
+	return result
+}
+
+// lineWriter returns a writer that passes each line to callbacks and echoes it when echo and color output are enabled
+func (c *Runner) lineWriter(name, prefix, color string, echo bool, callbacks []func(line string)) *lineWriter {
+	return &lineWriter{onLine: func(line string) {
+		if echo && c.ColorOutput && logging.JSON() {
+			logging.Log(slog.LevelInfo, "command", name, line, "stream", prefix)
+		} else if echo && c.ColorOutput {
+			fmt.Printf("%s%s%s: %s%s\n", color, prefix, colorReset, color, logging.Redact(line)+colorReset)
+		}
+		for _, fn := range callbacks {
+			fn(line)
+		}
+	}}
+}
+
+// lineWriter splits written bytes into lines, calling onLine for each complete line
+type lineWriter struct {
+	mu      sync.Mutex
+	partial []byte
+	onLine  func(line string)
 }
 
-func (c *Runner) streamOutput(reader io.Reader, prefix string, color string, buffer *bytes.Buffer, wg *sync.WaitGroup) {
-	defer wg.Done()
-	scanner := bufio.NewScanner(reader)
-	for scanner.Scan() {
-		line := scanner.Text()
-		buffer.WriteString(line + "\n")
-		if c.ColorOutput {
-			fmt.Printf("%s%s%s: %s%s\n", color, prefix, colorReset, color, line+colorReset)
+func (w *lineWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.partial = append(w.partial, p...)
+	for {
+		i := bytes.IndexByte(w.partial, '\n')
+		if i < 0 {
+			break
 		}
+		w.onLine(strings.TrimSuffix(string(w.partial[:i]), "\r"))
+		w.partial = w.partial[i+1:]
+	}
+	return len(p), nil
+}
+
+// Flush emits any trailing output not terminated by a newline
+func (w *lineWriter) Flush() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if len(w.partial) > 0 {
+		w.onLine(string(w.partial))
+		w.partial = nil
 	}
 }
 
 func (c *Runner) Debugf(format string, args ...interface{}) {
-	if c.ColorOutput {
-		fmt.Printf("%s%s%s%s\n", colorGray, colorBold, fmt.Sprintf(format, args...), colorReset)
+	if logging.JSON() {
+		logging.Log(slog.LevelDebug, "command", "", fmt.Sprintf(format, args...))
+	} else if c.ColorOutput {
+		fmt.Printf("%s%s%s%s\n", colorGray, colorBold, logging.Redact(fmt.Sprintf(format, args...)), colorReset)
 	} else {
-		fmt.Printf(format+"\n", args...)
+		fmt.Println(logging.Redact(fmt.Sprintf(format, args...)))
 	}
 }
 
 func (c *Runner) Infof(format string, args ...interface{}) {
-	if c.ColorOutput {
-		fmt.Printf("%s%s%s%s\n", colorBlue, colorBold, fmt.Sprintf(format, args...), colorReset)
+	if logging.JSON() {
+		logging.Log(slog.LevelInfo, "command", "", fmt.Sprintf(format, args...))
+	} else if c.ColorOutput {
+		fmt.Printf("%s%s%s%s\n", colorBlue, colorBold, logging.Redact(fmt.Sprintf(format, args...)), colorReset)
 	} else {
-		fmt.Printf(format+"\n", args...)
+		fmt.Println(logging.Redact(fmt.Sprintf(format, args...)))
 	}
 }
 
 func (c *Runner) Errorf(format string, args ...interface{}) {
-	if c.ColorOutput {
-		fmt.Printf("%s%s%s%s\n", colorRed, colorBold, fmt.Sprintf(format, args...), colorReset)
+	if logging.JSON() {
+		logging.Log(slog.LevelError, "command", "", fmt.Sprintf(format, args...))
+	} else if c.ColorOutput {
+		fmt.Printf("%s%s%s%s\n", colorRed, colorBold, logging.Redact(fmt.Sprintf(format, args...)), colorReset)
 	} else {
-		fmt.Printf(format+"\n", args...)
+		fmt.Println(logging.Redact(fmt.Sprintf(format, args...)))
 	}
 }
+
+// commandLine returns name and args joined with spaces and masked values redacted, e.g. for logs and telemetry
+func commandLine(name string, args []string) string {
+	return logging.Redact(strings.TrimSpace(name + " " + strings.Join(args, " ")))
+}
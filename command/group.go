@@ -0,0 +1,98 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+type groupCommand struct {
+	name string
+	args []string
+}
+
+// Group runs commands concurrently with a bounded number of workers
+type Group struct {
+	runner   *Runner
+	workers  int
+	failFast bool
+	commands []groupCommand
+}
+
+// NewGroup returns a group running commands quietly with runner, using one worker per CPU
+func NewGroup(runner *Runner) *Group {
+	return &Group{runner: runner, workers: runtime.NumCPU()}
+}
+
+// Workers sets the maximum number of commands running at once
+func (g *Group) Workers(n int) *Group {
+	if n > 0 {
+		g.workers = n
+	}
+	return g
+}
+
+// FailFast kills running commands and skips pending ones after the first failure,
+// by default every command runs and all failures are returned
+func (g *Group) FailFast() *Group {
+	g.failFast = true
+	return g
+}
+
+// Add queues a command
+func (g *Group) Add(name string, args ...string) *Group {
+	g.commands = append(g.commands, groupCommand{name: name, args: args})
+	return g
+}
+
+// Run executes the queued commands and returns their results in the order they were added,
+// with an error joining every failure (or only the first when FailFast is set)
+func (g *Group) Run(ctx context.Context) ([]Result, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make([]Result, len(g.commands))
+	jobs := make(chan int)
+
+	var mu sync.Mutex
+	var errs []error
+	var wg sync.WaitGroup
+	for w := 0; w < min(g.workers, len(g.commands)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				cmd := g.commands[i]
+				results[i] = g.runner.RunCommandQuietCtx(ctx, cmd.name, cmd.args...)
+				if results[i].Err == nil {
+					continue
+				}
+
+				mu.Lock()
+				// Failures of commands killed by fail fast are not reported
+				if !g.failFast || len(errs) == 0 {
+					errs = append(errs, fmt.Errorf("%s: %w", strings.TrimSpace(cmd.name+" "+strings.Join(cmd.args, " ")), results[i].Err))
+				}
+				mu.Unlock()
+				if g.failFast {
+					cancel()
+				}
+			}
+		}()
+	}
+
+	for i := range g.commands {
+		select {
+		case jobs <- i:
+		case <-ctx.Done():
+			results[i] = Result{Err: fmt.Errorf("skipped: %w", ctx.Err()), ExitCode: -1}
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	return results, errors.Join(errs...)
+}
@@ -0,0 +1,186 @@
+package command
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/flanksource/commons-test/logging"
+	"github.com/flanksource/commons-test/wait"
+)
+
+// DefaultExpectTimeout is how long RunInteractive waits for each prompt when Expect.Timeout is not set
+const DefaultExpectTimeout = 30 * time.Second
+
+// expectPollInterval is how often RunInteractive checks whether the current prompt was matched
+const expectPollInterval = 50 * time.Millisecond
+
+// Expect waits for output matching Pattern and then writes Send followed by a newline to stdin
+type Expect struct {
+	// Pattern is a regular expression matched against stdout and stderr, e.g. `\(y/N\)`
+	Pattern string
+	Send    string
+	// Hidden masks Send in logs and errors, e.g. for passwords
+	Hidden  bool
+	Timeout time.Duration
+}
+
+func (e Expect) sent() string {
+	if e.Hidden {
+		return "***"
+	}
+	return e.Send
+}
+
+// RunInteractive executes a command, answering its prompts with the expect/send pairs of script in order.
+// The command is killed if a prompt does not appear within its timeout. stdin is a pipe rather than a
+// terminal, so programs that only prompt on a TTY (e.g. bash read -p) need their own non-interactive flags.
+func (c *Runner) RunInteractive(ctx context.Context, script []Expect, name string, args ...string) Result {
+	patterns := make([]*regexp.Regexp, len(script))
+	for i, step := range script {
+		re, err := regexp.Compile(step.Pattern)
+		if err != nil {
+			return Result{Err: fmt.Errorf("invalid expect pattern %q: %w", step.Pattern, err), ExitCode: -1}
+		}
+		patterns[i] = re
+	}
+
+	if c.ColorOutput && logging.JSON() {
+		logging.Log(slog.LevelInfo, "command", name, "executing interactively", "command", commandLine(name, args))
+	} else if c.ColorOutput {
+		fmt.Printf("%s%s>>> Executing interactively: %s%s\n", colorBlue, colorBold, commandLine(name, args), colorReset)
+	}
+
+	cmd, ctx, cancel := c.command(ctx, name, args...)
+	defer cancel()
+	cmd.Stdin = nil
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return Result{Err: fmt.Errorf("failed to create stdin pipe: %w", err), ExitCode: -1}
+	}
+
+	e := &expecter{
+		runner:   c,
+		script:   script,
+		patterns: patterns,
+		sends:    make(chan string, len(script)),
+		matched:  make(chan struct{}, len(script)),
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = io.MultiWriter(&stdout, e)
+	cmd.Stderr = io.MultiWriter(&stderr, e)
+
+	_, span := startSpan(ctx, name, args)
+	start := time.Now()
+	if err := cmd.Start(); err != nil {
+		result := c.result(ctx, cmd, name, args, err, "", "")
+		endSpan(span, result)
+		return result
+	}
+
+	go func() {
+		for send := range e.sends {
+			if _, err := io.WriteString(stdin, send+"\n"); err != nil {
+				return
+			}
+		}
+	}()
+
+	var timeoutErr error
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i, step := range script {
+			timeout := step.Timeout
+			if timeout == 0 {
+				timeout = DefaultExpectTimeout
+			}
+			err := wait.Every(ctx, expectPollInterval, timeout, func(ctx context.Context) (bool, error) {
+				select {
+				case <-e.matched:
+					return true, nil
+				default:
+					return false, nil
+				}
+			})
+			if ctx.Err() != nil {
+				return
+			}
+			if err != nil {
+				timeoutErr = fmt.Errorf("timed out after %v waiting for prompt %d %q", timeout, i+1, step.Pattern)
+				cancel()
+				return
+			}
+		}
+	}()
+
+	err = cmd.Wait()
+	end := time.Now()
+	close(e.sends)
+	cancel()
+	<-done
+
+	result := c.result(ctx, cmd, name, args, err, stdout.String(), stderr.String())
+	result.Start, result.End, result.Duration = start, end, end.Sub(start)
+	defer func() {
+		endSpan(span, result)
+		track(name, args, result)
+	}()
+	if timeoutErr != nil {
+		result.Err = timeoutErr
+		if result.ExitCode == 0 {
+			result.ExitCode = -1
+		}
+	} else if step := e.step(); step < len(script) && result.Err == nil {
+		result.Err = fmt.Errorf("command exited before prompt %d %q", step+1, script[step].Pattern)
+	}
+	return result
+}
+
+// expecter scans output for the next expected prompt and queues its response
+type expecter struct {
+	runner   *Runner
+	script   []Expect
+	patterns []*regexp.Regexp
+	sends    chan string
+	matched  chan struct{}
+
+	mu      sync.Mutex
+	next    int
+	pending []byte
+}
+
+func (e *expecter) Write(p []byte) (int, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.pending = append(e.pending, p...)
+	for e.next < len(e.script) {
+		loc := e.patterns[e.next].FindIndex(e.pending)
+		if loc == nil {
+			break
+		}
+		step := e.script[e.next]
+		if e.runner.ColorOutput && logging.JSON() {
+			logging.Log(slog.LevelInfo, "command", "", "expect", "pattern", step.Pattern, "send", step.sent())
+		} else if e.runner.ColorOutput {
+			fmt.Printf("%sexpect%s: %q %ssend%s: %q\n", colorYellow, colorReset, step.Pattern, colorYellow, colorReset, step.sent())
+		}
+		e.pending = e.pending[loc[1]:]
+		e.sends <- step.Send
+		e.matched <- struct{}{}
+		e.next++
+	}
+	return len(p), nil
+}
+
+func (e *expecter) step() int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.next
+}
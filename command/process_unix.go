@@ -0,0 +1,38 @@
+//go:build !windows
+
+package command
+
+import (
+	"io"
+	"os"
+	"os/exec"
+	"syscall"
+
+	"golang.org/x/term"
+)
+
+// setProcessGroup starts the command in its own process group so its children can be killed with it.
+// When running in a terminal the command stays in the foreground process group instead: a background
+// group is stopped with SIGTTIN when it reads the terminal, and Ctrl-C would not reach its children.
+func setProcessGroup(cmd *exec.Cmd) {
+	if isTerminal(os.Stdin) || isTerminal(cmd.Stdin) {
+		return
+	}
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+// killProcessGroup kills the command and every process in its group
+func killProcessGroup(cmd *exec.Cmd) error {
+	if cmd.Process == nil {
+		return nil
+	}
+	if cmd.SysProcAttr == nil || !cmd.SysProcAttr.Setpgid {
+		return cmd.Process.Kill()
+	}
+	return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+}
+
+func isTerminal(r io.Reader) bool {
+	f, ok := r.(*os.File)
+	return ok && term.IsTerminal(int(f.Fd()))
+}
@@ -0,0 +1,137 @@
+package command
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/flanksource/commons-test/logging"
+)
+
+// RecordedCommand is a command and its result stored in a recording
+type RecordedCommand struct {
+	Name     string   `json:"name"`
+	Args     []string `json:"args,omitempty"`
+	Stdout   string   `json:"stdout,omitempty"`
+	Stderr   string   `json:"stderr,omitempty"`
+	ExitCode int      `json:"exit_code"`
+	Err      string   `json:"error,omitempty"`
+	TimedOut bool     `json:"timed_out,omitempty"`
+	Path     string   `json:"path,omitempty"`
+	Duration string   `json:"duration,omitempty"`
+}
+
+func (r RecordedCommand) key() string {
+	return r.Name + "\x00" + strings.Join(r.Args, "\x00")
+}
+
+func (r RecordedCommand) result() Result {
+	now := time.Now()
+	result := Result{
+		Stdout:   r.Stdout,
+		Stderr:   r.Stderr,
+		ExitCode: r.ExitCode,
+		TimedOut: r.TimedOut,
+		Path:     r.Path,
+		Start:    now,
+		End:      now,
+	}
+	result.Duration, _ = time.ParseDuration(r.Duration)
+	if r.Err != "" {
+		result.Err = errors.New(r.Err)
+	}
+	return result
+}
+
+// Recording is a golden file of executed commands. A recording created with Record captures every
+// command run through a Runner using it, one created with Replay serves the captured results instead
+// of executing anything. Masked values are stored as *** and matched against the masked arguments on replay.
+type Recording struct {
+	mu       sync.Mutex
+	path     string
+	replay   bool
+	commands []RecordedCommand
+	// served counts how many times each command has been replayed, so repeated commands replay in order
+	served map[string]int
+}
+
+// Record returns a recording that overwrites path with every command executed
+func Record(path string) *Recording {
+	return &Recording{path: path}
+}
+
+// Replay loads a recording made with Record
+func Replay(path string) (*Recording, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read recording: %w", err)
+	}
+	r := &Recording{path: path, replay: true, served: map[string]int{}}
+	if err := json.Unmarshal(data, &r.commands); err != nil {
+		return nil, fmt.Errorf("failed to parse recording %s: %w", path, err)
+	}
+	return r, nil
+}
+
+// Commands returns the recorded commands
+func (r *Recording) Commands() []RecordedCommand {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]RecordedCommand(nil), r.commands...)
+}
+
+// lookup returns the next recorded result for the command. Repeated commands are served in
+// the order they were recorded, the last result is reused once they are exhausted.
+func (r *Recording) lookup(name string, args []string) Result {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := RecordedCommand{Name: name, Args: redactArgs(args)}.key()
+	var matches []RecordedCommand
+	for _, cmd := range r.commands {
+		if cmd.key() == key {
+			matches = append(matches, cmd)
+		}
+	}
+	if len(matches) == 0 {
+		return Result{
+			Err:      fmt.Errorf("no recording of %s in %s", commandLine(name, args), r.path),
+			ExitCode: -1,
+		}
+	}
+
+	i := min(r.served[key], len(matches)-1)
+	r.served[key]++
+	return matches[i].result()
+}
+
+// record appends the command to the recording and rewrites the golden file
+func (r *Recording) record(name string, args []string, result Result) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	cmd := RecordedCommand{
+		Name:     name,
+		Args:     redactArgs(args),
+		Stdout:   logging.Redact(result.Stdout),
+		Stderr:   logging.Redact(result.Stderr),
+		ExitCode: result.ExitCode,
+		TimedOut: result.TimedOut,
+		Path:     result.Path,
+		Duration: result.Duration.String(),
+	}
+	if result.Err != nil {
+		cmd.Err = logging.Redact(result.Err.Error())
+	}
+	r.commands = append(r.commands, cmd)
+
+	data, err := json.MarshalIndent(r.commands, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(r.path, data, 0644)
+}
@@ -0,0 +1,35 @@
+package command
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestRecordAndReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "commands.json")
+
+	recorder := NewCommandRunner(false).WithRecording(Record(path))
+	first := recorder.RunCommandQuiet("echo", "first")
+	second := recorder.RunCommandQuiet("sh", "-c", "echo second; exit 3")
+	if first.Stdout != "first\n" || second.ExitCode != 3 {
+		t.Fatalf("unexpected results: %+v %+v", first, second)
+	}
+
+	recording, err := Replay(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recording.Commands()) != 2 {
+		t.Fatalf("expected 2 recorded commands, got %d", len(recording.Commands()))
+	}
+
+	replayer := NewCommandRunner(false).WithRecording(recording)
+	replayed := replayer.RunCommandQuiet("sh", "-c", "echo second; exit 3")
+	if replayed.Stdout != "second\n" || replayed.ExitCode != 3 || replayed.Err == nil {
+		t.Errorf("unexpected replay: %+v", replayed)
+	}
+
+	if missing := replayer.RunCommandQuiet("echo", "never-recorded"); missing.Err == nil {
+		t.Error("expected an error replaying an unrecorded command")
+	}
+}
@@ -0,0 +1,64 @@
+package command_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/flanksource/commons-test/command"
+	"github.com/flanksource/commons-test/secrets"
+)
+
+func TestSecretsAreRedactedFromFiles(t *testing.T) {
+	t.Setenv(secrets.EnvName("redact.password"), "s3cr3t-passw0rd")
+	password, err := secrets.Get("redact.password")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	runner := command.NewCommandRunner(false).
+		TeeTo(filepath.Join(dir, "tee.log")).
+		WithArtifacts(filepath.Join(dir, "artifacts")).
+		WithRecording(command.Record(filepath.Join(dir, "recording.json")))
+	result := runner.RunCommandQuiet("sh", "-c", "echo $0; echo $0 >&2", password)
+	if result.Err != nil || !strings.Contains(result.Stdout, password) {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+
+	files := 0
+	err = filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		files++
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if strings.Contains(string(data), password) {
+			t.Errorf("%s contains the secret:\n%s", path, data)
+		}
+		if !strings.Contains(string(data), "***") {
+			t.Errorf("expected %s to contain the masked secret:\n%s", path, data)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if files != 5 {
+		t.Errorf("expected a tee file, a recording and 3 artifacts, found %d files", files)
+	}
+
+	recording, err := command.Replay(filepath.Join(dir, "recording.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	replayed := command.NewCommandRunner(false).WithRecording(recording).
+		RunCommandQuiet("sh", "-c", "echo $0; echo $0 >&2", password)
+	if replayed.Err != nil || replayed.Stdout != "***\n" {
+		t.Errorf("expected the masked command to replay, got %+v", replayed)
+	}
+}
@@ -0,0 +1,126 @@
+package command
+
+import (
+	"context"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// ShellType selects the shell used by Runner.Shell and Runner.RunScript
+type ShellType string
+
+const (
+	ShellSh         ShellType = "sh"
+	ShellBash       ShellType = "bash"
+	ShellCmd        ShellType = "cmd"
+	ShellPowerShell ShellType = "powershell"
+)
+
+// DefaultShell returns bash (or sh when bash is not installed) on unix, and PowerShell
+// (or cmd when it is not installed) on windows
+func DefaultShell() ShellType {
+	if runtime.GOOS == "windows" {
+		if _, err := exec.LookPath("pwsh"); err == nil {
+			return ShellPowerShell
+		}
+		if _, err := exec.LookPath("powershell"); err == nil {
+			return ShellPowerShell
+		}
+		return ShellCmd
+	}
+	if _, err := exec.LookPath("bash"); err == nil {
+		return ShellBash
+	}
+	return ShellSh
+}
+
+// Shell returns a copy of the runner that runs commands through the default shell, quoting each argument
+// so it reaches the command unchanged. Use it for commands that rely on the shell's PATH or profile.
+func (c *Runner) Shell() *Runner {
+	return c.WithShell(DefaultShell())
+}
+
+// WithShell returns a copy of the runner that runs commands through shell
+func (c *Runner) WithShell(shell ShellType) *Runner {
+	r := c.clone()
+	r.shell = shell
+	return r
+}
+
+// NoShell returns a copy of the runner that executes commands directly, the default
+func (c *Runner) NoShell() *Runner {
+	r := c.clone()
+	r.shell = ""
+	return r
+}
+
+// RunScript runs script verbatim with the runner's shell (DefaultShell when none is set),
+// e.g. a pipeline like "helm status x -o json | jq .info"
+func (c *Runner) RunScript(ctx context.Context, script string) Result {
+	shell := c.shell
+	if shell == "" {
+		shell = DefaultShell()
+	}
+	name, args := shell.script(script)
+	return c.NoShell().RunCommandCtx(ctx, name, args...)
+}
+
+// script returns the command line running script in the shell
+func (s ShellType) script(script string) (string, []string) {
+	switch s {
+	case ShellCmd:
+		return "cmd", []string{"/C", script}
+	case ShellPowerShell:
+		binary := "powershell"
+		if _, err := exec.LookPath("pwsh"); err == nil {
+			binary = "pwsh"
+		}
+		return binary, []string{"-NoProfile", "-NonInteractive", "-Command", script}
+	default:
+		return string(s), []string{"-c", script}
+	}
+}
+
+// wrap returns the command line running name and args through the shell
+func (s ShellType) wrap(name string, args []string) (string, []string) {
+	quoted := make([]string, 0, len(args)+1)
+	for _, arg := range append([]string{name}, args...) {
+		quoted = append(quoted, s.Quote(arg))
+	}
+	line := strings.Join(quoted, " ")
+	if s == ShellPowerShell {
+		// A quoted command name is a string in PowerShell unless invoked with the call operator
+		line = "& " + line
+	}
+	return s.script(line)
+}
+
+// Quote quotes arg so the shell passes it through as a single literal argument
+func (s ShellType) Quote(arg string) string {
+	switch s {
+	case ShellCmd:
+		if arg != "" && !strings.ContainsAny(arg, " \t\"&|<>^%()") {
+			return arg
+		}
+		// Metacharacters are literal inside quotes, embedded quotes are doubled.
+		// cmd still expands %VAR% inside quotes and offers no way to escape it.
+		return `"` + strings.ReplaceAll(arg, `"`, `""`) + `"`
+	case ShellPowerShell:
+		return "'" + strings.ReplaceAll(arg, "'", "''") + "'"
+	default:
+		if arg != "" && !strings.ContainsAny(arg, " \t\n'\"\\$`|&;<>()*?[]{}~#!") {
+			return arg
+		}
+		return "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+	}
+}
+
+// nativePath converts slashes in an executable path to the platform separator
+func nativePath(name string) string {
+	if runtime.GOOS == "windows" && strings.Contains(name, "/") {
+		return filepath.FromSlash(name)
+	}
+	return name
+}
@@ -0,0 +1,71 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// ErrSudoPassword is returned when sudo would prompt for a password
+var ErrSudoPassword = errors.New("sudo requires a password: run as root or allow passwordless sudo (NOPASSWD) for this user")
+
+// Sudo returns a copy of the runner that runs commands as root with non-interactive sudo, or directly
+// when already root. Environment overrides from WithEnv are passed to the command, the rest of the
+// environment is subject to the sudoers policy. Commands running as root may survive a timeout.
+func (c *Runner) Sudo() *Runner {
+	r := c.clone()
+	r.sudo = true
+	return r
+}
+
+// CanSudo returns nil when commands can run as root without a password prompt, e.g. to skip tests needing host setup
+func CanSudo(ctx context.Context) error {
+	if runtime.GOOS == "windows" {
+		return errors.New("sudo is not supported on windows")
+	}
+	if os.Geteuid() == 0 {
+		return nil
+	}
+	if _, err := exec.LookPath("sudo"); err != nil {
+		return fmt.Errorf("not running as root and sudo is not installed: %w", err)
+	}
+	out, err := exec.CommandContext(ctx, "sudo", "-n", "true").CombinedOutput()
+	if err != nil {
+		if isSudoPasswordPrompt(string(out)) {
+			return ErrSudoPassword
+		}
+		return fmt.Errorf("failed to run sudo: %s: %w", strings.TrimSpace(string(out)), err)
+	}
+	return nil
+}
+
+// sudoWrap returns the command line running name and args with sudo, unless the process is already root
+func (c *Runner) sudoWrap(name string, args []string) (string, []string) {
+	if os.Geteuid() == 0 {
+		return name, args
+	}
+	// -n fails instead of prompting, env passes the overrides sudo would otherwise drop
+	wrapped := []string{"-n", "--"}
+	if len(c.env) > 0 {
+		wrapped = append(wrapped, "env")
+		keys := make([]string, 0, len(c.env))
+		for k := range c.env {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			wrapped = append(wrapped, k+"="+c.env[k])
+		}
+	}
+	return "sudo", append(append(wrapped, name), args...)
+}
+
+func isSudoPasswordPrompt(stderr string) bool {
+	return strings.Contains(stderr, "sudo: a password is required") ||
+		strings.Contains(stderr, "sudo: a terminal is required")
+}
@@ -0,0 +1,21 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestSudoPasswordError(t *testing.T) {
+	runner := NewCommandRunner(false).WithEnv("TOKEN", "hidden-value").Sudo()
+	cmd := exec.Command("id")
+	result := runner.result(context.Background(), cmd, "id", nil, errors.New("exit status 1"), "", "sudo: a password is required\n")
+	if !errors.Is(result.Err, ErrSudoPassword) {
+		t.Fatalf("expected ErrSudoPassword, got %v", result.Err)
+	}
+	if msg := result.Err.Error(); !strings.HasPrefix(msg, "failed to run id:") || strings.Contains(msg, "hidden-value") {
+		t.Errorf("unexpected error message: %s", msg)
+	}
+}
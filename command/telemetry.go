@@ -0,0 +1,100 @@
+package command
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// CommandStat is the timing and outcome of one executed command
+type CommandStat struct {
+	Command  string        `json:"command"`
+	Start    time.Time     `json:"start"`
+	Duration time.Duration `json:"duration"`
+	ExitCode int           `json:"exit_code"`
+	TimedOut bool          `json:"timed_out,omitempty"`
+}
+
+// stats records every command executed by any Runner in this process
+var stats struct {
+	sync.Mutex
+	commands []CommandStat
+}
+
+func track(name string, args []string, result Result) {
+	stats.Lock()
+	defer stats.Unlock()
+	stats.commands = append(stats.commands, CommandStat{
+		Command:  commandLine(name, args),
+		Start:    result.Start,
+		Duration: result.Duration,
+		ExitCode: result.ExitCode,
+		TimedOut: result.TimedOut,
+	})
+}
+
+// Stats returns every command executed so far, in execution order
+func Stats() []CommandStat {
+	stats.Lock()
+	defer stats.Unlock()
+	return append([]CommandStat(nil), stats.commands...)
+}
+
+// ResetStats clears the recorded commands
+func ResetStats() {
+	stats.Lock()
+	defer stats.Unlock()
+	stats.commands = nil
+}
+
+// Slowest returns the n slowest commands, all commands when n <= 0
+func Slowest(n int) []CommandStat {
+	commands := Stats()
+	sort.SliceStable(commands, func(i, j int) bool {
+		return commands[i].Duration > commands[j].Duration
+	})
+	if n > 0 && len(commands) > n {
+		commands = commands[:n]
+	}
+	return commands
+}
+
+// Report returns a table of the n slowest commands with a summary of all commands, e.g. to print at suite end
+func Report(n int) string {
+	all := Stats()
+	var total time.Duration
+	failed := 0
+	for _, c := range all {
+		total += c.Duration
+		if c.ExitCode != 0 {
+			failed++
+		}
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "%d commands, %d failed, %v total\n", len(all), failed, total.Round(time.Millisecond))
+	w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "DURATION\tEXIT\tCOMMAND")
+	for _, c := range Slowest(n) {
+		exit := fmt.Sprint(c.ExitCode)
+		if c.TimedOut {
+			exit = "timeout"
+		}
+		command := c.Command
+		if len(command) > 120 {
+			command = command[:117] + "..."
+		}
+		fmt.Fprintf(w, "%v\t%s\t%s\n", c.Duration.Round(time.Millisecond), exit, command)
+	}
+	w.Flush()
+	return buf.String()
+}
+
+// ReportJSON returns the n slowest commands as JSON
+func ReportJSON(n int) ([]byte, error) {
+	return json.MarshalIndent(Slowest(n), "", "  ")
+}
@@ -0,0 +1,31 @@
+package command
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// startSpan starts an OpenTelemetry span for the command, exported when tracing.Setup has configured
+// a provider. Only the OpenTelemetry API is used here so the command package stays free of exporters.
+func startSpan(ctx context.Context, name string, args []string) (context.Context, trace.Span) {
+	return otel.Tracer("github.com/flanksource/commons-test").Start(ctx, "exec "+name, trace.WithAttributes(
+		attribute.String("process.executable.name", name),
+		attribute.String("process.command_line", commandLine(name, args)),
+	))
+}
+
+func endSpan(span trace.Span, result Result) {
+	span.SetAttributes(attribute.Int("process.exit.code", result.ExitCode))
+	if result.TimedOut {
+		span.SetAttributes(attribute.Bool("process.timed_out", true))
+	}
+	if result.Err != nil {
+		span.RecordError(result.Err)
+		span.SetStatus(codes.Error, result.Err.Error())
+	}
+	span.End()
+}
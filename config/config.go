@@ -0,0 +1,332 @@
+// Package config resolves the defaults used by every module (image tags, registry mirrors, timeouts,
+// reuse and artifact directories) from a commons-test.yaml and COMMONS_TEST_* environment variables,
+// so CI can override behaviour without code changes. Environment variables take precedence over the file.
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/flanksource/commons/logger"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// EnvPrefix prefixes every environment variable read by Load
+	EnvPrefix = "COMMONS_TEST_"
+	// FileEnv names the config file, overriding the search for FileName
+	FileEnv = EnvPrefix + "CONFIG"
+	// FileName is searched for in the working directory and its parents
+	FileName = "commons-test.yaml"
+)
+
+// Config holds the defaults resolved from the config file and environment
+type Config struct {
+	// Images overrides the image of a container by repository, e.g. postgres: postgres:16-alpine.
+	// Set with COMMONS_TEST_IMAGE_POSTGRES=postgres:16-alpine.
+	Images map[string]string `json:"images,omitempty"`
+	// RegistryMirrors rewrites the registry of every image, e.g. docker.io: mirror.gcr.io.
+	// Set with COMMONS_TEST_REGISTRY_MIRRORS=docker.io=mirror.gcr.io,ghcr.io=ghcr.example.com.
+	RegistryMirrors map[string]string `json:"registryMirrors,omitempty"`
+	// Reuse reuses running containers with the same name, COMMONS_TEST_REUSE
+	Reuse bool `json:"reuse,omitempty"`
+	// PortLocks locks leased host ports in CacheDir so parallel processes never share one,
+	// COMMONS_TEST_PORT_LOCKS
+	PortLocks bool `json:"portLocks,omitempty"`
+	// LeakCheck reports (report) or fails the run on (fail) resources left behind after cleanup, see the
+	// leak package, COMMONS_TEST_LEAK_CHECK. Disabled when empty.
+	LeakCheck string `json:"leakCheck,omitempty"`
+	// KindVersion is the kindest/node tag of new clusters, COMMONS_TEST_KIND_VERSION
+	KindVersion string `json:"kindVersion,omitempty"`
+	// ArtifactsDir is where diagnostics and command output are written, COMMONS_TEST_ARTIFACTS_DIR
+	ArtifactsDir string `json:"artifactsDir,omitempty"`
+	// CacheDir records when helm repositories and images were last refreshed across runs,
+	// COMMONS_TEST_CACHE_DIR. Defaults to commons-test in the user cache directory.
+	CacheDir string `json:"cacheDir,omitempty"`
+	// CacheTTL is how long a refreshed repository or image is reused, 0 always refreshes, COMMONS_TEST_CACHE_TTL
+	CacheTTL Duration `json:"cacheTTL,omitempty"`
+	// BinDir holds the tools installed by bootstrap, COMMONS_TEST_BIN_DIR. Defaults to bin in CacheDir.
+	BinDir string `json:"binDir,omitempty"`
+	// Tools overrides the pinned version of a tool installed by bootstrap, e.g. helm: v3.16.0.
+	// Set with COMMONS_TEST_TOOL_HELM=v3.16.0.
+	Tools map[string]string `json:"tools,omitempty"`
+	// Budgets is the maximum duration of each setup phase by report category, e.g. cluster: 3m, multiplied
+	// by the timeout Scale. Set with COMMONS_TEST_BUDGET_CLUSTER=3m.
+	Budgets  map[string]Duration `json:"budgets,omitempty"`
+	Timeouts Timeouts            `json:"timeouts,omitempty"`
+}
+
+// Timeouts are the readiness timeouts of each module, multiplied by Scale
+type Timeouts struct {
+	// Container readiness, COMMONS_TEST_TIMEOUT_CONTAINER
+	Container Duration `json:"container,omitempty"`
+	// Chart is the helm --timeout, COMMONS_TEST_TIMEOUT_CHART
+	Chart Duration `json:"chart,omitempty"`
+	// Cluster readiness of new kind nodes, COMMONS_TEST_TIMEOUT_CLUSTER
+	Cluster Duration `json:"cluster,omitempty"`
+	// Scale multiplies every timeout, e.g. 2 on slow CI runners, COMMONS_TEST_TIMEOUT_SCALE
+	Scale float64 `json:"scale,omitempty"`
+}
+
+// Duration is a time.Duration written as a string like "5m" in the config file
+type Duration time.Duration
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("duration must be a string like 5m: %w", err)
+	}
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// Default returns the built-in defaults
+func Default() *Config {
+	return &Config{
+		Images:          map[string]string{},
+		RegistryMirrors: map[string]string{},
+		Tools:           map[string]string{},
+		Budgets:         map[string]Duration{},
+		KindVersion:     "latest",
+		ArtifactsDir:    "test-artifacts",
+		CacheDir:        defaultCacheDir(),
+		CacheTTL:        Duration(time.Hour),
+		Timeouts: Timeouts{
+			Container: Duration(2 * time.Minute),
+			Chart:     Duration(5 * time.Minute),
+			Cluster:   Duration(time.Minute),
+			Scale:     1,
+		},
+	}
+}
+
+var current struct {
+	sync.Mutex
+	config *Config
+}
+
+// Get returns the configuration, loading it on first use. An invalid file or variable is logged and
+// the defaults are used instead.
+func Get() *Config {
+	current.Lock()
+	defer current.Unlock()
+	if current.config == nil {
+		config, err := Load()
+		if err != nil {
+			logger.Warnf("Using default commons-test configuration: %v", err)
+			config = Default()
+		}
+		current.config = config
+	}
+	return current.config
+}
+
+// Set replaces the configuration returned by Get, nil reloads it on the next Get
+func Set(config *Config) {
+	current.Lock()
+	defer current.Unlock()
+	current.config = config
+}
+
+// Load reads the config file (COMMONS_TEST_CONFIG or the nearest commons-test.yaml) over the defaults
+// and applies the COMMONS_TEST_* environment variables
+func Load() (*Config, error) {
+	config := Default()
+	path := os.Getenv(FileEnv)
+	if path == "" {
+		path = find(FileName)
+	}
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		if err := yaml.UnmarshalStrict(data, config); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+	}
+	if err := config.applyEnv(os.Environ()); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+func defaultCacheDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, "commons-test")
+}
+
+// find returns the nearest file named name in the working directory or its parents
+func find(name string) string {
+	dir, err := os.Getwd()
+	if err != nil {
+		return ""
+	}
+	for {
+		path := filepath.Join(dir, name)
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+func (c *Config) applyEnv(environ []string) error {
+	var errs []error
+	for _, kv := range environ {
+		key, value, _ := strings.Cut(kv, "=")
+		name, ok := strings.CutPrefix(key, EnvPrefix)
+		if !ok {
+			continue
+		}
+		var err error
+		switch {
+		case name == "CONFIG":
+		case name == "REUSE":
+			c.Reuse, err = strconv.ParseBool(value)
+		case name == "PORT_LOCKS":
+			c.PortLocks, err = strconv.ParseBool(value)
+		case name == "LEAK_CHECK":
+			if !slices.Contains([]string{"", "report", "fail"}, value) {
+				err = fmt.Errorf("expected report or fail, got %q", value)
+			}
+			c.LeakCheck = value
+		case name == "KIND_VERSION":
+			c.KindVersion = value
+		case name == "ARTIFACTS_DIR":
+			c.ArtifactsDir = value
+		case name == "CACHE_DIR":
+			c.CacheDir = value
+		case name == "CACHE_TTL":
+			err = parseDuration(value, &c.CacheTTL)
+		case name == "BIN_DIR":
+			c.BinDir = value
+		case strings.HasPrefix(name, "TOOL_"):
+			c.Tools[strings.ToLower(strings.TrimPrefix(name, "TOOL_"))] = value
+		case strings.HasPrefix(name, "BUDGET_"):
+			var budget Duration
+			err = parseDuration(value, &budget)
+			c.Budgets[strings.ToLower(strings.TrimPrefix(name, "BUDGET_"))] = budget
+		case name == "TIMEOUT_CONTAINER":
+			err = parseDuration(value, &c.Timeouts.Container)
+		case name == "TIMEOUT_CHART":
+			err = parseDuration(value, &c.Timeouts.Chart)
+		case name == "TIMEOUT_CLUSTER":
+			err = parseDuration(value, &c.Timeouts.Cluster)
+		case name == "TIMEOUT_SCALE":
+			c.Timeouts.Scale, err = strconv.ParseFloat(value, 64)
+		case name == "REGISTRY_MIRRORS":
+			for _, pair := range strings.Split(value, ",") {
+				registry, mirror, ok := strings.Cut(strings.TrimSpace(pair), "=")
+				if !ok {
+					err = fmt.Errorf("expected registry=mirror, got %q", pair)
+					break
+				}
+				c.RegistryMirrors[registry] = mirror
+			}
+		case strings.HasPrefix(name, "IMAGE_"):
+			c.Images[strings.TrimPrefix(name, "IMAGE_")] = value
+		default:
+			// Don't discard the config file and valid overrides for an unrelated variable
+			logger.Warnf("Ignoring unknown commons-test variable %s", key)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("invalid %s: %w", key, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func parseDuration(value string, d *Duration) error {
+	parsed, err := time.ParseDuration(value)
+	*d = Duration(parsed)
+	return err
+}
+
+// ContainerTimeout is how long containers may take to become ready
+func (c *Config) ContainerTimeout() time.Duration {
+	return c.scale(c.Timeouts.Container)
+}
+
+// ChartTimeout is how long helm waits for a release
+func (c *Config) ChartTimeout() time.Duration {
+	return c.scale(c.Timeouts.Chart)
+}
+
+// ClusterTimeout is how long new kind nodes may take to become ready
+func (c *Config) ClusterTimeout() time.Duration {
+	return c.scale(c.Timeouts.Cluster)
+}
+
+// Budget is the maximum duration of a setup phase, 0 when unlimited
+func (c *Config) Budget(category string) time.Duration {
+	return c.scale(c.Budgets[category])
+}
+
+func (c *Config) scale(d Duration) time.Duration {
+	if c.Timeouts.Scale <= 0 {
+		return time.Duration(d)
+	}
+	return time.Duration(float64(d) * c.Timeouts.Scale)
+}
+
+var unsafeEnvChars = regexp.MustCompile(`[^A-Z0-9]+`)
+
+// Image returns image with any override for its repository and registry mirror applied. Overrides are
+// matched on the repository without tag, e.g. "postgres" or "ghcr.io/flanksource/config-db", and on its
+// environment form, e.g. POSTGRES or GHCR_IO_FLANKSOURCE_CONFIG_DB.
+func (c *Config) Image(image string) string {
+	repository := image
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		repository = image[:i]
+	}
+	if override, ok := c.Images[repository]; ok {
+		image = override
+	} else if override, ok := c.Images[unsafeEnvChars.ReplaceAllString(strings.ToUpper(repository), "_")]; ok {
+		image = override
+	}
+	return c.mirror(image)
+}
+
+// mirror rewrites the registry of image, docker hub images without a registry being docker.io
+func (c *Config) mirror(image string) string {
+	if len(c.RegistryMirrors) == 0 {
+		return image
+	}
+	registry, rest := "docker.io", image
+	if first, remainder, ok := strings.Cut(image, "/"); ok && (strings.ContainsAny(first, ".:") || first == "localhost") {
+		registry, rest = first, remainder
+	}
+	mirror, ok := c.RegistryMirrors[registry]
+	if !ok {
+		return image
+	}
+	if registry == "docker.io" && !strings.Contains(rest, "/") {
+		rest = "library/" + rest
+	}
+	return mirror + "/" + rest
+}
@@ -0,0 +1,58 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestApplyEnv(t *testing.T) {
+	c := Default()
+	err := c.applyEnv([]string{
+		"COMMONS_TEST_REUSE=true",
+		"COMMONS_TEST_TIMEOUT_CHART=10m",
+		"COMMONS_TEST_TIMEOUT_SCALE=2",
+		"COMMONS_TEST_IMAGE_POSTGRES=postgres:16-alpine",
+		"COMMONS_TEST_REGISTRY_MIRRORS=docker.io=mirror.gcr.io",
+		"COMMONS_TEST_TOOL_HELM=v3.16.0",
+		"HOME=/root",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !c.Reuse {
+		t.Error("expected reuse")
+	}
+	if c.Tools["helm"] != "v3.16.0" {
+		t.Errorf("expected the helm version override, got %v", c.Tools)
+	}
+	if got := c.ChartTimeout(); got != 20*time.Minute {
+		t.Errorf("expected a scaled chart timeout of 20m, got %v", got)
+	}
+	if err := Default().applyEnv([]string{"COMMONS_TEST_TIMEOUT_CHART=soon"}); err == nil || !strings.Contains(err.Error(), "COMMONS_TEST_TIMEOUT_CHART") {
+		t.Errorf("expected the invalid variable to be named, got %v", err)
+	}
+	c = Default()
+	if err := c.applyEnv([]string{"COMMONS_TEST_RESUE=true", "COMMONS_TEST_REUSE=true"}); err != nil || !c.Reuse {
+		t.Errorf("expected unknown variables to be ignored, got %v", err)
+	}
+}
+
+func TestImage(t *testing.T) {
+	c := Default()
+	c.Images["POSTGRES"] = "postgres:16-alpine"
+	c.RegistryMirrors["docker.io"] = "mirror.gcr.io"
+	c.RegistryMirrors["ghcr.io"] = "ghcr.example.com"
+
+	for image, want := range map[string]string{
+		"postgres:14":                      "mirror.gcr.io/library/postgres:16-alpine",
+		"grafana/loki:3.0":                 "mirror.gcr.io/grafana/loki:3.0",
+		"ghcr.io/flanksource/config-db:v1": "ghcr.example.com/flanksource/config-db:v1",
+		"localhost:5000/app":               "localhost:5000/app",
+		"quay.io/prometheus/prometheus:v2": "quay.io/prometheus/prometheus:v2",
+	} {
+		if got := c.Image(image); got != want {
+			t.Errorf("Image(%q) = %q, want %q", image, got, want)
+		}
+	}
+}
@@ -2,20 +2,62 @@ package container
 
 import (
 	"context"
+	"embed"
 	"fmt"
-	"io"
 	"net/http"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
+
+	"github.com/flanksource/commons-test/fixtures"
+	"github.com/flanksource/commons-test/names"
+	"github.com/flanksource/commons-test/probe"
+	"github.com/flanksource/commons-test/wait"
+)
+
+//go:embed fixtures/activemq.xml.tmpl
+var activemqFixtures embed.FS
+
+const (
+	// ActiveMQImage is the default ActiveMQ Classic image repository
+	ActiveMQImage = "apache/activemq-classic"
+	// ActiveMQVersion is the default ActiveMQ Classic image tag
+	ActiveMQVersion = "5.18.7"
 )
 
+// BrokerConfig describes the activemq.xml rendered for the container
+type BrokerConfig struct {
+	BrokerName string
+	Persistent bool
+	StoreLimit string   // e.g. "1 gb"
+	TempLimit  string   // e.g. "512 mb"
+	Queues     []string // queues created when the broker starts
+	Topics     []string // topics created when the broker starts
+}
+
+// DefaultBrokerConfig returns the broker configuration used when none is provided
+func DefaultBrokerConfig() BrokerConfig {
+	return BrokerConfig{
+		BrokerName: "localhost",
+		Persistent: true,
+		StoreLimit: "1 gb",
+		TempLimit:  "512 mb",
+	}
+}
+
 // ActiveMQContainer provides specialized ActiveMQ container management
 type ActiveMQContainer struct {
 	*Container
 	username      string
 	password      string
+	configPath    string
+	brokerName    string
 	brokerURL     string
+	stompURL      string
+	amqpURL       string
+	mqttURL       string
+	webSocketURL  string
 	webConsoleURL string
 }
 
@@ -31,59 +73,30 @@ func NewActiveMQ(name, username, password string, reuse bool) (*ActiveMQContaine
 	}
 
 	// Create temporary directories for ActiveMQ data and configuration
-	tempDataDir, err := os.MkdirTemp("", "activemq-data-"+name+"-*")
+	tempDataDir, err := os.MkdirTemp("", names.TempPrefix("activemq-data")+name+"-*")
 	if err != nil {
 		return nil, fmt.Errorf("failed to create temp data directory: %w", err)
 	}
 
-	tempConfDir, err := os.MkdirTemp("", "activemq-conf-"+name+"-*")
+	tempConfDir, err := os.MkdirTemp("", names.TempPrefix("activemq-conf")+name+"-*")
 	if err != nil {
 		return nil, fmt.Errorf("failed to create temp conf directory: %w", err)
 	}
 
-	// Find configuration files by walking up the directory tree
-	var fixturesDir string
-	cwd, err := os.Getwd()
-	if err != nil {
-		return nil, fmt.Errorf("failed to get working directory: %w", err)
-	}
-
-	// Search for test/fixtures directory by walking up from current directory
-	currentDir := cwd
-	for {
-		testPath := filepath.Join(currentDir, "test", "fixtures")
-		if stat, err := os.Stat(testPath); err == nil && stat.IsDir() {
-			fixturesDir = testPath
-			break
-		}
-
-		// Move up one directory
-		parentDir := filepath.Dir(currentDir)
-		if parentDir == currentDir {
-			// Reached filesystem root
-			break
-		}
-		currentDir = parentDir
-	}
-
-	if fixturesDir == "" {
-		return nil, fmt.Errorf("test/fixtures directory not found from %s", cwd)
-	}
-
-	// Copy configuration files, but we'll only mount activemq.xml and let the container
-	// use its default jetty.xml with an environment variable override for host binding
-	activemqXmlSrc := filepath.Join(fixturesDir, "activemq.xml")
 	activemqXmlDest := filepath.Join(tempConfDir, "activemq.xml")
-
-	if err := copyFile(activemqXmlSrc, activemqXmlDest); err != nil {
-		return nil, fmt.Errorf("failed to copy activemq.xml: %w", err)
+	if err := writeBrokerConfig(activemqXmlDest, DefaultBrokerConfig()); err != nil {
+		return nil, err
 	}
 
 	config := Config{
-		Image: "apache/activemq-classic:5.18.7",
+		Image: ActiveMQImage + ":" + ActiveMQVersion,
 		Name:  name,
 		Ports: map[string]string{
 			"61616": "0", // OpenWire broker port
+			"61613": "0", // STOMP
+			"5672":  "0", // AMQP
+			"1883":  "0", // MQTT
+			"61614": "0", // WebSocket (STOMP/MQTT over ws)
 			"8161":  "0", // Web console port
 			"1099":  "0", // JMX monitoring port
 		},
@@ -114,9 +127,11 @@ func NewActiveMQ(name, username, password string, reuse bool) (*ActiveMQContaine
 	}
 
 	activemqContainer := &ActiveMQContainer{
-		Container: container,
-		username:  username,
-		password:  password,
+		Container:  container,
+		username:   username,
+		password:   password,
+		configPath: activemqXmlDest,
+		brokerName: DefaultBrokerConfig().BrokerName,
 	}
 
 	// Now we can log since we have the container instance
@@ -128,6 +143,41 @@ func NewActiveMQ(name, username, password string, reuse bool) (*ActiveMQContaine
 	return activemqContainer, nil
 }
 
+// WithImage overrides the image, accepting either a full image reference or
+// a tag of ActiveMQImage (e.g. "5.18.3"), must be called before Start
+func (a *ActiveMQContainer) WithImage(image string) *ActiveMQContainer {
+	if !strings.ContainsAny(image, ":/") {
+		image = ActiveMQImage + ":" + image
+	}
+	a.config.Image = image
+	return a
+}
+
+// WithConfigFile replaces the default activemq.xml with a verbatim copy of a custom file, must be called
+// before Start. The file is expected to use the default brokerName of "localhost" for the management
+// helpers to work.
+func (a *ActiveMQContainer) WithConfigFile(path string) (*ActiveMQContainer, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file %s: %w", path, err)
+	}
+	if err := os.WriteFile(a.configPath, data, 0644); err != nil {
+		return nil, fmt.Errorf("failed to write config file: %w", err)
+	}
+	return a, nil
+}
+
+// WithBrokerConfig renders activemq.xml from cfg, must be called before Start
+func (a *ActiveMQContainer) WithBrokerConfig(cfg BrokerConfig) (*ActiveMQContainer, error) {
+	if err := writeBrokerConfig(a.configPath, cfg); err != nil {
+		return nil, err
+	}
+	if cfg.BrokerName != "" {
+		a.brokerName = cfg.BrokerName
+	}
+	return a, nil
+}
+
 // Start starts the ActiveMQ container and waits for it to be ready
 func (a *ActiveMQContainer) Start(ctx context.Context) error {
 	a.Infof("Starting container")
@@ -158,9 +208,23 @@ func (a *ActiveMQContainer) Start(ctx context.Context) error {
 		return fmt.Errorf("failed to get ActiveMQ JMX port: %w", err)
 	}
 
+	protocolPorts := map[string]string{}
+	for _, port := range []string{"61613", "5672", "1883", "61614"} {
+		hostPort, err := a.GetPort(port)
+		if err != nil {
+			a.Errorf("Failed to get port %s mapping: %v", port, err)
+			return fmt.Errorf("failed to get ActiveMQ port %s: %w", port, err)
+		}
+		protocolPorts[port] = hostPort
+	}
+
 	// Build URLs
 	a.brokerURL = fmt.Sprintf("tcp://localhost:%s", brokerPort)
 	a.webConsoleURL = fmt.Sprintf("http://localhost:%s", webPort)
+	a.stompURL = fmt.Sprintf("tcp://localhost:%s", protocolPorts["61613"])
+	a.amqpURL = fmt.Sprintf("amqp://localhost:%s", protocolPorts["5672"])
+	a.mqttURL = fmt.Sprintf("tcp://localhost:%s", protocolPorts["1883"])
+	a.webSocketURL = fmt.Sprintf("ws://localhost:%s", protocolPorts["61614"])
 
 	a.Infof("Port mappings - Broker: %s, Web Console: %s, JMX: %s",
 		brokerPort, webPort, jmxPort)
@@ -196,6 +260,26 @@ func (a *ActiveMQContainer) GetTCPBrokerURL() string {
 	return a.brokerURL
 }
 
+// GetStompURL returns the STOMP broker URL
+func (a *ActiveMQContainer) GetStompURL() string {
+	return a.stompURL
+}
+
+// GetAMQPURL returns the AMQP 1.0 broker URL
+func (a *ActiveMQContainer) GetAMQPURL() string {
+	return a.amqpURL
+}
+
+// GetMQTTURL returns the MQTT broker URL
+func (a *ActiveMQContainer) GetMQTTURL() string {
+	return a.mqttURL
+}
+
+// GetWebSocketURL returns the WebSocket broker URL used for STOMP and MQTT over ws
+func (a *ActiveMQContainer) GetWebSocketURL() string {
+	return a.webSocketURL
+}
+
 // GetJMXPort returns the JMX monitoring port
 func (a *ActiveMQContainer) GetJMXPort() (string, error) {
 	return a.GetPort("1099")
@@ -218,84 +302,24 @@ func (a *ActiveMQContainer) GetCredentials() (string, string) {
 
 // waitForReady waits for ActiveMQ to be ready to accept connections
 func (a *ActiveMQContainer) waitForReady(ctx context.Context) error {
-	maxRetries := 60 // ActiveMQ can take longer to start than SQL Server
-	retryDelay := 2 * time.Second
-
-	a.Infof("Starting readiness check (max %d attempts, %v between attempts)", maxRetries, retryDelay)
-
-	for i := 0; i < maxRetries; i++ {
-		select {
-		case <-ctx.Done():
-			a.Infof("Context cancelled during readiness check")
-			return ctx.Err()
-		default:
-		}
-
-		a.Infof("Readiness check attempt %d/%d", i+1, maxRetries)
-
-		if a.testConnection() {
-			a.Infof("Readiness check passed after %d attempts", i+1)
-			return nil
+	// ActiveMQ can take longer to start than SQL Server
+	timeout := 2 * time.Minute
+	a.Infof("Starting readiness check (up to %v)", timeout)
+
+	return a.Container.waitUntil(ctx, "ActiveMQ", 2*time.Second, timeout, wait.NoError(func(ctx context.Context) error {
+		_, err := a.webConsoleProbe().Check(ctx)
+		if err != nil {
+			a.Infof("Web console health check failed: %v", err)
 		}
-
-		// If we've failed several attempts, print logs for debugging
-		if i > 0 && (i+1)%10 == 0 {
-			a.Infof("Printing container logs for debugging after %d failed attempts", i+1)
-			a.Container.PrintLogsOnFailure(ctx, fmt.Sprintf("ActiveMQ readiness check failing after %d attempts", i+1))
-		}
-
-		if i < maxRetries-1 {
-			a.Infof("Readiness check failed, waiting %v before retry...", retryDelay)
-			time.Sleep(retryDelay)
-		}
-	}
-
-	a.Errorf("Readiness check failed after %d attempts", maxRetries)
-	a.Container.PrintLogsOnFailure(ctx, fmt.Sprintf("ActiveMQ readiness check failed after %d attempts", maxRetries))
-	return fmt.Errorf("ActiveMQ failed to become ready after %d attempts", maxRetries)
+		return err
+	}))
 }
 
-// testConnection tests if ActiveMQ is ready using web console health check
-func (a *ActiveMQContainer) testConnection() bool {
-	if a.webConsoleURL == "" {
-		a.Errorf("Web console URL not set - container may not be properly started")
-		return false
-	}
-
-	a.Infof("Testing web console health: %s", a.webConsoleURL)
-
-	client := &http.Client{
-		Timeout: 3 * time.Second,
-	}
-
-	// Test without credentials - should return 401 if service is up and running
-	req, err := http.NewRequest("GET", a.webConsoleURL+"/", nil)
-	if err != nil {
-		a.Infof("Failed to create web console request: %v", err)
-		return false
-	}
-
-	resp, err := client.Do(req)
-	if err != nil {
-		a.Infof("Web console request failed: %v", err)
-		return false
-	}
-	defer resp.Body.Close()
-
-	a.Infof("Web console response status: %d", resp.StatusCode)
-
-	// Accept 401 (unauthorized), 200 (success), or 302 (redirect) as indication of readiness
-	success := resp.StatusCode == http.StatusUnauthorized ||
-		resp.StatusCode == http.StatusOK ||
-		resp.StatusCode == http.StatusFound
-
-	if success {
-		a.Infof("Web console health check successful - service is running")
-	} else {
-		a.Infof("Web console health check failed with unexpected status %d", resp.StatusCode)
-	}
-
-	return success
+// webConsoleProbe checks the web console without credentials, a 401, 200 or 302 meaning it is up
+func (a *ActiveMQContainer) webConsoleProbe() *probe.HTTPProbe {
+	return probe.HTTP(a.webConsoleURL+"/").
+		ExpectStatus(http.StatusUnauthorized, http.StatusOK, http.StatusFound).
+		WithTimeout(3 * time.Second)
 }
 
 // HealthCheck performs a comprehensive health check
@@ -304,27 +328,8 @@ func (a *ActiveMQContainer) HealthCheck() error {
 		return fmt.Errorf("web console URL not set - container may not be started")
 	}
 
-	// Test web console connection without credentials - should return 401 if service is up
-	client := &http.Client{
-		Timeout: 5 * time.Second,
-	}
-
-	req, err := http.NewRequest("GET", a.webConsoleURL+"/", nil)
-	if err != nil {
-		return fmt.Errorf("health check failed - cannot create request: %w", err)
-	}
-
-	resp, err := client.Do(req)
-	if err != nil {
-		return fmt.Errorf("health check failed - web console request failed: %w", err)
-	}
-	defer resp.Body.Close()
-
-	// Accept 401 (unauthorized), 200 (success), or 302 (redirect) as indication of health
-	if resp.StatusCode != http.StatusUnauthorized &&
-		resp.StatusCode != http.StatusOK &&
-		resp.StatusCode != http.StatusFound {
-		return fmt.Errorf("health check failed - web console returned unexpected status %d", resp.StatusCode)
+	if _, err := a.webConsoleProbe().WithTimeout(5 * time.Second).Check(context.Background()); err != nil {
+		return fmt.Errorf("health check failed - %w", err)
 	}
 
 	// // Test ActiveMQ client connection
@@ -342,20 +347,33 @@ func (a *ActiveMQContainer) HealthCheck() error {
 	return nil
 }
 
-// copyFile copies a file from src to dst
-func copyFile(src, dst string) error {
-	srcFile, err := os.Open(src)
-	if err != nil {
-		return err
+// writeBrokerConfig renders the embedded activemq.xml fixture to path
+func writeBrokerConfig(path string, cfg BrokerConfig) error {
+	defaults := DefaultBrokerConfig()
+	if cfg.BrokerName == "" {
+		cfg.BrokerName = defaults.BrokerName
+	}
+	if cfg.StoreLimit == "" {
+		cfg.StoreLimit = defaults.StoreLimit
+	}
+	if cfg.TempLimit == "" {
+		cfg.TempLimit = defaults.TempLimit
 	}
-	defer srcFile.Close()
 
-	dstFile, err := os.Create(dst)
+	fixture, err := fixtures.Load(activemqFixtures, "fixtures/activemq.xml.tmpl")
 	if err != nil {
 		return err
 	}
-	defer dstFile.Close()
+	return fixture.WriteFile(path, brokerVars(cfg))
+}
 
-	_, err = io.Copy(dstFile, srcFile)
-	return err
+// brokerVars exposes the BrokerConfig fields to the activemq.xml template
+func brokerVars(cfg BrokerConfig) fixtures.Vars {
+	return fixtures.NewVars("").
+		With("BrokerName", cfg.BrokerName).
+		With("Persistent", cfg.Persistent).
+		With("StoreLimit", cfg.StoreLimit).
+		With("TempLimit", cfg.TempLimit).
+		With("Queues", cfg.Queues).
+		With("Topics", cfg.Topics)
 }
@@ -0,0 +1,136 @@
+package container
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	commonsHTTP "github.com/flanksource/commons/http"
+)
+
+// jolokiaRequest is a single Jolokia read/exec request
+type jolokiaRequest struct {
+	Type      string `json:"type"`
+	MBean     string `json:"mbean"`
+	Operation string `json:"operation,omitempty"`
+	Attribute string `json:"attribute,omitempty"`
+	Arguments []any  `json:"arguments,omitempty"`
+}
+
+// CreateQueue creates a queue on the broker, succeeding if it already exists
+func (a *ActiveMQContainer) CreateQueue(ctx context.Context, name string) error {
+	if _, err := a.jolokia(ctx, jolokiaRequest{
+		Type:      "exec",
+		MBean:     a.brokerMBean(),
+		Operation: "addQueue(java.lang.String)",
+		Arguments: []any{name},
+	}); err != nil {
+		return fmt.Errorf("failed to create queue %s: %w", name, err)
+	}
+	return nil
+}
+
+// CreateTopic creates a topic on the broker, succeeding if it already exists
+func (a *ActiveMQContainer) CreateTopic(ctx context.Context, name string) error {
+	if _, err := a.jolokia(ctx, jolokiaRequest{
+		Type:      "exec",
+		MBean:     a.brokerMBean(),
+		Operation: "addTopic(java.lang.String)",
+		Arguments: []any{name},
+	}); err != nil {
+		return fmt.Errorf("failed to create topic %s: %w", name, err)
+	}
+	return nil
+}
+
+// PurgeQueue removes all pending messages from a queue
+func (a *ActiveMQContainer) PurgeQueue(ctx context.Context, name string) error {
+	if _, err := a.jolokia(ctx, jolokiaRequest{
+		Type:      "exec",
+		MBean:     a.queueMBean(name),
+		Operation: "purge()",
+	}); err != nil {
+		return fmt.Errorf("failed to purge queue %s: %w", name, err)
+	}
+	return nil
+}
+
+// GetQueueDepth returns the number of pending messages on a queue
+func (a *ActiveMQContainer) GetQueueDepth(ctx context.Context, name string) (int64, error) {
+	value, err := a.jolokia(ctx, jolokiaRequest{
+		Type:      "read",
+		MBean:     a.queueMBean(name),
+		Attribute: "QueueSize",
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to get depth of queue %s: %w", name, err)
+	}
+
+	var depth int64
+	if err := json.Unmarshal(value, &depth); err != nil {
+		return 0, fmt.Errorf("unexpected QueueSize value %s: %w", value, err)
+	}
+	return depth, nil
+}
+
+// SendTestMessages sends n text messages to a queue, creating it if needed
+func (a *ActiveMQContainer) SendTestMessages(ctx context.Context, queue string, n int) error {
+	if err := a.CreateQueue(ctx, queue); err != nil {
+		return err
+	}
+
+	for i := 0; i < n; i++ {
+		if _, err := a.jolokia(ctx, jolokiaRequest{
+			Type:      "exec",
+			MBean:     a.queueMBean(queue),
+			Operation: "sendTextMessage(java.lang.String)",
+			Arguments: []any{fmt.Sprintf("test message %d", i+1)},
+		}); err != nil {
+			return fmt.Errorf("failed to send message %d to %s: %w", i+1, queue, err)
+		}
+	}
+	return nil
+}
+
+func (a *ActiveMQContainer) brokerMBean() string {
+	return fmt.Sprintf("org.apache.activemq:type=Broker,brokerName=%s", a.brokerName)
+}
+
+func (a *ActiveMQContainer) queueMBean(name string) string {
+	return fmt.Sprintf("%s,destinationType=Queue,destinationName=%s", a.brokerMBean(), name)
+}
+
+// jolokia executes a request against the web console's Jolokia endpoint and returns its value
+func (a *ActiveMQContainer) jolokia(ctx context.Context, request jolokiaRequest) (json.RawMessage, error) {
+	if a.webConsoleURL == "" {
+		return nil, fmt.Errorf("container not started")
+	}
+
+	// Jolokia rejects requests without an Origin matching its CORS policy
+	client := commonsHTTP.NewClient().
+		BaseURL(a.webConsoleURL+"/api/jolokia").
+		Auth(a.username, a.password).
+		Header("Origin", a.webConsoleURL)
+
+	r, err := client.R(ctx).Header("Content-Type", "application/json").Post("/", request)
+	if err != nil {
+		return nil, err
+	}
+	if !r.IsOK() {
+		body, _ := r.AsString()
+		return nil, fmt.Errorf("jolokia returned status %d: %s", r.StatusCode, body)
+	}
+
+	var response struct {
+		Status int             `json:"status"`
+		Value  json.RawMessage `json:"value"`
+		Error  string          `json:"error"`
+	}
+	if err := r.Into(&response); err != nil {
+		return nil, err
+	}
+	if response.Status != 200 {
+		return nil, fmt.Errorf("jolokia %s %s failed: %s", request.Type, request.MBean, response.Error)
+	}
+	return response.Value, nil
+}
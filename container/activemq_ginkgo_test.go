@@ -2,6 +2,8 @@ package container
 
 import (
 	"context"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
@@ -28,6 +30,10 @@ var _ = Describe("ActiveMQ Container", func() {
 				Expect(config.Ports).To(HaveKey("61616")) // OpenWire
 				Expect(config.Ports).To(HaveKey("8161"))  // Web console
 				Expect(config.Ports).To(HaveKey("1099"))  // JMX
+				Expect(config.Ports).To(HaveKey("61613")) // STOMP
+				Expect(config.Ports).To(HaveKey("5672"))  // AMQP
+				Expect(config.Ports).To(HaveKey("1883"))  // MQTT
+				Expect(config.Ports).To(HaveKey("61614")) // WebSocket
 
 				By("Verifying environment variables")
 				envMap := make(map[string]string)
@@ -69,6 +75,68 @@ var _ = Describe("ActiveMQ Container", func() {
 		})
 	})
 
+	Describe("Image Override", func() {
+		It("should accept a tag or a full image reference", func() {
+			container, err := NewActiveMQ("test-activemq-image", "", "", false)
+			Expect(err).ToNot(HaveOccurred())
+
+			container.WithImage("5.18.3")
+			Expect(container.Container.config.Image).To(Equal("apache/activemq-classic:5.18.3"))
+
+			container.WithImage("registry.local/activemq:custom")
+			Expect(container.Container.config.Image).To(Equal("registry.local/activemq:custom"))
+		})
+	})
+
+	Describe("Broker Configuration", func() {
+		It("should render the embedded default activemq.xml", func() {
+			container, err := NewActiveMQ("test-activemq-config", "", "", false)
+			Expect(err).ToNot(HaveOccurred())
+
+			data, err := os.ReadFile(container.configPath)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(data)).To(ContainSubstring(`brokerName="localhost"`))
+			Expect(string(data)).To(ContainSubstring(`<transportConnector name="openwire"`))
+			Expect(string(data)).ToNot(ContainSubstring("<destinations>"))
+		})
+
+		It("should render destinations from a BrokerConfig", func() {
+			container, err := NewActiveMQ("test-activemq-broker-config", "", "", false)
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = container.WithBrokerConfig(BrokerConfig{
+				BrokerName: "test-broker",
+				Queues:     []string{"orders", "a&b"},
+				Topics:     []string{"events"},
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			data, err := os.ReadFile(container.configPath)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(data)).To(ContainSubstring(`brokerName="test-broker"`))
+			Expect(string(data)).To(ContainSubstring(`persistent="false"`))
+			Expect(string(data)).To(ContainSubstring(`<queue physicalName="orders"/>`))
+			Expect(string(data)).To(ContainSubstring(`<queue physicalName="a&amp;b"/>`))
+			Expect(string(data)).To(ContainSubstring(`<topic physicalName="events"/>`))
+			Expect(string(data)).To(ContainSubstring(`<storeUsage limit="1 gb"/>`))
+		})
+
+		It("should copy a custom configuration file", func() {
+			container, err := NewActiveMQ("test-activemq-config-file", "", "", false)
+			Expect(err).ToNot(HaveOccurred())
+
+			custom := filepath.Join(GinkgoT().TempDir(), "activemq.xml")
+			Expect(os.WriteFile(custom, []byte("<beans/>"), 0644)).To(Succeed())
+
+			_, err = container.WithConfigFile(custom)
+			Expect(err).ToNot(HaveOccurred())
+
+			data, err := os.ReadFile(container.configPath)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(data)).To(Equal("<beans/>"))
+		})
+	})
+
 	Describe("Container Methods", func() {
 		Context("when container is not started", func() {
 			It("should handle method calls gracefully", func() {
@@ -79,6 +147,13 @@ var _ = Describe("ActiveMQ Container", func() {
 				Expect(container.GetBrokerURL()).To(BeEmpty())
 				Expect(container.GetTCPBrokerURL()).To(BeEmpty())
 				Expect(container.GetWebConsoleURL()).To(BeEmpty())
+				Expect(container.GetStompURL()).To(BeEmpty())
+				Expect(container.GetAMQPURL()).To(BeEmpty())
+
+				By("Testing management helpers before starting")
+				_, err = container.GetQueueDepth(context.Background(), "orders")
+				Expect(err).To(MatchError(ContainSubstring("container not started")))
+				Expect(container.queueMBean("orders")).To(Equal("org.apache.activemq:type=Broker,brokerName=localhost,destinationType=Queue,destinationName=orders"))
 
 				By("Testing JMX port before starting")
 				_, err = container.GetJMXPort()
@@ -0,0 +1,248 @@
+package container
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/flanksource/commons-test/wait"
+)
+
+const (
+	// AzuriteAccountName is the well-known development storage account name
+	AzuriteAccountName = "devstoreaccount1"
+	// AzuriteAccountKey is the well-known development storage account key
+	AzuriteAccountKey = "Eby8vdM02xNOcqFlqUwJPLlmEtlCDXJ1OUzFT50uSRZ6IFsuFq2UVErCz4I6tq/K1SZFPTOtr/KBHBeksoGMGw=="
+)
+
+// AzuriteContainer provides specialized Azurite (Azure Storage emulator) container management
+type AzuriteContainer struct {
+	*Container
+	blobURL  string
+	queueURL string
+	tableURL string
+}
+
+// NewAzurite creates a new Azurite container emulating Blob, Queue and Table storage
+func NewAzurite(name string, reuse bool) (*AzuriteContainer, error) {
+	config := Config{
+		Image: "mcr.microsoft.com/azure-storage/azurite:3.31.0",
+		Name:  name,
+		Cmd: []string{"azurite",
+			"--blobHost", "0.0.0.0",
+			"--queueHost", "0.0.0.0",
+			"--tableHost", "0.0.0.0",
+			"--skipApiVersionCheck",
+			"--loose",
+		},
+		Ports: map[string]string{
+			"10000": "0", // Blob
+			"10001": "0", // Queue
+			"10002": "0", // Table
+		},
+		Reuse: reuse,
+	}
+
+	container, err := New(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Azurite container: %w", err)
+	}
+
+	return &AzuriteContainer{
+		Container: container,
+	}, nil
+}
+
+// Start starts the Azurite container and waits for the blob service to respond
+func (a *AzuriteContainer) Start(ctx context.Context) error {
+	if err := a.Container.Start(ctx); err != nil {
+		return fmt.Errorf("failed to start Azurite container: %w", err)
+	}
+
+	for port, target := range map[string]*string{"10000": &a.blobURL, "10001": &a.queueURL, "10002": &a.tableURL} {
+		hostPort, err := a.GetPort(port)
+		if err != nil {
+			return fmt.Errorf("failed to get Azurite port %s: %w", port, err)
+		}
+		*target = fmt.Sprintf("http://localhost:%s/%s", hostPort, AzuriteAccountName)
+	}
+
+	return a.waitForReady(ctx)
+}
+
+// GetBlobURL returns the blob service endpoint, including the account name
+func (a *AzuriteContainer) GetBlobURL() string {
+	return a.blobURL
+}
+
+// GetQueueURL returns the queue service endpoint, including the account name
+func (a *AzuriteContainer) GetQueueURL() string {
+	return a.queueURL
+}
+
+// GetTableURL returns the table service endpoint, including the account name
+func (a *AzuriteContainer) GetTableURL() string {
+	return a.tableURL
+}
+
+// GetConnectionString returns a connection string covering all three services
+func (a *AzuriteContainer) GetConnectionString() string {
+	return fmt.Sprintf("DefaultEndpointsProtocol=http;AccountName=%s;AccountKey=%s;BlobEndpoint=%s;QueueEndpoint=%s;TableEndpoint=%s;",
+		AzuriteAccountName, AzuriteAccountKey, a.blobURL, a.queueURL, a.tableURL)
+}
+
+// GetBlobConnectionString returns a connection string for the blob service only
+func (a *AzuriteContainer) GetBlobConnectionString() string {
+	return a.serviceConnectionString("BlobEndpoint", a.blobURL)
+}
+
+// GetQueueConnectionString returns a connection string for the queue service only
+func (a *AzuriteContainer) GetQueueConnectionString() string {
+	return a.serviceConnectionString("QueueEndpoint", a.queueURL)
+}
+
+// GetTableConnectionString returns a connection string for the table service only
+func (a *AzuriteContainer) GetTableConnectionString() string {
+	return a.serviceConnectionString("TableEndpoint", a.tableURL)
+}
+
+func (a *AzuriteContainer) serviceConnectionString(key, endpoint string) string {
+	return fmt.Sprintf("DefaultEndpointsProtocol=http;AccountName=%s;AccountKey=%s;%s=%s;",
+		AzuriteAccountName, AzuriteAccountKey, key, endpoint)
+}
+
+// CreateContainer creates a blob container, succeeding if it already exists
+func (a *AzuriteContainer) CreateContainer(ctx context.Context, name string) error {
+	resp, err := a.blobRequest(ctx, http.MethodPut, "/"+name, url.Values{"restype": {"container"}})
+	if err != nil {
+		return fmt.Errorf("failed to create blob container %s: %w", name, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusConflict {
+		return fmt.Errorf("failed to create blob container %s: status %d", name, resp.StatusCode)
+	}
+	return nil
+}
+
+// HealthCheck lists blob containers to verify the blob service accepts authenticated requests
+func (a *AzuriteContainer) HealthCheck() error {
+	if a.blobURL == "" {
+		return fmt.Errorf("blob URL not set - container may not be started")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	resp, err := a.blobRequest(ctx, http.MethodGet, "", url.Values{"comp": {"list"}})
+	if err != nil {
+		return fmt.Errorf("health check failed - list containers failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("health check failed - list containers returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// waitForReady waits for the blob service to accept authenticated requests
+func (a *AzuriteContainer) waitForReady(ctx context.Context) error {
+	return a.Container.waitUntil(ctx, "Azurite", time.Second, 30*time.Second, wait.NoError(func(ctx context.Context) error {
+		return a.HealthCheck()
+	}))
+}
+
+// blobRequest sends a SharedKey-signed request to the blob service
+func (a *AzuriteContainer) blobRequest(ctx context.Context, method, path string, query url.Values) (*http.Response, error) {
+	if a.blobURL == "" {
+		return nil, fmt.Errorf("container not started")
+	}
+
+	u, err := url.Parse(a.blobURL + path)
+	if err != nil {
+		return nil, err
+	}
+	u.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("x-ms-date", time.Now().UTC().Format(http.TimeFormat))
+	req.Header.Set("x-ms-version", "2021-08-06")
+
+	signature, err := azureSharedKeySignature(req, AzuriteAccountName, AzuriteAccountKey)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", fmt.Sprintf("SharedKey %s:%s", AzuriteAccountName, signature))
+
+	return http.DefaultClient.Do(req)
+}
+
+// azureSharedKeySignature signs a request using the Azure Storage SharedKey scheme
+func azureSharedKeySignature(req *http.Request, account, key string) (string, error) {
+	var msHeaders []string
+	for name := range req.Header {
+		if lower := strings.ToLower(name); strings.HasPrefix(lower, "x-ms-") {
+			msHeaders = append(msHeaders, lower)
+		}
+	}
+	sort.Strings(msHeaders)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range msHeaders {
+		canonicalHeaders.WriteString(name + ":" + strings.TrimSpace(req.Header.Get(name)) + "\n")
+	}
+
+	// Path-style (emulator) URLs already contain the account name, which is
+	// prefixed again in the canonical resource
+	canonicalResource := "/" + account + req.URL.EscapedPath()
+	query := req.URL.Query()
+	var params []string
+	for name := range query {
+		params = append(params, strings.ToLower(name))
+	}
+	sort.Strings(params)
+	for _, name := range params {
+		values := query[name]
+		sort.Strings(values)
+		canonicalResource += "\n" + name + ":" + strings.Join(values, ",")
+	}
+
+	contentLength := ""
+	if req.ContentLength > 0 {
+		contentLength = fmt.Sprintf("%d", req.ContentLength)
+	}
+
+	stringToSign := strings.Join([]string{
+		req.Method,
+		req.Header.Get("Content-Encoding"),
+		req.Header.Get("Content-Language"),
+		contentLength,
+		req.Header.Get("Content-MD5"),
+		req.Header.Get("Content-Type"),
+		"", // Date, x-ms-date is used instead
+		req.Header.Get("If-Modified-Since"),
+		req.Header.Get("If-Match"),
+		req.Header.Get("If-None-Match"),
+		req.Header.Get("If-Unmodified-Since"),
+		req.Header.Get("Range"),
+	}, "\n") + "\n" + canonicalHeaders.String() + canonicalResource
+
+	decodedKey, err := base64.StdEncoding.DecodeString(key)
+	if err != nil {
+		return "", fmt.Errorf("invalid account key: %w", err)
+	}
+	mac := hmac.New(sha256.New, decodedKey)
+	mac.Write([]byte(stringToSign))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil)), nil
+}
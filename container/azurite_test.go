@@ -0,0 +1,114 @@
+package container
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// parseConnectionString splits an Azure Storage connection string into its settings
+func parseConnectionString(s string) map[string]string {
+	settings := map[string]string{}
+	for _, part := range strings.Split(strings.TrimSuffix(s, ";"), ";") {
+		key, value, ok := strings.Cut(part, "=")
+		Expect(ok).To(BeTrue(), "malformed setting %q", part)
+		settings[key] = value
+	}
+	return settings
+}
+
+var _ = Describe("Azurite Container", func() {
+	var container *AzuriteContainer
+
+	BeforeEach(func() {
+		var err error
+		container, err = NewAzurite("test-azurite", false)
+		Expect(err).ToNot(HaveOccurred())
+		container.blobURL = "http://localhost:10000/" + AzuriteAccountName
+		container.queueURL = "http://localhost:10001/" + AzuriteAccountName
+		container.tableURL = "http://localhost:10002/" + AzuriteAccountName
+	})
+
+	It("should build a connection string covering all services", func() {
+		settings := parseConnectionString(container.GetConnectionString())
+		Expect(settings).To(Equal(map[string]string{
+			"DefaultEndpointsProtocol": "http",
+			"AccountName":              AzuriteAccountName,
+			"AccountKey":               AzuriteAccountKey,
+			"BlobEndpoint":             container.blobURL,
+			"QueueEndpoint":            container.queueURL,
+			"TableEndpoint":            container.tableURL,
+		}))
+	})
+
+	It("should build per-service connection strings", func() {
+		for key, connectionString := range map[string]string{
+			"BlobEndpoint":  container.GetBlobConnectionString(),
+			"QueueEndpoint": container.GetQueueConnectionString(),
+			"TableEndpoint": container.GetTableConnectionString(),
+		} {
+			settings := parseConnectionString(connectionString)
+			Expect(settings).To(HaveLen(4))
+			Expect(settings).To(HaveKeyWithValue("AccountName", AzuriteAccountName))
+			Expect(settings).To(HaveKeyWithValue("AccountKey", AzuriteAccountKey))
+			Expect(settings).To(HaveKey(key))
+		}
+		Expect(parseConnectionString(container.GetQueueConnectionString())).To(HaveKeyWithValue("QueueEndpoint", container.queueURL))
+	})
+
+	It("should sign requests with the SharedKey scheme", func() {
+		req, err := http.NewRequest(http.MethodGet, container.blobURL+"?restype=container&comp=list", nil)
+		Expect(err).ToNot(HaveOccurred())
+		req.Header.Set("x-ms-version", "2021-08-06")
+		req.Header.Set("x-ms-date", "Mon, 01 Jan 2024 00:00:00 GMT")
+
+		signature, err := azureSharedKeySignature(req, AzuriteAccountName, AzuriteAccountKey)
+		Expect(err).ToNot(HaveOccurred())
+
+		stringToSign := "GET\n\n\n\n\n\n\n\n\n\n\n\n" +
+			"x-ms-date:Mon, 01 Jan 2024 00:00:00 GMT\nx-ms-version:2021-08-06\n" +
+			"/" + AzuriteAccountName + "/" + AzuriteAccountName + "\ncomp:list\nrestype:container"
+		key, _ := base64.StdEncoding.DecodeString(AzuriteAccountKey)
+		mac := hmac.New(sha256.New, key)
+		mac.Write([]byte(stringToSign))
+		Expect(signature).To(Equal(base64.StdEncoding.EncodeToString(mac.Sum(nil))))
+
+		_, err = azureSharedKeySignature(req, AzuriteAccountName, "not base64!")
+		Expect(err).To(MatchError(ContainSubstring("invalid account key")))
+	})
+
+	It("should create blob containers idempotently", func() {
+		status := http.StatusCreated
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			defer GinkgoRecover()
+			Expect(r.Method).To(Equal(http.MethodPut))
+			Expect(r.URL.Path).To(Equal("/" + AzuriteAccountName + "/uploads"))
+			Expect(r.URL.Query().Get("restype")).To(Equal("container"))
+			Expect(r.Header.Get("Authorization")).To(HavePrefix("SharedKey " + AzuriteAccountName + ":"))
+			w.WriteHeader(status)
+		}))
+		DeferCleanup(server.Close)
+		container.blobURL = server.URL + "/" + AzuriteAccountName
+
+		Expect(container.CreateContainer(context.Background(), "uploads")).To(Succeed())
+		status = http.StatusConflict
+		Expect(container.CreateContainer(context.Background(), "uploads")).To(Succeed())
+		status = http.StatusForbidden
+		Expect(container.CreateContainer(context.Background(), "uploads")).To(MatchError(ContainSubstring("status 403")))
+	})
+
+	It("should fail before the container is started", func() {
+		stopped, err := NewAzurite("test-azurite-stopped", false)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(stopped.CreateContainer(context.Background(), "uploads")).To(MatchError(ContainSubstring("container not started")))
+		Expect(stopped.HealthCheck()).To(MatchError(ContainSubstring("blob URL not set")))
+	})
+})
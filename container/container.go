@@ -7,15 +7,34 @@ import (
 	"fmt"
 	"io"
 	"net"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/flanksource/clicky"
 	"github.com/flanksource/commons/logger"
+
+	"github.com/flanksource/commons-test/budget"
+	"github.com/flanksource/commons-test/cache"
+	"github.com/flanksource/commons-test/cleanup"
+	testconfig "github.com/flanksource/commons-test/config"
+	"github.com/flanksource/commons-test/diagnostics"
+	"github.com/flanksource/commons-test/logging"
+	"github.com/flanksource/commons-test/names"
+	"github.com/flanksource/commons-test/ports"
+	"github.com/flanksource/commons-test/probe"
+	"github.com/flanksource/commons-test/report"
+	"github.com/flanksource/commons-test/wait"
 )
 
 const (
 	DefaultTimeout = 5 * time.Minute
+
+	// DockerHost is the hostname containers use to reach ports published on the host
+	DockerHost = "host.docker.internal"
 )
 
 // Container manages Docker containers with transparent reuse
@@ -24,18 +43,46 @@ type Container struct {
 	config      Config
 	containerID string
 	isRunning   bool
+	leasedPorts []int
+	mountDirs   []string
+	unregister  func()
 }
 
-// New creates a new Container manager
+var _ Manager = (*Container)(nil)
+
+// New creates a new Container manager, naming the container after its image when config.Name is empty.
+// The image overrides, registry mirrors and reuse flag of the commons-test configuration are applied.
 func New(config Config) (*Container, error) {
+	if config.Name == "" {
+		config.Name = names.New(imageName(config.Image))
+	}
+	if len(config.NetworkAliases) > 0 && config.Network == "" {
+		return nil, fmt.Errorf("network aliases of %s require a network", config.Name)
+	}
+	if err := config.WaitStrategy.validate(); err != nil {
+		return nil, fmt.Errorf("invalid wait strategy of %s: %w", config.Name, err)
+	}
+	settings := testconfig.Get()
+	config.Image = settings.Image(config.Image)
+	config.Reuse = config.Reuse || settings.Reuse
 	return &Container{
-		Logger: logger.GetLogger("docker").Named(config.Name),
+		Logger: logging.Logger("container", config.Name),
 		config: config,
 	}, nil
 }
 
-// Start starts or reuses an existing container
+// Start starts or reuses an existing container, which is removed by cleanup.Run unless Cleanup is called first
 func (c *Container) Start(ctx context.Context) error {
+	if err := budget.Run(ctx, report.Container, c.config.Name, c.start); err != nil {
+		return err
+	}
+	if c.unregister == nil {
+		c.unregister = cleanup.Register("container "+c.config.Name, c.Cleanup)
+	}
+	return nil
+}
+
+func (c *Container) start(ctx context.Context) error {
 	// Try to find and reuse existing container if enabled
 	if c.config.Reuse {
 		if err := c.findAndReuseContainer(ctx); err != nil {
@@ -142,16 +189,64 @@ func (c *Container) GetPort(port string) (string, error) {
 	return hostPort, nil
 }
 
+// GetHostAddress returns the host:port other containers can use to reach a published port
+// of this container, requires DockerHost to be resolvable in the calling container
+func (c *Container) GetHostAddress(port string) (string, error) {
+	hostPort, err := c.GetPort(port)
+	if err != nil {
+		return "", err
+	}
+	return net.JoinHostPort(DockerHost, hostPort), nil
+}
+
+// CopyTo copies a file or directory from the host into the container
+func (c *Container) CopyTo(ctx context.Context, src, dst string) error {
+	if c.containerID == "" {
+		return fmt.Errorf("container not started")
+	}
+
+	process := clicky.Exec("docker", "cp", src, c.containerID+":"+dst).Run()
+	if process.Err != nil {
+		return fmt.Errorf("failed to copy %s to container: %w", src, process.Err)
+	}
+	return nil
+}
+
+// CopyFrom copies a file or directory from the container to the host
+func (c *Container) CopyFrom(ctx context.Context, src, dst string) error {
+	if c.containerID == "" {
+		return fmt.Errorf("container not started")
+	}
+
+	process := clicky.Exec("docker", "cp", c.containerID+":"+src, dst).Run()
+	if process.Err != nil {
+		return fmt.Errorf("failed to copy %s from container: %w", src, process.Err)
+	}
+	return nil
+}
+
 // GetID returns the container ID
 func (c *Container) GetID() string {
 	return c.containerID
 }
 
+// GetName returns the configured container name
+func (c *Container) GetName() string {
+	return c.config.Name
+}
+
 // Cleanup removes the container
 func (c *Container) Cleanup(ctx context.Context) error {
 	if c.containerID == "" {
+		if !c.config.Reuse {
+			c.removeMountDirs()
+		}
 		return nil
 	}
+	if c.unregister != nil {
+		c.unregister()
+		c.unregister = nil
+	}
 
 	// Don't remove if reuse is enabled - just stop it
 	if c.config.Reuse {
@@ -169,10 +264,46 @@ func (c *Container) Cleanup(ctx context.Context) error {
 		return fmt.Errorf("failed to remove container: %w", process.Err)
 	}
 
+	diagnostics.UntrackContainer(c.containerID)
 	c.containerID = ""
+	c.releasePorts()
+	c.removeMountDirs()
 	return nil
 }
 
+// removeMountDirs removes the directories created by mountDir
+func (c *Container) removeMountDirs() {
+	for _, dir := range c.mountDirs {
+		_ = os.RemoveAll(dir)
+	}
+	c.mountDirs = nil
+}
+
+// mountDir returns a directory for files bind mounted into the container. It is named after the
+// container rather than created with os.MkdirTemp so that a reused container, which keeps the bind
+// mount of the run that created it, sees the files written by later runs. Cleanup removes it unless
+// the container is reused, the directories of other containers include the run ID.
+func (c *Container) mountDir(purpose string) (string, error) {
+	prefix := "commons-test-"
+	if !c.config.Reuse {
+		prefix = names.TempPrefix("commons-test")
+	}
+	dir := filepath.Join(os.TempDir(), prefix+c.config.Name+"-"+purpose)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create %s directory: %w", purpose, err)
+	}
+	if !slices.Contains(c.mountDirs, dir) {
+		c.mountDirs = append(c.mountDirs, dir)
+	}
+	return dir, nil
+}
+
+// releasePorts returns the host ports leased for the container
+func (c *Container) releasePorts() {
+	ports.Release(c.leasedPorts...)
+	c.leasedPorts = nil
+}
+
 // findAndReuseContainer tries to find and reuse an existing container
 func (c *Container) findAndReuseContainer(ctx context.Context) error {
 	// Use docker ps to list containers with matching name
@@ -193,6 +324,7 @@ func (c *Container) findAndReuseContainer(ctx context.Context) error {
 	}
 
 	c.containerID = parts[0]
+	diagnostics.TrackContainer(c.containerID)
 	c.isRunning = strings.HasPrefix(parts[1], "Up")
 	return nil
 }
@@ -212,18 +344,51 @@ func (c *Container) ensureContainerRunning(ctx context.Context) error {
 	return c.waitForStableState(ctx)
 }
 
+// imageName returns the repository name of image without registry, tag or digest, e.g. activemq for apache/activemq:6
+func imageName(image string) string {
+	image, _, _ = strings.Cut(image, "@")
+	name := image[strings.LastIndex(image, "/")+1:]
+	name, _, _ = strings.Cut(name, ":")
+	return name
+}
+
+// PullImages pulls each image missing locally, pre-warming the cache used by Start
+func PullImages(images ...string) error {
+	stamps := cache.Default()
+	for _, image := range images {
+		image = testconfig.Get().Image(image)
+		if err := stamps.Do(cache.Image, image, func() error { return pull(image, logger.StandardLogger()) }); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// pullImage pulls the image if it is missing, skipping the check when it was pulled within the configured CacheTTL
+func (c *Container) pullImage() error {
+	return cache.Default().Do(cache.Image, c.config.Image, func() error {
+		return pull(c.config.Image, c.Logger)
+	})
+}
+
+func pull(image string, log logger.Logger) error {
+	if clicky.Exec("docker", "image", "inspect", image).Run().Err == nil {
+		return nil
+	}
+	log.Infof("Pulling image %s...", image)
+	process := clicky.Exec("docker", "pull", image).Run()
+	if process.Err != nil {
+		return fmt.Errorf("failed to pull image %s: %w", image, process.Err)
+	}
+	log.Infof("Successfully pulled image %s", image)
+	return nil
+}
+
 // createAndStartContainer creates and starts a new container
 func (c *Container) createAndStartContainer(ctx context.Context) error {
-	// Check if image exists, pull if needed
-	checkProcess := clicky.Exec("docker", "image", "inspect", c.config.Image).Run()
-	if checkProcess.Err != nil {
-		c.Infof("Pulling image %s...", c.config.Image)
-		pullProcess := clicky.Exec("docker", "pull", c.config.Image).Run()
-		if pullProcess.Err != nil {
-			c.Errorf("Failed to pull image: %v", pullProcess.Err)
-			return fmt.Errorf("failed to pull image: %w", pullProcess.Err)
-		}
-		c.Infof("Successfully pulled image %s", c.config.Image)
+	if err := c.pullImage(); err != nil {
+		c.Errorf("Failed to pull image: %v", err)
+		return err
 	}
 
 	// Build docker create command
@@ -233,9 +398,19 @@ func (c *Container) createAndStartContainer(ctx context.Context) error {
 	if c.config.Name != "" {
 		args = append(args, "--name", c.config.Name)
 	}
+	args = append(args, "--label", names.RunLabel+"="+names.RunID())
 
-	// Add port bindings
+	// Add port bindings, leasing random host ports so parallel suites never race for the same one
 	for containerPort, hostPort := range c.config.Ports {
+		if hostPort == "" || hostPort == "0" {
+			port, err := ports.Get()
+			if err != nil {
+				c.releasePorts()
+				return err
+			}
+			c.leasedPorts = append(c.leasedPorts, port)
+			hostPort = strconv.Itoa(port)
+		}
 		args = append(args, "-p", fmt.Sprintf("%s:%s", hostPort, containerPort))
 	}
 
@@ -257,6 +432,19 @@ func (c *Container) createAndStartContainer(ctx context.Context) error {
 		}
 	}
 
+	// Add extra hosts
+	for _, host := range c.config.ExtraHosts {
+		args = append(args, "--add-host", host)
+	}
+
+	// Join the network, where other containers reach it by name and aliases
+	if c.config.Network != "" {
+		args = append(args, "--network", c.config.Network)
+		for _, alias := range c.config.NetworkAliases {
+			args = append(args, "--network-alias", alias)
+		}
+	}
+
 	// Add health check
 	if hc := c.config.HealthCheck; hc != nil {
 		args = append(args, "--health-cmd", hc.Cmd)
@@ -274,16 +462,19 @@ func (c *Container) createAndStartContainer(ctx context.Context) error {
 		}
 	}
 
-	// Add image
+	// Add image and command
 	args = append(args, c.config.Image)
+	args = append(args, c.config.Cmd...)
 
 	// Create container
 	createProcess := clicky.Exec(args[0], args[1:]...).Run()
 	if createProcess.Err != nil {
+		c.releasePorts()
 		return fmt.Errorf("failed to create container: %w", createProcess.Err)
 	}
 
 	c.containerID = strings.TrimSpace(createProcess.GetStdout())
+	diagnostics.TrackContainer(c.containerID)
 
 	// Start container
 	c.Infof("Starting container...")
@@ -361,10 +552,35 @@ func (c *Container) PrintLogsOnFailure(ctx context.Context, reason string) {
 	}
 }
 
+// waitUntil waits for condition, printing the container logs if it is not met in time
+func (c *Container) waitUntil(ctx context.Context, what string, interval, timeout time.Duration, condition wait.Condition) error {
+	if err := wait.For(ctx, interval, timeout, condition); err != nil {
+		c.PrintLogsOnFailure(ctx, fmt.Sprintf("%s readiness check failed: %v", what, err))
+		return fmt.Errorf("%s failed to become ready: %w", what, err)
+	}
+	return nil
+}
+
+// waitForHTTP polls url until it returns a 2xx response, for up to maxRetries*retryDelay
+func (c *Container) waitForHTTP(ctx context.Context, url string, maxRetries int, retryDelay time.Duration) error {
+	check := probe.HTTP(url)
+	return c.waitUntil(ctx, c.config.Name, retryDelay, time.Duration(maxRetries)*retryDelay, wait.NoError(func(ctx context.Context) error {
+		_, err := check.Check(ctx)
+		if err != nil {
+			c.Tracef("Readiness check %s failed: %v", url, err)
+		}
+		return err
+	}))
+}
+
 // waitForStableState waits for the container to reach a stable running state.
+// If a wait strategy is configured, it waits for each of its strategies.
 // If a health check is configured, it waits for the container to become healthy.
 // Otherwise, it waits for all exposed ports to accept TCP connections.
 func (c *Container) waitForStableState(ctx context.Context) error {
+	if !c.config.WaitStrategy.IsZero() {
+		return c.waitForStrategy(ctx)
+	}
 	if c.config.HealthCheck != nil {
 		return c.waitForHealthy(ctx)
 	}
@@ -377,7 +593,7 @@ func (c *Container) waitForPorts(ctx context.Context) error {
 		return nil
 	}
 
-	timeout := 2 * time.Minute
+	timeout := testconfig.Get().ContainerTimeout()
 	deadline := time.Now().Add(timeout)
 
 	for containerPort := range c.config.Ports {
@@ -389,41 +605,28 @@ func (c *Container) waitForPorts(ctx context.Context) error {
 		addr := "localhost:" + hostPort
 		c.Infof("Waiting for port %s (host %s)...", containerPort, hostPort)
 
-		ready := false
 		checks := 0
-		for time.Now().Before(deadline) {
-			conn, dialErr := net.DialTimeout("tcp", addr, 2*time.Second)
+		err = wait.Every(ctx, 500*time.Millisecond, time.Until(deadline), func(ctx context.Context) (bool, error) {
+			dialErr := probe.TCP(addr).Check(ctx)
 			if dialErr == nil {
-				conn.Close()
-				c.Infof("Port %s is ready", containerPort)
-				ready = true
-				break
+				return true, nil
 			}
 			c.Tracef("Port %s dial failed: %v", containerPort, dialErr)
 
 			checks++
-			if checks%10 == 0 {
-				running, _ := c.IsRunning(ctx)
-				if !running {
-					diag := c.containerDiagnostics()
-					c.PrintLogsOnFailure(ctx, fmt.Sprintf("Container stopped while waiting for port %s: %s", containerPort, diag))
-					return fmt.Errorf("container stopped while waiting for port %s: %s", containerPort, diag)
+			if checks%5 == 0 {
+				if running, _ := c.IsRunning(ctx); !running {
+					return false, wait.Stop(fmt.Errorf("container stopped while waiting for port %s", containerPort))
 				}
 			}
-
-			select {
-			case <-ctx.Done():
-				return ctx.Err()
-			default:
-			}
-			time.Sleep(500 * time.Millisecond)
-		}
-
-		if !ready {
+			return false, dialErr
+		})
+		if err != nil {
 			diag := c.containerDiagnostics()
-			c.PrintLogsOnFailure(ctx, fmt.Sprintf("Port %s not ready after %v: %s", containerPort, timeout, diag))
-			return fmt.Errorf("timed out waiting for port %s: %s", containerPort, diag)
+			c.PrintLogsOnFailure(ctx, fmt.Sprintf("Port %s not ready: %v: %s", containerPort, err, diag))
+			return fmt.Errorf("port %s not ready: %w: %s", containerPort, err, diag)
 		}
+		c.Infof("Port %s is ready", containerPort)
 	}
 	return nil
 }
@@ -498,51 +701,50 @@ func (c *Container) containerDiagnostics() string {
 }
 
 func (c *Container) waitForHealthy(ctx context.Context) error {
-	timeout := 2 * time.Minute
-	checkInterval := 2 * time.Second
-	deadline := time.Now().Add(timeout)
+	timeout := testconfig.Get().ContainerTimeout()
 
 	c.Infof("Waiting up to %v for container to become healthy...", timeout)
 
 	firstCheck := true
-	for time.Now().Before(deadline) {
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		default:
-		}
+	fallback := false
+	err := wait.For(ctx, 2*time.Second, timeout, func(ctx context.Context) (bool, error) {
+		defer func() { firstCheck = false }()
 
 		process := clicky.Exec("docker", "inspect", "--format", "{{.State.Health.Status}}", c.containerID).Run()
-		if process.Err == nil {
-			status := strings.TrimSpace(process.GetStdout())
-			c.Tracef("Health status: %s", status)
-
-			if firstCheck && (status == "" || status == "<no value>") {
-				c.Warnf("No Docker health check registered on container, falling back to port readiness")
-				return c.waitForPorts(ctx)
-			}
-
-			switch status {
-			case "healthy":
-				c.Infof("Container is healthy")
-				return nil
-			case "unhealthy":
-				diag := c.containerDiagnostics()
-				c.PrintLogsOnFailure(ctx, fmt.Sprintf("Container became unhealthy: %s", diag))
-				return fmt.Errorf("container became unhealthy: %s", diag)
+		if process.Err != nil {
+			if firstCheck {
+				c.Warnf("Health check inspect failed, falling back to port readiness: %v", process.Err)
+				fallback = true
+				return true, nil
 			}
-		} else if firstCheck {
-			c.Warnf("Health check inspect failed, falling back to port readiness: %v", process.Err)
-			return c.waitForPorts(ctx)
-		} else {
 			c.Tracef("Health check inspect failed: %v", process.Err)
+			return false, process.Err
 		}
 
-		firstCheck = false
-		time.Sleep(checkInterval)
-	}
+		status := strings.TrimSpace(process.GetStdout())
+		c.Tracef("Health status: %s", status)
+		if firstCheck && (status == "" || status == "<no value>") {
+			c.Warnf("No Docker health check registered on container, falling back to port readiness")
+			fallback = true
+			return true, nil
+		}
 
-	diag := c.containerDiagnostics()
-	c.PrintLogsOnFailure(ctx, fmt.Sprintf("Timed out waiting for healthy status: %s", diag))
-	return fmt.Errorf("timed out waiting for container to become healthy: %s", diag)
+		switch status {
+		case "healthy":
+			return true, nil
+		case "unhealthy":
+			return false, wait.Stop(fmt.Errorf("container became unhealthy"))
+		}
+		return false, fmt.Errorf("health status is %s", status)
+	})
+	if fallback {
+		return c.waitForPorts(ctx)
+	}
+	if err != nil {
+		diag := c.containerDiagnostics()
+		c.PrintLogsOnFailure(ctx, fmt.Sprintf("Container is not healthy: %v: %s", err, diag))
+		return fmt.Errorf("container is not healthy: %w: %s", err, diag)
+	}
+	c.Infof("Container is healthy")
+	return nil
 }
@@ -0,0 +1,237 @@
+package container
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"time"
+
+	commonsHTTP "github.com/flanksource/commons/http"
+)
+
+// GiteaRepo is a repository hosted by a GiteaContainer
+type GiteaRepo struct {
+	ID            int64  `json:"id"`
+	Name          string `json:"name"`
+	FullName      string `json:"full_name"`
+	CloneURL      string `json:"clone_url"`
+	DefaultBranch string `json:"default_branch"`
+}
+
+// GiteaContainer provides specialized Gitea container management
+type GiteaContainer struct {
+	*Container
+	adminUser     string
+	adminPassword string
+	url           string
+}
+
+// NewGitea creates a new Gitea container using SQLite, with the installer skipped
+func NewGitea(name, adminUser, adminPassword string, reuse bool) (*GiteaContainer, error) {
+	if adminUser == "" {
+		adminUser = "gitea_admin"
+	}
+	if adminPassword == "" {
+		adminPassword = "gitea_admin"
+	}
+
+	config := Config{
+		Image: "gitea/gitea:1.22.1",
+		Name:  name,
+		Ports: map[string]string{"3000": "0"},
+		Env: []string{
+			"GITEA__security__INSTALL_LOCK=true",
+			"GITEA__database__DB_TYPE=sqlite3",
+			"GITEA__service__DISABLE_REGISTRATION=true",
+			"GITEA__webhook__ALLOWED_HOST_LIST=*",
+			"GITEA__repository__DEFAULT_BRANCH=main",
+		},
+		ExtraHosts: []string{DockerHost + ":host-gateway"},
+		Reuse:      reuse,
+	}
+
+	container, err := New(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Gitea container: %w", err)
+	}
+
+	return &GiteaContainer{
+		Container:     container,
+		adminUser:     adminUser,
+		adminPassword: adminPassword,
+	}, nil
+}
+
+// Start starts the Gitea container, waits for it to be healthy and creates the admin user
+func (g *GiteaContainer) Start(ctx context.Context) error {
+	if err := g.Container.Start(ctx); err != nil {
+		return fmt.Errorf("failed to start Gitea container: %w", err)
+	}
+
+	port, err := g.GetPort("3000")
+	if err != nil {
+		return fmt.Errorf("failed to get Gitea port: %w", err)
+	}
+	g.url = fmt.Sprintf("http://localhost:%s", port)
+
+	if err := g.waitForHTTP(ctx, g.url+"/api/healthz", 60, 2*time.Second); err != nil {
+		return err
+	}
+
+	return g.createAdmin(ctx)
+}
+
+// GetURL returns the Gitea web/API URL
+func (g *GiteaContainer) GetURL() string {
+	return g.url
+}
+
+// GetCredentials returns the admin username and password
+func (g *GiteaContainer) GetCredentials() (string, string) {
+	return g.adminUser, g.adminPassword
+}
+
+// GetCloneURL returns an authenticated HTTP clone URL for a repository owned by the admin
+func (g *GiteaContainer) GetCloneURL(repo string) string {
+	u, err := url.Parse(g.url)
+	if err != nil {
+		return ""
+	}
+	u.User = url.UserPassword(g.adminUser, g.adminPassword)
+	u.Path = fmt.Sprintf("/%s/%s.git", g.adminUser, repo)
+	return u.String()
+}
+
+// AdminClient returns an HTTP client for the Gitea API authenticated as the admin user
+func (g *GiteaContainer) AdminClient() *commonsHTTP.Client {
+	return commonsHTTP.NewClient().BaseURL(g.url+"/api/v1").Auth(g.adminUser, g.adminPassword)
+}
+
+// CreateRepo creates a repository owned by the admin and commits initialFiles (path -> content) to main
+func (g *GiteaContainer) CreateRepo(ctx context.Context, name string, initialFiles map[string]string) (*GiteaRepo, error) {
+	r, err := g.AdminClient().R(ctx).Header("Content-Type", "application/json").Post("/user/repos", map[string]any{
+		"name":           name,
+		"auto_init":      true,
+		"default_branch": "main",
+		"private":        false,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !r.IsOK(201) {
+		body, _ := r.AsString()
+		return nil, fmt.Errorf("create repo %s failed: %s", name, body)
+	}
+
+	var repo GiteaRepo
+	if err := r.Into(&repo); err != nil {
+		return nil, err
+	}
+
+	if len(initialFiles) > 0 {
+		if _, err := g.PushCommit(ctx, name, "main", "Initial files", initialFiles); err != nil {
+			return nil, err
+		}
+	}
+	return &repo, nil
+}
+
+// PushCommit creates or updates files (path -> content) in a single commit and returns the commit SHA
+func (g *GiteaContainer) PushCommit(ctx context.Context, repo, branch, message string, files map[string]string) (string, error) {
+	client := g.AdminClient()
+
+	var changes []map[string]string
+	for path, content := range files {
+		change := map[string]string{
+			"operation": "create",
+			"path":      path,
+			"content":   base64.StdEncoding.EncodeToString([]byte(content)),
+		}
+
+		// Existing files must be updated by their blob SHA
+		existing, err := client.R(ctx).QueryParam("ref", branch).Get(fmt.Sprintf("/repos/%s/%s/contents/%s", g.adminUser, repo, path))
+		if err != nil {
+			return "", err
+		}
+		if existing.IsOK() {
+			var file struct {
+				SHA string `json:"sha"`
+			}
+			if err := existing.Into(&file); err != nil {
+				return "", err
+			}
+			change["operation"] = "update"
+			change["sha"] = file.SHA
+		}
+		changes = append(changes, change)
+	}
+
+	r, err := client.R(ctx).Header("Content-Type", "application/json").Post(fmt.Sprintf("/repos/%s/%s/contents", g.adminUser, repo), map[string]any{
+		"branch":  branch,
+		"message": message,
+		"files":   changes,
+	})
+	if err != nil {
+		return "", err
+	}
+	if !r.IsOK(201) {
+		body, _ := r.AsString()
+		return "", fmt.Errorf("commit to %s failed: %s", repo, body)
+	}
+
+	var response struct {
+		Commit struct {
+			SHA string `json:"sha"`
+		} `json:"commit"`
+	}
+	if err := r.Into(&response); err != nil {
+		return "", err
+	}
+	return response.Commit.SHA, nil
+}
+
+// AddWebhook registers a JSON push webhook on a repository, signed with secret
+func (g *GiteaContainer) AddWebhook(ctx context.Context, repo, targetURL, secret string, events ...string) error {
+	if len(events) == 0 {
+		events = []string{"push"}
+	}
+
+	r, err := g.AdminClient().R(ctx).Header("Content-Type", "application/json").Post(fmt.Sprintf("/repos/%s/%s/hooks", g.adminUser, repo), map[string]any{
+		"type":   "gitea",
+		"active": true,
+		"events": events,
+		"config": map[string]string{
+			"url":          targetURL,
+			"content_type": "json",
+			"secret":       secret,
+		},
+	})
+	if err != nil {
+		return err
+	}
+	if !r.IsOK(201) {
+		body, _ := r.AsString()
+		return fmt.Errorf("add webhook to %s failed: %s", repo, body)
+	}
+	return nil
+}
+
+// createAdmin creates the admin user through the gitea CLI unless it can already log in
+func (g *GiteaContainer) createAdmin(ctx context.Context) error {
+	if r, err := g.AdminClient().R(ctx).Get("/user"); err == nil && r.IsOK() {
+		return nil
+	}
+
+	_, err := g.Exec(ctx, []string{"su-exec", "git", "gitea", "admin", "user", "create",
+		"--admin",
+		"--username", g.adminUser,
+		"--password", g.adminPassword,
+		"--email", g.adminUser + "@example.com",
+		"--must-change-password=false",
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create Gitea admin user: %w", err)
+	}
+	return nil
+}
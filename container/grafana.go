@@ -0,0 +1,152 @@
+package container
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	commonsHTTP "github.com/flanksource/commons/http"
+	"sigs.k8s.io/yaml"
+)
+
+// GrafanaDatasource is a datasource provisioned into Grafana on startup
+type GrafanaDatasource struct {
+	Name      string `json:"name"`
+	Type      string `json:"type"` // e.g. "prometheus" or "loki"
+	URL       string `json:"url"`
+	Access    string `json:"access"`
+	IsDefault bool   `json:"isDefault,omitempty"`
+}
+
+// PrometheusDatasource returns a datasource pointing at a running Prometheus container
+func PrometheusDatasource(p *PrometheusContainer) (GrafanaDatasource, error) {
+	addr, err := p.GetHostAddress("9090")
+	if err != nil {
+		return GrafanaDatasource{}, err
+	}
+	return GrafanaDatasource{Name: "Prometheus", Type: "prometheus", URL: "http://" + addr, Access: "proxy"}, nil
+}
+
+// LokiDatasource returns a datasource pointing at a running Loki container
+func LokiDatasource(l *LokiContainer) (GrafanaDatasource, error) {
+	addr, err := l.GetHostAddress("3100")
+	if err != nil {
+		return GrafanaDatasource{}, err
+	}
+	return GrafanaDatasource{Name: "Loki", Type: "loki", URL: "http://" + addr, Access: "proxy"}, nil
+}
+
+// GrafanaContainer provides specialized Grafana container management
+type GrafanaContainer struct {
+	*Container
+	username string
+	password string
+	url      string
+}
+
+// NewGrafana creates a new Grafana container with the given datasources pre-provisioned
+func NewGrafana(name, username, password string, reuse bool, datasources ...GrafanaDatasource) (*GrafanaContainer, error) {
+	if username == "" {
+		username = "admin"
+	}
+	if password == "" {
+		password = "admin"
+	}
+
+	config := Config{
+		Image: "grafana/grafana:11.1.0",
+		Name:  name,
+		Ports: map[string]string{"3000": "0"},
+		Env: []string{
+			fmt.Sprintf("GF_SECURITY_ADMIN_USER=%s", username),
+			fmt.Sprintf("GF_SECURITY_ADMIN_PASSWORD=%s", password),
+			"GF_USERS_ALLOW_SIGN_UP=false",
+			"GF_ANALYTICS_REPORTING_ENABLED=false",
+		},
+		ExtraHosts: []string{DockerHost + ":host-gateway"},
+		Reuse:      reuse,
+	}
+
+	container, err := New(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Grafana container: %w", err)
+	}
+
+	data, err := yaml.Marshal(map[string]any{
+		"apiVersion":  1,
+		"datasources": datasources,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to render datasources: %w", err)
+	}
+	provisioningDir, err := container.mountDir("provisioning")
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(filepath.Join(provisioningDir, "datasources.yaml"), data, 0644); err != nil {
+		container.removeMountDirs()
+		return nil, fmt.Errorf("failed to write datasources: %w", err)
+	}
+	container.config.Mounts = append(container.config.Mounts, Mount{
+		Source:   provisioningDir,
+		Target:   "/etc/grafana/provisioning/datasources",
+		Type:     "bind",
+		ReadOnly: true,
+	})
+
+	return &GrafanaContainer{
+		Container: container,
+		username:  username,
+		password:  password,
+	}, nil
+}
+
+// Start starts the Grafana container and waits for it to be ready
+func (g *GrafanaContainer) Start(ctx context.Context) error {
+	if err := g.Container.Start(ctx); err != nil {
+		return fmt.Errorf("failed to start Grafana container: %w", err)
+	}
+
+	port, err := g.GetPort("3000")
+	if err != nil {
+		return fmt.Errorf("failed to get Grafana port: %w", err)
+	}
+	g.url = fmt.Sprintf("http://localhost:%s", port)
+
+	return g.waitForHTTP(ctx, g.url+"/api/health", 60, 2*time.Second)
+}
+
+// GetURL returns the Grafana URL
+func (g *GrafanaContainer) GetURL() string {
+	return g.url
+}
+
+// GetCredentials returns the admin username and password
+func (g *GrafanaContainer) GetCredentials() (string, string) {
+	return g.username, g.password
+}
+
+// AdminClient returns an HTTP client authenticated as the Grafana admin user
+func (g *GrafanaContainer) AdminClient() *commonsHTTP.Client {
+	return commonsHTTP.NewClient().BaseURL(g.url).Auth(g.username, g.password)
+}
+
+// ListDatasources returns the datasources currently configured in Grafana
+func (g *GrafanaContainer) ListDatasources(ctx context.Context) ([]GrafanaDatasource, error) {
+	r, err := g.AdminClient().R(ctx).Get("/api/datasources")
+	if err != nil {
+		return nil, err
+	}
+	if !r.IsOK() {
+		body, _ := r.AsString()
+		return nil, fmt.Errorf("list datasources failed: %s", body)
+	}
+
+	var datasources []GrafanaDatasource
+	if err := r.Into(&datasources); err != nil {
+		return nil, err
+	}
+	return datasources, nil
+}
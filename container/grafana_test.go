@@ -0,0 +1,75 @@
+package container
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Grafana Container", func() {
+	It("should provision datasources", func() {
+		container, err := NewGrafana("test-grafana", "", "", false,
+			GrafanaDatasource{Name: "Prometheus", Type: "prometheus", URL: "http://prometheus:9090", Access: "proxy", IsDefault: true},
+			GrafanaDatasource{Name: "Loki", Type: "loki", URL: "http://loki:3100", Access: "proxy"},
+		)
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(container.Cleanup)
+
+		Expect(container.config.Mounts).To(ContainElement(HaveField("Target", "/etc/grafana/provisioning/datasources")))
+		source := container.config.Mounts[len(container.config.Mounts)-1].Source
+		data, err := os.ReadFile(filepath.Join(source, "datasources.yaml"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(data)).To(ContainSubstring("apiVersion: 1"))
+		Expect(string(data)).To(ContainSubstring("url: http://prometheus:9090"))
+		Expect(string(data)).To(ContainSubstring("isDefault: true"))
+		Expect(string(data)).To(ContainSubstring("type: loki"))
+	})
+
+	It("should default the admin credentials", func() {
+		container, err := NewGrafana("test-grafana-creds", "", "", false)
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(container.Cleanup)
+
+		username, password := container.GetCredentials()
+		Expect(username).To(Equal("admin"))
+		Expect(password).To(Equal("admin"))
+
+		container, err = NewGrafana("test-grafana-custom", "user", "secret", false)
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(container.Cleanup)
+		Expect(container.config.Env).To(ContainElements("GF_SECURITY_ADMIN_USER=user", "GF_SECURITY_ADMIN_PASSWORD=secret"))
+	})
+
+	It("should list datasources as the admin user", func() {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			defer GinkgoRecover()
+			username, password, ok := r.BasicAuth()
+			if !ok || username != "user" || password != "secret" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			Expect(r.URL.Path).To(Equal("/api/datasources"))
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`[{"name":"Loki","type":"loki","url":"http://loki:3100","access":"proxy"}]`))
+		}))
+		DeferCleanup(server.Close)
+
+		container, err := NewGrafana("test-grafana-list", "user", "secret", false)
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(container.Cleanup)
+		container.url = server.URL
+
+		datasources, err := container.ListDatasources(context.Background())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(datasources).To(Equal([]GrafanaDatasource{{Name: "Loki", Type: "loki", URL: "http://loki:3100", Access: "proxy"}}))
+
+		container.password = "wrong"
+		_, err = container.ListDatasources(context.Background())
+		Expect(err).To(MatchError(ContainSubstring("list datasources failed")))
+	})
+})
@@ -0,0 +1,159 @@
+package container
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Group starts containers on a shared network in dependency order, e.g. Zookeeper before Kafka:
+//
+//	group := container.NewGroup("").
+//		Add("zookeeper", Config{Image: "zookeeper:3.9.2"}).
+//		Add("kafka", Config{Image: "bitnami/kafka:3.7", Env: []string{"KAFKA_CFG_ZOOKEEPER_CONNECT=zookeeper:2181"}}, "zookeeper")
+//	err := group.Start(ctx)
+//	defer group.Cleanup(ctx)
+//
+// Each container joins the network with its service name as alias, so services reach each other by name.
+type Group struct {
+	network  string
+	created  bool
+	services []*service
+	started  []*service
+}
+
+type service struct {
+	name      string
+	config    Config
+	dependsOn []string
+	container *Container
+}
+
+// NewGroup returns an empty group on network, generated when empty
+func NewGroup(network string) *Group {
+	return &Group{network: network}
+}
+
+// Add adds a service started after the services it depends on, which must be added to the group too
+func (g *Group) Add(name string, config Config, dependsOn ...string) *Group {
+	g.services = append(g.services, &service{name: name, config: config, dependsOn: dependsOn})
+	return g
+}
+
+// Start creates the network and starts each service once its dependencies are ready. On failure the
+// services already started are left for Cleanup or cleanup.Run.
+func (g *Group) Start(ctx context.Context) error {
+	order, err := g.order()
+	if err != nil {
+		return err
+	}
+	if !g.created {
+		if g.network, g.created, err = createNetwork(g.network); err != nil {
+			return err
+		}
+	}
+
+	for _, s := range order {
+		if s.container == nil {
+			config := s.config
+			config.Network = g.network
+			config.NetworkAliases = append(slices.Clone(config.NetworkAliases), s.name)
+			if s.container, err = New(config); err != nil {
+				return fmt.Errorf("failed to create service %s: %w", s.name, err)
+			}
+		}
+		if err := s.container.Start(ctx); err != nil {
+			return fmt.Errorf("failed to start service %s: %w", s.name, err)
+		}
+		if !slices.Contains(g.started, s) {
+			g.started = append(g.started, s)
+		}
+	}
+	return nil
+}
+
+// order returns the services sorted so that each comes after its dependencies, in the order they were
+// added otherwise
+func (g *Group) order() ([]*service, error) {
+	byName := map[string]*service{}
+	for _, s := range g.services {
+		if _, ok := byName[s.name]; ok {
+			return nil, fmt.Errorf("duplicate service %s", s.name)
+		}
+		byName[s.name] = s
+	}
+
+	var order []*service
+	visited := map[*service]bool{}
+	var visit func(s *service, path []string) error
+	visit = func(s *service, path []string) error {
+		if slices.Contains(path, s.name) {
+			return fmt.Errorf("dependency cycle %s", strings.Join(append(path, s.name), " -> "))
+		}
+		if visited[s] {
+			return nil
+		}
+		for _, name := range s.dependsOn {
+			dependency, ok := byName[name]
+			if !ok {
+				return fmt.Errorf("service %s depends on unknown service %s", s.name, name)
+			}
+			if err := visit(dependency, append(path, s.name)); err != nil {
+				return err
+			}
+		}
+		visited[s] = true
+		order = append(order, s)
+		return nil
+	}
+	for _, s := range g.services {
+		if err := visit(s, nil); err != nil {
+			return nil, err
+		}
+	}
+	return order, nil
+}
+
+// Stop stops the started services, dependents first
+func (g *Group) Stop(ctx context.Context) error {
+	var errs []error
+	for _, s := range slices.Backward(g.started) {
+		if err := s.container.Stop(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("failed to stop service %s: %w", s.name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Cleanup removes the started services, dependents first, and then the network unless it existed before
+func (g *Group) Cleanup(ctx context.Context) error {
+	var errs []error
+	for _, s := range slices.Backward(g.started) {
+		if err := s.container.Cleanup(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("failed to remove service %s: %w", s.name, err))
+		}
+	}
+	g.started = nil
+	if g.created {
+		errs = append(errs, RemoveNetwork(g.network))
+		g.created = false
+	}
+	return errors.Join(errs...)
+}
+
+// Get returns the container of a service, nil before Start
+func (g *Group) Get(name string) *Container {
+	for _, s := range g.services {
+		if s.name == name {
+			return s.container
+		}
+	}
+	return nil
+}
+
+// GetNetwork returns the network of the group, generated by Start when empty
+func (g *Group) GetNetwork() string {
+	return g.network
+}
@@ -0,0 +1,38 @@
+package container
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Group", func() {
+	serviceNames := func(g *Group) []string {
+		order, err := g.order()
+		Expect(err).ToNot(HaveOccurred())
+		var names []string
+		for _, s := range order {
+			names = append(names, s.name)
+		}
+		return names
+	}
+
+	It("should start dependencies first", func() {
+		g := NewGroup("").
+			Add("app", Config{}, "kafka", "postgres").
+			Add("kafka", Config{}, "zookeeper").
+			Add("postgres", Config{}).
+			Add("zookeeper", Config{})
+		Expect(serviceNames(g)).To(Equal([]string{"zookeeper", "kafka", "postgres", "app"}))
+	})
+
+	It("should reject unknown dependencies and cycles", func() {
+		_, err := NewGroup("").Add("kafka", Config{}, "zookeeper").order()
+		Expect(err).To(MatchError("service kafka depends on unknown service zookeeper"))
+
+		_, err = NewGroup("").Add("a", Config{}, "b").Add("b", Config{}, "c").Add("c", Config{}, "a").order()
+		Expect(err).To(MatchError("dependency cycle a -> b -> c -> a"))
+
+		_, err = NewGroup("").Add("a", Config{}).Add("a", Config{}).order()
+		Expect(err).To(MatchError("duplicate service a"))
+	})
+})
@@ -0,0 +1,172 @@
+package container
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Span is a single span as returned by the Jaeger query API
+type Span struct {
+	TraceID       string
+	SpanID        string
+	OperationName string
+	ServiceName   string
+	StartTime     time.Time
+	Duration      time.Duration
+	Tags          map[string]any
+}
+
+// Trace is a collection of spans sharing a trace ID
+type Trace struct {
+	TraceID string
+	Spans   []Span
+}
+
+// JaegerContainer provides specialized Jaeger all-in-one container management
+type JaegerContainer struct {
+	*Container
+	otlpEndpoint     string
+	otlpHTTPEndpoint string
+	queryURL         string
+}
+
+// NewJaeger creates a new Jaeger all-in-one container accepting OTLP
+func NewJaeger(name string, reuse bool) (*JaegerContainer, error) {
+	config := Config{
+		Image: "jaegertracing/all-in-one:1.58",
+		Name:  name,
+		Ports: map[string]string{
+			"4317":  "0", // OTLP gRPC
+			"4318":  "0", // OTLP HTTP
+			"16686": "0", // Query UI/API
+		},
+		Env:   []string{"COLLECTOR_OTLP_ENABLED=true"},
+		Reuse: reuse,
+	}
+
+	container, err := New(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Jaeger container: %w", err)
+	}
+
+	return &JaegerContainer{
+		Container: container,
+	}, nil
+}
+
+// Start starts the Jaeger container and waits for it to be ready
+func (j *JaegerContainer) Start(ctx context.Context) error {
+	if err := j.Container.Start(ctx); err != nil {
+		return fmt.Errorf("failed to start Jaeger container: %w", err)
+	}
+
+	grpcPort, err := j.GetPort("4317")
+	if err != nil {
+		return fmt.Errorf("failed to get OTLP gRPC port: %w", err)
+	}
+	httpPort, err := j.GetPort("4318")
+	if err != nil {
+		return fmt.Errorf("failed to get OTLP HTTP port: %w", err)
+	}
+	queryPort, err := j.GetPort("16686")
+	if err != nil {
+		return fmt.Errorf("failed to get Jaeger query port: %w", err)
+	}
+
+	j.otlpEndpoint = fmt.Sprintf("localhost:%s", grpcPort)
+	j.otlpHTTPEndpoint = fmt.Sprintf("http://localhost:%s", httpPort)
+	j.queryURL = fmt.Sprintf("http://localhost:%s", queryPort)
+
+	return j.waitForHTTP(ctx, j.queryURL+"/api/services", 30, time.Second)
+}
+
+// GetOTLPEndpoint returns the host:port of the OTLP gRPC receiver
+func (j *JaegerContainer) GetOTLPEndpoint() string {
+	return j.otlpEndpoint
+}
+
+// GetOTLPHTTPEndpoint returns the URL of the OTLP HTTP receiver
+func (j *JaegerContainer) GetOTLPHTTPEndpoint() string {
+	return j.otlpHTTPEndpoint
+}
+
+// GetQueryURL returns the Jaeger query UI/API URL
+func (j *JaegerContainer) GetQueryURL() string {
+	return j.queryURL
+}
+
+// FindTraces returns recent traces for a service, optionally filtered by operation
+func (j *JaegerContainer) FindTraces(ctx context.Context, service, operation string) ([]Trace, error) {
+	if j.queryURL == "" {
+		return nil, fmt.Errorf("container not started")
+	}
+
+	params := url.Values{"service": {service}, "limit": {"100"}}
+	if operation != "" {
+		params.Set("operation", operation)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, j.queryURL+"/api/traces?"+params.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("find traces failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("find traces failed: status %d", resp.StatusCode)
+	}
+
+	var response struct {
+		Data []struct {
+			TraceID string `json:"traceID"`
+			Spans   []struct {
+				TraceID       string `json:"traceID"`
+				SpanID        string `json:"spanID"`
+				OperationName string `json:"operationName"`
+				StartTime     int64  `json:"startTime"` // microseconds
+				Duration      int64  `json:"duration"`  // microseconds
+				ProcessID     string `json:"processID"`
+				Tags          []struct {
+					Key   string `json:"key"`
+					Value any    `json:"value"`
+				} `json:"tags"`
+			} `json:"spans"`
+			Processes map[string]struct {
+				ServiceName string `json:"serviceName"`
+			} `json:"processes"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("failed to decode traces: %w", err)
+	}
+
+	var traces []Trace
+	for _, t := range response.Data {
+		trace := Trace{TraceID: t.TraceID}
+		for _, s := range t.Spans {
+			span := Span{
+				TraceID:       s.TraceID,
+				SpanID:        s.SpanID,
+				OperationName: s.OperationName,
+				ServiceName:   t.Processes[s.ProcessID].ServiceName,
+				StartTime:     time.UnixMicro(s.StartTime),
+				Duration:      time.Duration(s.Duration) * time.Microsecond,
+				Tags:          map[string]any{},
+			}
+			for _, tag := range s.Tags {
+				span.Tags[tag.Key] = tag.Value
+			}
+			trace.Spans = append(trace.Spans, span)
+		}
+		traces = append(traces, trace)
+	}
+	return traces, nil
+}
@@ -0,0 +1,66 @@
+package container
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Jaeger Container", func() {
+	It("should enable the OTLP receiver", func() {
+		container, err := NewJaeger("test-jaeger", false)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(container.config.Env).To(ContainElement("COLLECTOR_OTLP_ENABLED=true"))
+		Expect(container.config.Ports).To(HaveKey("4317"))
+		Expect(container.config.Ports).To(HaveKey("4318"))
+	})
+
+	It("should fail before the container is started", func() {
+		container, err := NewJaeger("test-jaeger-stopped", false)
+		Expect(err).ToNot(HaveOccurred())
+
+		_, err = container.FindTraces(context.Background(), "app", "")
+		Expect(err).To(MatchError("container not started"))
+	})
+
+	It("should resolve span services and tags", func() {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			defer GinkgoRecover()
+			Expect(r.URL.Path).To(Equal("/api/traces"))
+			Expect(r.URL.Query().Get("service")).To(Equal("app"))
+			Expect(r.URL.Query().Get("operation")).To(Equal("GET /"))
+			_, _ = w.Write([]byte(`{"data":[{
+				"traceID":"abc",
+				"spans":[{
+					"traceID":"abc","spanID":"1","operationName":"GET /",
+					"startTime":1700000000000000,"duration":1500,"processID":"p1",
+					"tags":[{"key":"http.status_code","value":200},{"key":"error","value":false}]
+				}],
+				"processes":{"p1":{"serviceName":"app"}}
+			}]}`))
+		}))
+		DeferCleanup(server.Close)
+
+		container, err := NewJaeger("test-jaeger-api", false)
+		Expect(err).ToNot(HaveOccurred())
+		container.queryURL = server.URL
+
+		traces, err := container.FindTraces(context.Background(), "app", "GET /")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(traces).To(HaveLen(1))
+		Expect(traces[0].TraceID).To(Equal("abc"))
+		Expect(traces[0].Spans).To(HaveLen(1))
+
+		span := traces[0].Spans[0]
+		Expect(span.ServiceName).To(Equal("app"))
+		Expect(span.StartTime).To(Equal(time.UnixMicro(1700000000000000)))
+		Expect(span.Duration).To(Equal(1500 * time.Microsecond))
+		Expect(span.Tags).To(HaveKeyWithValue("http.status_code", 200.0))
+		Expect(span.Tags).To(HaveKeyWithValue("error", false))
+	})
+})
@@ -0,0 +1,172 @@
+package container
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// LogEntry is a single log line stored in Loki
+type LogEntry struct {
+	Timestamp time.Time
+	Line      string
+}
+
+// LogStream is a set of log entries sharing the same labels
+type LogStream struct {
+	Labels  map[string]string
+	Entries []LogEntry
+}
+
+// LokiContainer provides specialized Loki container management
+type LokiContainer struct {
+	*Container
+	url string
+}
+
+// NewLoki creates a new single-binary Loki container
+func NewLoki(name string, reuse bool) (*LokiContainer, error) {
+	config := Config{
+		Image:        "grafana/loki:3.1.0",
+		Name:         name,
+		Cmd:          []string{"-config.file=/etc/loki/local-config.yaml"},
+		Ports:        map[string]string{"3100": "0"},
+		WaitStrategy: WaitStrategy{HTTP: "3100/ready", Timeout: "2m"},
+		Reuse:        reuse,
+	}
+
+	container, err := New(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Loki container: %w", err)
+	}
+
+	return &LokiContainer{
+		Container: container,
+	}, nil
+}
+
+// Start starts the Loki container and waits for it to be ready
+func (l *LokiContainer) Start(ctx context.Context) error {
+	if err := l.Container.Start(ctx); err != nil {
+		return fmt.Errorf("failed to start Loki container: %w", err)
+	}
+
+	port, err := l.GetPort("3100")
+	if err != nil {
+		return fmt.Errorf("failed to get Loki port: %w", err)
+	}
+	l.url = fmt.Sprintf("http://localhost:%s", port)
+	return nil
+}
+
+// GetURL returns the Loki HTTP API URL
+func (l *LokiContainer) GetURL() string {
+	return l.url
+}
+
+// Push writes log lines with the given stream labels, timestamped now
+func (l *LokiContainer) Push(ctx context.Context, labels map[string]string, lines ...string) error {
+	if l.url == "" {
+		return fmt.Errorf("container not started")
+	}
+
+	now := time.Now()
+	values := make([][]string, 0, len(lines))
+	for i, line := range lines {
+		// Keep entries ordered even when pushed within the same nanosecond
+		ts := now.Add(time.Duration(i)).UnixNano()
+		values = append(values, []string{strconv.FormatInt(ts, 10), line})
+	}
+
+	body, err := json.Marshal(map[string]any{
+		"streams": []map[string]any{
+			{"stream": labels, "values": values},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, l.url+"/loki/api/v1/push", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("push failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("push failed: status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// QueryRange evaluates a LogQL query between start and end
+func (l *LokiContainer) QueryRange(ctx context.Context, logql string, start, end time.Time) ([]LogStream, error) {
+	if l.url == "" {
+		return nil, fmt.Errorf("container not started")
+	}
+
+	params := url.Values{
+		"query":     {logql},
+		"start":     {strconv.FormatInt(start.UnixNano(), 10)},
+		"end":       {strconv.FormatInt(end.UnixNano(), 10)},
+		"direction": {"forward"},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, l.url+"/loki/api/v1/query_range?"+params.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("query failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("query %q failed: status %d", logql, resp.StatusCode)
+	}
+
+	var response struct {
+		Data struct {
+			ResultType string `json:"resultType"`
+			Result     []struct {
+				Stream map[string]string `json:"stream"`
+				Values [][]string        `json:"values"`
+			} `json:"result"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("failed to decode query response: %w", err)
+	}
+	if response.Data.ResultType != "streams" {
+		return nil, fmt.Errorf("unsupported result type: %s", response.Data.ResultType)
+	}
+
+	var streams []LogStream
+	for _, r := range response.Data.Result {
+		stream := LogStream{Labels: r.Stream}
+		for _, v := range r.Values {
+			if len(v) != 2 {
+				continue
+			}
+			ns, err := strconv.ParseInt(v[0], 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("unexpected log timestamp %q: %w", v[0], err)
+			}
+			stream.Entries = append(stream.Entries, LogEntry{Timestamp: time.Unix(0, ns), Line: v[1]})
+		}
+		streams = append(streams, stream)
+	}
+	return streams, nil
+}
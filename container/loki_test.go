@@ -0,0 +1,89 @@
+package container
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Loki Container", func() {
+	It("should wait for the ready endpoint", func() {
+		container, err := NewLoki("test-loki", false)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(container.config.Cmd).To(Equal([]string{"-config.file=/etc/loki/local-config.yaml"}))
+		Expect(container.config.WaitStrategy.HTTP).To(Equal("3100/ready"))
+	})
+
+	It("should fail before the container is started", func() {
+		container, err := NewLoki("test-loki-stopped", false)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(container.Push(context.Background(), map[string]string{"app": "test"}, "line")).To(MatchError("container not started"))
+		_, err = container.QueryRange(context.Background(), `{app="test"}`, time.Now(), time.Now())
+		Expect(err).To(MatchError("container not started"))
+	})
+
+	It("should push ordered entries and parse query results", func() {
+		var pushed struct {
+			Streams []struct {
+				Stream map[string]string `json:"stream"`
+				Values [][]string        `json:"values"`
+			} `json:"streams"`
+		}
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			defer GinkgoRecover()
+			switch r.URL.Path {
+			case "/loki/api/v1/push":
+				Expect(json.NewDecoder(r.Body).Decode(&pushed)).To(Succeed())
+				w.WriteHeader(http.StatusNoContent)
+			case "/loki/api/v1/query_range":
+				Expect(r.URL.Query().Get("query")).To(Equal(`{app="test"}`))
+				Expect(r.URL.Query().Get("direction")).To(Equal("forward"))
+				_, _ = w.Write([]byte(`{"data":{"resultType":"streams","result":[
+					{"stream":{"app":"test"},"values":[["1700000000000000000","first"],["1700000000000000001","second"]]}
+				]}}`))
+			default:
+				w.WriteHeader(http.StatusNotFound)
+			}
+		}))
+		DeferCleanup(server.Close)
+
+		container, err := NewLoki("test-loki-api", false)
+		Expect(err).ToNot(HaveOccurred())
+		container.url = server.URL
+
+		Expect(container.Push(context.Background(), map[string]string{"app": "test"}, "first", "second")).To(Succeed())
+		Expect(pushed.Streams).To(HaveLen(1))
+		Expect(pushed.Streams[0].Stream).To(HaveKeyWithValue("app", "test"))
+		Expect(pushed.Streams[0].Values).To(HaveLen(2))
+		Expect(pushed.Streams[0].Values[0][0] < pushed.Streams[0].Values[1][0]).To(BeTrue())
+
+		streams, err := container.QueryRange(context.Background(), `{app="test"}`, time.Now().Add(-time.Hour), time.Now())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(streams).To(HaveLen(1))
+		Expect(streams[0].Labels).To(HaveKeyWithValue("app", "test"))
+		Expect(streams[0].Entries).To(HaveLen(2))
+		Expect(streams[0].Entries[1].Line).To(Equal("second"))
+		Expect(streams[0].Entries[0].Timestamp).To(Equal(time.Unix(0, 1700000000000000000)))
+	})
+
+	It("should reject non-stream query results", func() {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(`{"data":{"resultType":"matrix","result":[]}}`))
+		}))
+		DeferCleanup(server.Close)
+
+		container, err := NewLoki("test-loki-matrix", false)
+		Expect(err).ToNot(HaveOccurred())
+		container.url = server.URL
+
+		_, err = container.QueryRange(context.Background(), `rate({app="test"}[1m])`, time.Now(), time.Now())
+		Expect(err).To(MatchError(ContainSubstring("unsupported result type: matrix")))
+	})
+})
@@ -0,0 +1,196 @@
+package container
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/flanksource/commons-test/wait"
+)
+
+// MailMessage is an email captured by MailHog
+type MailMessage struct {
+	ID      string
+	From    string
+	To      []string
+	Subject string
+	Body    string
+	Headers map[string][]string
+	Created time.Time
+}
+
+// MailHogContainer provides specialized MailHog SMTP capture container management
+type MailHogContainer struct {
+	*Container
+	smtpAddr string
+	apiURL   string
+}
+
+// NewMailHog creates a new MailHog container
+func NewMailHog(name string, reuse bool) (*MailHogContainer, error) {
+	config := Config{
+		Image: "mailhog/mailhog:v1.0.1",
+		Name:  name,
+		Ports: map[string]string{
+			"1025": "0", // SMTP
+			"8025": "0", // HTTP API and UI
+		},
+		Reuse: reuse,
+	}
+
+	container, err := New(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create MailHog container: %w", err)
+	}
+
+	return &MailHogContainer{
+		Container: container,
+	}, nil
+}
+
+// Start starts the MailHog container and waits for the API to be ready
+func (m *MailHogContainer) Start(ctx context.Context) error {
+	if err := m.Container.Start(ctx); err != nil {
+		return fmt.Errorf("failed to start MailHog container: %w", err)
+	}
+
+	smtpPort, err := m.GetPort("1025")
+	if err != nil {
+		return fmt.Errorf("failed to get SMTP port: %w", err)
+	}
+	apiPort, err := m.GetPort("8025")
+	if err != nil {
+		return fmt.Errorf("failed to get API port: %w", err)
+	}
+
+	m.smtpAddr = fmt.Sprintf("localhost:%s", smtpPort)
+	m.apiURL = fmt.Sprintf("http://localhost:%s", apiPort)
+
+	return m.waitForHTTP(ctx, m.apiURL+"/api/v2/messages?limit=1", 30, time.Second)
+}
+
+// GetSMTPAddr returns the host:port of the SMTP server
+func (m *MailHogContainer) GetSMTPAddr() string {
+	return m.smtpAddr
+}
+
+// GetAPIURL returns the MailHog HTTP API URL
+func (m *MailHogContainer) GetAPIURL() string {
+	return m.apiURL
+}
+
+// Messages returns all captured messages, newest first
+func (m *MailHogContainer) Messages(ctx context.Context) ([]MailMessage, error) {
+	if m.apiURL == "" {
+		return nil, fmt.Errorf("container not started")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, m.apiURL+"/api/v2/messages", nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list messages: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to list messages: status %d", resp.StatusCode)
+	}
+
+	type mailbox struct {
+		Mailbox string `json:"Mailbox"`
+		Domain  string `json:"Domain"`
+	}
+	var response struct {
+		Items []struct {
+			ID      string    `json:"ID"`
+			From    mailbox   `json:"From"`
+			To      []mailbox `json:"To"`
+			Created time.Time `json:"Created"`
+			Content struct {
+				Headers map[string][]string `json:"Headers"`
+				Body    string              `json:"Body"`
+			} `json:"Content"`
+		} `json:"items"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("failed to decode messages: %w", err)
+	}
+
+	var messages []MailMessage
+	for _, item := range response.Items {
+		msg := MailMessage{
+			ID:      item.ID,
+			From:    item.From.Mailbox + "@" + item.From.Domain,
+			Body:    item.Content.Body,
+			Headers: item.Content.Headers,
+			Created: item.Created,
+		}
+		for _, to := range item.To {
+			msg.To = append(msg.To, to.Mailbox+"@"+to.Domain)
+		}
+		if subject := item.Content.Headers["Subject"]; len(subject) > 0 {
+			msg.Subject = subject[0]
+		}
+		messages = append(messages, msg)
+	}
+	return messages, nil
+}
+
+// WaitForMessage polls until a captured message satisfies matcher or the timeout expires
+func (m *MailHogContainer) WaitForMessage(ctx context.Context, matcher func(MailMessage) bool, timeout time.Duration) (*MailMessage, error) {
+	var found *MailMessage
+	seen := 0
+	err := wait.Every(ctx, 500*time.Millisecond, timeout, func(ctx context.Context) (bool, error) {
+		messages, err := m.Messages(ctx)
+		if err != nil {
+			return false, wait.Stop(err)
+		}
+		seen = len(messages)
+		for _, msg := range messages {
+			if matcher(msg) {
+				found = &msg
+				return true, nil
+			}
+		}
+		return false, nil
+	})
+	if err == nil {
+		return found, nil
+	}
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	var timeoutErr *wait.TimeoutError
+	if errors.As(err, &timeoutErr) {
+		return nil, fmt.Errorf("timed out after %v waiting for matching message (%d messages captured)", timeout, seen)
+	}
+	return nil, err
+}
+
+// DeleteAllMessages removes all captured messages
+func (m *MailHogContainer) DeleteAllMessages(ctx context.Context) error {
+	if m.apiURL == "" {
+		return fmt.Errorf("container not started")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, m.apiURL+"/api/v1/messages", nil)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to delete messages: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to delete messages: status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package container
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+const mailhogMessages = `{"items":[{
+	"ID":"1",
+	"From":{"Mailbox":"noreply","Domain":"example.com"},
+	"To":[{"Mailbox":"alice","Domain":"example.com"},{"Mailbox":"bob","Domain":"example.com"}],
+	"Created":"2024-01-01T00:00:00Z",
+	"Content":{"Headers":{"Subject":["Welcome"]},"Body":"Hello"}
+}]}`
+
+// mailhogAPI serves an empty mailbox until ready is set
+func mailhogAPI(ready *atomic.Bool, deleted *atomic.Bool) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/api/v2/messages":
+			if ready.Load() {
+				_, _ = w.Write([]byte(mailhogMessages))
+				return
+			}
+			_, _ = w.Write([]byte(`{"items":[]}`))
+		case r.Method == http.MethodDelete && r.URL.Path == "/api/v1/messages":
+			deleted.Store(true)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	DeferCleanup(server.Close)
+	return server
+}
+
+var _ = Describe("MailHog Container", func() {
+	var (
+		container *MailHogContainer
+		ready     atomic.Bool
+		deleted   atomic.Bool
+	)
+
+	BeforeEach(func() {
+		ready.Store(false)
+		deleted.Store(false)
+
+		var err error
+		container, err = NewMailHog("test-mailhog", false)
+		Expect(err).ToNot(HaveOccurred())
+		container.apiURL = mailhogAPI(&ready, &deleted).URL
+	})
+
+	It("should fail before the container is started", func() {
+		stopped, err := NewMailHog("test-mailhog-stopped", false)
+		Expect(err).ToNot(HaveOccurred())
+
+		_, err = stopped.Messages(context.Background())
+		Expect(err).To(MatchError("container not started"))
+		Expect(stopped.DeleteAllMessages(context.Background())).To(MatchError("container not started"))
+	})
+
+	It("should parse captured messages", func() {
+		ready.Store(true)
+
+		messages, err := container.Messages(context.Background())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(messages).To(HaveLen(1))
+		Expect(messages[0].ID).To(Equal("1"))
+		Expect(messages[0].From).To(Equal("noreply@example.com"))
+		Expect(messages[0].To).To(Equal([]string{"alice@example.com", "bob@example.com"}))
+		Expect(messages[0].Subject).To(Equal("Welcome"))
+		Expect(messages[0].Body).To(Equal("Hello"))
+		Expect(messages[0].Created).To(Equal(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)))
+	})
+
+	It("should wait for a matching message", func() {
+		time.AfterFunc(600*time.Millisecond, func() { ready.Store(true) })
+
+		msg, err := container.WaitForMessage(context.Background(), func(m MailMessage) bool {
+			return m.Subject == "Welcome"
+		}, 5*time.Second)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(msg.To).To(ContainElement("alice@example.com"))
+	})
+
+	It("should time out when no message matches", func() {
+		ready.Store(true)
+
+		_, err := container.WaitForMessage(context.Background(), func(m MailMessage) bool {
+			return m.Subject == "Goodbye"
+		}, time.Second)
+		Expect(err).To(MatchError(ContainSubstring("waiting for matching message (1 messages captured)")))
+	})
+
+	It("should delete all messages", func() {
+		Expect(container.DeleteAllMessages(context.Background())).To(Succeed())
+		Expect(deleted.Load()).To(BeTrue())
+	})
+})
@@ -0,0 +1,82 @@
+package container
+
+import (
+	"context"
+	"fmt"
+)
+
+// MinIOImage is the default MinIO image, overridable with COMMONS_TEST_IMAGE_MINIO_MINIO
+const MinIOImage = "minio/minio:RELEASE.2024-06-13T22-53-53Z"
+
+// MinIOContainer provides specialized MinIO (S3 compatible object storage) container management
+type MinIOContainer struct {
+	*Container
+	accessKey string
+	secretKey string
+	port      string
+}
+
+// NewMinIO creates a new MinIO container whose root user is accessKey/secretKey, minioadmin/minioadmin
+// when empty
+func NewMinIO(name, accessKey, secretKey string, reuse bool) (*MinIOContainer, error) {
+	if accessKey == "" {
+		accessKey = "minioadmin"
+	}
+	if secretKey == "" {
+		secretKey = "minioadmin"
+	}
+
+	config := Config{
+		Image: MinIOImage,
+		Name:  name,
+		Cmd:   []string{"server", "/data"},
+		Ports: map[string]string{"9000": "0"},
+		Env: []string{
+			"MINIO_ROOT_USER=" + accessKey,
+			"MINIO_ROOT_PASSWORD=" + secretKey,
+		},
+		WaitStrategy: WaitStrategy{HTTP: "9000/minio/health/live", Timeout: "30s"},
+		Reuse:        reuse,
+	}
+
+	container, err := New(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create MinIO container: %w", err)
+	}
+
+	return &MinIOContainer{
+		Container: container,
+		accessKey: accessKey,
+		secretKey: secretKey,
+	}, nil
+}
+
+// Start starts the MinIO container and waits for it to be live
+func (m *MinIOContainer) Start(ctx context.Context) error {
+	if err := m.Container.Start(ctx); err != nil {
+		return fmt.Errorf("failed to start MinIO container: %w", err)
+	}
+
+	port, err := m.GetPort("9000")
+	if err != nil {
+		return fmt.Errorf("failed to get MinIO port: %w", err)
+	}
+	m.port = port
+	return nil
+}
+
+// GetEndpoint returns the S3 endpoint reachable from the host
+func (m *MinIOContainer) GetEndpoint() string {
+	return fmt.Sprintf("http://localhost:%s", m.port)
+}
+
+// GetInternalEndpoint returns the S3 endpoint reachable from other containers started with an
+// ExtraHosts entry for DockerHost
+func (m *MinIOContainer) GetInternalEndpoint() string {
+	return fmt.Sprintf("http://%s:%s", DockerHost, m.port)
+}
+
+// GetCredentials returns the access key and secret key of the root user
+func (m *MinIOContainer) GetCredentials() (string, string) {
+	return m.accessKey, m.secretKey
+}
@@ -0,0 +1,185 @@
+package container
+
+import (
+	"context"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha512"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/flanksource/commons-test/wait"
+)
+
+// MosquittoContainer provides specialized Mosquitto MQTT broker container management
+type MosquittoContainer struct {
+	*Container
+	configDir string
+	username  string
+	password  string
+	brokerURL string
+}
+
+// NewMosquitto creates a new Mosquitto container listening on 1883 with anonymous access
+func NewMosquitto(name string, reuse bool) (*MosquittoContainer, error) {
+	config := Config{
+		Image: "eclipse-mosquitto:2.0.18",
+		Name:  name,
+		Cmd:   []string{"mosquitto", "-c", "/mosquitto/test/mosquitto.conf"},
+		Ports: map[string]string{"1883": "0"},
+		Reuse: reuse,
+	}
+
+	container, err := New(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Mosquitto container: %w", err)
+	}
+
+	configDir, err := container.mountDir("conf")
+	if err != nil {
+		return nil, err
+	}
+	container.config.Mounts = append(container.config.Mounts, Mount{
+		Source:   configDir,
+		Target:   "/mosquitto/test",
+		Type:     "bind",
+		ReadOnly: true,
+	})
+
+	m := &MosquittoContainer{
+		Container: container,
+		configDir: configDir,
+	}
+	if err := m.writeConfig(); err != nil {
+		container.removeMountDirs()
+		return nil, err
+	}
+	return m, nil
+}
+
+// WithAuth disables anonymous access and requires the given credentials, must be called before Start
+func (m *MosquittoContainer) WithAuth(username, password string) (*MosquittoContainer, error) {
+	m.username = username
+	m.password = password
+	if err := m.writeConfig(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Start starts the Mosquitto container and waits for it to accept a publish
+func (m *MosquittoContainer) Start(ctx context.Context) error {
+	if err := m.Container.Start(ctx); err != nil {
+		return fmt.Errorf("failed to start Mosquitto container: %w", err)
+	}
+
+	port, err := m.GetPort("1883")
+	if err != nil {
+		return fmt.Errorf("failed to get Mosquitto port: %w", err)
+	}
+	m.brokerURL = fmt.Sprintf("tcp://localhost:%s", port)
+
+	return m.waitForReady(ctx)
+}
+
+// GetBrokerURL returns the tcp:// broker URL for MQTT clients
+func (m *MosquittoContainer) GetBrokerURL() string {
+	return m.brokerURL
+}
+
+// GetCredentials returns the configured username and password, empty when anonymous
+func (m *MosquittoContainer) GetCredentials() (string, string) {
+	return m.username, m.password
+}
+
+// Publish publishes a single message from inside the container
+func (m *MosquittoContainer) Publish(ctx context.Context, topic, payload string, retain bool) error {
+	args := append(m.clientArgs("mosquitto_pub"), "-t", topic, "-m", payload, "-q", "1")
+	if retain {
+		args = append(args, "-r")
+	}
+	if _, err := m.Exec(ctx, args); err != nil {
+		return fmt.Errorf("failed to publish to %s: %w", topic, err)
+	}
+	return nil
+}
+
+// Subscribe blocks until count messages have been received on topic or timeout expires.
+// Start it in a goroutine before publishing non-retained messages.
+func (m *MosquittoContainer) Subscribe(ctx context.Context, topic string, count int, timeout time.Duration) ([]string, error) {
+	args := append(m.clientArgs("mosquitto_sub"), "-t", topic,
+		"-C", fmt.Sprintf("%d", count),
+		"-W", fmt.Sprintf("%d", int(timeout.Seconds())),
+	)
+	out, err := m.Exec(ctx, args)
+	if err != nil {
+		return nil, fmt.Errorf("failed to receive %d messages on %s within %v: %w", count, topic, timeout, err)
+	}
+	return strings.Split(strings.TrimRight(out, "\n"), "\n"), nil
+}
+
+// HealthCheck publishes a message to a health topic
+func (m *MosquittoContainer) HealthCheck(ctx context.Context) error {
+	if m.brokerURL == "" {
+		return fmt.Errorf("broker URL not set - container may not be started")
+	}
+	return m.Publish(ctx, "commons-test/health", "ping", false)
+}
+
+// waitForReady waits for the broker to accept a publish
+func (m *MosquittoContainer) waitForReady(ctx context.Context) error {
+	return m.Container.waitUntil(ctx, "Mosquitto", time.Second, 30*time.Second, wait.NoError(m.HealthCheck))
+}
+
+func (m *MosquittoContainer) clientArgs(bin string) []string {
+	args := []string{bin, "-h", "localhost", "-p", "1883"}
+	if m.username != "" {
+		args = append(args, "-u", m.username, "-P", m.password)
+	}
+	return args
+}
+
+// writeConfig renders mosquitto.conf and, when auth is enabled, the password file
+func (m *MosquittoContainer) writeConfig() error {
+	conf := "listener 1883 0.0.0.0\npersistence false\n"
+	if m.username == "" {
+		conf += "allow_anonymous true\n"
+		if err := os.Remove(filepath.Join(m.configDir, "passwd")); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove password file: %w", err)
+		}
+	} else {
+		entry, err := mosquittoPasswordEntry(m.username, m.password)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(filepath.Join(m.configDir, "passwd"), []byte(entry+"\n"), 0644); err != nil {
+			return fmt.Errorf("failed to write password file: %w", err)
+		}
+		conf += "allow_anonymous false\npassword_file /mosquitto/test/passwd\n"
+	}
+
+	if err := os.WriteFile(filepath.Join(m.configDir, "mosquitto.conf"), []byte(conf), 0644); err != nil {
+		return fmt.Errorf("failed to write mosquitto.conf: %w", err)
+	}
+	return nil
+}
+
+// mosquittoPasswordEntry returns a password file line in the mosquitto_passwd PBKDF2-SHA512 ($7$) format
+func mosquittoPasswordEntry(username, password string) (string, error) {
+	const iterations = 101
+	salt := make([]byte, 12)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	hash, err := pbkdf2.Key(sha512.New, password, salt, iterations, sha512.Size)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s:$7$%d$%s$%s", username, iterations,
+		base64.StdEncoding.EncodeToString(salt),
+		base64.StdEncoding.EncodeToString(hash)), nil
+}
@@ -0,0 +1,42 @@
+package container
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Mosquitto Container", func() {
+	It("should allow anonymous access by default", func() {
+		container, err := NewMosquitto("test-mosquitto", false)
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(container.Cleanup)
+
+		conf, err := os.ReadFile(filepath.Join(container.configDir, "mosquitto.conf"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(conf)).To(ContainSubstring("allow_anonymous true"))
+		Expect(filepath.Join(container.configDir, "passwd")).ToNot(BeAnExistingFile())
+	})
+
+	It("should write a password file when auth is enabled", func() {
+		container, err := NewMosquitto("test-mosquitto-auth", false)
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(container.Cleanup)
+
+		_, err = container.WithAuth("user", "secret")
+		Expect(err).ToNot(HaveOccurred())
+
+		conf, err := os.ReadFile(filepath.Join(container.configDir, "mosquitto.conf"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(conf)).To(ContainSubstring("allow_anonymous false"))
+		Expect(string(conf)).To(ContainSubstring("password_file /mosquitto/test/passwd"))
+
+		passwd, err := os.ReadFile(filepath.Join(container.configDir, "passwd"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(strings.Split(strings.TrimSpace(string(passwd)), "$")).To(HaveLen(5))
+		Expect(string(passwd)).To(HavePrefix("user:$7$101$"))
+	})
+})
@@ -0,0 +1,229 @@
+package container
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/flanksource/commons-test/wait"
+)
+
+// NATSContainer provides specialized NATS container management
+type NATSContainer struct {
+	*Container
+	jetstream     bool
+	url           string
+	monitoringURL string
+}
+
+// NewNATS creates a new NATS container
+func NewNATS(name string, reuse bool) (*NATSContainer, error) {
+	config := Config{
+		Image: "nats:2.10-alpine",
+		Name:  name,
+		Cmd:   []string{"nats-server", "--port", "4222", "--http_port", "8222"},
+		Ports: map[string]string{
+			"4222": "0", // Client port
+			"8222": "0", // Monitoring port
+		},
+		Reuse: reuse,
+	}
+
+	container, err := New(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create NATS container: %w", err)
+	}
+
+	return &NATSContainer{
+		Container: container,
+	}, nil
+}
+
+// WithJetStream enables JetStream persistence, must be called before Start
+func (n *NATSContainer) WithJetStream() *NATSContainer {
+	if !n.jetstream {
+		n.jetstream = true
+		n.config.Cmd = append(n.config.Cmd, "--jetstream")
+	}
+	return n
+}
+
+// Start starts the NATS container and waits for it to be ready
+func (n *NATSContainer) Start(ctx context.Context) error {
+	if err := n.Container.Start(ctx); err != nil {
+		return fmt.Errorf("failed to start NATS container: %w", err)
+	}
+
+	clientPort, err := n.GetPort("4222")
+	if err != nil {
+		return fmt.Errorf("failed to get NATS client port: %w", err)
+	}
+
+	monitoringPort, err := n.GetPort("8222")
+	if err != nil {
+		return fmt.Errorf("failed to get NATS monitoring port: %w", err)
+	}
+
+	n.url = fmt.Sprintf("nats://localhost:%s", clientPort)
+	n.monitoringURL = fmt.Sprintf("http://localhost:%s", monitoringPort)
+
+	n.Infof("Service URLs - Client: %s, Monitoring: %s", n.url, n.monitoringURL)
+
+	return n.waitForReady(ctx)
+}
+
+// GetURL returns the nats:// URL for client connections
+func (n *NATSContainer) GetURL() string {
+	return n.url
+}
+
+// GetMonitoringURL returns the HTTP monitoring endpoint URL
+func (n *NATSContainer) GetMonitoringURL() string {
+	return n.monitoringURL
+}
+
+// IsJetStreamEnabled returns true if the server was started with JetStream
+func (n *NATSContainer) IsJetStreamEnabled() bool {
+	return n.jetstream
+}
+
+// waitForReady waits for the monitoring endpoint to report the server as healthy
+func (n *NATSContainer) waitForReady(ctx context.Context) error {
+	return n.Container.waitUntil(ctx, "NATS", time.Second, 30*time.Second, wait.NoError(func(ctx context.Context) error {
+		err := n.HealthCheck()
+		if err != nil {
+			n.Tracef("Readiness check failed: %v", err)
+		}
+		return err
+	}))
+}
+
+// HealthCheck queries the /healthz monitoring endpoint
+func (n *NATSContainer) HealthCheck() error {
+	if n.monitoringURL == "" {
+		return fmt.Errorf("monitoring URL not set - container may not be started")
+	}
+
+	url := n.monitoringURL + "/healthz"
+	if n.jetstream {
+		url += "?js-enabled-only=true"
+	}
+
+	client := &http.Client{Timeout: 3 * time.Second}
+	resp, err := client.Get(url)
+	if err != nil {
+		return fmt.Errorf("health check failed - monitoring request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("health check failed - monitoring endpoint returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// CreateStream creates a JetStream stream capturing the given subjects.
+// If no subjects are given the stream name is used as the only subject.
+func (n *NATSContainer) CreateStream(name string, subjects ...string) error {
+	if !n.jetstream {
+		return fmt.Errorf("JetStream is not enabled, call WithJetStream() before Start")
+	}
+	if len(subjects) == 0 {
+		subjects = []string{name}
+	}
+
+	payload, err := json.Marshal(map[string]any{
+		"name":     name,
+		"subjects": subjects,
+	})
+	if err != nil {
+		return err
+	}
+
+	body, err := n.request("$JS.API.STREAM.CREATE."+name, payload, 5*time.Second)
+	if err != nil {
+		return fmt.Errorf("failed to create stream %s: %w", name, err)
+	}
+
+	var response struct {
+		Error *struct {
+			Code        int    `json:"code"`
+			Description string `json:"description"`
+		} `json:"error,omitempty"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return fmt.Errorf("failed to parse stream create response: %w", err)
+	}
+	if response.Error != nil {
+		return fmt.Errorf("failed to create stream %s: %s (%d)", name, response.Error.Description, response.Error.Code)
+	}
+
+	n.Infof("Created JetStream stream %s with subjects %v", name, subjects)
+	return nil
+}
+
+// request performs a single request/reply exchange using the NATS text protocol
+func (n *NATSContainer) request(subject string, payload []byte, timeout time.Duration) ([]byte, error) {
+	if n.url == "" {
+		return nil, fmt.Errorf("container not started")
+	}
+
+	conn, err := net.DialTimeout("tcp", strings.TrimPrefix(n.url, "nats://"), timeout)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(timeout))
+
+	reader := bufio.NewReader(conn)
+
+	// The server greets every connection with an INFO line
+	if _, err := reader.ReadString('\n'); err != nil {
+		return nil, fmt.Errorf("failed to read server info: %w", err)
+	}
+
+	inbox := "_INBOX." + strings.ReplaceAll(uuid.New().String(), "-", "")
+	msg := fmt.Sprintf("CONNECT {\"verbose\":false,\"pedantic\":false}\r\nSUB %s 1\r\nPUB %s %s %d\r\n%s\r\n",
+		inbox, subject, inbox, len(payload), payload)
+	if _, err := conn.Write([]byte(msg)); err != nil {
+		return nil, err
+	}
+
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		line = strings.TrimSpace(line)
+
+		switch {
+		case line == "PING":
+			if _, err := conn.Write([]byte("PONG\r\n")); err != nil {
+				return nil, err
+			}
+		case strings.HasPrefix(line, "-ERR"):
+			return nil, fmt.Errorf("server error: %s", strings.TrimSpace(strings.TrimPrefix(line, "-ERR")))
+		case strings.HasPrefix(line, "MSG "):
+			// MSG <subject> <sid> [reply-to] <#bytes>
+			fields := strings.Fields(line)
+			size, err := strconv.Atoi(fields[len(fields)-1])
+			if err != nil {
+				return nil, fmt.Errorf("unexpected message header: %s", line)
+			}
+			body := make([]byte, size+2) // payload followed by \r\n
+			if _, err := io.ReadFull(reader, body); err != nil {
+				return nil, err
+			}
+			return body[:size], nil
+		}
+	}
+}
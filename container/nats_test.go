@@ -0,0 +1,37 @@
+package container
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("NATS Container", func() {
+	It("should configure client and monitoring ports", func() {
+		container, err := NewNATS("test-nats", false)
+		Expect(err).ToNot(HaveOccurred())
+
+		config := container.Container.config
+		Expect(config.Ports).To(HaveKey("4222"))
+		Expect(config.Ports).To(HaveKey("8222"))
+		Expect(config.Cmd).ToNot(ContainElement("--jetstream"))
+		Expect(container.GetURL()).To(BeEmpty())
+	})
+
+	It("should enable JetStream only once", func() {
+		container, err := NewNATS("test-nats-js", false)
+		Expect(err).ToNot(HaveOccurred())
+
+		container.WithJetStream().WithJetStream()
+		Expect(container.IsJetStreamEnabled()).To(BeTrue())
+		Expect(container.Container.config.Cmd).To(HaveLen(6))
+		Expect(container.Container.config.Cmd).To(ContainElement("--jetstream"))
+	})
+
+	It("should reject stream creation without JetStream", func() {
+		container, err := NewNATS("test-nats-nojs", false)
+		Expect(err).ToNot(HaveOccurred())
+
+		err = container.CreateStream("orders", "orders.>")
+		Expect(err).To(MatchError(ContainSubstring("JetStream is not enabled")))
+	})
+})
@@ -0,0 +1,71 @@
+package container
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/flanksource/clicky"
+
+	"github.com/flanksource/commons-test/cleanup"
+	"github.com/flanksource/commons-test/names"
+)
+
+var (
+	networksMu sync.Mutex
+	// networks holds the unregister funcs of the networks created by CreateNetwork
+	networks = map[string]func(){}
+)
+
+// CreateNetwork creates a user-defined bridge network that containers join with Config.Network, generating
+// its name when empty. The network is removed by cleanup.Run unless RemoveNetwork is called first, an
+// existing network of the same name is reused and left in place.
+func CreateNetwork(name string) (string, error) {
+	name, _, err := createNetwork(name)
+	return name, err
+}
+
+// createNetwork returns false when an existing network was reused
+func createNetwork(name string) (string, bool, error) {
+	if name == "" {
+		name = names.New("network")
+	}
+	if clicky.Exec("docker", "network", "inspect", name).Run().Err == nil {
+		return name, false, nil
+	}
+
+	process := clicky.Exec("docker", "network", "create", "--label", names.RunLabel+"="+names.RunID(), name).Run()
+	if process.Err != nil {
+		return "", false, fmt.Errorf("failed to create network %s: %w", name, process.Err)
+	}
+
+	networksMu.Lock()
+	defer networksMu.Unlock()
+	networks[name] = cleanup.Register("network "+name, func(context.Context) error {
+		return removeNetwork(name)
+	})
+	return name, true, nil
+}
+
+// RemoveNetwork removes a network, which fails while containers are still attached to it
+func RemoveNetwork(name string) error {
+	networksMu.Lock()
+	if unregister, ok := networks[name]; ok {
+		unregister()
+		delete(networks, name)
+	}
+	networksMu.Unlock()
+	return removeNetwork(name)
+}
+
+func removeNetwork(name string) error {
+	process := clicky.Exec("docker", "network", "rm", name).Run()
+	if process.Err != nil {
+		if strings.Contains(process.GetStderr(), "not found") {
+			return nil
+		}
+		return fmt.Errorf("failed to remove network %s: %w", name, process.Err)
+	}
+	return nil
+}
@@ -0,0 +1,17 @@
+package container
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Network", func() {
+	It("should require a network for aliases", func() {
+		_, err := New(Config{Image: "nginx:alpine", NetworkAliases: []string{"web"}})
+		Expect(err).To(MatchError(ContainSubstring("require a network")))
+
+		container, err := New(Config{Image: "nginx:alpine", Network: "backend", NetworkAliases: []string{"web"}})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(container.config.Network).To(Equal("backend"))
+	})
+})
@@ -0,0 +1,150 @@
+package container
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// DefaultOTelCollectorConfig receives OTLP over gRPC/HTTP and logs every span
+const DefaultOTelCollectorConfig = `
+extensions:
+  health_check:
+    endpoint: 0.0.0.0:13133
+receivers:
+  otlp:
+    protocols:
+      grpc:
+        endpoint: 0.0.0.0:4317
+      http:
+        endpoint: 0.0.0.0:4318
+exporters:
+  debug:
+    verbosity: detailed
+service:
+  extensions: [health_check]
+  pipelines:
+    traces:
+      receivers: [otlp]
+      exporters: [debug]
+`
+
+// OTelForwardConfig returns a collector configuration that forwards traces
+// to another OTLP gRPC endpoint, e.g. a JaegerContainer's host address.
+func OTelForwardConfig(endpoint string) string {
+	return fmt.Sprintf(`
+extensions:
+  health_check:
+    endpoint: 0.0.0.0:13133
+receivers:
+  otlp:
+    protocols:
+      grpc:
+        endpoint: 0.0.0.0:4317
+      http:
+        endpoint: 0.0.0.0:4318
+exporters:
+  otlp:
+    endpoint: %s
+    tls:
+      insecure: true
+service:
+  extensions: [health_check]
+  pipelines:
+    traces:
+      receivers: [otlp]
+      exporters: [otlp]
+`, endpoint)
+}
+
+// OTelCollectorContainer provides specialized OpenTelemetry collector container management
+type OTelCollectorContainer struct {
+	*Container
+	otlpEndpoint     string
+	otlpHTTPEndpoint string
+	healthURL        string
+}
+
+// NewOTelCollector creates a new OpenTelemetry collector container.
+// collectorConfig is the collector YAML, it must enable the health_check
+// extension on port 13133; DefaultOTelCollectorConfig is used when empty.
+func NewOTelCollector(name, collectorConfig string, reuse bool) (*OTelCollectorContainer, error) {
+	if collectorConfig == "" {
+		collectorConfig = DefaultOTelCollectorConfig
+	}
+
+	config := Config{
+		Image: "otel/opentelemetry-collector-contrib:0.104.0",
+		Name:  name,
+		Cmd:   []string{"--config=/etc/otelcol-test/config.yaml"},
+		Ports: map[string]string{
+			"4317":  "0", // OTLP gRPC
+			"4318":  "0", // OTLP HTTP
+			"13133": "0", // Health check extension
+		},
+		ExtraHosts: []string{DockerHost + ":host-gateway"},
+		Reuse:      reuse,
+	}
+
+	container, err := New(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OpenTelemetry collector container: %w", err)
+	}
+
+	configDir, err := container.mountDir("conf")
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(filepath.Join(configDir, "config.yaml"), []byte(collectorConfig), 0644); err != nil {
+		container.removeMountDirs()
+		return nil, fmt.Errorf("failed to write collector config: %w", err)
+	}
+	container.config.Mounts = append(container.config.Mounts, Mount{
+		Source:   configDir,
+		Target:   "/etc/otelcol-test",
+		Type:     "bind",
+		ReadOnly: true,
+	})
+
+	return &OTelCollectorContainer{
+		Container: container,
+	}, nil
+}
+
+// Start starts the collector and waits for the health check extension to report ready
+func (o *OTelCollectorContainer) Start(ctx context.Context) error {
+	if err := o.Container.Start(ctx); err != nil {
+		return fmt.Errorf("failed to start OpenTelemetry collector container: %w", err)
+	}
+
+	grpcPort, err := o.GetPort("4317")
+	if err != nil {
+		return fmt.Errorf("failed to get OTLP gRPC port: %w", err)
+	}
+	httpPort, err := o.GetPort("4318")
+	if err != nil {
+		return fmt.Errorf("failed to get OTLP HTTP port: %w", err)
+	}
+	healthPort, err := o.GetPort("13133")
+	if err != nil {
+		return fmt.Errorf("failed to get health check port: %w", err)
+	}
+
+	o.otlpEndpoint = fmt.Sprintf("localhost:%s", grpcPort)
+	o.otlpHTTPEndpoint = fmt.Sprintf("http://localhost:%s", httpPort)
+	o.healthURL = fmt.Sprintf("http://localhost:%s/", healthPort)
+
+	return o.waitForHTTP(ctx, o.healthURL, 30, time.Second)
+}
+
+// GetOTLPEndpoint returns the host:port of the OTLP gRPC receiver
+func (o *OTelCollectorContainer) GetOTLPEndpoint() string {
+	return o.otlpEndpoint
+}
+
+// GetOTLPHTTPEndpoint returns the URL of the OTLP HTTP receiver
+func (o *OTelCollectorContainer) GetOTLPHTTPEndpoint() string {
+	return o.otlpHTTPEndpoint
+}
@@ -0,0 +1,71 @@
+package container
+
+import (
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"sigs.k8s.io/yaml"
+)
+
+// collectorConfig is the subset of the collector configuration wiring receivers to exporters
+type collectorConfig struct {
+	Exporters map[string]map[string]any `json:"exporters"`
+	Service   struct {
+		Extensions []string `json:"extensions"`
+		Pipelines  map[string]struct {
+			Receivers []string `json:"receivers"`
+			Exporters []string `json:"exporters"`
+		} `json:"pipelines"`
+	} `json:"service"`
+}
+
+func parseCollectorConfig(data string) collectorConfig {
+	var config collectorConfig
+	Expect(yaml.Unmarshal([]byte(data), &config)).To(Succeed())
+	return config
+}
+
+var _ = Describe("OpenTelemetry Collector Container", func() {
+	It("should log traces with the default config", func() {
+		config := parseCollectorConfig(DefaultOTelCollectorConfig)
+
+		Expect(config.Service.Extensions).To(ContainElement("health_check"))
+		Expect(config.Service.Pipelines).To(HaveKey("traces"))
+		Expect(config.Service.Pipelines["traces"].Receivers).To(Equal([]string{"otlp"}))
+		Expect(config.Service.Pipelines["traces"].Exporters).To(Equal([]string{"debug"}))
+	})
+
+	It("should forward traces to the given endpoint", func() {
+		config := parseCollectorConfig(OTelForwardConfig("jaeger:4317"))
+
+		Expect(config.Service.Pipelines["traces"].Exporters).To(Equal([]string{"otlp"}))
+		Expect(config.Exporters).To(HaveKey("otlp"))
+		Expect(config.Exporters["otlp"]).To(HaveKeyWithValue("endpoint", "jaeger:4317"))
+		Expect(config.Exporters["otlp"]).To(HaveKeyWithValue("tls", HaveKeyWithValue("insecure", true)))
+	})
+
+	It("should mount the collector config", func() {
+		container, err := NewOTelCollector("test-otel", OTelForwardConfig("jaeger:4317"), false)
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(container.Cleanup)
+
+		Expect(container.config.Cmd).To(Equal([]string{"--config=/etc/otelcol-test/config.yaml"}))
+		mount := container.config.Mounts[len(container.config.Mounts)-1]
+		Expect(mount.Target).To(Equal("/etc/otelcol-test"))
+		data, err := os.ReadFile(filepath.Join(mount.Source, "config.yaml"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(data)).To(Equal(OTelForwardConfig("jaeger:4317")))
+	})
+
+	It("should use the default config when none is given", func() {
+		container, err := NewOTelCollector("test-otel-default", "", false)
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(container.Cleanup)
+
+		data, err := os.ReadFile(filepath.Join(container.config.Mounts[len(container.config.Mounts)-1].Source, "config.yaml"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(data)).To(Equal(DefaultOTelCollectorConfig))
+	})
+})
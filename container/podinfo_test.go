@@ -7,6 +7,8 @@ import (
 	"net/http"
 	"testing"
 	"time"
+
+	"github.com/flanksource/commons-test/probe"
 )
 
 func TestPodinfoContainer(t *testing.T) {
@@ -71,23 +73,9 @@ func TestPodinfoContainer(t *testing.T) {
 	t.Run("HTTP endpoint is accessible", func(t *testing.T) {
 		url := fmt.Sprintf("http://localhost:%s/healthz", hostPort)
 
-		var resp *http.Response
-		var err error
-		for i := 0; i < 10; i++ {
-			resp, err = http.Get(url)
-			if err == nil && resp.StatusCode == http.StatusOK {
-				break
-			}
-			time.Sleep(time.Second)
-		}
-
+		resp, err := probe.HTTP(url).ExpectStatus(http.StatusOK).ExpectBodyContains("OK").WaitUntil(10 * time.Second)
 		if err != nil {
-			t.Fatalf("Failed to reach podinfo endpoint: %v", err)
-		}
-		defer resp.Body.Close()
-
-		if resp.StatusCode != http.StatusOK {
-			t.Fatalf("Expected status 200, got %d", resp.StatusCode)
+			t.Fatalf("Failed to reach podinfo endpoint (last response %+v): %v", resp, err)
 		}
 	})
 
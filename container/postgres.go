@@ -0,0 +1,184 @@
+package container
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+
+	"github.com/flanksource/commons-test/wait"
+)
+
+// PostgresImage is the default Postgres image, overridable with COMMONS_TEST_IMAGE_POSTGRES
+const PostgresImage = "postgres:16-alpine"
+
+// PostgresContainer provides specialized Postgres container management
+type PostgresContainer struct {
+	*Container
+	password string
+	port     string
+
+	mu sync.Mutex
+	db *sql.DB
+}
+
+// NewPostgres creates a new Postgres container whose postgres superuser has password
+func NewPostgres(name, password string, reuse bool) (*PostgresContainer, error) {
+	config := Config{
+		Image: PostgresImage,
+		Name:  name,
+		Ports: map[string]string{"5432": "0"},
+		Env: []string{
+			"POSTGRES_PASSWORD=" + password,
+		},
+		HealthCheck: &HealthCheck{
+			Cmd:      "pg_isready -U postgres",
+			Interval: time.Second,
+			Retries:  30,
+		},
+		Reuse: reuse,
+	}
+
+	container, err := New(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Postgres container: %w", err)
+	}
+
+	return &PostgresContainer{
+		Container: container,
+		password:  password,
+	}, nil
+}
+
+// Start starts the Postgres container and waits for it to accept queries
+func (p *PostgresContainer) Start(ctx context.Context) error {
+	if err := p.Container.Start(ctx); err != nil {
+		return fmt.Errorf("failed to start Postgres container: %w", err)
+	}
+
+	port, err := p.GetPort("5432")
+	if err != nil {
+		return fmt.Errorf("failed to get Postgres port: %w", err)
+	}
+	p.port = port
+
+	db, err := p.GetDB()
+	if err != nil {
+		return err
+	}
+	err = wait.For(ctx, time.Second, time.Minute, wait.NoError(func(ctx context.Context) error {
+		return db.PingContext(ctx)
+	}))
+	if err != nil {
+		return fmt.Errorf("Postgres failed to become ready: %w", err)
+	}
+	return nil
+}
+
+// DriverName returns the database/sql driver of the connection strings
+func (p *PostgresContainer) DriverName() string {
+	return "pgx"
+}
+
+// GetConnectionString returns the connection string of the postgres database
+func (p *PostgresContainer) GetConnectionString() string {
+	return p.GetDatabaseConnectionString("postgres")
+}
+
+// GetDatabaseConnectionString returns a connection string for a specific database
+func (p *PostgresContainer) GetDatabaseConnectionString(database string) string {
+	return fmt.Sprintf("postgres://postgres:%s@localhost:%s/%s?sslmode=disable", p.password, p.port, database)
+}
+
+// GetDB returns a pooled connection to the postgres database, shared across calls
+func (p *PostgresContainer) GetDB() (*sql.DB, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.db != nil {
+		return p.db, nil
+	}
+	if p.port == "" {
+		return nil, fmt.Errorf("container not started")
+	}
+
+	db, err := sql.Open("pgx", p.GetConnectionString())
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	db.SetMaxOpenConns(10)
+	db.SetConnMaxIdleTime(time.Minute)
+	p.db = db
+	return p.db, nil
+}
+
+// CreateDatabase creates a database if it does not already exist
+func (p *PostgresContainer) CreateDatabase(ctx context.Context, name string) error {
+	db, err := p.GetDB()
+	if err != nil {
+		return err
+	}
+
+	var exists bool
+	if err := db.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM pg_database WHERE datname = $1)", name).Scan(&exists); err != nil {
+		return fmt.Errorf("failed to check database %s: %w", name, err)
+	}
+	if exists {
+		return nil
+	}
+	if _, err := db.ExecContext(ctx, "CREATE DATABASE "+quotePostgresIdentifier(name)); err != nil {
+		return fmt.Errorf("failed to create database %s: %w", name, err)
+	}
+	return nil
+}
+
+// DropDatabase drops a database if it exists, closing any open connections to it
+func (p *PostgresContainer) DropDatabase(ctx context.Context, name string) error {
+	db, err := p.GetDB()
+	if err != nil {
+		return err
+	}
+
+	if _, err := db.ExecContext(ctx, "DROP DATABASE IF EXISTS "+quotePostgresIdentifier(name)+" WITH (FORCE)"); err != nil {
+		return fmt.Errorf("failed to drop database %s: %w", name, err)
+	}
+	return nil
+}
+
+// RunScript executes a SQL script against the postgres database. pathOrSQL is either a path to a .sql
+// file or the script itself, a path that cannot be read is returned as an error.
+func (p *PostgresContainer) RunScript(ctx context.Context, pathOrSQL string) error {
+	db, err := p.GetDB()
+	if err != nil {
+		return err
+	}
+
+	script, err := readScript(pathOrSQL)
+	if err != nil {
+		return err
+	}
+	if _, err := db.ExecContext(ctx, script); err != nil {
+		return fmt.Errorf("failed to run script: %w", err)
+	}
+	return nil
+}
+
+// Cleanup closes the pooled connection and removes the container
+func (p *PostgresContainer) Cleanup(ctx context.Context) error {
+	p.mu.Lock()
+	if p.db != nil {
+		p.db.Close()
+		p.db = nil
+	}
+	p.mu.Unlock()
+	return p.Container.Cleanup(ctx)
+}
+
+// quotePostgresIdentifier wraps a name in double quotes for use as a Postgres identifier
+func quotePostgresIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
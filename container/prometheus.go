@@ -0,0 +1,293 @@
+package container
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"sigs.k8s.io/yaml"
+)
+
+// ScrapeConfig describes a single Prometheus scrape job
+type ScrapeConfig struct {
+	JobName        string
+	Targets        []string // host:port pairs, see Container.GetHostAddress for other test containers
+	MetricsPath    string
+	ScrapeInterval time.Duration
+	Labels         map[string]string
+}
+
+// Sample is a single value returned from a PromQL query
+type Sample struct {
+	Metric    map[string]string
+	Value     float64
+	Timestamp time.Time
+}
+
+// PrometheusContainer provides specialized Prometheus container management
+type PrometheusContainer struct {
+	*Container
+	configDir string
+	scrapes   []ScrapeConfig
+	url       string
+}
+
+// NewPrometheus creates a new Prometheus container scraping the given targets
+func NewPrometheus(name string, reuse bool, scrapes ...ScrapeConfig) (*PrometheusContainer, error) {
+	config := Config{
+		Image: "prom/prometheus:v2.53.0",
+		Name:  name,
+		Cmd: []string{
+			"--config.file=/etc/prometheus/test/prometheus.yml",
+			"--storage.tsdb.path=/prometheus",
+			"--web.enable-lifecycle",
+		},
+		Ports:        map[string]string{"9090": "0"},
+		ExtraHosts:   []string{DockerHost + ":host-gateway"},
+		WaitStrategy: WaitStrategy{HTTP: "9090/-/ready", Timeout: "30s"},
+		Reuse:        reuse,
+	}
+
+	container, err := New(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Prometheus container: %w", err)
+	}
+
+	configDir, err := container.mountDir("conf")
+	if err != nil {
+		return nil, err
+	}
+	container.config.Mounts = append(container.config.Mounts, Mount{
+		Source:   configDir,
+		Target:   "/etc/prometheus/test",
+		Type:     "bind",
+		ReadOnly: true,
+	})
+
+	p := &PrometheusContainer{
+		Container: container,
+		configDir: configDir,
+		scrapes:   scrapes,
+	}
+
+	if err := p.writeConfig(); err != nil {
+		container.removeMountDirs()
+		return nil, err
+	}
+	return p, nil
+}
+
+// Start starts the Prometheus container and waits for it to be ready
+func (p *PrometheusContainer) Start(ctx context.Context) error {
+	if err := p.Container.Start(ctx); err != nil {
+		return fmt.Errorf("failed to start Prometheus container: %w", err)
+	}
+
+	port, err := p.GetPort("9090")
+	if err != nil {
+		return fmt.Errorf("failed to get Prometheus port: %w", err)
+	}
+	p.url = fmt.Sprintf("http://localhost:%s", port)
+	return nil
+}
+
+// GetURL returns the Prometheus HTTP API URL
+func (p *PrometheusContainer) GetURL() string {
+	return p.url
+}
+
+// AddScrapeTarget adds a scrape job, reloading the configuration if the container is running
+func (p *PrometheusContainer) AddScrapeTarget(ctx context.Context, scrape ScrapeConfig) error {
+	p.scrapes = append(p.scrapes, scrape)
+	if err := p.writeConfig(); err != nil {
+		return err
+	}
+	if p.url == "" {
+		return nil
+	}
+	return p.Reload(ctx)
+}
+
+// Reload asks Prometheus to re-read its configuration file
+func (p *PrometheusContainer) Reload(ctx context.Context) error {
+	if p.url == "" {
+		return fmt.Errorf("container not started")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url+"/-/reload", nil)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reload Prometheus config: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to reload Prometheus config: status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// Query evaluates an instant PromQL query and returns the resulting samples
+func (p *PrometheusContainer) Query(ctx context.Context, promql string) ([]Sample, error) {
+	if p.url == "" {
+		return nil, fmt.Errorf("container not started")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		p.url+"/api/v1/query?"+url.Values{"query": {promql}}.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("query failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var response struct {
+		Status string `json:"status"`
+		Error  string `json:"error"`
+		Data   struct {
+			ResultType string          `json:"resultType"`
+			Result     json.RawMessage `json:"result"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("failed to decode query response: %w", err)
+	}
+	if response.Status != "success" {
+		return nil, fmt.Errorf("query %q failed: %s", promql, response.Error)
+	}
+
+	return parseSamples(response.Data.ResultType, response.Data.Result)
+}
+
+// parseSamples converts a Prometheus API result into samples
+func parseSamples(resultType string, result json.RawMessage) ([]Sample, error) {
+	switch resultType {
+	case "scalar":
+		var value []any
+		if err := json.Unmarshal(result, &value); err != nil {
+			return nil, err
+		}
+		sample, err := parseSample(nil, value)
+		if err != nil {
+			return nil, err
+		}
+		return []Sample{sample}, nil
+
+	case "vector":
+		var series []struct {
+			Metric map[string]string `json:"metric"`
+			Value  []any             `json:"value"`
+		}
+		if err := json.Unmarshal(result, &series); err != nil {
+			return nil, err
+		}
+		var samples []Sample
+		for _, s := range series {
+			sample, err := parseSample(s.Metric, s.Value)
+			if err != nil {
+				return nil, err
+			}
+			samples = append(samples, sample)
+		}
+		return samples, nil
+
+	case "matrix":
+		var series []struct {
+			Metric map[string]string `json:"metric"`
+			Values [][]any           `json:"values"`
+		}
+		if err := json.Unmarshal(result, &series); err != nil {
+			return nil, err
+		}
+		var samples []Sample
+		for _, s := range series {
+			for _, v := range s.Values {
+				sample, err := parseSample(s.Metric, v)
+				if err != nil {
+					return nil, err
+				}
+				samples = append(samples, sample)
+			}
+		}
+		return samples, nil
+	}
+
+	return nil, fmt.Errorf("unsupported result type: %s", resultType)
+}
+
+// parseSample parses a [<unix_time>, "<value>"] pair
+func parseSample(metric map[string]string, pair []any) (Sample, error) {
+	if len(pair) != 2 {
+		return Sample{}, fmt.Errorf("unexpected sample format: %v", pair)
+	}
+	ts, ok := pair[0].(float64)
+	if !ok {
+		return Sample{}, fmt.Errorf("unexpected sample timestamp: %v", pair[0])
+	}
+	raw, ok := pair[1].(string)
+	if !ok {
+		return Sample{}, fmt.Errorf("unexpected sample value: %v", pair[1])
+	}
+	value, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		return Sample{}, err
+	}
+	return Sample{
+		Metric:    metric,
+		Value:     value,
+		Timestamp: time.UnixMilli(int64(ts * 1000)),
+	}, nil
+}
+
+// writeConfig renders prometheus.yml into the mounted config directory
+func (p *PrometheusContainer) writeConfig() error {
+	data, err := renderPrometheusConfig(p.scrapes)
+	if err != nil {
+		return err
+	}
+	// Truncate in place so the bind mount keeps pointing at the same inode
+	return os.WriteFile(filepath.Join(p.configDir, "prometheus.yml"), data, 0644)
+}
+
+func renderPrometheusConfig(scrapes []ScrapeConfig) ([]byte, error) {
+	jobs := []map[string]any{}
+	for _, s := range scrapes {
+		job := map[string]any{
+			"job_name": s.JobName,
+			"static_configs": []map[string]any{
+				{"targets": s.Targets, "labels": s.Labels},
+			},
+		}
+		if s.MetricsPath != "" {
+			job["metrics_path"] = s.MetricsPath
+		}
+		if s.ScrapeInterval > 0 {
+			job["scrape_interval"] = s.ScrapeInterval.String()
+		}
+		jobs = append(jobs, job)
+	}
+
+	data, err := yaml.Marshal(map[string]any{
+		"global": map[string]any{
+			"scrape_interval":     "5s",
+			"evaluation_interval": "5s",
+		},
+		"scrape_configs": jobs,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to render prometheus.yml: %w", err)
+	}
+	return data, nil
+}
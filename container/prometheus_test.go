@@ -0,0 +1,69 @@
+package container
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Prometheus Container", func() {
+	It("should render scrape jobs into prometheus.yml", func() {
+		container, err := NewPrometheus("test-prometheus", false, ScrapeConfig{
+			JobName:        "app",
+			Targets:        []string{DockerHost + ":8080"},
+			MetricsPath:    "/custom/metrics",
+			ScrapeInterval: 2 * time.Second,
+		})
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(container.Cleanup)
+
+		Expect(container.AddScrapeTarget(context.Background(), ScrapeConfig{JobName: "other", Targets: []string{"localhost:9100"}})).To(Succeed())
+
+		data, err := os.ReadFile(filepath.Join(container.configDir, "prometheus.yml"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(data)).To(ContainSubstring("job_name: app"))
+		Expect(string(data)).To(ContainSubstring("metrics_path: /custom/metrics"))
+		Expect(string(data)).To(ContainSubstring("scrape_interval: 2s"))
+		Expect(string(data)).To(ContainSubstring("job_name: other"))
+		Expect(string(data)).To(ContainSubstring(DockerHost + ":8080"))
+	})
+
+	It("should parse vector results into samples", func() {
+		result := json.RawMessage(`[{"metric":{"job":"app"},"value":[1700000000.5,"42"]}]`)
+
+		samples, err := parseSamples("vector", result)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(samples).To(HaveLen(1))
+		Expect(samples[0].Metric).To(HaveKeyWithValue("job", "app"))
+		Expect(samples[0].Value).To(Equal(42.0))
+		Expect(samples[0].Timestamp.UnixMilli()).To(Equal(int64(1700000000500)))
+	})
+})
+
+var _ = Describe("Mount directories", func() {
+	It("should be named after the container so reused containers see later writes", func() {
+		first, err := NewPrometheus("test-prometheus-mount", true)
+		Expect(err).ToNot(HaveOccurred())
+		second, err := NewPrometheus("test-prometheus-mount", true)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(second.configDir).To(Equal(first.configDir))
+
+		Expect(second.Cleanup(context.Background())).To(Succeed())
+		Expect(second.configDir).To(BeADirectory())
+		Expect(os.RemoveAll(second.configDir)).To(Succeed())
+	})
+
+	It("should be removed by Cleanup unless the container is reused", func() {
+		container, err := NewPrometheus("test-prometheus-cleanup", false)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(container.configDir).To(BeADirectory())
+
+		Expect(container.Cleanup(context.Background())).To(Succeed())
+		Expect(container.configDir).ToNot(BeAnExistingFile())
+	})
+})
@@ -0,0 +1,304 @@
+package container
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/flanksource/commons-test/command"
+	"github.com/flanksource/commons-test/names"
+	"github.com/flanksource/commons-test/wait"
+)
+
+// RegistryContainer provides specialized Docker registry container management
+type RegistryContainer struct {
+	*Container
+	username string
+	password string
+	tls      bool
+	host     string
+	// dockerConfig is the DOCKER_CONFIG directory holding the login of Start
+	dockerConfig string
+}
+
+// NewRegistry creates a new Docker registry (distribution) container with deletes enabled
+func NewRegistry(name string, reuse bool) (*RegistryContainer, error) {
+	config := Config{
+		Image: "registry:2.8.3",
+		Name:  name,
+		Ports: map[string]string{"5000": "0"},
+		Env:   []string{"REGISTRY_STORAGE_DELETE_ENABLED=true"},
+		Reuse: reuse,
+	}
+
+	container, err := New(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create registry container: %w", err)
+	}
+
+	return &RegistryContainer{
+		Container: container,
+	}, nil
+}
+
+// WithAuth enables htpasswd authentication, must be called before Start
+func (r *RegistryContainer) WithAuth(username, password string) (*RegistryContainer, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash registry password: %w", err)
+	}
+
+	authDir, err := r.mountDir("auth")
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(filepath.Join(authDir, "htpasswd"), []byte(fmt.Sprintf("%s:%s\n", username, hash)), 0644); err != nil {
+		return nil, fmt.Errorf("failed to write htpasswd: %w", err)
+	}
+
+	r.username = username
+	r.password = password
+	r.config.Env = append(r.config.Env,
+		"REGISTRY_AUTH=htpasswd",
+		"REGISTRY_AUTH_HTPASSWD_REALM=Registry Realm",
+		"REGISTRY_AUTH_HTPASSWD_PATH=/auth/htpasswd",
+	)
+	r.config.Mounts = append(r.config.Mounts, Mount{Source: authDir, Target: "/auth", Type: "bind", ReadOnly: true})
+	return r, nil
+}
+
+// WithTLS serves the registry over HTTPS using the given PEM certificate and key,
+// must be called before Start
+func (r *RegistryContainer) WithTLS(certFile, keyFile string) *RegistryContainer {
+	r.tls = true
+	r.config.Env = append(r.config.Env,
+		"REGISTRY_HTTP_TLS_CERTIFICATE=/certs/tls.crt",
+		"REGISTRY_HTTP_TLS_KEY=/certs/tls.key",
+	)
+	r.config.Mounts = append(r.config.Mounts,
+		Mount{Source: certFile, Target: "/certs/tls.crt", Type: "bind", ReadOnly: true},
+		Mount{Source: keyFile, Target: "/certs/tls.key", Type: "bind", ReadOnly: true},
+	)
+	return r
+}
+
+// Start starts the registry container and waits for the /v2/ API to respond
+func (r *RegistryContainer) Start(ctx context.Context) error {
+	if err := r.Container.Start(ctx); err != nil {
+		return fmt.Errorf("failed to start registry container: %w", err)
+	}
+
+	port, err := r.GetPort("5000")
+	if err != nil {
+		return fmt.Errorf("failed to get registry port: %w", err)
+	}
+	r.host = fmt.Sprintf("localhost:%s", port)
+
+	if err := r.waitForReady(ctx); err != nil {
+		return err
+	}
+
+	if r.username != "" {
+		return r.login(ctx)
+	}
+	return nil
+}
+
+// login logs docker in to the registry for PushImage, using a DOCKER_CONFIG of its own so the password
+// is neither passed as an argument nor stored in the user's ~/.docker/config.json
+func (r *RegistryContainer) login(ctx context.Context) error {
+	if r.dockerConfig == "" {
+		dir, err := os.MkdirTemp("", names.TempPrefix("registry-docker-config")+"*")
+		if err != nil {
+			return fmt.Errorf("failed to create docker config directory: %w", err)
+		}
+		r.dockerConfig = dir
+	}
+	result := r.docker(ctx, strings.NewReader(r.password), "login", r.host, "-u", r.username, "--password-stdin")
+	if result.Err != nil {
+		return fmt.Errorf("failed to login to registry: %s", result.String())
+	}
+	return nil
+}
+
+// Cleanup removes the container and the docker config holding its login
+func (r *RegistryContainer) Cleanup(ctx context.Context) error {
+	err := r.Container.Cleanup(ctx)
+	if r.dockerConfig != "" {
+		_ = os.RemoveAll(r.dockerConfig)
+		r.dockerConfig = ""
+	}
+	return err
+}
+
+// docker runs a docker command with the registry's docker config, when logged in
+func (r *RegistryContainer) docker(ctx context.Context, stdin io.Reader, args ...string) command.Result {
+	runner := command.NewCommandRunner(false)
+	if r.dockerConfig != "" {
+		runner = runner.WithEnv("DOCKER_CONFIG", r.dockerConfig)
+	}
+	if stdin != nil {
+		runner = runner.WithStdin(stdin)
+	}
+	return runner.RunCommandQuietCtx(ctx, "docker", args...)
+}
+
+// GetURL returns the registry host:port, used as the image reference prefix
+func (r *RegistryContainer) GetURL() string {
+	return r.host
+}
+
+// GetClusterEndpoint returns the registry URL on the docker networks it is connected to, e.g. the
+// kind network of kind.WithLocalRegistry
+func (r *RegistryContainer) GetClusterEndpoint() string {
+	return fmt.Sprintf("%s://%s:5000", r.scheme(), r.config.Name)
+}
+
+// GetCredentials returns the htpasswd username and password, empty without auth
+func (r *RegistryContainer) GetCredentials() (string, string) {
+	return r.username, r.password
+}
+
+// PushImage tags a local image into the registry and pushes it, returning the remote reference
+func (r *RegistryContainer) PushImage(ctx context.Context, localTag string) (string, error) {
+	if r.host == "" {
+		return "", fmt.Errorf("container not started")
+	}
+
+	// Drop any existing registry prefix, keeping the repository path and tag
+	ref := localTag
+	if parts := strings.SplitN(localTag, "/", 2); len(parts) == 2 && strings.ContainsAny(parts[0], ".:") {
+		ref = parts[1]
+	}
+	remote := r.host + "/" + ref
+
+	if result := r.docker(ctx, nil, "tag", localTag, remote); result.Err != nil {
+		return "", fmt.Errorf("failed to tag %s as %s: %s", localTag, remote, result.String())
+	}
+	if result := r.docker(ctx, nil, "push", remote); result.Err != nil {
+		return "", fmt.Errorf("failed to push %s: %s", remote, result.String())
+	}
+
+	r.Infof("Pushed %s to %s", localTag, remote)
+	return remote, nil
+}
+
+// ListRepositories returns all repositories in the registry catalog
+func (r *RegistryContainer) ListRepositories(ctx context.Context) ([]string, error) {
+	resp, err := r.apiRequest(ctx, http.MethodGet, "/v2/_catalog")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to list repositories: status %d", resp.StatusCode)
+	}
+
+	var catalog struct {
+		Repositories []string `json:"repositories"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&catalog); err != nil {
+		return nil, err
+	}
+	return catalog.Repositories, nil
+}
+
+// DeleteTag deletes the manifest referenced by repository:tag
+func (r *RegistryContainer) DeleteTag(ctx context.Context, repository, tag string) error {
+	req, err := r.newAPIRequest(ctx, http.MethodHead, fmt.Sprintf("/v2/%s/manifests/%s", repository, tag))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/vnd.docker.distribution.manifest.v2+json, application/vnd.oci.image.manifest.v1+json")
+
+	resp, err := r.httpClient().Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	digest := resp.Header.Get("Docker-Content-Digest")
+	if resp.StatusCode != http.StatusOK || digest == "" {
+		return fmt.Errorf("failed to resolve %s:%s: status %d", repository, tag, resp.StatusCode)
+	}
+
+	del, err := r.apiRequest(ctx, http.MethodDelete, fmt.Sprintf("/v2/%s/manifests/%s", repository, digest))
+	if err != nil {
+		return err
+	}
+	defer del.Body.Close()
+
+	if del.StatusCode != http.StatusAccepted {
+		return fmt.Errorf("failed to delete %s:%s: status %d", repository, tag, del.StatusCode)
+	}
+	return nil
+}
+
+// GarbageCollect removes unreferenced blobs and untagged manifests from storage
+func (r *RegistryContainer) GarbageCollect(ctx context.Context) (string, error) {
+	return r.Exec(ctx, []string{"registry", "garbage-collect", "--delete-untagged", "/etc/docker/registry/config.yml"})
+}
+
+// waitForReady waits for the /v2/ endpoint to respond
+func (r *RegistryContainer) waitForReady(ctx context.Context) error {
+	return r.Container.waitUntil(ctx, "registry", time.Second, 30*time.Second, wait.NoError(func(ctx context.Context) error {
+		resp, err := r.apiRequest(ctx, http.MethodGet, "/v2/")
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("/v2/ returned %d", resp.StatusCode)
+		}
+		return nil
+	}))
+}
+
+func (r *RegistryContainer) apiRequest(ctx context.Context, method, path string) (*http.Response, error) {
+	req, err := r.newAPIRequest(ctx, method, path)
+	if err != nil {
+		return nil, err
+	}
+	return r.httpClient().Do(req)
+}
+
+func (r *RegistryContainer) newAPIRequest(ctx context.Context, method, path string) (*http.Request, error) {
+	if r.host == "" {
+		return nil, fmt.Errorf("container not started")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, fmt.Sprintf("%s://%s%s", r.scheme(), r.host, path), nil)
+	if err != nil {
+		return nil, err
+	}
+	if r.username != "" {
+		req.SetBasicAuth(r.username, r.password)
+	}
+	return req, nil
+}
+
+func (r *RegistryContainer) scheme() string {
+	if r.tls {
+		return "https"
+	}
+	return "http"
+}
+
+func (r *RegistryContainer) httpClient() *http.Client {
+	client := &http.Client{Timeout: 10 * time.Second}
+	if r.tls {
+		// Test registries use self-signed certificates
+		client.Transport = &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}} // #nosec G402
+	}
+	return client
+}
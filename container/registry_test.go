@@ -0,0 +1,70 @@
+package container
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var _ = Describe("Registry Container", func() {
+	It("should write a bcrypt htpasswd file when auth is enabled", func() {
+		container, err := NewRegistry("test-registry-auth", false)
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(container.Cleanup)
+
+		_, err = container.WithAuth("user", "secret")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(container.config.Env).To(ContainElement("REGISTRY_AUTH=htpasswd"))
+		Expect(container.config.Mounts).To(HaveLen(1))
+
+		data, err := os.ReadFile(filepath.Join(container.config.Mounts[0].Source, "htpasswd"))
+		Expect(err).ToNot(HaveOccurred())
+		user, hash, found := strings.Cut(strings.TrimSpace(string(data)), ":")
+		Expect(found).To(BeTrue())
+		Expect(user).To(Equal("user"))
+		Expect(bcrypt.CompareHashAndPassword([]byte(hash), []byte("secret"))).To(Succeed())
+	})
+
+	It("should return the endpoint on the docker network", func() {
+		container, err := NewRegistry("test-registry-endpoint", false)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(container.GetClusterEndpoint()).To(Equal("http://test-registry-endpoint:5000"))
+		Expect(container.WithTLS("tls.crt", "tls.key").GetClusterEndpoint()).To(Equal("https://test-registry-endpoint:5000"))
+	})
+
+	It("should login with the password on stdin and a docker config of its own", func() {
+		// A fake docker records its arguments, stdin and DOCKER_CONFIG
+		bin := GinkgoT().TempDir()
+		record := filepath.Join(bin, "record")
+		script := "#!/bin/sh\necho \"$@\" >> " + record + "\necho \"config=$DOCKER_CONFIG\" >> " + record + "\ncat >> " + record + "\n"
+		Expect(os.WriteFile(filepath.Join(bin, "docker"), []byte(script), 0755)).To(Succeed())
+		GinkgoT().Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+		container, err := NewRegistry("test-registry-login", false)
+		Expect(err).ToNot(HaveOccurred())
+		_, err = container.WithAuth("user", "s3cr3t")
+		Expect(err).ToNot(HaveOccurred())
+		container.host = "localhost:5000"
+
+		Expect(container.login(context.Background())).To(Succeed())
+		dockerConfig := container.dockerConfig
+		Expect(dockerConfig).To(BeADirectory())
+
+		data, err := os.ReadFile(record)
+		Expect(err).ToNot(HaveOccurred())
+		lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+		Expect(lines).To(Equal([]string{
+			"login localhost:5000 -u user --password-stdin",
+			"config=" + dockerConfig,
+			"s3cr3t",
+		}))
+
+		Expect(container.Cleanup(context.Background())).To(Succeed())
+		Expect(dockerConfig).ToNot(BeAnExistingFile())
+	})
+})
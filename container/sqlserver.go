@@ -1,25 +1,45 @@
 package container
 
 import (
+	"bufio"
 	"context"
 	"database/sql"
 	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	_ "github.com/microsoft/go-mssqldb"
+
+	"github.com/flanksource/commons-test/wait"
+)
+
+const (
+	// AzureSQLEdgeImage is the default, lightweight SQL Server compatible image
+	AzureSQLEdgeImage = "mcr.microsoft.com/azure-sql-edge:latest"
+	// SQLServerImage is the full SQL Server image, with SQL Agent and the complete T-SQL surface
+	SQLServerImage = "mcr.microsoft.com/mssql/server:2022-latest"
 )
 
 // SQLServerContainer provides specialized SQL Server container management
 type SQLServerContainer struct {
 	*Container
 	password         string
+	port             string
 	connectionString string
+
+	mu sync.Mutex
+	db *sql.DB
 }
 
 // NewSQLServer creates a new SQL Server container
 func NewSQLServer(name, password string, reuse bool) (*SQLServerContainer, error) {
 	config := Config{
-		Image: "mcr.microsoft.com/azure-sql-edge:latest",
+		Image: AzureSQLEdgeImage,
 		Name:  name,
 		Ports: map[string]string{"1433": "0"}, // Let Docker assign random port
 		Env: []string{
@@ -41,6 +61,29 @@ func NewSQLServer(name, password string, reuse bool) (*SQLServerContainer, error
 	}, nil
 }
 
+// WithFullServer switches to the full SQL Server image with the given edition
+// (e.g. Developer, Express, Standard) and server collation, must be called before Start.
+// Empty values keep the Developer edition and the default collation.
+func (s *SQLServerContainer) WithFullServer(edition, collation string) *SQLServerContainer {
+	if edition == "" {
+		edition = "Developer"
+	}
+
+	s.config.Image = SQLServerImage
+	env := []string{"MSSQL_AGENT_ENABLED=true"}
+	for _, e := range s.config.Env {
+		if !strings.HasPrefix(e, "MSSQL_PID=") && !strings.HasPrefix(e, "MSSQL_COLLATION=") && !strings.HasPrefix(e, "MSSQL_AGENT_ENABLED=") {
+			env = append(env, e)
+		}
+	}
+	env = append(env, fmt.Sprintf("MSSQL_PID=%s", edition))
+	if collation != "" {
+		env = append(env, fmt.Sprintf("MSSQL_COLLATION=%s", collation))
+	}
+	s.config.Env = env
+	return s
+}
+
 // Start starts the SQL Server container and waits for it to be ready
 func (s *SQLServerContainer) Start(ctx context.Context) error {
 	if err := s.Container.Start(ctx); err != nil {
@@ -54,39 +97,293 @@ func (s *SQLServerContainer) Start(ctx context.Context) error {
 	}
 
 	// Build connection string
-	s.connectionString = fmt.Sprintf("server=localhost;port=%s;database=master;user id=sa;password=%s;encrypt=disable", hostPort, s.password)
+	s.port = hostPort
+	s.connectionString = s.GetDatabaseConnectionString("master")
 
 	// Wait for SQL Server to be ready
 	return s.waitForReady(ctx)
 }
 
+// DriverName returns the database/sql driver of the connection strings
+func (s *SQLServerContainer) DriverName() string {
+	return "sqlserver"
+}
+
 // GetConnectionString returns the JDBC connection string
 func (s *SQLServerContainer) GetConnectionString() string {
 	return s.connectionString
 }
 
-// waitForReady waits for SQL Server to be ready to accept connections
-func (s *SQLServerContainer) waitForReady(ctx context.Context) error {
-	maxRetries := 30
-	retryDelay := 2 * time.Second
-
-	for i := 0; i < maxRetries; i++ {
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		default:
+// GetDatabaseConnectionString returns a connection string for a specific database
+func (s *SQLServerContainer) GetDatabaseConnectionString(database string) string {
+	return fmt.Sprintf("server=localhost;port=%s;database=%s;user id=sa;password=%s;encrypt=disable", s.port, database, s.password)
+}
+
+// GetDB returns a pooled connection to the master database, shared across calls
+func (s *SQLServerContainer) GetDB() (*sql.DB, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.db != nil {
+		return s.db, nil
+	}
+	if s.connectionString == "" {
+		return nil, fmt.Errorf("container not started")
+	}
+
+	db, err := sql.Open("sqlserver", s.connectionString)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	db.SetMaxOpenConns(10)
+	db.SetConnMaxIdleTime(time.Minute)
+	s.db = db
+	return s.db, nil
+}
+
+// CreateDatabase creates a database if it does not already exist
+func (s *SQLServerContainer) CreateDatabase(ctx context.Context, name string) error {
+	db, err := s.GetDB()
+	if err != nil {
+		return err
+	}
+
+	query := fmt.Sprintf("IF DB_ID(N'%s') IS NULL CREATE DATABASE %s", escapeSQLString(name), quoteSQLIdentifier(name))
+	if _, err := db.ExecContext(ctx, query); err != nil {
+		return fmt.Errorf("failed to create database %s: %w", name, err)
+	}
+	return nil
+}
+
+// DropDatabase drops a database if it exists, closing any open connections to it
+func (s *SQLServerContainer) DropDatabase(ctx context.Context, name string) error {
+	db, err := s.GetDB()
+	if err != nil {
+		return err
+	}
+
+	query := fmt.Sprintf("IF DB_ID(N'%[1]s') IS NOT NULL BEGIN ALTER DATABASE %[2]s SET SINGLE_USER WITH ROLLBACK IMMEDIATE; DROP DATABASE %[2]s; END",
+		escapeSQLString(name), quoteSQLIdentifier(name))
+	if _, err := db.ExecContext(ctx, query); err != nil {
+		return fmt.Errorf("failed to drop database %s: %w", name, err)
+	}
+	return nil
+}
+
+// RunScript executes a T-SQL script against the master database. pathOrSQL is either
+// a path to a .sql file or the script itself, batches are separated by GO lines. A path that
+// cannot be read is returned as an error.
+func (s *SQLServerContainer) RunScript(ctx context.Context, pathOrSQL string) error {
+	db, err := s.GetDB()
+	if err != nil {
+		return err
+	}
+
+	script, err := readScript(pathOrSQL)
+	if err != nil {
+		return err
+	}
+
+	// Batches run on a single connection so that USE and session settings carry over
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get connection: %w", err)
+	}
+	defer conn.Close()
+
+	for i, batch := range SplitSQLBatches(script) {
+		if _, err := conn.ExecContext(ctx, batch); err != nil {
+			return fmt.Errorf("batch %d failed: %w", i+1, err)
 		}
+	}
+	return nil
+}
+
+// RestoreBackup restores a .bak file from the host as database, replacing it if it exists.
+// Data and log files are relocated into the server's default data directory.
+func (s *SQLServerContainer) RestoreBackup(ctx context.Context, bakPath, database string) error {
+	db, err := s.GetDB()
+	if err != nil {
+		return err
+	}
 
-		if s.testConnection() {
-			return nil
+	remote := "/tmp/" + filepath.Base(bakPath)
+	if err := s.CopyTo(ctx, bakPath, remote); err != nil {
+		return err
+	}
+
+	files, err := backupLogicalFiles(ctx, db, remote)
+	if err != nil {
+		return fmt.Errorf("failed to read backup file list: %w", err)
+	}
+
+	query := fmt.Sprintf("RESTORE DATABASE %s FROM DISK = N'%s' WITH REPLACE",
+		quoteSQLIdentifier(database), escapeSQLString(remote))
+	for i, f := range files {
+		ext := "mdf"
+		if f.fileType == "L" {
+			ext = "ldf"
+		} else if i > 0 {
+			ext = "ndf"
+		}
+		target := fmt.Sprintf("/var/opt/mssql/data/%s_%d.%s", database, i, ext)
+		query += fmt.Sprintf(", MOVE N'%s' TO N'%s'", escapeSQLString(f.logicalName), escapeSQLString(target))
+	}
+
+	if _, err := db.ExecContext(ctx, query); err != nil {
+		return fmt.Errorf("failed to restore %s from %s: %w", database, bakPath, err)
+	}
+	return nil
+}
+
+// BackupTo takes a full backup of database and copies it to path on the host
+func (s *SQLServerContainer) BackupTo(ctx context.Context, database, path string) error {
+	db, err := s.GetDB()
+	if err != nil {
+		return err
+	}
+
+	remote := "/tmp/" + filepath.Base(path)
+	query := fmt.Sprintf("BACKUP DATABASE %s TO DISK = N'%s' WITH INIT, FORMAT",
+		quoteSQLIdentifier(database), escapeSQLString(remote))
+	if _, err := db.ExecContext(ctx, query); err != nil {
+		return fmt.Errorf("failed to backup %s: %w", database, err)
+	}
+
+	return s.CopyFrom(ctx, remote, path)
+}
+
+type backupFile struct {
+	logicalName string
+	fileType    string // D for data, L for log
+}
+
+// backupLogicalFiles lists the logical files contained in a backup using RESTORE FILELISTONLY
+func backupLogicalFiles(ctx context.Context, db *sql.DB, disk string) ([]backupFile, error) {
+	rows, err := db.QueryContext(ctx, fmt.Sprintf("RESTORE FILELISTONLY FROM DISK = N'%s'", escapeSQLString(disk)))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+
+	var files []backupFile
+	for rows.Next() {
+		values := make([]any, len(columns))
+		for i := range values {
+			values[i] = new(any)
+		}
+		if err := rows.Scan(values...); err != nil {
+			return nil, err
+		}
+
+		var f backupFile
+		for i, column := range columns {
+			value := *(values[i].(*any))
+			if b, ok := value.([]byte); ok {
+				value = string(b)
+			}
+			switch column {
+			case "LogicalName":
+				f.logicalName = fmt.Sprintf("%v", value)
+			case "Type":
+				f.fileType = fmt.Sprintf("%v", value)
+			}
 		}
+		files = append(files, f)
+	}
+	return files, rows.Err()
+}
+
+// Cleanup closes the pooled connection and removes the container
+func (s *SQLServerContainer) Cleanup(ctx context.Context) error {
+	s.mu.Lock()
+	if s.db != nil {
+		s.db.Close()
+		s.db = nil
+	}
+	s.mu.Unlock()
+	return s.Container.Cleanup(ctx)
+}
+
+var goBatchSeparator = regexp.MustCompile(`(?i)^\s*GO(?:\s+(\d+))?\s*(?:--.*)?$`)
+
+// SplitSQLBatches splits a T-SQL script on GO separator lines, repeating a batch for "GO <n>"
+func SplitSQLBatches(script string) []string {
+	var batches []string
+	var current strings.Builder
+
+	flush := func(count int) {
+		batch := strings.TrimSpace(current.String())
+		current.Reset()
+		if batch == "" {
+			return
+		}
+		for i := 0; i < count; i++ {
+			batches = append(batches, batch)
+		}
+	}
 
-		if i < maxRetries-1 {
-			time.Sleep(retryDelay)
+	scanner := bufio.NewScanner(strings.NewReader(script))
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if match := goBatchSeparator.FindStringSubmatch(line); match != nil {
+			count := 1
+			if match[1] != "" {
+				count, _ = strconv.Atoi(match[1])
+			}
+			flush(count)
+			continue
 		}
+		current.WriteString(line)
+		current.WriteString("\n")
 	}
+	flush(1)
+	return batches
+}
+
+// readScript returns pathOrSQL, or the content of the file it names when it is a single line. A single
+// line that looks like a path, ending in .sql or being a path without spaces, must be readable rather
+// than being run as SQL.
+func readScript(pathOrSQL string) (string, error) {
+	if strings.Contains(pathOrSQL, "\n") {
+		return pathOrSQL, nil
+	}
+	data, err := os.ReadFile(pathOrSQL)
+	if err == nil {
+		return string(data), nil
+	}
+	if strings.HasSuffix(strings.ToLower(pathOrSQL), ".sql") ||
+		strings.ContainsAny(pathOrSQL, `/\`) && !strings.ContainsAny(pathOrSQL, " \t") {
+		return "", fmt.Errorf("failed to read script: %w", err)
+	}
+	return pathOrSQL, nil
+}
+
+// quoteSQLIdentifier wraps a name in square brackets for use as a T-SQL identifier
+func quoteSQLIdentifier(name string) string {
+	return "[" + strings.ReplaceAll(name, "]", "]]") + "]"
+}
 
-	return fmt.Errorf("SQL Server failed to become ready after %d attempts", maxRetries)
+// escapeSQLString escapes a value for use inside a T-SQL string literal
+func escapeSQLString(value string) string {
+	return strings.ReplaceAll(value, "'", "''")
+}
+
+// waitForReady waits for SQL Server to be ready to accept connections
+func (s *SQLServerContainer) waitForReady(ctx context.Context) error {
+	err := wait.For(ctx, 2*time.Second, time.Minute, func(ctx context.Context) (bool, error) {
+		return s.testConnection(), nil
+	})
+	if err != nil {
+		return fmt.Errorf("SQL Server failed to become ready: %w", err)
+	}
+	return nil
 }
 
 // testConnection tests if SQL Server is ready
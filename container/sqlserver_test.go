@@ -0,0 +1,69 @@
+package container
+
+import (
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("SQL Server Container", func() {
+	Describe("SplitSQLBatches", func() {
+		It("should split on GO separators", func() {
+			script := "CREATE TABLE a (id int)\nGO\n\ninsert into a values (1)\n  go  \n"
+			Expect(SplitSQLBatches(script)).To(Equal([]string{
+				"CREATE TABLE a (id int)",
+				"insert into a values (1)",
+			}))
+		})
+
+		It("should repeat batches for GO <count>", func() {
+			Expect(SplitSQLBatches("INSERT INTO a VALUES (1)\nGO 3")).To(HaveLen(3))
+		})
+
+		It("should not split on GO inside identifiers", func() {
+			script := "SELECT * FROM GOODS\nGOTO label"
+			Expect(SplitSQLBatches(script)).To(Equal([]string{script}))
+		})
+	})
+
+	It("should read scripts from files and reject unreadable paths", func() {
+		path := filepath.Join(GinkgoT().TempDir(), "seed.sql")
+		Expect(os.WriteFile(path, []byte("SELECT 1"), 0644)).To(Succeed())
+
+		Expect(readScript(path)).To(Equal("SELECT 1"))
+		Expect(readScript("SELECT 4/2")).To(Equal("SELECT 4/2"))
+		Expect(readScript("SELECT 1\nGO")).To(Equal("SELECT 1\nGO"))
+
+		_, err := readScript(filepath.Join(filepath.Dir(path), "sed.sql"))
+		Expect(err).To(HaveOccurred())
+		_, err = readScript("fixtures/seed")
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should quote identifiers", func() {
+		Expect(quoteSQLIdentifier("my]db")).To(Equal("[my]]db]"))
+		Expect(escapeSQLString("o'brien")).To(Equal("o''brien"))
+	})
+
+	It("should switch to the full SQL Server image", func() {
+		container, err := NewSQLServer("test-sqlserver-full", "Passw0rd!", false)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(container.Container.config.Image).To(Equal(AzureSQLEdgeImage))
+
+		container.WithFullServer("Express", "Latin1_General_CS_AS")
+		config := container.Container.config
+		Expect(config.Image).To(Equal(SQLServerImage))
+		Expect(config.Env).To(ContainElements("MSSQL_PID=Express", "MSSQL_COLLATION=Latin1_General_CS_AS", "MSSQL_AGENT_ENABLED=true", "ACCEPT_EULA=Y"))
+		Expect(config.Env).ToNot(ContainElement("MSSQL_PID=Developer"))
+	})
+
+	It("should require the container to be started for GetDB", func() {
+		container, err := NewSQLServer("test-sqlserver", "Passw0rd!", false)
+		Expect(err).ToNot(HaveOccurred())
+
+		_, err = container.GetDB()
+		Expect(err).To(MatchError(ContainSubstring("container not started")))
+	})
+})
@@ -0,0 +1,173 @@
+package container
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/flanksource/commons-test/wait"
+)
+
+// TemporalContainer provides specialized Temporal dev-server container management
+type TemporalContainer struct {
+	*Container
+	hostPort string
+	uiURL    string
+}
+
+// TemporalClient runs temporal CLI commands inside the dev-server container,
+// scoped to a single namespace
+type TemporalClient struct {
+	container *TemporalContainer
+	namespace string
+}
+
+// NewTemporal creates a new Temporal dev-server container with an in-memory store
+func NewTemporal(name string, reuse bool) (*TemporalContainer, error) {
+	config := Config{
+		Image: "temporalio/temporal:1.1.2",
+		Name:  name,
+		Cmd:   []string{"server", "start-dev", "--ip", "0.0.0.0", "--ui-port", "8233"},
+		Ports: map[string]string{
+			"7233": "0", // Frontend gRPC
+			"8233": "0", // Web UI
+		},
+		Reuse: reuse,
+	}
+
+	container, err := New(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Temporal container: %w", err)
+	}
+
+	return &TemporalContainer{
+		Container: container,
+	}, nil
+}
+
+// Start starts the Temporal container and waits for the frontend to report SERVING
+func (t *TemporalContainer) Start(ctx context.Context) error {
+	if err := t.Container.Start(ctx); err != nil {
+		return fmt.Errorf("failed to start Temporal container: %w", err)
+	}
+
+	grpcPort, err := t.GetPort("7233")
+	if err != nil {
+		return fmt.Errorf("failed to get Temporal frontend port: %w", err)
+	}
+	uiPort, err := t.GetPort("8233")
+	if err != nil {
+		return fmt.Errorf("failed to get Temporal UI port: %w", err)
+	}
+
+	t.hostPort = fmt.Sprintf("localhost:%s", grpcPort)
+	t.uiURL = fmt.Sprintf("http://localhost:%s", uiPort)
+
+	return t.waitForReady(ctx)
+}
+
+// GetHostPort returns the host:port of the frontend gRPC service, suitable for client.Options.HostPort
+func (t *TemporalContainer) GetHostPort() string {
+	return t.hostPort
+}
+
+// GetUIURL returns the web UI URL
+func (t *TemporalContainer) GetUIURL() string {
+	return t.uiURL
+}
+
+// GetClient returns a CLI-backed client scoped to namespace
+func (t *TemporalContainer) GetClient(namespace string) *TemporalClient {
+	if namespace == "" {
+		namespace = "default"
+	}
+	return &TemporalClient{container: t, namespace: namespace}
+}
+
+// CreateNamespace registers a namespace, succeeding if it already exists
+func (t *TemporalContainer) CreateNamespace(ctx context.Context, namespace string) error {
+	if _, err := t.GetClient(namespace).Run(ctx, "operator", "namespace", "describe"); err == nil {
+		return nil
+	}
+	if _, err := t.GetClient(namespace).Run(ctx, "operator", "namespace", "create", "--retention", "24h"); err != nil {
+		return fmt.Errorf("failed to create namespace %s: %w", namespace, err)
+	}
+	return nil
+}
+
+// HealthCheck checks the frontend service health through the CLI
+func (t *TemporalContainer) HealthCheck(ctx context.Context) error {
+	out, err := t.Exec(ctx, []string{"temporal", "operator", "cluster", "health", "--address", "localhost:7233"})
+	if err != nil {
+		return fmt.Errorf("health check failed: %w", err)
+	}
+	// NOT_SERVING also contains SERVING, so match the whole status
+	if !slices.Contains(strings.Fields(out), "SERVING") {
+		return fmt.Errorf("health check failed - frontend not serving: %s", strings.TrimSpace(out))
+	}
+	return nil
+}
+
+// waitForReady waits for the frontend to report SERVING
+func (t *TemporalContainer) waitForReady(ctx context.Context) error {
+	return t.Container.waitUntil(ctx, "Temporal", 2*time.Second, time.Minute, wait.NoError(t.HealthCheck))
+}
+
+// Namespace returns the namespace this client is scoped to
+func (c *TemporalClient) Namespace() string {
+	return c.namespace
+}
+
+// Run executes a temporal CLI command against the namespace and returns its output
+func (c *TemporalClient) Run(ctx context.Context, args ...string) (string, error) {
+	cmd := append([]string{"temporal"}, args...)
+	cmd = append(cmd, "--address", "localhost:7233", "--namespace", c.namespace)
+	return c.container.Exec(ctx, cmd)
+}
+
+// StartWorkflow starts a workflow execution and returns its run ID
+func (c *TemporalClient) StartWorkflow(ctx context.Context, workflowType, taskQueue, workflowID string, input any) (string, error) {
+	args := []string{"workflow", "start",
+		"--type", workflowType,
+		"--task-queue", taskQueue,
+		"--workflow-id", workflowID,
+		"--output", "json",
+	}
+	if input != nil {
+		data, err := json.Marshal(input)
+		if err != nil {
+			return "", fmt.Errorf("failed to marshal workflow input: %w", err)
+		}
+		args = append(args, "--input", string(data))
+	}
+
+	out, err := c.Run(ctx, args...)
+	if err != nil {
+		return "", fmt.Errorf("failed to start workflow %s: %w", workflowType, err)
+	}
+
+	var result struct {
+		RunID string `json:"runId"`
+	}
+	if err := json.Unmarshal([]byte(out), &result); err != nil {
+		return "", fmt.Errorf("failed to parse workflow start output: %w", err)
+	}
+	return result.RunID, nil
+}
+
+// DescribeWorkflow returns the raw JSON description of a workflow execution
+func (c *TemporalClient) DescribeWorkflow(ctx context.Context, workflowID string) (map[string]any, error) {
+	out, err := c.Run(ctx, "workflow", "describe", "--workflow-id", workflowID, "--output", "json")
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe workflow %s: %w", workflowID, err)
+	}
+
+	var result map[string]any
+	if err := json.Unmarshal([]byte(out), &result); err != nil {
+		return nil, fmt.Errorf("failed to parse workflow description: %w", err)
+	}
+	return result, nil
+}
@@ -0,0 +1,91 @@
+package container
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// fakeTemporalDocker logs the arguments of every docker call to $DOCKER_LOG and answers like the temporal CLI
+const fakeTemporalDocker = `#!/bin/bash
+echo "$*" >> "$DOCKER_LOG"
+case "$*" in
+  *"cluster health"*) echo "${HEALTH:-SERVING}" ;;
+  *"namespace describe"*) [ -n "$NAMESPACE_EXISTS" ] || { echo "namespace not found" >&2; exit 1; } ;;
+  *"workflow start"*) echo '{"workflowId":"wf-1","runId":"run-1"}' ;;
+  *"workflow describe"*) echo '{"workflowExecutionInfo":{"status":"WORKFLOW_EXECUTION_STATUS_RUNNING"}}' ;;
+esac
+`
+
+var _ = Describe("Temporal Container", func() {
+	var (
+		container *TemporalContainer
+		log       string
+	)
+
+	// calls returns the temporal CLI invocations made through docker exec
+	calls := func() []string {
+		data, err := os.ReadFile(log)
+		if os.IsNotExist(err) {
+			return nil
+		}
+		Expect(err).ToNot(HaveOccurred())
+		return strings.Split(strings.TrimSpace(string(data)), "\n")
+	}
+
+	BeforeEach(func() {
+		bin := GinkgoT().TempDir()
+		Expect(os.WriteFile(filepath.Join(bin, "docker"), []byte(fakeTemporalDocker), 0o755)).To(Succeed())
+		log = filepath.Join(bin, "docker.log")
+		GinkgoT().Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+		GinkgoT().Setenv("DOCKER_LOG", log)
+
+		var err error
+		container, err = NewTemporal("test-temporal", false)
+		Expect(err).ToNot(HaveOccurred())
+		container.containerID = "temporal-id"
+	})
+
+	It("should scope clients to a namespace", func() {
+		Expect(container.GetClient("").Namespace()).To(Equal("default"))
+
+		_, err := container.GetClient("orders").Run(context.Background(), "workflow", "list")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(calls()).To(Equal([]string{"exec temporal-id temporal workflow list --address localhost:7233 --namespace orders"}))
+	})
+
+	It("should start workflows with JSON input", func() {
+		runID, err := container.GetClient("orders").StartWorkflow(context.Background(), "Checkout", "queue", "wf-1", map[string]string{"id": "42"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(runID).To(Equal("run-1"))
+		Expect(calls()[0]).To(ContainSubstring(`workflow start --type Checkout --task-queue queue --workflow-id wf-1 --output json --input {"id":"42"}`))
+	})
+
+	It("should describe workflows", func() {
+		description, err := container.GetClient("orders").DescribeWorkflow(context.Background(), "wf-1")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(description).To(HaveKeyWithValue("workflowExecutionInfo", HaveKeyWithValue("status", "WORKFLOW_EXECUTION_STATUS_RUNNING")))
+	})
+
+	It("should only create missing namespaces", func() {
+		Expect(container.CreateNamespace(context.Background(), "orders")).To(Succeed())
+		Expect(calls()).To(HaveLen(2))
+		Expect(calls()[1]).To(ContainSubstring("operator namespace create --retention 24h --address localhost:7233 --namespace orders"))
+
+		Expect(os.Remove(log)).To(Succeed())
+		GinkgoT().Setenv("NAMESPACE_EXISTS", "true")
+		Expect(container.CreateNamespace(context.Background(), "orders")).To(Succeed())
+		Expect(calls()).To(HaveLen(1))
+	})
+
+	It("should report a frontend that is not serving", func() {
+		Expect(container.HealthCheck(context.Background())).To(Succeed())
+
+		GinkgoT().Setenv("HEALTH", "NOT_SERVING")
+		Expect(container.HealthCheck(context.Background())).To(MatchError(ContainSubstring("frontend not serving: NOT_SERVING")))
+	})
+})
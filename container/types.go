@@ -18,6 +18,12 @@ type Manager interface {
 	Cleanup(ctx context.Context) error
 }
 
+// NamedManager is a Manager that also reports its container name
+type NamedManager interface {
+	Manager
+	GetName() string
+}
+
 // Mount represents a volume mount
 type Mount struct {
 	Source   string // Host path or volume name
@@ -37,21 +43,29 @@ type HealthCheck struct {
 
 // Config holds container configuration
 type Config struct {
-	Image        string
-	Name         string
-	Ports        map[string]string // container_port:host_port
-	Env          []string
-	Mounts       []Mount
-	HealthCheck  *HealthCheck
-	WaitStrategy WaitStrategy
-	Reuse        bool
+	Image          string
+	Name           string
+	Cmd            []string          // command and arguments passed after the image
+	Ports          map[string]string // container_port:host_port, a host port of "0" is leased from the ports package
+	Env            []string
+	Mounts         []Mount
+	ExtraHosts     []string // host:ip entries added to /etc/hosts, e.g. DockerHost + ":host-gateway"
+	Network        string   // user-defined network to join, see CreateNetwork
+	NetworkAliases []string // extra names other containers on Network reach the container by
+	HealthCheck    *HealthCheck
+	WaitStrategy   WaitStrategy
+	Reuse          bool
 }
 
-// WaitStrategy defines how to wait for container readiness
+// WaitStrategy defines how to wait for container readiness. The strategies set are waited for in turn,
+// each for up to Timeout, instead of the health check or published ports.
 type WaitStrategy struct {
-	Port     string
-	LogMatch string
-	Timeout  string
+	Port       string   // container port accepting TCP connections
+	LogMatch   string   // regular expression matched by the container logs, e.g. "Server started"
+	HTTP       string   // container port and path answering HTTPStatus, e.g. 8080/healthz
+	HTTPStatus int      // status expected from HTTP, any 2xx by default
+	Exec       []string // command exiting 0 in the container, e.g. pg_isready
+	Timeout    string   // duration each strategy may take, the configured container timeout by default
 }
 
 // ContainerInfo holds information about an existing container
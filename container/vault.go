@@ -0,0 +1,100 @@
+package container
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// VaultContainer provides a HashiCorp Vault dev server, with a KV v2 engine mounted at secret/, e.g. for
+// secrets.Vault
+type VaultContainer struct {
+	*Container
+	token   string
+	address string
+}
+
+// NewVault creates a new Vault dev server container whose root token is token
+func NewVault(name, token string, reuse bool) (*VaultContainer, error) {
+	if token == "" {
+		token = "root"
+	}
+	config := Config{
+		Image: "hashicorp/vault:1.17",
+		Name:  name,
+		Ports: map[string]string{"8200": "0"},
+		Env: []string{
+			"VAULT_DEV_ROOT_TOKEN_ID=" + token,
+			"VAULT_DEV_LISTEN_ADDRESS=0.0.0.0:8200",
+			"SKIP_SETCAP=true",
+		},
+		Reuse: reuse,
+	}
+
+	container, err := New(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Vault container: %w", err)
+	}
+
+	return &VaultContainer{
+		Container: container,
+		token:     token,
+	}, nil
+}
+
+// Start starts the Vault container and waits for it to be unsealed
+func (v *VaultContainer) Start(ctx context.Context) error {
+	if err := v.Container.Start(ctx); err != nil {
+		return fmt.Errorf("failed to start Vault container: %w", err)
+	}
+
+	port, err := v.GetPort("8200")
+	if err != nil {
+		return fmt.Errorf("failed to get Vault port: %w", err)
+	}
+	v.address = fmt.Sprintf("http://localhost:%s", port)
+
+	return v.waitForHTTP(ctx, v.address+"/v1/sys/health", 30, time.Second)
+}
+
+// GetAddress returns the Vault API address
+func (v *VaultContainer) GetAddress() string {
+	return v.address
+}
+
+// GetToken returns the root token
+func (v *VaultContainer) GetToken() string {
+	return v.token
+}
+
+// Put writes data to the KV v2 secret at path, e.g. Put(ctx, "db/sqlserver", {"password": ...}) for the
+// secrets key db.sqlserver.password
+func (v *VaultContainer) Put(ctx context.Context, path string, data map[string]string) error {
+	if v.address == "" {
+		return fmt.Errorf("container not started")
+	}
+
+	body, err := json.Marshal(map[string]any{"data": data})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, v.address+"/v1/secret/data/"+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("X-Vault-Token", v.token)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
+		return fmt.Errorf("failed to write %s: status %d", path, resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+package container
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/flanksource/clicky"
+
+	testconfig "github.com/flanksource/commons-test/config"
+	"github.com/flanksource/commons-test/probe"
+	"github.com/flanksource/commons-test/wait"
+)
+
+// IsZero returns true when no strategy is set
+func (w WaitStrategy) IsZero() bool {
+	return w.Port == "" && w.LogMatch == "" && w.HTTP == "" && len(w.Exec) == 0
+}
+
+// validate returns an error for an invalid log pattern, HTTP target or timeout
+func (w WaitStrategy) validate() error {
+	if _, err := regexp.Compile(w.LogMatch); err != nil {
+		return fmt.Errorf("invalid log match %q: %w", w.LogMatch, err)
+	}
+	if _, _, err := w.httpTarget(); err != nil {
+		return err
+	}
+	_, err := w.timeout()
+	return err
+}
+
+// httpTarget splits HTTP into the container port and path
+func (w WaitStrategy) httpTarget() (port, path string, err error) {
+	if w.HTTP == "" {
+		return "", "", nil
+	}
+	port, path, _ = strings.Cut(w.HTTP, "/")
+	if _, err := strconv.Atoi(port); err != nil {
+		return "", "", fmt.Errorf("invalid HTTP wait %q, expected <port>/<path>", w.HTTP)
+	}
+	return port, "/" + path, nil
+}
+
+func (w WaitStrategy) timeout() (time.Duration, error) {
+	if w.Timeout == "" {
+		return testconfig.Get().ContainerTimeout(), nil
+	}
+	timeout, err := time.ParseDuration(w.Timeout)
+	if err != nil {
+		return 0, fmt.Errorf("invalid wait timeout %q: %w", w.Timeout, err)
+	}
+	return timeout, nil
+}
+
+// waitForStrategy waits for the port, log line, HTTP endpoint and command of the wait strategy in turn
+func (c *Container) waitForStrategy(ctx context.Context) error {
+	strategy := c.config.WaitStrategy
+	timeout, err := strategy.timeout()
+	if err != nil {
+		return err
+	}
+
+	if strategy.Port != "" {
+		hostPort, err := c.GetPort(strategy.Port)
+		if err != nil {
+			return fmt.Errorf("get host port for %s: %w", strategy.Port, err)
+		}
+		check := probe.TCP("localhost:" + hostPort)
+		if err := c.waitForCheck(ctx, "port "+strategy.Port, timeout, check.Check); err != nil {
+			return err
+		}
+	}
+
+	if strategy.LogMatch != "" {
+		re := regexp.MustCompile(strategy.LogMatch)
+		err := c.waitForCheck(ctx, fmt.Sprintf("log %q", strategy.LogMatch), timeout, func(ctx context.Context) error {
+			process := clicky.Exec("docker", "logs", c.containerID).Run()
+			if process.Err != nil {
+				return fmt.Errorf("failed to get container logs: %w", process.Err)
+			}
+			if !re.MatchString(process.GetStdout()) && !re.MatchString(process.GetStderr()) {
+				return fmt.Errorf("no log line matched %q", strategy.LogMatch)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if strategy.HTTP != "" {
+		port, path, _ := strategy.httpTarget()
+		hostPort, err := c.GetPort(port)
+		if err != nil {
+			return fmt.Errorf("get host port for %s: %w", port, err)
+		}
+		check := probe.HTTP("http://localhost:" + hostPort + path)
+		if strategy.HTTPStatus != 0 {
+			check.ExpectStatus(strategy.HTTPStatus)
+		}
+		err = c.waitForCheck(ctx, "HTTP "+strategy.HTTP, timeout, func(ctx context.Context) error {
+			_, err := check.Check(ctx)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(strategy.Exec) > 0 {
+		err := c.waitForCheck(ctx, fmt.Sprintf("command %q", strings.Join(strategy.Exec, " ")), timeout, func(ctx context.Context) error {
+			_, err := c.Exec(ctx, strategy.Exec)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// waitForCheck polls check until it succeeds or timeout expires, failing early when the container exits
+func (c *Container) waitForCheck(ctx context.Context, what string, timeout time.Duration, check func(ctx context.Context) error) error {
+	c.Infof("Waiting up to %v for %s...", timeout, what)
+
+	checks := 0
+	err := wait.For(ctx, 500*time.Millisecond, timeout, func(ctx context.Context) (bool, error) {
+		err := check(ctx)
+		if err == nil {
+			return true, nil
+		}
+		c.Tracef("Waiting for %s: %v", what, err)
+
+		checks++
+		if checks%5 == 0 {
+			if running, _ := c.IsRunning(ctx); !running {
+				return false, wait.Stop(fmt.Errorf("container stopped while waiting for %s", what))
+			}
+		}
+		return false, err
+	})
+	if err != nil {
+		diag := c.containerDiagnostics()
+		c.PrintLogsOnFailure(ctx, fmt.Sprintf("%s not ready: %v: %s", what, err, diag))
+		return fmt.Errorf("%s not ready: %w: %s", what, err, diag)
+	}
+	c.Infof("%s is ready", what)
+	return nil
+}
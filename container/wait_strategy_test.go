@@ -0,0 +1,37 @@
+package container
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("WaitStrategy", func() {
+	It("should split the HTTP target", func() {
+		port, path, err := WaitStrategy{HTTP: "8080/api/health"}.httpTarget()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(port).To(Equal("8080"))
+		Expect(path).To(Equal("/api/health"))
+
+		_, path, err = WaitStrategy{HTTP: "8080"}.httpTarget()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(path).To(Equal("/"))
+	})
+
+	It("should reject invalid strategies", func() {
+		_, err := New(Config{Image: "nginx:alpine", WaitStrategy: WaitStrategy{LogMatch: "ready("}})
+		Expect(err).To(MatchError(ContainSubstring("invalid log match")))
+
+		_, err = New(Config{Image: "nginx:alpine", WaitStrategy: WaitStrategy{HTTP: "/healthz"}})
+		Expect(err).To(MatchError(ContainSubstring("expected <port>/<path>")))
+
+		_, err = New(Config{Image: "nginx:alpine", WaitStrategy: WaitStrategy{Port: "80", Timeout: "soon"}})
+		Expect(err).To(MatchError(ContainSubstring("invalid wait timeout")))
+	})
+
+	It("should configure specialized containers", func() {
+		minio, err := NewMinIO("test-minio", "", "", false)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(minio.config.WaitStrategy.IsZero()).To(BeFalse())
+		Expect(WaitStrategy{Timeout: "1m"}.IsZero()).To(BeTrue())
+	})
+})
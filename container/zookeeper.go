@@ -0,0 +1,184 @@
+package container
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/flanksource/commons-test/wait"
+)
+
+// ZookeeperContainer provides specialized Zookeeper container management
+type ZookeeperContainer struct {
+	*Container
+	connectString string
+}
+
+// NewZookeeper creates a new standalone Zookeeper container
+func NewZookeeper(name string, reuse bool) (*ZookeeperContainer, error) {
+	config := Config{
+		Image: "zookeeper:3.9.2",
+		Name:  name,
+		Ports: map[string]string{"2181": "0"},
+		Env: []string{
+			"ZOO_4LW_COMMANDS_WHITELIST=ruok,stat,mntr,srvr",
+			"ZOO_STANDALONE_ENABLED=true",
+		},
+		Reuse: reuse,
+	}
+
+	container, err := New(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Zookeeper container: %w", err)
+	}
+
+	return &ZookeeperContainer{
+		Container: container,
+	}, nil
+}
+
+// Start starts the Zookeeper container and waits for it to answer ruok
+func (z *ZookeeperContainer) Start(ctx context.Context) error {
+	if err := z.Container.Start(ctx); err != nil {
+		return fmt.Errorf("failed to start Zookeeper container: %w", err)
+	}
+
+	port, err := z.GetPort("2181")
+	if err != nil {
+		return fmt.Errorf("failed to get Zookeeper port: %w", err)
+	}
+	z.connectString = fmt.Sprintf("localhost:%s", port)
+
+	return z.waitForReady(ctx)
+}
+
+// GetConnectString returns the host:port connect string for Zookeeper clients
+func (z *ZookeeperContainer) GetConnectString() string {
+	return z.connectString
+}
+
+// FourLetterWord sends a four letter admin command (e.g. ruok, stat, mntr) and returns the response
+func (z *ZookeeperContainer) FourLetterWord(cmd string) (string, error) {
+	if z.connectString == "" {
+		return "", fmt.Errorf("container not started")
+	}
+
+	conn, err := net.DialTimeout("tcp", z.connectString, 2*time.Second)
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(3 * time.Second))
+
+	if _, err := conn.Write([]byte(cmd)); err != nil {
+		return "", err
+	}
+	out, err := io.ReadAll(conn)
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
+
+// HealthCheck sends ruok and expects imok
+func (z *ZookeeperContainer) HealthCheck() error {
+	out, err := z.FourLetterWord("ruok")
+	if err != nil {
+		return fmt.Errorf("health check failed - ruok failed: %w", err)
+	}
+	if strings.TrimSpace(out) != "imok" {
+		return fmt.Errorf("health check failed - unexpected ruok response: %q", out)
+	}
+	return nil
+}
+
+// Seed creates (or overwrites) znodes with the given data, creating parent nodes as needed. It fails
+// when any node cannot be written, e.g. when the server is down or an ACL denies the write.
+func (z *ZookeeperContainer) Seed(ctx context.Context, nodes map[string]string) error {
+	script, err := seedScript(nodes)
+	if err != nil {
+		return err
+	}
+	if _, err := z.Exec(ctx, []string{"bash", "-c", script}); err != nil {
+		return fmt.Errorf("failed to seed znodes: %w", err)
+	}
+	return nil
+}
+
+// seedScriptFunctions check the output of every zkCli command, as zkCli exits with 0 on most errors.
+// ensure creates a parent node unless it exists, put creates a node or sets its data when it exists.
+const seedScriptFunctions = `set -e
+ensure() {
+  out=$(` + zkCli + ` create "$1" "$2" 2>&1) || true
+  case "$out" in
+    *"Created $1"*|*"Node already exists"*) ;;
+    *) echo "failed to create $1: $out" >&2; exit 1 ;;
+  esac
+}
+put() {
+  out=$(` + zkCli + ` create "$1" "$2" 2>&1) || true
+  case "$out" in
+    *"Created $1"*) return ;;
+    *"Node already exists"*) ;;
+    *) echo "failed to create $1: $out" >&2; exit 1 ;;
+  esac
+  out=$(` + zkCli + ` set "$1" "$2" 2>&1) || { echo "failed to set $1: $out" >&2; exit 1; }
+  case "$out" in
+    *KeeperErrorCode*|*"Node does not exist"*|*"Insufficient permission"*) echo "failed to set $1: $out" >&2; exit 1 ;;
+  esac
+}
+`
+
+// seedScript returns the bash script writing nodes in path order
+func seedScript(nodes map[string]string) (string, error) {
+	paths := make([]string, 0, len(nodes))
+	for path := range nodes {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	var script strings.Builder
+	script.WriteString(seedScriptFunctions)
+	for _, path := range paths {
+		if !strings.HasPrefix(path, "/") {
+			return "", fmt.Errorf("znode path must be absolute: %s", path)
+		}
+		parts := strings.Split(strings.Trim(path, "/"), "/")
+		for i := 1; i < len(parts); i++ {
+			fmt.Fprintf(&script, "ensure %s ''\n", shellQuote("/"+strings.Join(parts[:i], "/")))
+		}
+		fmt.Fprintf(&script, "put %s %s\n", shellQuote(path), shellQuote(nodes[path]))
+	}
+	return script.String(), nil
+}
+
+// Read returns the data stored at a znode
+func (z *ZookeeperContainer) Read(ctx context.Context, path string) (string, error) {
+	out, err := z.Exec(ctx, []string{"bash", "-c", fmt.Sprintf("%s get %s 2>/dev/null | tail -n 1", zkCli, shellQuote(path))})
+	if err != nil {
+		return "", fmt.Errorf("failed to read znode %s: %w", path, err)
+	}
+	out = strings.TrimRight(out, "\n")
+	if strings.Contains(out, "Node does not exist") {
+		return "", fmt.Errorf("znode %s does not exist", path)
+	}
+	return out, nil
+}
+
+// waitForReady waits for the server to answer ruok
+func (z *ZookeeperContainer) waitForReady(ctx context.Context) error {
+	return z.Container.waitUntil(ctx, "Zookeeper", time.Second, 30*time.Second, wait.NoError(func(ctx context.Context) error {
+		return z.HealthCheck()
+	}))
+}
+
+const zkCli = "zkCli.sh -server localhost:2181"
+
+// shellQuote wraps s in single quotes for use in a bash script
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
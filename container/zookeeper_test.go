@@ -0,0 +1,85 @@
+package container
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// fakeZkCli stores znodes as files below $ZK_DATA and answers like zkCli.sh
+const fakeZkCli = `#!/bin/bash
+shift 2
+cmd=$1 path=$2 data=$3
+node="$ZK_DATA$path/.data"
+case "$cmd" in
+  create)
+    if [ -f "$node" ]; then echo "Node already exists: $path"; exit 0; fi
+    if [ "$(dirname "$path")" != / ] && [ ! -f "$ZK_DATA$(dirname "$path")/.data" ]; then echo "Node does not exist: $path"; exit 0; fi
+    mkdir -p "$(dirname "$node")" && printf '%s' "$data" > "$node" && echo "Created $path" ;;
+  set)
+    printf '%s' "$data" > "$node" ;;
+esac
+`
+
+// runSeedScript runs the seed script against a zkCli.sh stub
+func runSeedScript(zkCli string, nodes map[string]string) (string, string, error) {
+	script, err := seedScript(nodes)
+	if err != nil {
+		return "", "", err
+	}
+	bin, data := GinkgoT().TempDir(), GinkgoT().TempDir()
+	Expect(os.WriteFile(filepath.Join(bin, "zkCli.sh"), []byte(zkCli), 0o755)).To(Succeed())
+
+	cmd := exec.Command("bash", "-c", script)
+	cmd.Env = append(os.Environ(), "PATH="+bin+":"+os.Getenv("PATH"), "ZK_DATA="+data)
+	out, err := cmd.CombinedOutput()
+	return data, string(out), err
+}
+
+var _ = Describe("Zookeeper Seed", func() {
+	It("should create parents and overwrite existing nodes", func() {
+		data, out, err := runSeedScript(fakeZkCli, map[string]string{
+			"/app":         "root",
+			"/app/config":  "it's set",
+			"/app/a/b/key": "value",
+		})
+		Expect(err).ToNot(HaveOccurred(), out)
+
+		read := func(path string) string {
+			b, err := os.ReadFile(filepath.Join(data, path, ".data"))
+			Expect(err).ToNot(HaveOccurred())
+			return string(b)
+		}
+		Expect(read("/app")).To(Equal("root"))
+		Expect(read("/app/config")).To(Equal("it's set"))
+		Expect(read("/app/a")).To(BeEmpty())
+		Expect(read("/app/a/b/key")).To(Equal("value"))
+	})
+
+	It("should fail when zkCli reports an error", func() {
+		failing := "#!/bin/bash\necho 'KeeperErrorCode = ConnectionLoss for /app'\n"
+		_, out, err := runSeedScript(failing, map[string]string{"/app/config": "value"})
+		Expect(err).To(HaveOccurred())
+		Expect(out).To(ContainSubstring("failed to create /app"))
+	})
+
+	It("should fail when setting an existing node fails", func() {
+		denied := `#!/bin/bash
+case "$3" in
+  create) echo "Node already exists: $4" ;;
+  set) echo "Insufficient permission : $4" ;;
+esac
+`
+		_, out, err := runSeedScript(denied, map[string]string{"/app": "value"})
+		Expect(err).To(HaveOccurred())
+		Expect(out).To(ContainSubstring("failed to set /app"))
+	})
+
+	It("should reject relative paths", func() {
+		_, err := seedScript(map[string]string{"app": "value"})
+		Expect(err).To(MatchError(ContainSubstring("must be absolute")))
+	})
+})
@@ -0,0 +1,159 @@
+// Package diagnostics collects helm, kubernetes, docker and command state into a directory
+// when a test fails, so CI artifacts explain the failure without re-running it.
+package diagnostics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/flanksource/commons-test/command"
+)
+
+// CommandTimeout bounds each command run while collecting
+var CommandTimeout = time.Minute
+
+// LogTail is the number of log lines collected from each pod and container
+var LogTail = 2000
+
+var tracked struct {
+	sync.Mutex
+	namespaces []string
+	containers []string
+}
+
+// TrackNamespace adds namespaces whose helm releases, events and pod logs are collected.
+// helm.HelmChart tracks the namespaces it installs into.
+func TrackNamespace(namespaces ...string) {
+	tracked.Lock()
+	defer tracked.Unlock()
+	for _, ns := range namespaces {
+		if ns != "" && !slices.Contains(tracked.namespaces, ns) {
+			tracked.namespaces = append(tracked.namespaces, ns)
+		}
+	}
+}
+
+// Namespaces returns the tracked namespaces
+func Namespaces() []string {
+	tracked.Lock()
+	defer tracked.Unlock()
+	return slices.Clone(tracked.namespaces)
+}
+
+// TrackContainer adds a docker container (ID or name) whose logs and inspect output are collected.
+// container.Container tracks the containers it starts.
+func TrackContainer(container string) {
+	tracked.Lock()
+	defer tracked.Unlock()
+	if container != "" && !slices.Contains(tracked.containers, container) {
+		tracked.containers = append(tracked.containers, container)
+	}
+}
+
+// UntrackContainer stops collecting a removed container
+func UntrackContainer(container string) {
+	tracked.Lock()
+	defer tracked.Unlock()
+	tracked.containers = slices.DeleteFunc(tracked.containers, func(c string) bool { return c == container })
+}
+
+// Collect writes the state of every tracked namespace and container, and the transcript of every
+// command run so far, to dir:
+//
+//	dir/commands.txt, commands.json
+//	dir/namespaces/<namespace>/helm.txt, events.txt, resources.txt, pods/<pod>.log
+//	dir/containers/<container>/logs.txt, inspect.json
+//
+// Failing commands don't stop the collection, their errors are written in place of the output.
+func Collect(dir string) error {
+	tracked.Lock()
+	namespaces := slices.Clone(tracked.namespaces)
+	containers := slices.Clone(tracked.containers)
+	tracked.Unlock()
+
+	c := &collector{
+		runner: command.NewCommandRunner(false).WithTimeout(CommandTimeout),
+		dir:    dir,
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create diagnostics directory: %w", err)
+	}
+
+	// Written first so the transcript doesn't include the collection itself
+	c.write("commands.txt", []byte(command.Report(0)))
+	if data, err := command.ReportJSON(0); err == nil {
+		c.write("commands.json", data)
+	}
+
+	for _, ns := range namespaces {
+		c.namespace(ns)
+	}
+	for _, container := range containers {
+		c.container(container)
+	}
+	return errors.Join(c.errs...)
+}
+
+type collector struct {
+	runner *command.Runner
+	dir    string
+	errs   []error
+}
+
+// write saves data to a path relative to the collection directory
+func (c *collector) write(path string, data []byte) {
+	path = filepath.Join(c.dir, path)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		c.errs = append(c.errs, err)
+		return
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		c.errs = append(c.errs, fmt.Errorf("failed to write %s: %w", path, err))
+	}
+}
+
+// run runs a command and saves its output, or its error and stderr when it fails, to path
+func (c *collector) run(path string, name string, args ...string) command.Result {
+	result := c.runner.RunCommandQuietCtx(context.Background(), name, args...)
+	output := result.Stdout
+	if result.Err != nil {
+		output += fmt.Sprintf("\n%s: %v\n%s", name, result.Err, result.Stderr)
+	}
+	c.write(path, []byte(output))
+	return result
+}
+
+func (c *collector) namespace(ns string) {
+	base := filepath.Join("namespaces", ns)
+	c.run(filepath.Join(base, "helm.txt"), "helm", "list", "--all", "--namespace", ns)
+	if releases := c.runner.RunCommandQuiet("helm", "list", "--all", "--short", "--namespace", ns); releases.Err == nil {
+		for _, release := range releases.Lines() {
+			c.run(filepath.Join(base, "helm", release+".txt"), "helm", "status", release, "--namespace", ns, "--show-resources")
+		}
+	}
+	c.run(filepath.Join(base, "events.txt"), "kubectl", "get", "events", "--namespace", ns, "--sort-by=.lastTimestamp")
+	c.run(filepath.Join(base, "resources.txt"), "kubectl", "get", "all", "--namespace", ns, "-o", "wide")
+	c.run(filepath.Join(base, "pods.txt"), "kubectl", "describe", "pods", "--namespace", ns)
+
+	pods := c.runner.RunCommandQuiet("kubectl", "get", "pods", "--namespace", ns, "-o", "name")
+	if pods.Err != nil {
+		return
+	}
+	for _, pod := range pods.Lines() {
+		name := filepath.Base(pod)
+		c.run(filepath.Join(base, "pods", name+".log"), "kubectl", "logs", pod, "--namespace", ns,
+			"--all-containers", "--prefix", fmt.Sprintf("--tail=%d", LogTail))
+	}
+}
+
+func (c *collector) container(container string) {
+	base := filepath.Join("containers", container)
+	c.run(filepath.Join(base, "logs.txt"), "docker", "logs", "--timestamps", "--tail", fmt.Sprint(LogTail), container)
+	c.run(filepath.Join(base, "inspect.json"), "docker", "inspect", container)
+}
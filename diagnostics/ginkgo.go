@@ -0,0 +1,32 @@
+package diagnostics
+
+import (
+	"github.com/flanksource/commons/logger"
+	"github.com/onsi/ginkgo/v2"
+
+	"github.com/flanksource/commons-test/command"
+	"github.com/flanksource/commons-test/config"
+)
+
+// CollectOnFailure registers a ginkgo ReportAfterEach that collects diagnostics into a folder
+// under root named after each failed spec, the configured artifacts directory when root is empty. Call it
+// at the top level of a suite, e.g.
+//
+//	var _ = diagnostics.CollectOnFailure("artifacts")
+func CollectOnFailure(root string) bool {
+	if root == "" {
+		root = config.Get().ArtifactsDir
+	}
+	ginkgo.ReportAfterEach(func(report ginkgo.SpecReport) {
+		if !report.Failed() {
+			return
+		}
+		dir := command.ArtifactsDir(root, report.FullText())
+		if err := Collect(dir); err != nil {
+			logger.Warnf("failed to collect diagnostics for %q: %v", report.FullText(), err)
+			return
+		}
+		logger.Infof("Diagnostics for %q written to %s", report.FullText(), dir)
+	})
+	return true
+}
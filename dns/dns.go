@@ -0,0 +1,194 @@
+// Package dns waits for records to resolve, overrides hostnames inside containers and pods, and queries
+// CoreDNS from inside a cluster, for ingress-hostname and external-dns style tests:
+//
+//	addrs, err := dns.WaitFor(ctx, "127.0.0.1:5353", "app.example.com", time.Minute, "10.0.0.1")
+package dns
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"net"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/flanksource/clicky/exec"
+
+	"github.com/flanksource/commons-test/names"
+	"github.com/flanksource/commons-test/wait"
+)
+
+// DefaultInterval is the initial interval between lookups
+const DefaultInterval = 500 * time.Millisecond
+
+// QueryImage is the image of the throwaway pod used by Query
+var QueryImage = "busybox:1.36"
+
+// hostsMarker tags the /etc/hosts lines added by AddHosts so they can be removed
+const hostsMarker = "# commons-test"
+
+// Resolver returns a resolver querying server (host or host:port, port 53 by default), or the system
+// resolver when server is empty
+func Resolver(server string) *net.Resolver {
+	if server == "" {
+		return net.DefaultResolver
+	}
+	if _, _, err := net.SplitHostPort(server); err != nil {
+		server = net.JoinHostPort(server, "53")
+	}
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			var dialer net.Dialer
+			return dialer.DialContext(ctx, network, server)
+		},
+	}
+}
+
+// Lookup resolves name against server, returning its addresses
+func Lookup(ctx context.Context, server, name string) ([]string, error) {
+	addrs, err := Resolver(server).LookupHost(ctx, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve %s: %w", name, err)
+	}
+	return addrs, nil
+}
+
+// WaitFor retries Lookup until name resolves to every expected address (any address when none are given),
+// returning the last addresses resolved
+func WaitFor(ctx context.Context, server, name string, timeout time.Duration, expected ...string) ([]string, error) {
+	var last []string
+	err := wait.For(ctx, DefaultInterval, timeout, wait.NoError(func(ctx context.Context) error {
+		addrs, err := Lookup(ctx, server, name)
+		if err != nil {
+			return err
+		}
+		last = addrs
+		return missing(name, addrs, expected)
+	}))
+	return last, err
+}
+
+func missing(name string, addrs, expected []string) error {
+	for _, addr := range expected {
+		if !slices.Contains(addrs, addr) {
+			return fmt.Errorf("%s resolved to %s, not %s", name, strings.Join(addrs, ", "), addr)
+		}
+	}
+	return nil
+}
+
+// Target is anything commands can be executed in, e.g. a container.Container or a Pod
+type Target interface {
+	Exec(ctx context.Context, cmd []string) (string, error)
+}
+
+// PodTarget executes commands in a pod with kubectl exec
+type PodTarget struct {
+	kubectl   exec.WrapperFunc
+	namespace string
+	name      string
+}
+
+// Pod returns a target for the pod, using kubectl, e.g. kind.Kubectl()
+func Pod(kubectl exec.WrapperFunc, namespace, name string) *PodTarget {
+	return &PodTarget{kubectl: kubectl, namespace: namespace, name: name}
+}
+
+// Exec executes cmd in the pod
+func (p *PodTarget) Exec(ctx context.Context, cmd []string) (string, error) {
+	return run(ctx, p.kubectl, append([]string{"exec", "-n", p.namespace, p.name, "--"}, cmd...)...)
+}
+
+// AddHosts appends hostname to IP overrides to /etc/hosts in target, the returned function removes them.
+// The file is rewritten in place as it is usually bind-mounted.
+func AddHosts(ctx context.Context, target Target, hosts map[string]string) (func() error, error) {
+	if _, err := target.Exec(ctx, []string{"sh", "-c", addHostsScript(hosts)}); err != nil {
+		return nil, fmt.Errorf("failed to add hosts: %w", err)
+	}
+	return func() error {
+		if _, err := target.Exec(context.Background(), []string{"sh", "-c", removeHostsScript()}); err != nil {
+			return fmt.Errorf("failed to remove hosts: %w", err)
+		}
+		return nil
+	}, nil
+}
+
+func addHostsScript(hosts map[string]string) string {
+	var lines []string
+	for _, host := range slices.Sorted(maps.Keys(hosts)) {
+		lines = append(lines, fmt.Sprintf("%s\t%s %s", hosts[host], host, hostsMarker))
+	}
+	return fmt.Sprintf("printf '%%s\\n' '%s' >> /etc/hosts", strings.Join(lines, "' '"))
+}
+
+func removeHostsScript() string {
+	return fmt.Sprintf("grep -v '%s$' /etc/hosts > /tmp/hosts; cat /tmp/hosts > /etc/hosts; rm -f /tmp/hosts", hostsMarker)
+}
+
+// Query resolves name with the cluster DNS (CoreDNS in kind) by running nslookup in a throwaway pod in
+// namespace, using kubectl, e.g. kind.Kubectl()
+func Query(ctx context.Context, kubectl exec.WrapperFunc, namespace, name string) ([]string, error) {
+	pod := names.Sanitize("dns-query-" + names.RunID() + "-" + time.Now().Format("150405.000"))
+	out, err := run(ctx, kubectl, "run", pod, "-n", namespace, "--image", QueryImage, "--restart=Never",
+		"--rm", "--attach", "--quiet", "--labels", names.RunLabel+"="+names.RunID(), "--", "nslookup", name)
+	addrs := parseNslookup(out)
+	if err != nil && len(addrs) == 0 {
+		return nil, fmt.Errorf("failed to query %s: %w", name, err)
+	}
+	if len(addrs) == 0 {
+		return nil, fmt.Errorf("failed to query %s: no addresses in %q", name, out)
+	}
+	return addrs, nil
+}
+
+// WaitForCluster retries Query until name resolves to every expected address (any address when none are
+// given), returning the last addresses resolved
+func WaitForCluster(ctx context.Context, kubectl exec.WrapperFunc, namespace, name string, timeout time.Duration, expected ...string) ([]string, error) {
+	var last []string
+	err := wait.For(ctx, DefaultInterval, timeout, wait.NoError(func(ctx context.Context) error {
+		addrs, err := Query(ctx, kubectl, namespace, name)
+		if err != nil {
+			return err
+		}
+		last = addrs
+		return missing(name, addrs, expected)
+	}))
+	return last, err
+}
+
+// parseNslookup returns the answer addresses of busybox nslookup output, skipping the server address
+func parseNslookup(out string) []string {
+	var addrs []string
+	answers := false
+	for _, line := range strings.Split(out, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(line, "Name:"):
+			answers = true
+		case answers && strings.HasPrefix(line, "Address"):
+			_, addr, _ := strings.Cut(line, ":")
+			addr = strings.TrimSpace(addr)
+			if addr != "" && !slices.Contains(addrs, addr) {
+				addrs = append(addrs, addr)
+			}
+		}
+	}
+	return addrs
+}
+
+func run(ctx context.Context, kubectl exec.WrapperFunc, args ...string) (string, error) {
+	argv := []any{exec.WithContext(ctx)}
+	for _, arg := range args {
+		argv = append(argv, arg)
+	}
+	result, err := kubectl(argv...)
+	if err != nil {
+		if result != nil && result.Stderr != "" {
+			return result.Stdout, fmt.Errorf("%w: %s", err, strings.TrimSpace(result.Stderr))
+		}
+		return "", err
+	}
+	return result.Stdout, nil
+}
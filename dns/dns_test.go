@@ -0,0 +1,41 @@
+package dns
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestParseNslookup(t *testing.T) {
+	out := `Server:		10.96.0.10
+Address:	10.96.0.10:53
+
+Name:	app.default.svc.cluster.local
+Address: 10.244.0.7
+Address: 10.244.0.7
+Address: fd00::7
+`
+	addrs := parseNslookup(out)
+	if !slices.Equal(addrs, []string{"10.244.0.7", "fd00::7"}) {
+		t.Errorf("unexpected addresses %v", addrs)
+	}
+}
+
+func TestAddHostsScript(t *testing.T) {
+	script := addHostsScript(map[string]string{"b.test": "10.0.0.2", "a.test": "10.0.0.1"})
+	expected := "printf '%s\\n' '10.0.0.1\ta.test # commons-test' '10.0.0.2\tb.test # commons-test' >> /etc/hosts"
+	if script != expected {
+		t.Errorf("expected %q, got %q", expected, script)
+	}
+}
+
+func TestWaitFor(t *testing.T) {
+	addrs, err := WaitFor(context.Background(), "", "localhost", time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := WaitFor(context.Background(), "", "localhost", 100*time.Millisecond, "192.0.2.1"); err == nil {
+		t.Errorf("expected localhost (%v) not to resolve to 192.0.2.1", addrs)
+	}
+}
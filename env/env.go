@@ -0,0 +1,349 @@
+// Package env brings up a complete test environment (kind cluster, helm charts, containers and
+// mission-control) from a declarative definition and tears it down again.
+package env
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"time"
+
+	flanksourceCtx "github.com/flanksource/commons-db/context"
+	"github.com/flanksource/commons/logger"
+	"sigs.k8s.io/yaml"
+
+	"github.com/flanksource/commons-test/container"
+	"github.com/flanksource/commons-test/helm"
+	"github.com/flanksource/commons-test/kind"
+	"github.com/flanksource/commons-test/mission_control"
+)
+
+// Definition describes a test environment. Charts and containers are started in the order given
+// by DependsOn, which may name any chart, container or "mission-control".
+type Definition struct {
+	Kind           *KindSpec           `json:"kind,omitempty"`
+	Charts         []ChartSpec         `json:"charts,omitempty"`
+	Containers     []ContainerSpec     `json:"containers,omitempty"`
+	MissionControl *MissionControlSpec `json:"missionControl,omitempty"`
+}
+
+// KindSpec is the kind cluster that charts and mission-control are installed into
+type KindSpec struct {
+	Name     string   `json:"name,omitempty"`
+	Version  string   `json:"version,omitempty"`
+	Services []string `json:"services,omitempty"`
+	// Keep leaves the cluster running on Down, e.g. to reuse it across local runs
+	Keep bool `json:"keep,omitempty"`
+}
+
+// ChartSpec is a helm release installed into the kind cluster
+type ChartSpec struct {
+	// Name is the release name
+	Name          string         `json:"name"`
+	Chart         string         `json:"chart"`
+	Repository    string         `json:"repository,omitempty"`
+	RepositoryURL string         `json:"repositoryURL,omitempty"`
+	Namespace     string         `json:"namespace,omitempty"`
+	Values        map[string]any `json:"values,omitempty"`
+	// Timeout to wait for the release to become ready, e.g. 5m
+	Timeout   string   `json:"timeout,omitempty"`
+	DependsOn []string `json:"dependsOn,omitempty"`
+}
+
+// ContainerSpec is a docker container started next to the cluster
+type ContainerSpec struct {
+	container.Config
+	DependsOn []string `json:"dependsOn,omitempty"`
+}
+
+// MissionControlSpec deploys mission-control into the kind cluster with mission_control.Deploy
+type MissionControlSpec struct {
+	Chart         string         `json:"chart,omitempty"`
+	Repository    string         `json:"repository,omitempty"`
+	RepositoryURL string         `json:"repositoryURL,omitempty"`
+	Namespace     string         `json:"namespace,omitempty"`
+	Values        map[string]any `json:"values,omitempty"`
+	Username      string         `json:"username,omitempty"`
+	Password      string         `json:"password,omitempty"`
+	Kratos        bool           `json:"kratos,omitempty"`
+	Timeout       string         `json:"timeout,omitempty"`
+	DependsOn     []string       `json:"dependsOn,omitempty"`
+}
+
+// MissionControlName is the name other components use to depend on mission-control
+const MissionControlName = "mission-control"
+
+// Load reads a YAML (or JSON) definition
+func Load(path string) (Definition, error) {
+	var def Definition
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return def, fmt.Errorf("failed to read environment definition: %w", err)
+	}
+	if err := yaml.UnmarshalStrict(data, &def); err != nil {
+		return def, fmt.Errorf("failed to parse environment definition %s: %w", path, err)
+	}
+	return def, nil
+}
+
+// Environment holds typed handles to a running environment
+type Environment struct {
+	Kind           *kind.Kind
+	Charts         map[string]*helm.HelmChart
+	Containers     map[string]*container.Container
+	MissionControl *mission_control.MissionControl
+
+	def Definition
+	// started is every component that came up, in start order
+	started []*component
+}
+
+// Chart returns the release named name
+func (e *Environment) Chart(name string) *helm.HelmChart {
+	return e.Charts[name]
+}
+
+// Container returns the container named name
+func (e *Environment) Container(name string) *container.Container {
+	return e.Containers[name]
+}
+
+// component is a node of the dependency graph
+type component struct {
+	name      string
+	dependsOn []string
+	// inCluster components are skipped on Down when the cluster is deleted with them
+	inCluster bool
+	up        func(ctx context.Context) error
+	down      func(ctx context.Context) error
+}
+
+// Up starts every component of def in dependency order. When a component fails the ones
+// already started are torn down and the error is returned.
+func Up(ctx context.Context, def Definition) (*Environment, error) {
+	e := &Environment{
+		Charts:     map[string]*helm.HelmChart{},
+		Containers: map[string]*container.Container{},
+		def:        def,
+	}
+	components, err := e.components()
+	if err != nil {
+		return nil, err
+	}
+	ordered, err := sortComponents(components)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, c := range ordered {
+		logger.Infof("Starting %s", c.name)
+		start := time.Now()
+		if err := c.up(ctx); err != nil {
+			err = fmt.Errorf("failed to start %s: %w", c.name, err)
+			if downErr := e.Down(ctx); downErr != nil {
+				err = errors.Join(err, downErr)
+			}
+			return nil, err
+		}
+		logger.Infof("Started %s in %v", c.name, time.Since(start).Round(time.Second))
+		e.started = append(e.started, c)
+	}
+	return e, nil
+}
+
+// Down tears down the started components in reverse dependency order, returning every failure
+func (e *Environment) Down(ctx context.Context) error {
+	deleteCluster := e.def.Kind != nil && !e.def.Kind.Keep
+	var errs []error
+	for i := len(e.started) - 1; i >= 0; i-- {
+		c := e.started[i]
+		if c.down == nil || c.inCluster && deleteCluster {
+			continue
+		}
+		logger.Infof("Stopping %s", c.name)
+		if err := c.down(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("failed to stop %s: %w", c.name, err))
+		}
+	}
+	e.started = nil
+	return errors.Join(errs...)
+}
+
+// components builds the dependency graph of the definition
+func (e *Environment) components() ([]*component, error) {
+	var components []*component
+	var clusterDeps []string
+
+	if spec := e.def.Kind; spec != nil {
+		clusterDeps = []string{"kind"}
+		components = append(components, &component{
+			name: "kind",
+			up: func(ctx context.Context) error {
+				e.Kind = kind.NewKind(spec.Name).WithServices(spec.Services...)
+				if spec.Version != "" {
+					e.Kind.WithVersion(spec.Version)
+				}
+				return e.Kind.GetOrCreate().Error()
+			},
+			down: func(ctx context.Context) error {
+				if spec.Keep {
+					return nil
+				}
+				return e.Kind.Delete().Error()
+			},
+		})
+	}
+
+	for _, spec := range e.def.Charts {
+		timeout, err := parseTimeout(spec.Timeout, 5*time.Minute)
+		if err != nil {
+			return nil, fmt.Errorf("chart %s: %w", spec.Name, err)
+		}
+		if spec.Namespace == "" {
+			spec.Namespace = "default"
+		}
+		components = append(components, &component{
+			name:      spec.Name,
+			dependsOn: slices.Concat(spec.DependsOn, clusterDeps),
+			inCluster: true,
+			up: func(ctx context.Context) error {
+				chart := helm.NewHelmChart(flanksourceCtx.New(), spec.Chart).
+					Release(spec.Name).
+					Namespace(spec.Namespace).
+					Values(spec.Values).
+					WaitFor(timeout)
+				if spec.Repository != "" {
+					chart = chart.Repository(spec.Repository, spec.RepositoryURL)
+				}
+				if err := chart.InstallOrUpgrade(); err != nil {
+					return err
+				}
+				e.Charts[spec.Name] = chart
+				return nil
+			},
+			down: func(ctx context.Context) error {
+				return e.Charts[spec.Name].Delete().Error()
+			},
+		})
+	}
+
+	for _, spec := range e.def.Containers {
+		components = append(components, &component{
+			name:      spec.Name,
+			dependsOn: spec.DependsOn,
+			up: func(ctx context.Context) error {
+				c, err := container.New(spec.Config)
+				if err != nil {
+					return err
+				}
+				if err := c.Start(ctx); err != nil {
+					// Down only stops started components, remove what Start left behind
+					if cleanupErr := c.Cleanup(ctx); cleanupErr != nil {
+						err = errors.Join(err, fmt.Errorf("failed to remove %s: %w", spec.Name, cleanupErr))
+					}
+					return err
+				}
+				e.Containers[spec.Name] = c
+				return nil
+			},
+			down: func(ctx context.Context) error {
+				return e.Containers[spec.Name].Cleanup(ctx)
+			},
+		})
+	}
+
+	if spec := e.def.MissionControl; spec != nil {
+		if e.def.Kind == nil {
+			return nil, fmt.Errorf("%s requires a kind cluster", MissionControlName)
+		}
+		timeout, err := parseTimeout(spec.Timeout, 0)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", MissionControlName, err)
+		}
+		opts := mission_control.ChartOptions{
+			Chart:         spec.Chart,
+			Repository:    spec.Repository,
+			RepositoryURL: spec.RepositoryURL,
+			Namespace:     spec.Namespace,
+			Values:        spec.Values,
+			Username:      spec.Username,
+			Password:      spec.Password,
+			Kratos:        spec.Kratos,
+			Timeout:       timeout,
+		}
+		var stop func()
+		components = append(components, &component{
+			name:      MissionControlName,
+			dependsOn: slices.Concat(spec.DependsOn, clusterDeps),
+			up: func(ctx context.Context) error {
+				var err error
+				e.MissionControl, stop, err = mission_control.Deploy(ctx, e.Kind, opts)
+				return err
+			},
+			// Only the port-forward needs stopping, the release goes with the cluster or is reused
+			down: func(ctx context.Context) error {
+				stop()
+				return nil
+			},
+		})
+	}
+	return components, nil
+}
+
+// sortComponents orders components so that each one comes after its dependencies
+func sortComponents(components []*component) ([]*component, error) {
+	byName := map[string]*component{}
+	for _, c := range components {
+		if c.name == "" {
+			return nil, fmt.Errorf("every chart and container needs a name")
+		}
+		if _, ok := byName[c.name]; ok {
+			return nil, fmt.Errorf("duplicate component %s", c.name)
+		}
+		byName[c.name] = c
+	}
+
+	var ordered []*component
+	state := map[string]int{} // 1 visiting, 2 done
+	var visit func(c *component, path []string) error
+	visit = func(c *component, path []string) error {
+		switch state[c.name] {
+		case 1:
+			return fmt.Errorf("dependency cycle: %v", append(path, c.name))
+		case 2:
+			return nil
+		}
+		state[c.name] = 1
+		for _, dep := range c.dependsOn {
+			d, ok := byName[dep]
+			if !ok {
+				return fmt.Errorf("%s depends on unknown component %s", c.name, dep)
+			}
+			if err := visit(d, append(path, c.name)); err != nil {
+				return err
+			}
+		}
+		state[c.name] = 2
+		ordered = append(ordered, c)
+		return nil
+	}
+	for _, c := range components {
+		if err := visit(c, nil); err != nil {
+			return nil, err
+		}
+	}
+	return ordered, nil
+}
+
+func parseTimeout(s string, def time.Duration) (time.Duration, error) {
+	if s == "" {
+		return def, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid timeout %q: %w", s, err)
+	}
+	return d, nil
+}
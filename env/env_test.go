@@ -0,0 +1,103 @@
+package env
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSortComponents(t *testing.T) {
+	names := func(components []*component) []string {
+		var names []string
+		for _, c := range components {
+			names = append(names, c.name)
+		}
+		return names
+	}
+
+	t.Run("dependencies first", func(t *testing.T) {
+		ordered, err := sortComponents([]*component{
+			{name: "app", dependsOn: []string{"kafka", "postgres"}},
+			{name: "kafka", dependsOn: []string{"zookeeper"}},
+			{name: "postgres"},
+			{name: "zookeeper"},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := names(ordered), []string{"zookeeper", "kafka", "postgres", "app"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("expected %v, got %v", want, got)
+		}
+	})
+
+	for name, tc := range map[string]struct {
+		components []*component
+		err        string
+	}{
+		"cycle": {
+			components: []*component{
+				{name: "a", dependsOn: []string{"b"}},
+				{name: "b", dependsOn: []string{"c"}},
+				{name: "c", dependsOn: []string{"a"}},
+			},
+			err: "dependency cycle: [a b c a]",
+		},
+		"unknown dependency": {
+			components: []*component{{name: "kafka", dependsOn: []string{"zookeeper"}}},
+			err:        "kafka depends on unknown component zookeeper",
+		},
+		"duplicate name": {
+			components: []*component{{name: "postgres"}, {name: "postgres"}},
+			err:        "duplicate component postgres",
+		},
+		"missing name": {
+			components: []*component{{name: ""}},
+			err:        "every chart and container needs a name",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if _, err := sortComponents(tc.components); err == nil || err.Error() != tc.err {
+				t.Errorf("expected %q, got %v", tc.err, err)
+			}
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	write := func(t *testing.T, content string) string {
+		path := filepath.Join(t.TempDir(), "env.yaml")
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	def, err := Load(write(t, `
+kind:
+  name: test
+charts:
+  - name: postgres
+    chart: postgresql
+    dependsOn: [kind]
+containers:
+  - Name: redis
+    Image: redis:7
+missionControl:
+  dependsOn: [postgres]
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if def.Kind.Name != "test" || len(def.Charts) != 1 || len(def.Containers) != 1 || def.Containers[0].Image != "redis:7" {
+		t.Errorf("unexpected definition %+v", def)
+	}
+	if def.MissionControl == nil || def.MissionControl.DependsOn[0] != "postgres" {
+		t.Errorf("expected mission-control to depend on postgres, got %+v", def.MissionControl)
+	}
+
+	if _, err := Load(write(t, "charts:\n  - name: postgres\n    valuesFile: values.yaml\n")); err == nil || !strings.Contains(err.Error(), "valuesFile") {
+		t.Errorf("expected unknown fields to be rejected, got %v", err)
+	}
+}
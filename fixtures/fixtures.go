@@ -0,0 +1,197 @@
+// Package fixtures loads YAML, JSON and SQL fixtures from an fs.FS or disk, renders them with gomplate and
+// applies them to clusters or copies them into containers.
+package fixtures
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/flanksource/clicky"
+	"github.com/flanksource/clicky/exec"
+	"github.com/flanksource/commons-test/names"
+	"github.com/flanksource/gomplate/v3"
+)
+
+// Fixture types, derived from the file extension with any .tmpl/.tpl suffix removed
+const (
+	YAML = "yaml"
+	JSON = "json"
+	SQL  = "sql"
+)
+
+var kubectl = clicky.Exec("kubectl").AsWrapper()
+
+// Vars are the variables available to fixture templates
+type Vars map[string]any
+
+// NewVars returns the default variables: namespace and runID
+func NewVars(namespace string) Vars {
+	return Vars{
+		"namespace": namespace,
+		"runID":     names.RunID(),
+	}
+}
+
+// With sets key to value
+func (v Vars) With(key string, value any) Vars {
+	v[key] = value
+	return v
+}
+
+// WithPorts exposes container to host port mappings as .ports
+func (v Vars) WithPorts(ports map[string]string) Vars {
+	return v.With("ports", ports)
+}
+
+// Cluster is the subset of kind.Cluster needed to apply fixtures
+type Cluster interface {
+	GetKubeconfig() (string, error)
+}
+
+// Container is the subset of container.Manager needed to copy and execute fixtures
+type Container interface {
+	CopyTo(ctx context.Context, src, dst string) error
+	Exec(ctx context.Context, cmd []string) (string, error)
+}
+
+// Fixture is a template loaded from an fs.FS or disk
+type Fixture struct {
+	Name    string
+	Content []byte
+}
+
+// Load reads name from fsys, e.g. an embed.FS
+func Load(fsys fs.FS, name string) (*Fixture, error) {
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fixture %s: %w", name, err)
+	}
+	return &Fixture{Name: name, Content: data}, nil
+}
+
+// LoadFile reads a fixture from disk
+func LoadFile(name string) (*Fixture, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fixture %s: %w", name, err)
+	}
+	return &Fixture{Name: name, Content: data}, nil
+}
+
+// Type returns YAML, JSON, SQL or the bare extension of the fixture
+func (f *Fixture) Type() string {
+	switch ext := strings.TrimPrefix(path.Ext(f.base()), "."); ext {
+	case "yml":
+		return YAML
+	default:
+		return ext
+	}
+}
+
+// Render executes the fixture as a gomplate template with vars
+func (f *Fixture) Render(vars Vars) ([]byte, error) {
+	out, err := gomplate.RunTemplate(vars, gomplate.Template{Template: string(f.Content)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to render fixture %s: %w", f.Name, err)
+	}
+	// gomplate trims the trailing newline of the template
+	if bytes.HasSuffix(f.Content, []byte("\n")) && !strings.HasSuffix(out, "\n") {
+		out += "\n"
+	}
+	return []byte(out), nil
+}
+
+// WriteFile renders the fixture to dst
+func (f *Fixture) WriteFile(dst string, vars Vars) error {
+	data, err := f.Render(vars)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(dst, data, 0644); err != nil {
+		return fmt.Errorf("failed to write fixture %s: %w", f.Name, err)
+	}
+	return nil
+}
+
+// Apply renders a YAML or JSON fixture and runs kubectl apply against cluster
+func (f *Fixture) Apply(ctx context.Context, cluster Cluster, vars Vars) error {
+	if t := f.Type(); t != YAML && t != JSON {
+		return fmt.Errorf("cannot apply %s fixture %s", t, f.Name)
+	}
+
+	kubeconfig, err := cluster.GetKubeconfig()
+	if err != nil {
+		return fmt.Errorf("failed to get kubeconfig: %w", err)
+	}
+
+	dir, err := os.MkdirTemp("", names.TempPrefix("fixture")+"*")
+	if err != nil {
+		return fmt.Errorf("failed to create temp dir: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	kubeconfigPath := filepath.Join(dir, "kubeconfig")
+	if err := os.WriteFile(kubeconfigPath, []byte(kubeconfig), 0600); err != nil {
+		return fmt.Errorf("failed to write kubeconfig: %w", err)
+	}
+
+	manifest := filepath.Join(dir, "manifest."+f.Type())
+	if err := f.WriteFile(manifest, vars); err != nil {
+		return err
+	}
+
+	result, err := kubectl(exec.WithContext(ctx), "--kubeconfig", kubeconfigPath, "apply", "-f", manifest)
+	if err != nil {
+		stderr := ""
+		if result != nil {
+			stderr = result.Stderr
+		}
+		return fmt.Errorf("failed to apply fixture %s: %w %s", f.Name, err, stderr)
+	}
+	return nil
+}
+
+// CopyTo renders the fixture and copies it to dst inside c
+func (f *Fixture) CopyTo(ctx context.Context, c Container, dst string, vars Vars) error {
+	dir, err := os.MkdirTemp("", names.TempPrefix("fixture")+"*")
+	if err != nil {
+		return fmt.Errorf("failed to create temp dir: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	src := filepath.Join(dir, path.Base(dst))
+	if err := f.WriteFile(src, vars); err != nil {
+		return err
+	}
+	return c.CopyTo(ctx, src, dst)
+}
+
+// Exec copies a SQL fixture into c and runs cmd with the path appended, e.g.
+// Exec(ctx, postgres, vars, "psql", "-U", "postgres", "-f")
+func (f *Fixture) Exec(ctx context.Context, c Container, vars Vars, cmd ...string) (string, error) {
+	if t := f.Type(); t != SQL {
+		return "", fmt.Errorf("cannot execute %s fixture %s", t, f.Name)
+	}
+
+	dst := "/tmp/" + names.RunID() + "-" + f.base()
+	if err := f.CopyTo(ctx, c, dst, vars); err != nil {
+		return "", err
+	}
+
+	out, err := c.Exec(ctx, append(cmd, dst))
+	if err != nil {
+		return out, fmt.Errorf("failed to execute fixture %s: %w", f.Name, err)
+	}
+	return out, nil
+}
+
+// base returns the file name of the fixture without any template suffix
+func (f *Fixture) base() string {
+	return strings.TrimSuffix(strings.TrimSuffix(path.Base(f.Name), ".tmpl"), ".tpl")
+}
@@ -0,0 +1,56 @@
+package fixtures
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestRender(t *testing.T) {
+	fsys := fstest.MapFS{
+		"testdata/configmap.yml.tmpl": {Data: []byte(`apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: seed-{{ .runID }}
+  namespace: {{ .namespace }}
+data:
+  port: "{{ .ports.http }}"`)},
+	}
+
+	fixture, err := Load(fsys, "testdata/configmap.yml.tmpl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fixture.Type() != YAML {
+		t.Errorf("expected type %s, got %s", YAML, fixture.Type())
+	}
+
+	out, err := fixture.Render(NewVars("test").WithPorts(map[string]string{"http": "8080"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"namespace: test", `port: "8080"`, "name: seed-"} {
+		if !strings.Contains(string(out), want) {
+			t.Errorf("expected %q in:\n%s", want, out)
+		}
+	}
+
+	if _, err := Load(fsys, "missing.sql"); err == nil {
+		t.Error("expected an error for a missing fixture")
+	}
+}
+
+func TestRenderTrailingNewline(t *testing.T) {
+	for content, want := range map[string]string{
+		"name: {{ .namespace }}":   "name: test",
+		"name: {{ .namespace }}\n": "name: test\n",
+	} {
+		out, err := (&Fixture{Name: "test.yaml", Content: []byte(content)}).Render(NewVars("test"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(out) != want {
+			t.Errorf("expected %q, got %q", want, out)
+		}
+	}
+}
@@ -1,8 +1,10 @@
 package helm
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"log/slog"
 	"os"
 	"path"
 	"strconv"
@@ -18,14 +20,22 @@ import (
 	"github.com/flanksource/gomplate/v3/base64"
 	"sigs.k8s.io/yaml"
 
+	"github.com/flanksource/commons-test/budget"
+	"github.com/flanksource/commons-test/cache"
+	"github.com/flanksource/commons-test/cleanup"
 	"github.com/flanksource/commons-test/command"
+	"github.com/flanksource/commons-test/config"
+	"github.com/flanksource/commons-test/diagnostics"
+	"github.com/flanksource/commons-test/logging"
+	"github.com/flanksource/commons-test/names"
+	"github.com/flanksource/commons-test/report"
 )
 
 type Helm = clickyExec.WrapperFunc
 
-var kubectl clickyExec.WrapperFunc = clicky.Exec("kubectl").AsWrapper()
-var helm clickyExec.WrapperFunc = clicky.Exec("helm").AsWrapper()
-var bash clickyExec.WrapperFunc = clicky.Exec("bash").AsWrapper()
+var kubectl clickyExec.WrapperFunc = traced("kubectl", nil, clicky.Exec("kubectl").AsWrapper())
+var helm clickyExec.WrapperFunc = traced("helm", nil, clicky.Exec("helm").AsWrapper())
+var bash clickyExec.WrapperFunc = traced("bash", nil, clicky.Exec("bash").AsWrapper())
 
 // HelmChart represents a Helm chart with fluent interface
 type HelmChart struct {
@@ -36,6 +46,7 @@ type HelmChart struct {
 	repositoryURL  string
 	namespace      string
 	chartPath      string
+	version        string
 	values         map[string]interface{}
 	wait           bool
 	timeout        time.Duration
@@ -47,15 +58,26 @@ type HelmChart struct {
 
 	lastResult *clickyExec.ExecResult
 	lastError  error
+	unregister func()
 }
 
+// ChartInstaller installs and removes a helm release, implemented by HelmChart and testingfakes.Chart
+type ChartInstaller interface {
+	InstallOrUpgrade() error
+	Uninstall() error
+	Status() (string, error)
+	GetReleaseName() string
+}
+
+var _ ChartInstaller = (*HelmChart)(nil)
+
 // NewHelmChart creates a new HelmChart builder
 func NewHelmChart(ctx flanksourceCtx.Context, chartPath string) *HelmChart {
 	return &HelmChart{
 		Context:     ctx,
 		chartPath:   chartPath,
 		colorOutput: true,
-		timeout:     5 * time.Minute,
+		timeout:     config.Get().ChartTimeout(),
 		values:      make(map[string]interface{}),
 	}
 }
@@ -66,6 +88,11 @@ func (h *HelmChart) Release(name string) *HelmChart {
 	return h
 }
 
+// GetReleaseName returns the release name
+func (h *HelmChart) GetReleaseName() string {
+	return h.releaseName
+}
+
 // Namespace sets the namespace
 func (h *HelmChart) Namespace(ns string) *HelmChart {
 	h.namespace = ns
@@ -79,6 +106,17 @@ func (h *HelmChart) Repository(repo, url string) *HelmChart {
 	return h
 }
 
+// Version sets the chart version to install, the latest when empty
+func (h *HelmChart) Version(version string) *HelmChart {
+	h.version = version
+	return h
+}
+
+// GetVersion returns the chart version
+func (h *HelmChart) GetVersion() string {
+	return h.version
+}
+
 // Values sets or merges Helm values
 func (h *HelmChart) Values(values map[string]interface{}) *HelmChart {
 	for k, v := range values {
@@ -147,9 +185,12 @@ func (h *HelmChart) NoColor() *HelmChart {
 	return h
 }
 
+// InstallOrUpgrade installs the chart, or upgrades the release if it exists. Without a release name
+// a unique one is generated from the chart name.
 func (h *HelmChart) InstallOrUpgrade() error {
+	h.defaultReleaseName()
 	if h.repository != "" && h.repositoryURL != "" {
-		if err := h.addAndUpdateRepository(h.repository, h.repositoryURL); err != nil {
+		if err := updateRepository(h.repository, h.repositoryURL); err != nil {
 			return err
 		}
 		h.chartPath = h.repository + "/" + h.chartPath
@@ -161,7 +202,25 @@ func (h *HelmChart) InstallOrUpgrade() error {
 	return h.Install()
 }
 
-func (h HelmChart) addAndUpdateRepository(repo, url string) error {
+// UpdateRepositories adds and updates each repository name => url, pre-warming the cache used by
+// InstallOrUpgrade so later installs skip the update
+func UpdateRepositories(repos map[string]string) error {
+	for repo, url := range repos {
+		if err := updateRepository(repo, url); err != nil {
+			return fmt.Errorf("failed to update repository %s: %w", repo, err)
+		}
+	}
+	return nil
+}
+
+// updateRepository adds and updates repo unless it was updated within the configured CacheTTL
+func updateRepository(repo, url string) error {
+	return cache.Default().Do(cache.Repository, repo+"="+url, func() error {
+		return addAndUpdateRepository(repo, url)
+	})
+}
+
+func addAndUpdateRepository(repo, url string) error {
 	p := clicky.Exec("helm", "repo", "add", repo, url).Run()
 	if p.Err != nil {
 		return p.Err
@@ -181,32 +240,39 @@ func (h HelmChart) addAndUpdateRepository(repo, url string) error {
 	return nil
 }
 
-// Install installs the Helm chart
+// Install installs the Helm chart, generating a unique release name when none is set
 func (h *HelmChart) Install() error {
-	if h.releaseName == "" {
-		return fmt.Errorf("release name is required")
-	}
+	h.defaultReleaseName()
 	logger.Infof("Installing Helm chart %s in namespace %s", h.chartPath, h.namespace)
+	diagnostics.TrackNamespace(h.namespace)
 	h.helm = h.command()
-	result, err := h.helm("install", h.releaseName, h.chartPath, "--create-namespace")
-	logger.Errorf(result.Pretty().ANSI())
-	logger.Errorf(result.Output())
-	return err
+	return budget.Run(context.Background(), report.Chart, h.namespace+"/"+h.releaseName, func(ctx context.Context) error {
+		result, err := h.helm("install", h.releaseName, h.chartPath, "--create-namespace", clickyExec.WithContext(ctx))
+		h.logResult(result, err)
+		if err == nil && !h.dryRun && h.unregister == nil {
+			h.unregister = cleanup.Register("helm release "+h.namespace+"/"+h.releaseName, func(context.Context) error {
+				return h.Delete().Error()
+			})
+		}
+		return err
+	})
 }
 
 // Upgrade upgrades the Helm release
 func (h *HelmChart) Upgrade() error {
 	logger.Infof("Upgrading Helm chart %s in namespace %s", h.chartPath, h.namespace)
+	diagnostics.TrackNamespace(h.namespace)
 
 	if h.releaseName == "" {
 		return fmt.Errorf("release name is required")
 	}
 	h.helm = h.command()
 
-	result, err := h.helm("upgrade", h.releaseName, h.chartPath)
-	logger.Infof(result.Pretty().ANSI())
-	logger.Errorf(result.Output())
-	return err
+	return budget.Run(context.Background(), report.Chart, h.namespace+"/"+h.releaseName, func(ctx context.Context) error {
+		result, err := h.helm("upgrade", h.releaseName, h.chartPath, clickyExec.WithContext(ctx))
+		h.logResult(result, err)
+		return err
+	})
 }
 
 // Delete deletes the Helm release
@@ -216,10 +282,19 @@ func (h *HelmChart) Delete() *HelmChart {
 		return h
 	}
 
+	if h.unregister != nil {
+		h.unregister()
+		h.unregister = nil
+	}
 	h.lastResult, h.lastError = helm("delete", "--namespace", h.namespace, h.releaseName, "--wait=false")
 	return h
 }
 
+// Uninstall deletes the Helm release, returning the error of Delete
+func (h *HelmChart) Uninstall() error {
+	return h.Delete().Error()
+}
+
 // GetPod returns a Pod accessor for the current release
 func (h *HelmChart) GetPod(selector string) *Pod {
 	return &Pod{
@@ -242,6 +317,15 @@ func (h *HelmChart) GetStatefulSet(name string) *StatefulSet {
 	}
 }
 
+// GetDeployment returns a Deployment accessor
+func (h *HelmChart) GetDeployment(name string) *Deployment {
+	return &Deployment{
+		Name:      name,
+		Namespace: h.namespace,
+		helm:      h,
+	}
+}
+
 // GetSecret returns a Secret accessor
 func (h *HelmChart) GetSecret(name string) *Secret {
 	return &Secret{
@@ -339,12 +423,34 @@ func (h *HelmChart) Result() *clickyExec.ExecResult {
 // MustSucceed panics if there was an error
 func (h *HelmChart) MustSucceed() *HelmChart {
 	if h.lastError != nil {
-		_, _ = os.Stderr.WriteString(h.lastResult.Pretty().ANSI())
+		if logging.JSON() {
+			h.logResult(h.lastResult, h.lastError)
+		} else if h.lastResult != nil {
+			_, _ = os.Stderr.WriteString(h.lastResult.Pretty().ANSI())
+		}
 		panic(h.lastError)
 	}
 	return h
 }
 
+// logResult logs the result of a helm command, as a JSON line with the release as resource in JSON mode
+func (h *HelmChart) logResult(result *clickyExec.ExecResult, err error) {
+	if result == nil {
+		return
+	}
+	if !logging.JSON() {
+		logger.Infof(result.Pretty().ANSI())
+		logger.Errorf(result.Output())
+		return
+	}
+	level := slog.LevelInfo
+	if err != nil {
+		level = slog.LevelError
+	}
+	logging.Log(level, "helm", h.namespace+"/"+h.releaseName, result.Command,
+		"args", result.Args, "exit_code", result.ExitCode, "stdout", result.Stdout, "stderr", result.Stderr)
+}
+
 func (h *HelmChart) Matches(o Object) bool {
 	if release, ok := o.Annotations["meta.helm.sh/release-name"]; !ok || release != h.releaseName {
 		return false
@@ -378,11 +484,12 @@ func (d *Deployment) GetReplicas() (int, error) {
 		return 0, err
 	}
 
-	i, err := strconv.Atoi(p.Stdout)
-	if err != nil {
-		return 0, err
-	}
-	return i, nil
+	return readyReplicas(p.Stdout)
+}
+
+// IsReady returns true when every replica of the latest generation is ready, or an error describing the rollout
+func (d *Deployment) IsReady() (bool, error) {
+	return rolloutReady("deployment", d.Name, d.Namespace)
 }
 
 // StatefulSet represents a Kubernetes StatefulSet
@@ -418,11 +525,12 @@ func (s *StatefulSet) GetReplicas() (int, error) {
 		return 0, err
 	}
 
-	i, err := strconv.Atoi(p.Stdout)
-	if err != nil {
-		return 0, err
-	}
-	return i, nil
+	return readyReplicas(p.Stdout)
+}
+
+// IsReady returns true when every replica of the latest generation is ready, or an error describing the rollout
+func (s *StatefulSet) IsReady() (bool, error) {
+	return rolloutReady("statefulset", s.name, s.namespace)
 }
 
 // GetGeneration returns the current generation
@@ -505,10 +613,59 @@ func (p *PVC) Status() (map[string]interface{}, error) {
 
 // Helper methods
 
+func (h *HelmChart) defaultReleaseName() {
+	if h.releaseName == "" {
+		h.releaseName = names.New(path.Base(h.chartPath))
+	}
+}
+
+// readyReplicas parses .status.readyReplicas, which is omitted when no replica is ready
+func readyReplicas(s string) (int, error) {
+	if s = strings.TrimSpace(s); s == "" {
+		return 0, nil
+	}
+	return strconv.Atoi(s)
+}
+
+// rolloutReady returns an error describing the rollout until every replica of the latest generation is ready
+func rolloutReady(kind, name, namespace string) (bool, error) {
+	p, err := kubectl("get", kind, name, "-n", namespace, "-o", "json")
+	if err != nil {
+		return false, err
+	}
+	var workload struct {
+		Metadata `json:"metadata"`
+		Spec     struct {
+			Replicas *int `json:"replicas"`
+		} `json:"spec"`
+		Status struct {
+			ObservedGeneration int64 `json:"observedGeneration"`
+			ReadyReplicas      int   `json:"readyReplicas"`
+			UpdatedReplicas    int   `json:"updatedReplicas"`
+		} `json:"status"`
+	}
+	if err := json.Unmarshal([]byte(p.Stdout), &workload); err != nil {
+		return false, fmt.Errorf("failed to unmarshal %s: %w", kind, err)
+	}
+	replicas := 1
+	if workload.Spec.Replicas != nil {
+		replicas = *workload.Spec.Replicas
+	}
+	status := workload.Status
+	if status.ObservedGeneration < workload.Generation || status.UpdatedReplicas < replicas || status.ReadyReplicas < replicas {
+		return false, fmt.Errorf("%s %s/%s: %d/%d ready, %d/%d updated, generation %d/%d observed", kind, namespace, name,
+			status.ReadyReplicas, replicas, status.UpdatedReplicas, replicas, status.ObservedGeneration, workload.Generation)
+	}
+	return true, nil
+}
+
 func (h *HelmChart) command(args ...string) Helm {
 	if h.namespace != "" {
 		args = append(args, "--namespace", h.namespace)
 	}
+	if h.version != "" {
+		args = append(args, "--version", h.version)
+	}
 	if h.wait {
 		args = append(args, "--wait")
 	}
@@ -529,30 +686,70 @@ func (h *HelmChart) command(args ...string) Helm {
 		args = append(args, "--dry-run")
 	}
 
-	// Add values if any
-	if len(h.values) > 0 {
-		valuesYaml, err := yaml.Marshal(h.values)
-		if err != nil {
-			h.lastError = fmt.Errorf("failed to marshal values: %w", err)
-			logger.Errorf("failed to marshal values: %v", err)
-			return nil
-		}
+	values, err := h.valuesArgs()
+	if err != nil {
+		h.lastError = err
+		logger.Errorf("%v", err)
+		return nil
+	}
+	args = append(args, values...)
 
-		// Write values to temp file
-		tempFile := path.Join(os.TempDir(), fmt.Sprintf("helm-test-values-%s.yaml", time.Now().Format("2006-01-02-15-04-05")))
-		if err := os.WriteFile(tempFile, valuesYaml, 0644); err != nil {
-			h.lastError = fmt.Errorf("failed to write values file: %w", err)
-			logger.Errorf("failed to write values file: %v", err)
-			return nil
-		}
+	cmd := clicky.Exec("helm", args...)
+
+	return traced("helm", args, cmd.AsWrapper())
+}
 
-		args = append(args, "--values", tempFile)
-		// Note: In production, should defer cleanup of temp file
+// valuesArgs writes the values to a temp file and returns the --values flag for it
+func (h *HelmChart) valuesArgs() ([]string, error) {
+	if len(h.values) == 0 {
+		return nil, nil
+	}
+	valuesYaml, err := yaml.Marshal(h.values)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal values: %w", err)
 	}
 
-	cmd := clicky.Exec("helm", args...)
+	// Write values to temp file
+	tempFile := path.Join(os.TempDir(), fmt.Sprintf("helm-test-values-%s.yaml", time.Now().Format("2006-01-02-15-04-05")))
+	if err := os.WriteFile(tempFile, valuesYaml, 0644); err != nil {
+		return nil, fmt.Errorf("failed to write values file: %w", err)
+	}
+	cleanup.RemoveFile(tempFile)
+	return []string{"--values", tempFile}, nil
+}
+
+// Template renders the chart with its values and namespace without installing it, returning the manifests
+func (h *HelmChart) Template() (string, error) {
+	h.defaultReleaseName()
+	chartPath := h.chartPath
+	if h.repository != "" && h.repositoryURL != "" && !strings.HasPrefix(chartPath, h.repository+"/") {
+		if err := updateRepository(h.repository, h.repositoryURL); err != nil {
+			return "", err
+		}
+		chartPath = h.repository + "/" + chartPath
+	}
 
-	return cmd.AsWrapper()
+	args := []any{"template", h.releaseName, chartPath}
+	if h.namespace != "" {
+		args = append(args, "--namespace", h.namespace)
+	}
+	if h.version != "" {
+		args = append(args, "--version", h.version)
+	}
+	values, err := h.valuesArgs()
+	if err != nil {
+		h.lastError = err
+		return "", err
+	}
+	for _, v := range values {
+		args = append(args, v)
+	}
+
+	h.lastResult, h.lastError = helm(args...)
+	if h.lastError != nil {
+		return "", fmt.Errorf("failed to template %s: %w: %s", chartPath, h.lastError, h.lastResult.Stderr)
+	}
+	return h.lastResult.Stdout, nil
 }
 
 func (h *HelmChart) collectDiagnostics() {
@@ -1,11 +1,18 @@
 package helm
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"strings"
 
 	"github.com/flanksource/clicky/exec"
+	flanksourceCtx "github.com/flanksource/commons-db/context"
+
+	"github.com/flanksource/commons-test/budget"
+	"github.com/flanksource/commons-test/cleanup"
+	"github.com/flanksource/commons-test/names"
+	"github.com/flanksource/commons-test/report"
 )
 
 // Namespace represents a Kubernetes namespace with fluent interface
@@ -14,6 +21,7 @@ type Namespace struct {
 	colorOutput bool
 	lastResult  *exec.ExecResult
 	lastError   error
+	unregister  func()
 }
 
 func (h *Namespace) GetPods(selectors ...string) ([]*Pod, error) {
@@ -48,6 +56,9 @@ func (h *Namespace) GetPods(selectors ...string) ([]*Pod, error) {
 
 // NewNamespace creates a new Namespace accessor
 func NewNamespace(name string) *Namespace {
+	if name == "" {
+		name = names.New("test")
+	}
 	return &Namespace{
 		name:        name,
 		colorOutput: true,
@@ -56,20 +67,76 @@ func NewNamespace(name string) *Namespace {
 
 // Create creates the namespace
 func (n *Namespace) Create() *Namespace {
-	n.lastResult, n.lastError = kubectl("create", "namespace", n.name)
-	if n.lastError != nil && strings.Contains(n.lastResult.Stderr, "already exists") {
-		// Namespace already exists, that's ok
-		n.lastError = nil
+	existed := false
+	n.lastError = budget.Run(context.Background(), report.Namespace, n.name, func(ctx context.Context) error {
+		var err error
+		n.lastResult, err = kubectl("create", "namespace", n.name, exec.WithContext(ctx))
+		if err != nil && strings.Contains(n.lastResult.Stderr, "already exists") {
+			// Namespace already exists, that's ok
+			existed = true
+			return nil
+		}
+		return err
+	})
+	if n.lastError == nil && !existed && n.unregister == nil {
+		// Label namespaces created by this run so leftovers can be traced back to it
+		_, _ = kubectl("label", "namespace", n.name, "--overwrite", names.RunLabel+"="+names.RunID())
+		n.unregister = cleanup.Register("namespace "+n.name, func(context.Context) error {
+			return n.Delete().Error()
+		})
 	}
 	return n
 }
 
 // Delete deletes the namespace
 func (n *Namespace) Delete() *Namespace {
+	if n.unregister != nil {
+		n.unregister()
+		n.unregister = nil
+	}
 	n.lastResult, n.lastError = kubectl("delete", "namespace", n.name, "--wait=false")
 	return n
 }
 
+// GetPod returns a Pod accessor for the first pod in the namespace matching selector
+func (n *Namespace) GetPod(selector string) *Pod {
+	return &Pod{
+		Metadata: Metadata{
+			Namespace: n.name,
+		},
+		selector:    selector,
+		colorOutput: n.colorOutput,
+	}
+}
+
+// Chart returns a HelmChart that installs chartPath into the namespace
+func (n *Namespace) Chart(ctx flanksourceCtx.Context, chartPath string) *HelmChart {
+	return NewHelmChart(ctx, chartPath).Namespace(n.name)
+}
+
+// Label adds or overwrites labels on the namespace
+func (n *Namespace) Label(labels map[string]string) *Namespace {
+	if len(labels) == 0 {
+		return n
+	}
+	args := []any{"label", "namespace", n.name, "--overwrite"}
+	for k, v := range labels {
+		args = append(args, k+"="+v)
+	}
+	n.lastResult, n.lastError = kubectl(args...)
+	return n
+}
+
+// Name returns the namespace name
+func (n *Namespace) Name() string {
+	return n.name
+}
+
+// Error returns the last error
+func (n *Namespace) Error() error {
+	return n.lastError
+}
+
 // MustSucceed panics if there was an error
 func (n *Namespace) MustSucceed() *Namespace {
 	if n.lastError != nil {
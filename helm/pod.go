@@ -10,6 +10,9 @@ import (
 	"github.com/flanksource/clicky"
 	"github.com/flanksource/clicky/exec"
 	"github.com/samber/lo"
+
+	"github.com/flanksource/commons-test/ports"
+	"github.com/flanksource/commons-test/wait"
 )
 
 // Pod represents a Kubernetes pod with fluent interface
@@ -132,25 +135,48 @@ func (p *Pod) Status() (string, error) {
 	return strings.TrimSpace(p.lastResult.Stdout), p.lastError
 }
 
-func getFreePort() int {
-	// Use net.Listen to find a free port
-	listener, err := net.Listen("tcp", ":0")
-	if err != nil {
-		panic(fmt.Sprintf("failed to get free port: %v", err))
+// IsReady returns true when the pod's Ready condition is True, or an error with the observed status
+func (p *Pod) IsReady() (bool, error) {
+	if p.Name == "" && p.selector != "" {
+		if err := p.resolvePodName(); err != nil {
+			return false, err
+		}
+	}
+
+	args := []any{"get", "pod", p.Name, "-n", p.Namespace,
+		"-o", `jsonpath={.status.conditions[?(@.type=="Ready")].status}`}
+	p.lastResult, p.lastError = kubectl(args...)
+	if p.lastError != nil {
+		return false, p.lastError
+	}
+	if status := strings.TrimSpace(p.lastResult.Stdout); status != "True" {
+		return false, fmt.Errorf("pod %s/%s is not ready (Ready=%q)", p.Namespace, p.Name, status)
 	}
-	defer listener.Close()
-	return listener.Addr().(*net.TCPAddr).Port
+	return true, nil
 }
 
 // ForwardPort forwards a port from the pod to the local machine
 func (p *Pod) ForwardPort(port int) (*int, func()) {
+	localPort, stop, err := ForwardPort(p.Namespace, "pod/"+p.GetName(), port)
+	if err != nil {
+		clicky.Errorf("Port forward failed: %v", err)
+		return nil, func() {}
+	}
+	return &localPort, stop
+}
 
-	localPort := getFreePort()
-	clicky.Infof("Forwarding pod %s port %d to local port %d", p.GetName(), port, localPort)
+// ForwardPort forwards port of target (pod/<name> or svc/<name>) in namespace to a leased local port,
+// returning the local port and a function stopping the forward
+func ForwardPort(namespace, target string, port int) (int, func(), error) {
+	localPort, err := ports.Get()
+	if err != nil {
+		return 0, nil, err
+	}
+	clicky.Infof("Forwarding %s/%s port %d to local port %d", namespace, target, port, localPort)
 
 	ctx, cancel := context.WithCancel(context.Background())
 	go func() {
-		resp, err := kubectl(exec.WithContext(ctx), "port-forward", "-n", p.Namespace, p.GetName(), fmt.Sprintf("%d:%d", localPort, port))
+		resp, err := kubectl(exec.WithContext(ctx), "port-forward", "-n", namespace, target, fmt.Sprintf("%d:%d", localPort, port))
 		if err != nil {
 			clicky.Errorf("Port forward failed: %v", err)
 			return
@@ -159,23 +185,22 @@ func (p *Pod) ForwardPort(port int) (*int, func()) {
 		}
 	}()
 
-	start := time.Now()
-	for {
+	err = wait.For(ctx, 100*time.Millisecond, 10*time.Second, wait.NoError(func(ctx context.Context) error {
 		conn, err := net.DialTimeout("tcp", fmt.Sprintf("localhost:%d", localPort), 500*time.Millisecond)
 		if err == nil {
 			_ = conn.Close()
-			break
 		}
-		if time.Since(start) > 10*time.Second {
-			clicky.Errorf("Timed out waiting for port forward to be ready")
-			cancel()
-			return nil, func() {}
-		}
-		time.Sleep(100 * time.Millisecond)
-	}
-	return &localPort, func() {
+		return err
+	}))
+	if err != nil {
 		cancel()
+		ports.Release(localPort)
+		return 0, nil, fmt.Errorf("port forward to %s/%s:%d is not ready: %w", namespace, target, port, err)
 	}
+	return localPort, func() {
+		cancel()
+		ports.Release(localPort)
+	}, nil
 }
 
 // Result returns the last command result
@@ -0,0 +1,31 @@
+package helm
+
+import (
+	"context"
+	"fmt"
+
+	clickyExec "github.com/flanksource/clicky/exec"
+
+	"github.com/flanksource/commons-test/tracing"
+)
+
+// traced wraps a helm, kubectl or bash wrapper so every invocation is recorded as a span,
+// base being the arguments the wrapper was created with
+func traced(name string, base []string, fn clickyExec.WrapperFunc) clickyExec.WrapperFunc {
+	return func(args ...any) (*clickyExec.ExecResult, error) {
+		commandLine := append([]string(nil), base...)
+		for _, arg := range args {
+			if _, ok := arg.(clickyExec.WrapperOption); !ok {
+				commandLine = append(commandLine, fmt.Sprint(arg))
+			}
+		}
+		_, span := tracing.StartExec(context.Background(), name, commandLine)
+		result, err := fn(args...)
+		exitCode := -1
+		if result != nil {
+			exitCode = result.ExitCode
+		}
+		tracing.EndExec(span, exitCode, err)
+		return result, err
+	}
+}
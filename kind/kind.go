@@ -1,8 +1,11 @@
 package kind
 
 import (
+	gocontext "context"
 	"fmt"
+	"maps"
 	"os"
+	"path/filepath"
 	"slices"
 	"strings"
 	"time"
@@ -14,9 +17,17 @@ import (
 	"github.com/flanksource/commons/logger"
 	"github.com/flanksource/deps"
 	"github.com/samber/lo"
+	"sigs.k8s.io/yaml"
 
+	"github.com/flanksource/commons-test/budget"
+	"github.com/flanksource/commons-test/cleanup"
 	"github.com/flanksource/commons-test/command"
+	"github.com/flanksource/commons-test/config"
 	"github.com/flanksource/commons-test/helm"
+	"github.com/flanksource/commons-test/names"
+	"github.com/flanksource/commons-test/ports"
+	"github.com/flanksource/commons-test/report"
+	"github.com/flanksource/commons-test/wait"
 )
 
 type Kind struct {
@@ -29,8 +40,35 @@ type Kind struct {
 	kubectl    *exec.WrapperFunc
 	lastResult command.Result
 	lastError  error
+	unregister func()
+	registry   LocalRegistry
 
 	Services []string
+	// PortMappings maps node container ports to host ports, e.g. 80 for an ingress controller
+	PortMappings map[int]int `yaml:"port_mappings"`
+}
+
+// Cluster creates and deletes a kind cluster, implemented by Kind and testingfakes.Cluster
+type Cluster interface {
+	Create() error
+	Destroy() error
+	Load(image string) error
+	Exists() bool
+	GetName() string
+	GetKubeconfig() (string, error)
+}
+
+var _ Cluster = (*Kind)(nil)
+
+// LocalRegistry is a started registry container the cluster nodes pull images from, implemented by
+// container.RegistryContainer
+type LocalRegistry interface {
+	// GetName returns the container name, connected to the kind network
+	GetName() string
+	// GetURL returns the host:port images are pushed to and referenced by
+	GetURL() string
+	// GetClusterEndpoint returns the URL the nodes reach the registry at on the kind network
+	GetClusterEndpoint() string
 }
 
 // NewKind creates a new Kind cluster manager
@@ -40,7 +78,7 @@ func NewKind(name string) *Kind {
 	}
 	return &Kind{
 		Name:        name,
-		Version:     "latest",
+		Version:     config.Get().KindVersion,
 		ColorOutput: true,
 		runner:      command.NewCommandRunner(true),
 	}
@@ -64,6 +102,118 @@ func (k *Kind) WithServices(svcs ...string) *Kind {
 	return k
 }
 
+// WithPortMapping publishes containerPort of the control-plane node on a host port leased from the ports
+// package, see HostPort. Only applies to clusters created by GetOrCreate.
+func (k *Kind) WithPortMapping(containerPort int) *Kind {
+	hostPort, err := ports.Get()
+	if err != nil {
+		k.lastError = err
+		return k
+	}
+	if k.PortMappings == nil {
+		k.PortMappings = map[int]int{}
+	}
+	k.PortMappings[containerPort] = hostPort
+	return k
+}
+
+// WithLocalRegistry lets the nodes pull the images pushed to registry, referenced as
+// registry.GetURL()/<image>. The registry must be started first, and clusters that are not created by
+// GetOrCreate need to have been created with the same containerd config_path patch.
+func (k *Kind) WithLocalRegistry(registry LocalRegistry) *Kind {
+	k.registry = registry
+	return k
+}
+
+// HostPort returns the host port containerPort is published on, 0 when it is not mapped
+func (k *Kind) HostPort(containerPort int) int {
+	return k.PortMappings[containerPort]
+}
+
+// kindConfig returns the kind cluster config publishing PortMappings, and reading containerd registry
+// hosts from /etc/containerd/certs.d when a local registry is used
+func (k *Kind) kindConfig() ([]byte, error) {
+	var mappings []map[string]int
+	for _, containerPort := range slices.Sorted(maps.Keys(k.PortMappings)) {
+		mappings = append(mappings, map[string]int{"containerPort": containerPort, "hostPort": k.PortMappings[containerPort]})
+	}
+	node := map[string]any{"role": "control-plane"}
+	if len(mappings) > 0 {
+		node["extraPortMappings"] = mappings
+	}
+	config := map[string]any{
+		"kind":       "Cluster",
+		"apiVersion": "kind.x-k8s.io/v1alpha4",
+		"nodes":      []any{node},
+	}
+	if k.registry != nil {
+		config["containerdConfigPatches"] = []string{
+			"[plugins.\"io.containerd.grpc.v1.cri\".registry]\n  config_path = \"/etc/containerd/certs.d\"",
+		}
+	}
+	return yaml.Marshal(config)
+}
+
+// registryHosts returns the containerd hosts.toml resolving the registry's host:port on the nodes
+func registryHosts(registry LocalRegistry) string {
+	return fmt.Sprintf("[host.%q]\n  skip_verify = true\n", registry.GetClusterEndpoint())
+}
+
+// registryHostingConfigMap returns the local-registry-hosting ConfigMap of KEP-1755, which tools such
+// as tilt and skaffold read to discover the registry
+func registryHostingConfigMap(registry LocalRegistry) ([]byte, error) {
+	hosting, err := yaml.Marshal(map[string]string{
+		"host": registry.GetURL(),
+		"help": "https://kind.sigs.k8s.io/docs/user/local-registry/",
+	})
+	if err != nil {
+		return nil, err
+	}
+	return yaml.Marshal(map[string]any{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata":   map[string]string{"name": "local-registry-hosting", "namespace": "kube-public"},
+		"data":       map[string]string{"localRegistryHosting.v1": string(hosting)},
+	})
+}
+
+// connectRegistry connects the local registry to the kind network, configures every node to resolve
+// it and documents it in the local-registry-hosting ConfigMap
+func (k *Kind) connectRegistry() error {
+	if k.registry == nil {
+		return nil
+	}
+	name := k.registry.GetName()
+	result := k.runner.RunCommandQuiet("docker", "network", "connect", "kind", name)
+	if result.Err != nil && !strings.Contains(result.Stderr, "already exists") {
+		return fmt.Errorf("failed to connect registry %s to the kind network: %s", name, result.String())
+	}
+
+	nodes := k.runner.RunCommandQuiet("kind", "get", "nodes", "--name", k.Name)
+	if nodes.Err != nil {
+		return fmt.Errorf("failed to list nodes: %s", nodes.String())
+	}
+	dir := "/etc/containerd/certs.d/" + k.registry.GetURL()
+	for _, node := range nodes.Lines() {
+		result := k.runner.WithStdin(strings.NewReader(registryHosts(k.registry))).
+			RunCommandQuiet("docker", "exec", "-i", node, "sh", "-c", fmt.Sprintf("mkdir -p %q && cat > %q/hosts.toml", dir, dir))
+		if result.Err != nil {
+			return fmt.Errorf("failed to configure registry on node %s: %s", node, result.String())
+		}
+	}
+
+	configMap, err := registryHostingConfigMap(k.registry)
+	if err != nil {
+		return fmt.Errorf("failed to render local-registry-hosting: %w", err)
+	}
+	result = k.runner.WithStdin(strings.NewReader(string(configMap))).
+		RunCommandQuiet("kubectl", "--context", "kind-"+k.Name, "apply", "-f", "-")
+	if result.Err != nil {
+		return fmt.Errorf("failed to apply local-registry-hosting: %s", result.String())
+	}
+	return nil
+}
+
 // GetOrCreate gets an existing kind cluster or creates a new one
 func (k *Kind) GetOrCreate() *Kind {
 	// Check if cluster already exists
@@ -74,6 +224,10 @@ func (k *Kind) GetOrCreate() *Kind {
 			if cluster == k.Name {
 				k.runner.Debugf("Using existing cluster: %s", k.Name)
 				k.Use()
+				if err := k.connectRegistry(); err != nil {
+					k.lastError = err
+					return k
+				}
 				if err := k.setupServices(); err != nil {
 					k.lastError = fmt.Errorf("failed to setup services: %w", err)
 					return k
@@ -90,19 +244,49 @@ func (k *Kind) GetOrCreate() *Kind {
 	if k.Version != "" && k.Version != "latest" {
 		args = append(args, "--image", fmt.Sprintf("kindest/node:%s", k.Version))
 	}
+	if len(k.PortMappings) > 0 || k.registry != nil {
+		data, err := k.kindConfig()
+		if err != nil {
+			k.lastError = fmt.Errorf("failed to generate kind config: %w", err)
+			return k
+		}
+		file := filepath.Join(os.TempDir(), fmt.Sprintf("%s%s-config-%d.yaml", names.TempPrefix("kind"), k.Name, time.Now().UnixNano()))
+		if err := os.WriteFile(file, data, 0600); err != nil {
+			k.lastError = fmt.Errorf("failed to write kind config: %w", err)
+			return k
+		}
+		defer os.Remove(file)
+		args = append(args, "--config", file)
+	}
 
-	k.lastResult = k.runner.RunCommand("kind", args...)
-	if k.lastResult.Err != nil {
-		k.lastError = fmt.Errorf("failed to create kind cluster: %s", k.lastResult.String())
+	err := budget.Run(gocontext.Background(), report.Cluster, k.Name, func(ctx gocontext.Context) error {
+		k.lastResult = k.runner.RunCommandCtx(ctx, "kind", args...)
+		if k.lastResult.Err != nil {
+			return fmt.Errorf("failed to create kind cluster: %s", k.lastResult.String())
+		}
+
+		// Only clusters created by this run are deleted by cleanup.Run
+		k.unregister = cleanup.Register("kind cluster "+k.Name, func(gocontext.Context) error {
+			return k.Delete().Error()
+		})
+
+		// Wait for cluster to be ready
+		k.runner.Debugf("Waiting for cluster to be ready...")
+		k.waitForCluster(ctx)
+		return nil
+	})
+	if err != nil {
+		k.lastError = err
 		return k
 	}
 
-	// Wait for cluster to be ready
-	k.runner.Debugf("Waiting for cluster to be ready...")
-	k.waitForCluster()
-
 	k.Use()
 
+	if err := k.connectRegistry(); err != nil {
+		k.lastError = err
+		return k
+	}
+
 	logger.Infof("Setting up services")
 	if err := k.setupServices(); err != nil {
 		k.lastError = fmt.Errorf("failed to setup services: %w", err)
@@ -111,6 +295,26 @@ func (k *Kind) GetOrCreate() *Kind {
 	return k
 }
 
+// Create gets or creates the cluster, returning the error of GetOrCreate
+func (k *Kind) Create() error {
+	return k.GetOrCreate().Error()
+}
+
+// Destroy deletes the cluster, returning the error of Delete
+func (k *Kind) Destroy() error {
+	return k.Delete().Error()
+}
+
+// Load loads a docker image into the cluster, returning the error of LoadImage
+func (k *Kind) Load(image string) error {
+	return k.LoadImage(image).Error()
+}
+
+// GetName returns the cluster name
+func (k *Kind) GetName() string {
+	return k.Name
+}
+
 // Use updates KUBECONFIG to use the kind cluster
 func (k *Kind) Use() *Kind {
 	k.runner.Infof("Switching to cluster context: kind-%s", k.Name)
@@ -145,20 +349,31 @@ func (k *Kind) Use() *Kind {
 // Delete deletes the kind cluster
 func (k *Kind) Delete() *Kind {
 	k.runner.Errorf("=== Deleting Kind Cluster: %s ===", k.Name)
+	if k.unregister != nil {
+		k.unregister()
+		k.unregister = nil
+	}
 
 	k.lastResult = k.runner.RunCommand("kind", "delete", "cluster", "--name", k.Name)
 	if k.lastResult.Err != nil {
 		k.lastError = fmt.Errorf("failed to delete kind cluster: %s", k.lastResult.String())
+		return k
+	}
+	for _, hostPort := range k.PortMappings {
+		ports.Release(hostPort)
 	}
 	return k
 }
 
 // LoadImage loads a docker image into the kind cluster
 func (k *Kind) LoadImage(image string) *Kind {
-	k.lastResult = k.runner.RunCommand("kind", "load", "docker-image", image, "--name", k.Name)
-	if k.lastResult.Err != nil {
-		k.lastError = fmt.Errorf("failed to load image: %s", k.lastResult.String())
-	}
+	k.lastError = budget.Run(gocontext.Background(), report.Image, image, func(ctx gocontext.Context) error {
+		k.lastResult = k.runner.RunCommandCtx(ctx, "kind", "load", "docker-image", image, "--name", k.Name)
+		if k.lastResult.Err != nil {
+			return fmt.Errorf("failed to load image: %s", k.lastResult.String())
+		}
+		return nil
+	})
 	return k
 }
 
@@ -206,14 +421,19 @@ func (k *Kind) MustSucceed() *Kind {
 }
 
 // waitForCluster waits for the cluster to be ready
-func (k *Kind) waitForCluster() {
-	maxRetries := 30
-	for i := 0; i < maxRetries; i++ {
-		result := k.runner.RunCommandQuiet("kubectl", "get", "nodes")
-		if result.Err == nil && strings.Contains(result.Stdout, "Ready") {
-			return
+func (k *Kind) waitForCluster(ctx gocontext.Context) {
+	err := wait.For(ctx, 2*time.Second, config.Get().ClusterTimeout(), wait.NoError(func(ctx gocontext.Context) error {
+		result := k.runner.RunCommandQuietCtx(ctx, "kubectl", "get", "nodes")
+		if result.Err != nil {
+			return fmt.Errorf("%v: %s", result.Err, strings.TrimSpace(result.Stderr))
 		}
-		time.Sleep(2 * time.Second)
+		if !strings.Contains(result.Stdout, "Ready") {
+			return fmt.Errorf("no ready nodes:\n%s", result.Stdout)
+		}
+		return nil
+	}))
+	if err != nil {
+		k.runner.Errorf("Cluster %s is not ready: %v", k.Name, err)
 	}
 }
 
@@ -226,11 +446,12 @@ func (k *Kind) SetKubeconfig() *Kind {
 	}
 
 	// Write kubeconfig to temp file
-	tempFile := fmt.Sprintf("/tmp/kind-%s-kubeconfig-%d", k.Name, time.Now().UnixNano())
+	tempFile := filepath.Join(os.TempDir(), fmt.Sprintf("%s%s-kubeconfig-%d", names.TempPrefix("kind"), k.Name, time.Now().UnixNano()))
 	if err := os.WriteFile(tempFile, []byte(kubeconfig), 0600); err != nil {
 		k.lastError = fmt.Errorf("failed to write kubeconfig to temp file: %w", err)
 		return k
 	}
+	cleanup.RemoveFile(tempFile)
 	// Set KUBECONFIG environment variable
 	os.Setenv("KUBECONFIG", tempFile)
 	k.runner.Debugf("KUBECONFIG set to: %s", tempFile)
@@ -248,10 +469,11 @@ func (k Kind) Kubectl() exec.WrapperFunc {
 	}
 
 	// Write kubeconfig to temp file
-	tempFile := fmt.Sprintf("/tmp/kind-%s-kubeconfig-%d", k.Name, time.Now().UnixNano())
+	tempFile := filepath.Join(os.TempDir(), fmt.Sprintf("%s%s-kubeconfig-%d", names.TempPrefix("kind"), k.Name, time.Now().UnixNano()))
 	if err := os.WriteFile(tempFile, []byte(kubeconfig), 0600); err != nil {
 		panic(fmt.Errorf("failed to write kubeconfig to temp file: %w", err))
 	}
+	cleanup.RemoveFile(tempFile)
 	p := clicky.Exec("kubectl", "--context", fmt.Sprintf("kind-%s", k.Name), "--kubeconfig", tempFile)
 	k.kubectl = lo.ToPtr(p.AsWrapper())
 	return *k.kubectl
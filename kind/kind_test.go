@@ -2,11 +2,15 @@ package kind
 
 import (
 	"os"
+	"strings"
 	"testing"
 
 	"github.com/flanksource/commons-test/command"
+	"github.com/flanksource/commons-test/container"
 )
 
+var _ LocalRegistry = (*container.RegistryContainer)(nil)
+
 func TestKindCluster(t *testing.T) {
 	// Skip if not running integration tests
 	if os.Getenv("INTEGRATION_TEST") != "true" {
@@ -134,3 +138,42 @@ func TestCommandRunner(t *testing.T) {
 		}
 	})
 }
+
+type fakeRegistry struct{}
+
+func (fakeRegistry) GetName() string            { return "kind-registry" }
+func (fakeRegistry) GetURL() string             { return "localhost:5001" }
+func (fakeRegistry) GetClusterEndpoint() string { return "http://kind-registry:5000" }
+
+func TestLocalRegistry(t *testing.T) {
+	kind := NewKind("registry-test").NoColor()
+	config, err := kind.kindConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(config), "containerdConfigPatches") || strings.Contains(string(config), "extraPortMappings") {
+		t.Errorf("expected a plain config without a registry or port mappings:\n%s", config)
+	}
+
+	config, err = kind.WithLocalRegistry(fakeRegistry{}).kindConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(config), `config_path = "/etc/containerd/certs.d"`) {
+		t.Errorf("expected the containerd config_path patch:\n%s", config)
+	}
+
+	if hosts := registryHosts(fakeRegistry{}); hosts != "[host.\"http://kind-registry:5000\"]\n  skip_verify = true\n" {
+		t.Errorf("unexpected hosts.toml:\n%s", hosts)
+	}
+
+	configMap, err := registryHostingConfigMap(fakeRegistry{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"name: local-registry-hosting", "namespace: kube-public", "localRegistryHosting.v1: |", "host: localhost:5001"} {
+		if !strings.Contains(string(configMap), want) {
+			t.Errorf("expected %q in:\n%s", want, configMap)
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package kubeconfig
+
+import (
+	"fmt"
+
+	"github.com/flanksource/clicky"
+	"github.com/flanksource/clicky/exec"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// Kubectl returns a kubectl wrapper bound to context of the kubeconfig file
+func (c *Config) Kubectl(context string) exec.WrapperFunc {
+	return clicky.Exec("kubectl", "--kubeconfig", c.path, "--context", context).AsWrapper()
+}
+
+// Helm returns a helm wrapper bound to context of the kubeconfig file
+func (c *Config) Helm(context string) exec.WrapperFunc {
+	return clicky.Exec("helm", "--kubeconfig", c.path, "--kube-context", context).AsWrapper()
+}
+
+// RESTConfig returns the client config of context. Only the kubeconfig file is loaded, ignoring
+// KUBECONFIG and ~/.kube/config.
+func (c *Config) RESTConfig(context string) (*rest.Config, error) {
+	config, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
+		&clientcmd.ClientConfigLoadingRules{ExplicitPath: c.path},
+		&clientcmd.ConfigOverrides{CurrentContext: context},
+	).ClientConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load context %s: %w", context, err)
+	}
+	return config, nil
+}
+
+// Clientset returns a client for context
+func (c *Config) Clientset(context string) (kubernetes.Interface, error) {
+	config, err := c.RESTConfig(context)
+	if err != nil {
+		return nil, err
+	}
+	return kubernetes.NewForConfig(config)
+}
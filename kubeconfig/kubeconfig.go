@@ -0,0 +1,215 @@
+// Package kubeconfig merges the contexts of several kind or external clusters into an isolated kubeconfig
+// file, so multi-cluster tests can address each cluster explicitly without touching ~/.kube/config or the
+// KUBECONFIG of the process:
+//
+//	kc, err := kubeconfig.New()
+//	err = kc.AddCluster(hub)
+//	err = kc.AddFile("staging", os.ExpandEnv("$HOME/.kube/config"), "staging-admin")
+//	result, err := kc.Kubectl("kind-hub")("get", "nodes")
+package kubeconfig
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+
+	"sigs.k8s.io/yaml"
+
+	"github.com/flanksource/commons-test/cleanup"
+	"github.com/flanksource/commons-test/names"
+)
+
+// Cluster provides the kubeconfig of a cluster, implemented by kind.Kind and testingfakes.Cluster
+type Cluster interface {
+	GetName() string
+	GetKubeconfig() (string, error)
+}
+
+// Config is an isolated kubeconfig file in os.TempDir, removed by cleanup.Run
+type Config struct {
+	path string
+
+	mu   sync.Mutex
+	file file
+}
+
+// file is the subset of the kubeconfig format that is merged, the cluster, user and context stanzas are
+// copied verbatim
+type file struct {
+	APIVersion     string  `json:"apiVersion"`
+	Kind           string  `json:"kind"`
+	CurrentContext string  `json:"current-context"`
+	Clusters       []named `json:"clusters"`
+	Users          []named `json:"users"`
+	Contexts       []named `json:"contexts"`
+}
+
+type named struct {
+	Name    string         `json:"name"`
+	Cluster map[string]any `json:"cluster,omitempty"`
+	User    map[string]any `json:"user,omitempty"`
+	Context map[string]any `json:"context,omitempty"`
+}
+
+// New creates an empty kubeconfig file
+func New() (*Config, error) {
+	f, err := os.CreateTemp("", names.TempPrefix("kubeconfig")+"*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create kubeconfig: %w", err)
+	}
+	f.Close()
+	cleanup.RemoveFile(f.Name())
+
+	c := &Config{path: f.Name(), file: file{APIVersion: "v1", Kind: "Config"}}
+	if err := c.write(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Path returns the path of the kubeconfig file
+func (c *Config) Path() string {
+	return c.path
+}
+
+// Env returns KUBECONFIG=<path>, for the environment of commands and containers that should only see
+// the merged clusters
+func (c *Config) Env() []string {
+	return []string{"KUBECONFIG=" + c.path}
+}
+
+// AddCluster adds the kind cluster as the context kind-<name>, matching the context kind creates
+func (c *Config) AddCluster(cluster Cluster) error {
+	data, err := cluster.GetKubeconfig()
+	if err != nil {
+		return fmt.Errorf("failed to get kubeconfig of %s: %w", cluster.GetName(), err)
+	}
+	return c.Add("kind-"+cluster.GetName(), []byte(data), "")
+}
+
+// AddFile adds context of the kubeconfig at path as name, the current context when empty. The file is
+// only read.
+func (c *Config) AddFile(name, path, context string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read kubeconfig %s: %w", path, err)
+	}
+	return c.add(name, data, context, filepath.Dir(path))
+}
+
+// Add adds context of the kubeconfig data as name, the current context when empty. The cluster and user
+// of the context are renamed to name, replacing any previously added with the same name. The first
+// context added becomes the current context.
+func (c *Config) Add(name string, data []byte, context string) error {
+	return c.add(name, data, context, "")
+}
+
+func (c *Config) add(name string, data []byte, context, dir string) error {
+	var source file
+	if err := yaml.Unmarshal(data, &source); err != nil {
+		return fmt.Errorf("failed to parse kubeconfig for %s: %w", name, err)
+	}
+	if context == "" {
+		context = source.CurrentContext
+	}
+	if context == "" && len(source.Contexts) == 1 {
+		context = source.Contexts[0].Name
+	}
+	ctx, ok := find(source.Contexts, context)
+	if !ok {
+		return fmt.Errorf("context %q not found in kubeconfig for %s", context, name)
+	}
+	clusterName, _ := ctx.Context["cluster"].(string)
+	cluster, ok := find(source.Clusters, clusterName)
+	if !ok {
+		return fmt.Errorf("cluster %q of context %s not found in kubeconfig for %s", clusterName, context, name)
+	}
+	userName, _ := ctx.Context["user"].(string)
+	user, _ := find(source.Users, userName)
+	if dir != "" {
+		resolve(dir, cluster.Cluster, "certificate-authority")
+		resolve(dir, user.User, "client-certificate", "client-key", "tokenFile")
+	}
+
+	merged := map[string]any{"cluster": name, "user": name}
+	if namespace, ok := ctx.Context["namespace"]; ok {
+		merged["namespace"] = namespace
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.file.Clusters = upsert(c.file.Clusters, named{Name: name, Cluster: cluster.Cluster})
+	c.file.Users = upsert(c.file.Users, named{Name: name, User: user.User})
+	c.file.Contexts = upsert(c.file.Contexts, named{Name: name, Context: merged})
+	if c.file.CurrentContext == "" {
+		c.file.CurrentContext = name
+	}
+	return c.write()
+}
+
+// Contexts returns the names of the contexts added
+func (c *Config) Contexts() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var contexts []string
+	for _, ctx := range c.file.Contexts {
+		contexts = append(contexts, ctx.Name)
+	}
+	return contexts
+}
+
+// Use sets the current context of the kubeconfig file, used by commands given only Env
+func (c *Config) Use(context string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := find(c.file.Contexts, context); !ok {
+		return fmt.Errorf("context %q not found", context)
+	}
+	c.file.CurrentContext = context
+	return c.write()
+}
+
+// write replaces the kubeconfig file, callers hold mu
+func (c *Config) write() error {
+	data, err := yaml.Marshal(c.file)
+	if err != nil {
+		return fmt.Errorf("failed to marshal kubeconfig: %w", err)
+	}
+	tmp := c.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write kubeconfig: %w", err)
+	}
+	if err := os.Rename(tmp, c.path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write kubeconfig: %w", err)
+	}
+	return nil
+}
+
+func find(list []named, name string) (named, bool) {
+	i := slices.IndexFunc(list, func(n named) bool { return n.Name == name })
+	if i < 0 {
+		return named{}, false
+	}
+	return list[i], true
+}
+
+func upsert(list []named, entry named) []named {
+	if i := slices.IndexFunc(list, func(n named) bool { return n.Name == entry.Name }); i >= 0 {
+		list[i] = entry
+		return list
+	}
+	return append(list, entry)
+}
+
+// resolve makes the relative file paths of a cluster or user stanza absolute, as they are relative to
+// the kubeconfig they were read from
+func resolve(dir string, stanza map[string]any, keys ...string) {
+	for _, key := range keys {
+		if path, ok := stanza[key].(string); ok && path != "" && !filepath.IsAbs(path) {
+			stanza[key] = filepath.Join(dir, path)
+		}
+	}
+}
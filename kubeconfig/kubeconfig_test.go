@@ -0,0 +1,98 @@
+package kubeconfig
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"sigs.k8s.io/yaml"
+)
+
+const external = `apiVersion: v1
+kind: Config
+current-context: dev
+clusters:
+- name: shared
+  cluster:
+    server: https://staging.example.com
+    certificate-authority: certs/ca.crt
+- name: other
+  cluster:
+    server: https://dev.example.com
+contexts:
+- name: dev
+  context: {cluster: other, user: admin}
+- name: staging-admin
+  context: {cluster: shared, user: admin, namespace: apps}
+users:
+- name: admin
+  user:
+    client-certificate: certs/admin.crt
+    client-key: /abs/admin.key
+`
+
+func TestMerge(t *testing.T) {
+	home := filepath.Join(t.TempDir(), ".kube")
+	if err := os.MkdirAll(home, 0755); err != nil {
+		t.Fatal(err)
+	}
+	source := filepath.Join(home, "config")
+	if err := os.WriteFile(source, []byte(external), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	kc, err := New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(kc.Path())
+
+	if err := kc.AddFile("staging", source, "staging-admin"); err != nil {
+		t.Fatal(err)
+	}
+	if err := kc.Add("dev", []byte(external), ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := kc.Add("missing", []byte(external), "prod"); err == nil {
+		t.Error("expected an error for a missing context")
+	}
+	if err := kc.Use("dev"); err != nil {
+		t.Fatal(err)
+	}
+	if got := kc.Contexts(); !slices.Equal(got, []string{"staging", "dev"}) {
+		t.Errorf("Contexts() = %v", got)
+	}
+
+	data, err := os.ReadFile(kc.Path())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var merged file
+	if err := yaml.Unmarshal(data, &merged); err != nil {
+		t.Fatal(err)
+	}
+	if merged.CurrentContext != "dev" {
+		t.Errorf("current-context = %s", merged.CurrentContext)
+	}
+	staging, _ := find(merged.Clusters, "staging")
+	if staging.Cluster["server"] != "https://staging.example.com" || staging.Cluster["certificate-authority"] != filepath.Join(home, "certs/ca.crt") {
+		t.Errorf("staging cluster = %v", staging.Cluster)
+	}
+	user, _ := find(merged.Users, "staging")
+	if user.User["client-certificate"] != filepath.Join(home, "certs/admin.crt") || user.User["client-key"] != "/abs/admin.key" {
+		t.Errorf("staging user = %v", user.User)
+	}
+	ctx, _ := find(merged.Contexts, "staging")
+	if ctx.Context["cluster"] != "staging" || ctx.Context["user"] != "staging" || ctx.Context["namespace"] != "apps" {
+		t.Errorf("staging context = %v", ctx.Context)
+	}
+	dev, _ := find(merged.Clusters, "dev")
+	if dev.Cluster["server"] != "https://dev.example.com" {
+		t.Errorf("dev cluster = %v", dev.Cluster)
+	}
+	if strings.Contains(string(data), "name: admin") {
+		t.Errorf("source names leaked into the merged kubeconfig:\n%s", data)
+	}
+}
@@ -3,7 +3,6 @@ package kubernetes
 import (
 	"context"
 	"fmt"
-	"net"
 	"net/http"
 	"net/url"
 
@@ -12,6 +11,8 @@ import (
 	"k8s.io/client-go/tools/clientcmd"
 	"k8s.io/client-go/tools/portforward"
 	"k8s.io/client-go/transport/spdy"
+
+	"github.com/flanksource/commons-test/ports"
 )
 
 // portForwardPod sets up port forwarding to a pod matching the given label selector.
@@ -29,14 +30,6 @@ func PortForwardPod(ctx context.Context, k8s kubernetes.Interface, kubeconfig, n
 	}
 	podName := pods.Items[0].Name
 
-	// Get a free local port
-	listener, err := net.Listen("tcp", "localhost:0")
-	if err != nil {
-		return 0, nil, fmt.Errorf("failed to get free port: %w", err)
-	}
-	localPort := listener.Addr().(*net.TCPAddr).Port
-	listener.Close()
-
 	// Build rest config from kubeconfig
 	restConfig, err := clientcmd.BuildConfigFromFlags("", kubeconfig)
 	if err != nil {
@@ -62,10 +55,15 @@ func PortForwardPod(ctx context.Context, k8s kubernetes.Interface, kubeconfig, n
 	stopChan := make(chan struct{}, 1)
 	readyChan := make(chan struct{})
 
-	// Create port forwarder
-	ports := []string{fmt.Sprintf("%d:%d", localPort, remotePort)}
-	pf, err := portforward.New(dialer, ports, stopChan, readyChan, nil, nil)
+	// Lease a local port, released once forwarding stops
+	localPort, err := ports.Get()
+	if err != nil {
+		return 0, nil, err
+	}
+	forwards := []string{fmt.Sprintf("%d:%d", localPort, remotePort)}
+	pf, err := portforward.New(dialer, forwards, stopChan, readyChan, nil, nil)
 	if err != nil {
+		ports.Release(localPort)
 		return 0, nil, fmt.Errorf("failed to create port forwarder: %w", err)
 	}
 
@@ -73,6 +71,7 @@ func PortForwardPod(ctx context.Context, k8s kubernetes.Interface, kubeconfig, n
 	errChan := make(chan error, 1)
 	go func() {
 		errChan <- pf.ForwardPorts()
+		ports.Release(localPort)
 	}()
 
 	// Wait for port forward to be ready or error
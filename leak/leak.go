@@ -0,0 +1,200 @@
+// Package leak catches resources that a suite leaves behind after cleanup, so slow leaks in shared CI
+// clusters surface in the run that causes them. A snapshot taken before the suite is compared with the
+// state after cleanup:
+//
+//	func TestMain(m *testing.M) { os.Exit(leak.Main(m.Run)) }
+//
+// Namespaces, containers and networks are matched on the run label, helm releases on the namespaces the
+// run installed into and temp files on the TempPrefixes commons-test creates them with, which include the
+// run ID.
+package leak
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/flanksource/commons/logger"
+
+	"github.com/flanksource/commons-test/cleanup"
+	"github.com/flanksource/commons-test/command"
+	"github.com/flanksource/commons-test/config"
+	"github.com/flanksource/commons-test/diagnostics"
+	"github.com/flanksource/commons-test/names"
+)
+
+// Kind is the type of a leaked resource
+type Kind string
+
+const (
+	Namespace Kind = "namespace"
+	Release   Kind = "helm release"
+	Container Kind = "container"
+	Network   Kind = "network"
+	TempFile  Kind = "temp file"
+)
+
+// Modes of config.LeakCheck
+const (
+	Report = "report"
+	Fail   = "fail"
+)
+
+// CommandTimeout bounds each command listing resources
+var CommandTimeout = 30 * time.Second
+
+// TempPrefixes are the names.TempPrefix bases of the temp files and directories commons-test creates in
+// os.TempDir. Only those of the current run are checked, the mount directories of reused containers are
+// kept deliberately and don't include the run ID.
+var TempPrefixes = []string{
+	"fixture", "gitops", "chaos-partition", "kind", "activemq-data", "activemq-conf", "commons-test",
+	"registry-docker-config", "kubeconfig",
+}
+
+// Resource identifies a namespace, release, container, network or temp file
+type Resource struct {
+	Kind Kind
+	Name string
+}
+
+func (r Resource) String() string {
+	return fmt.Sprintf("%s %s", r.Kind, r.Name)
+}
+
+// Snapshot is the set of resources that existed at a point in time
+type Snapshot struct {
+	resources map[Resource]bool
+}
+
+// Take lists the resources of the current run. Sources that are unavailable, e.g. kubectl without a
+// cluster, are skipped.
+func Take(ctx context.Context) *Snapshot {
+	runner := command.NewCommandRunner(false).WithTimeout(CommandTimeout)
+	selector := names.RunLabel + "=" + names.RunID()
+	s := &Snapshot{resources: map[Resource]bool{}}
+
+	list := func(kind Kind, name string, args ...string) []string {
+		result := runner.RunCommandQuietCtx(ctx, name, args...)
+		if result.Err != nil {
+			logger.Debugf("Skipping %s leak check: %v", kind, result.Err)
+			return nil
+		}
+		return result.Lines()
+	}
+
+	for _, ns := range list(Namespace, "kubectl", "get", "namespaces", "-l", selector, "-o", "name") {
+		s.add(Namespace, strings.TrimPrefix(ns, "namespace/"))
+	}
+	for _, ns := range diagnostics.Namespaces() {
+		output := strings.Join(list(Release, "helm", "list", "-n", ns, "-o", "json"), "\n")
+		var releases []struct {
+			Name string `json:"name"`
+		}
+		if err := json.Unmarshal([]byte(output), &releases); err == nil {
+			for _, release := range releases {
+				s.add(Release, ns+"/"+release.Name)
+			}
+		}
+	}
+	for _, c := range list(Container, "docker", "ps", "-a", "--filter", "label="+selector, "--format", "{{.Names}}") {
+		s.add(Container, c)
+	}
+	for _, n := range list(Network, "docker", "network", "ls", "--filter", "label="+selector, "--format", "{{.Name}}") {
+		s.add(Network, n)
+	}
+	for _, prefix := range TempPrefixes {
+		matches, _ := filepath.Glob(filepath.Join(os.TempDir(), names.TempPrefix(prefix)+"*"))
+		for _, path := range matches {
+			s.add(TempFile, path)
+		}
+	}
+	return s
+}
+
+func (s *Snapshot) add(kind Kind, name string) {
+	if name = strings.TrimSpace(name); name != "" {
+		s.resources[Resource{Kind: kind, Name: name}] = true
+	}
+}
+
+// Resources returns the resources in the snapshot, sorted by kind and name
+func (s *Snapshot) Resources() []Resource {
+	var resources []Resource
+	for r := range s.resources {
+		resources = append(resources, r)
+	}
+	slices.SortFunc(resources, func(a, b Resource) int {
+		if c := strings.Compare(string(a.Kind), string(b.Kind)); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Name, b.Name)
+	})
+	return resources
+}
+
+// Since returns the resources in s that were not in before
+func (s *Snapshot) Since(before *Snapshot) []Resource {
+	var leaked []Resource
+	for _, r := range s.Resources() {
+		if before == nil || !before.resources[r] {
+			leaked = append(leaked, r)
+		}
+	}
+	return leaked
+}
+
+// LeakError lists the resources left behind
+type LeakError struct {
+	Resources []Resource
+}
+
+func (e *LeakError) Error() string {
+	var lines []string
+	for _, r := range e.Resources {
+		lines = append(lines, "  "+r.String())
+	}
+	return fmt.Sprintf("%d resources leaked after cleanup:\n%s", len(e.Resources), strings.Join(lines, "\n"))
+}
+
+// Check compares the current resources with before, logging the leaks and returning a LeakError when
+// config.LeakCheck is fail. It does nothing when the leak check is disabled.
+func Check(ctx context.Context, before *Snapshot) error {
+	mode := config.Get().LeakCheck
+	if mode == "" || cleanup.Keep() {
+		return nil
+	}
+	leaked := Take(ctx).Since(before)
+	if len(leaked) == 0 {
+		return nil
+	}
+	err := &LeakError{Resources: leaked}
+	if mode != Fail {
+		logger.Warnf("%v", err)
+		return nil
+	}
+	return err
+}
+
+// Main takes a snapshot, runs the tests and the registered cleanups with cleanup.Main and then checks for
+// leaks, failing the run when config.LeakCheck is fail
+func Main(run func() int) int {
+	var before *Snapshot
+	if config.Get().LeakCheck != "" {
+		before = Take(context.Background())
+	}
+	code := cleanup.Main(run)
+	ctx, cancel := context.WithTimeout(context.Background(), cleanup.Timeout)
+	defer cancel()
+	if err := Check(ctx, before); err != nil {
+		logger.Errorf("%v", err)
+		if code == 0 {
+			code = 1
+		}
+	}
+	return code
+}
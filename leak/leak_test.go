@@ -0,0 +1,35 @@
+package leak
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/flanksource/commons-test/names"
+)
+
+func TestTempFileLeak(t *testing.T) {
+	t.Setenv("TMPDIR", t.TempDir())
+	existing := filepath.Join(os.TempDir(), names.TempPrefix("fixture")+"existing")
+	if err := os.Mkdir(existing, 0755); err != nil {
+		t.Fatal(err)
+	}
+	before := Take(context.Background())
+
+	leaked := filepath.Join(os.TempDir(), names.TempPrefix("gitops")+"123.yaml")
+	if err := os.WriteFile(leaked, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	// Files of other runs and the mount directories kept for reused containers are not leaks of this run
+	for _, name := range []string{"unrelated.txt", "gitops-otherrun-123.yaml", "commons-test-postgres-conf"} {
+		if err := os.WriteFile(filepath.Join(os.TempDir(), name), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	resources := Take(context.Background()).Since(before)
+	if len(resources) != 1 || resources[0] != (Resource{Kind: TempFile, Name: leaked}) {
+		t.Errorf("expected only %s to leak, got %v", leaked, resources)
+	}
+}
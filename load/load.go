@@ -0,0 +1,245 @@
+// Package load runs small HTTP load tests against port-forwarded pods and services or container ports, so
+// performance smoke tests can run inside the same suites as functional ones:
+//
+//	result, err := load.HTTP(url).WithRate(50).WithDuration(30 * time.Second).Run(ctx)
+//	Expect(result.ErrorRate()).To(BeNumerically("<", 0.01))
+//	Expect(result.P99).To(BeNumerically("<", 500*time.Millisecond))
+package load
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Generator is a fluent HTTP load generator
+type Generator struct {
+	url         string
+	method      string
+	header      http.Header
+	body        []byte
+	rate        float64
+	duration    time.Duration
+	concurrency int
+	client      *http.Client
+}
+
+// HTTP returns a generator sending GET requests to url as fast as 10 workers can for 10 seconds
+func HTTP(url string) *Generator {
+	return &Generator{
+		url:         url,
+		method:      http.MethodGet,
+		header:      http.Header{},
+		duration:    10 * time.Second,
+		concurrency: 10,
+		client:      &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+// WithMethod sets the request method
+func (g *Generator) WithMethod(method string) *Generator {
+	g.method = method
+	return g
+}
+
+// WithHeader adds a request header
+func (g *Generator) WithHeader(key, value string) *Generator {
+	g.header.Add(key, value)
+	return g
+}
+
+// WithBody sets the request body
+func (g *Generator) WithBody(body []byte) *Generator {
+	g.body = body
+	return g
+}
+
+// WithRate limits the requests per second across every worker, 0 is unlimited
+func (g *Generator) WithRate(perSecond float64) *Generator {
+	g.rate = perSecond
+	return g
+}
+
+// WithDuration sets how long requests are sent for
+func (g *Generator) WithDuration(duration time.Duration) *Generator {
+	g.duration = duration
+	return g
+}
+
+// WithConcurrency sets the number of workers sending requests
+func (g *Generator) WithConcurrency(workers int) *Generator {
+	g.concurrency = max(workers, 1)
+	return g
+}
+
+// WithTimeout sets the timeout of each request, 5s by default
+func (g *Generator) WithTimeout(timeout time.Duration) *Generator {
+	g.client.Timeout = timeout
+	return g
+}
+
+// WithClient replaces the HTTP client, e.g. with tls.CA.HTTPClient
+func (g *Generator) WithClient(client *http.Client) *Generator {
+	g.client = client
+	return g
+}
+
+// Result summarizes a load test. Requests that failed or returned a 5xx status count as errors.
+type Result struct {
+	Requests    int
+	Errors      int
+	StatusCodes map[int]int
+	Duration    time.Duration
+	// Latency percentiles of every request, including failed ones
+	Mean, P50, P90, P99, Max time.Duration
+	// LastError is the error of the last failed request
+	LastError error
+}
+
+// ErrorRate returns the fraction of requests that failed
+func (r *Result) ErrorRate() float64 {
+	if r.Requests == 0 {
+		return 0
+	}
+	return float64(r.Errors) / float64(r.Requests)
+}
+
+// Throughput returns the requests per second
+func (r *Result) Throughput() float64 {
+	if r.Duration == 0 {
+		return 0
+	}
+	return float64(r.Requests) / r.Duration.Seconds()
+}
+
+func (r *Result) String() string {
+	var codes []string
+	for _, code := range slices.Sorted(maps.Keys(r.StatusCodes)) {
+		codes = append(codes, fmt.Sprintf("%d=%d", code, r.StatusCodes[code]))
+	}
+	return fmt.Sprintf("%d requests in %v (%.1f/s), %.2f%% errors, p50=%v p90=%v p99=%v max=%v, status %s",
+		r.Requests, r.Duration.Round(time.Millisecond), r.Throughput(), r.ErrorRate()*100,
+		r.P50, r.P90, r.P99, r.Max, strings.Join(codes, " "))
+}
+
+type sample struct {
+	latency time.Duration
+	status  int
+	err     error
+}
+
+// Run sends requests until the duration elapses or ctx is done. Failed requests are counted in the result,
+// an error is only returned when the request is invalid.
+func (g *Generator) Run(ctx context.Context) (*Result, error) {
+	if _, err := http.NewRequest(g.method, g.url, nil); err != nil {
+		return nil, fmt.Errorf("invalid load test of %s: %w", g.url, err)
+	}
+	ctx, cancel := context.WithTimeout(ctx, g.duration)
+	defer cancel()
+
+	// Workers take a token per request, closed when the duration elapses
+	tokens := make(chan struct{})
+	go func() {
+		defer close(tokens)
+		var ticker <-chan time.Time
+		if g.rate > 0 {
+			t := time.NewTicker(time.Duration(float64(time.Second) / g.rate))
+			defer t.Stop()
+			ticker = t.C
+		}
+		for {
+			if ticker != nil {
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker:
+				}
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case tokens <- struct{}{}:
+			}
+		}
+	}()
+
+	var mu sync.Mutex
+	var samples []sample
+	var wg sync.WaitGroup
+	start := time.Now()
+	for range g.concurrency {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range tokens {
+				s := g.send(ctx)
+				// Requests cut short by the end of the test are not failures of the target
+				if s.err != nil && ctx.Err() != nil {
+					continue
+				}
+				mu.Lock()
+				samples = append(samples, s)
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	return summarize(samples, time.Since(start)), nil
+}
+
+func (g *Generator) send(ctx context.Context) sample {
+	start := time.Now()
+	req, err := http.NewRequestWithContext(ctx, g.method, g.url, bytes.NewReader(g.body))
+	if err != nil {
+		return sample{err: err}
+	}
+	req.Header = g.header.Clone()
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return sample{latency: time.Since(start), err: err}
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	s := sample{latency: time.Since(start), status: resp.StatusCode}
+	if resp.StatusCode >= 500 {
+		s.err = fmt.Errorf("%s %s returned %d", g.method, g.url, resp.StatusCode)
+	}
+	return s
+}
+
+func summarize(samples []sample, duration time.Duration) *Result {
+	result := &Result{Requests: len(samples), StatusCodes: map[int]int{}, Duration: duration}
+	if len(samples) == 0 {
+		return result
+	}
+	latencies := make([]time.Duration, 0, len(samples))
+	var total time.Duration
+	for _, s := range samples {
+		latencies = append(latencies, s.latency)
+		total += s.latency
+		if s.status != 0 {
+			result.StatusCodes[s.status]++
+		}
+		if s.err != nil {
+			result.Errors++
+			result.LastError = s.err
+		}
+	}
+	slices.Sort(latencies)
+	percentile := func(p float64) time.Duration {
+		return latencies[min(int(p*float64(len(latencies))), len(latencies)-1)]
+	}
+	result.Mean = total / time.Duration(len(samples))
+	result.P50 = percentile(0.50)
+	result.P90 = percentile(0.90)
+	result.P99 = percentile(0.99)
+	result.Max = latencies[len(latencies)-1]
+	return result
+}
@@ -0,0 +1,34 @@
+package load
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRun(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1)%10 == 0 {
+			http.Error(w, "overloaded", http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	result, err := HTTP(server.URL).WithRate(200).WithConcurrency(4).WithDuration(500 * time.Millisecond).Run(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Requests < 50 || result.Requests > 110 {
+		t.Errorf("expected about 100 requests at 200/s for 500ms, got %s", result)
+	}
+	if rate := result.ErrorRate(); rate < 0.05 || rate > 0.15 {
+		t.Errorf("expected an error rate of about 10%%, got %s", result)
+	}
+	if result.P50 > result.P99 || result.P99 > result.Max {
+		t.Errorf("expected ordered percentiles, got %s", result)
+	}
+}
@@ -0,0 +1,38 @@
+package load
+
+import (
+	"github.com/flanksource/commons-test/helm"
+	"github.com/flanksource/commons-test/targets"
+)
+
+// Ports resolves the host port of a container port, implemented by container.Container
+type Ports = targets.Ports
+
+// Pod returns a generator targeting path on port of the pod through a port-forward, the returned function
+// stops the forward
+func Pod(pod *helm.Pod, port int, path string) (*Generator, func(), error) {
+	url, stop, err := targets.Pod(pod, port, path)
+	if err != nil {
+		return nil, nil, err
+	}
+	return HTTP(url), stop, nil
+}
+
+// Service returns a generator targeting path on port of the service through a port-forward, the returned
+// function stops the forward
+func Service(namespace, service string, port int, path string) (*Generator, func(), error) {
+	url, stop, err := targets.Service(namespace, service, port, path)
+	if err != nil {
+		return nil, nil, err
+	}
+	return HTTP(url), stop, nil
+}
+
+// Container returns a generator targeting path on the host port published for port of the container
+func Container(c Ports, port, path string) (*Generator, error) {
+	url, err := targets.Container(c, port, path)
+	if err != nil {
+		return nil, err
+	}
+	return HTTP(url), nil
+}
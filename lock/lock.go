@@ -0,0 +1,88 @@
+// Package lock serializes cluster-mutating steps (CRD installs, ingress setup) across the test processes
+// and packages sharing one kind cluster, using a lock file per name in the configured cache directory.
+package lock
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/flanksource/commons-test/config"
+	"github.com/flanksource/commons-test/names"
+)
+
+// PollInterval is how often Lock retries a held lock
+var PollInterval = 100 * time.Millisecond
+
+// Lock is an exclusive lock shared by every process on the host
+type Lock struct {
+	name string
+	path string
+	file *os.File
+}
+
+// New returns the lock called name, e.g. the name of a shared cluster
+func New(name string) *Lock {
+	return &Lock{
+		name: name,
+		path: filepath.Join(config.Get().CacheDir, "locks", names.Sanitize(name)+".lock"),
+	}
+}
+
+// TryLock acquires the lock without waiting, returning false when another holder has it
+func (l *Lock) TryLock() (bool, error) {
+	if l.file != nil {
+		return false, fmt.Errorf("lock %s is already held", l.name)
+	}
+	if err := os.MkdirAll(filepath.Dir(l.path), 0755); err != nil {
+		return false, fmt.Errorf("failed to create lock dir: %w", err)
+	}
+	file, ok, err := tryLock(l.path)
+	if err != nil {
+		return false, fmt.Errorf("failed to lock %s: %w", l.name, err)
+	}
+	l.file = file
+	return ok, nil
+}
+
+// Lock waits until the lock is acquired or ctx is done
+func (l *Lock) Lock(ctx context.Context) error {
+	ticker := time.NewTicker(PollInterval)
+	defer ticker.Stop()
+	for {
+		ok, err := l.TryLock()
+		if err != nil || ok {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("timed out waiting for lock %s: %w", l.name, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+// Unlock releases the lock
+func (l *Lock) Unlock() error {
+	if l.file == nil {
+		return nil
+	}
+	err := unlock(l.file, l.path)
+	l.file = nil
+	if err != nil {
+		return fmt.Errorf("failed to unlock %s: %w", l.name, err)
+	}
+	return nil
+}
+
+// With runs fn while holding the lock called name
+func With(ctx context.Context, name string, fn func() error) error {
+	l := New(name)
+	if err := l.Lock(ctx); err != nil {
+		return err
+	}
+	defer l.Unlock()
+	return fn()
+}
@@ -0,0 +1,68 @@
+package lock
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/flanksource/commons-test/config"
+)
+
+func TestLock(t *testing.T) {
+	c := config.Default()
+	c.CacheDir = t.TempDir()
+	config.Set(c)
+	defer config.Set(nil)
+
+	held := New("shared cluster")
+	if ok, err := held.TryLock(); err != nil || !ok {
+		t.Fatalf("expected to acquire the lock, got %v %v", ok, err)
+	}
+
+	other := New("shared cluster")
+	if ok, err := other.TryLock(); err != nil || ok {
+		t.Fatalf("expected the held lock to be unavailable, got %v %v", ok, err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := other.Lock(ctx); err == nil {
+		t.Fatal("expected Lock to time out while the lock is held")
+	}
+
+	if err := held.Unlock(); err != nil {
+		t.Fatal(err)
+	}
+	ran := false
+	if err := With(context.Background(), "shared cluster", func() error { ran = true; return nil }); err != nil || !ran {
+		t.Errorf("expected fn to run once the lock is released, got %v", err)
+	}
+}
+
+func TestRefs(t *testing.T) {
+	c := config.Default()
+	c.CacheDir = t.TempDir()
+	config.Set(c)
+	defer config.Set(nil)
+
+	// A process that exited without calling Unref is not counted
+	path := filepath.Join(c.CacheDir, "locks", "shared.refs")
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("999999999\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if n, err := Ref("shared"); err != nil || n != 1 {
+		t.Fatalf("expected 1 reference, got %d %v", n, err)
+	}
+	if n, err := Ref("shared"); err != nil || n != 1 {
+		t.Fatalf("expected a second Ref of the same process to be ignored, got %d %v", n, err)
+	}
+	if n, err := Unref("shared"); err != nil || n != 0 {
+		t.Fatalf("expected no references left, got %d %v", n, err)
+	}
+}
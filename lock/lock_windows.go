@@ -0,0 +1,35 @@
+//go:build windows
+
+package lock
+
+import (
+	"errors"
+	"os"
+)
+
+// tryLock creates path exclusively, a lock left by a crashed process must be removed by hand
+func tryLock(path string) (*os.File, bool, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_RDWR, 0644)
+	if errors.Is(err, os.ErrExist) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return file, true, nil
+}
+
+func unlock(file *os.File, path string) error {
+	file.Close()
+	return os.Remove(path)
+}
+
+// processAlive reports whether pid is a running process
+func processAlive(pid int) bool {
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	p.Release()
+	return true
+}
@@ -0,0 +1,62 @@
+package lock
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/flanksource/commons-test/config"
+	"github.com/flanksource/commons-test/names"
+)
+
+// Ref records that the current process uses the resource guarded by the lock called name, e.g. a shared
+// cluster, returning the number of running processes using it. Call it while holding the lock.
+func Ref(name string) (int, error) {
+	return updateRefs(name, func(pids []int) []int {
+		if !slices.Contains(pids, os.Getpid()) {
+			pids = append(pids, os.Getpid())
+		}
+		return pids
+	})
+}
+
+// Unref removes the reference of the current process made with Ref, returning the number of running
+// processes still using the resource. Call it while holding the lock.
+func Unref(name string) (int, error) {
+	return updateRefs(name, func(pids []int) []int {
+		return slices.DeleteFunc(pids, func(pid int) bool { return pid == os.Getpid() })
+	})
+}
+
+// updateRefs rewrites the pids in the refs file of name, dropping processes that have exited without
+// calling Unref
+func updateRefs(name string, fn func(pids []int) []int) (int, error) {
+	path := filepath.Join(config.Get().CacheDir, "locks", names.Sanitize(name)+".refs")
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return 0, fmt.Errorf("failed to create lock dir: %w", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return 0, fmt.Errorf("failed to read references of %s: %w", name, err)
+	}
+
+	var pids []int
+	for _, line := range strings.Fields(string(data)) {
+		if pid, err := strconv.Atoi(line); err == nil && processAlive(pid) {
+			pids = append(pids, pid)
+		}
+	}
+	pids = fn(pids)
+
+	var out strings.Builder
+	for _, pid := range pids {
+		fmt.Fprintln(&out, pid)
+	}
+	if err := os.WriteFile(path, []byte(out.String()), 0644); err != nil {
+		return 0, fmt.Errorf("failed to write references of %s: %w", name, err)
+	}
+	return len(pids), nil
+}
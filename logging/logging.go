@@ -0,0 +1,106 @@
+// Package logging switches the output of every module (runner output, container and helm logs) between
+// the default pretty ANSI mode and structured JSON lines carrying module and resource fields, so CI
+// log processors can index and filter them.
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/flanksource/commons/logger"
+)
+
+// FormatEnv is the environment variable selecting the log format, LOG_FORMAT=json for JSON lines
+const FormatEnv = "LOG_FORMAT"
+
+var jsonLines atomic.Bool
+
+func init() {
+	if os.Getenv(FormatEnv) == "json" {
+		UseJSON()
+	}
+}
+
+// UseJSON switches every module to JSON lines. Call it before creating runners, containers or charts,
+// loggers created earlier keep their format.
+func UseJSON() {
+	if jsonLines.Swap(true) {
+		return
+	}
+	logger.Configure(logger.Flags{JsonLogs: true})
+}
+
+// JSON returns true when modules log JSON lines
+func JSON() bool {
+	return jsonLines.Load()
+}
+
+// output resolves the commons logger output on every write, so JSON lines follow logger.SetOutput
+type output struct{}
+
+func (output) Write(p []byte) (int, error) {
+	return logger.GetOutput().Write(p)
+}
+
+var handler = slog.NewJSONHandler(output{}, &slog.HandlerOptions{Level: slog.LevelDebug})
+
+// Log writes a JSON line for module (e.g. "command") and resource (e.g. "kubectl"), attrs being
+// alternating keys and values
+func Log(level slog.Level, module, resource, msg string, attrs ...any) {
+	args := []any{"module", module, "resource", resource}
+	for _, attr := range attrs {
+		if s, ok := attr.(string); ok {
+			attr = Redact(s)
+		}
+		args = append(args, attr)
+	}
+	slog.New(handler).Log(context.Background(), level, Redact(msg), args...)
+}
+
+// MinMaskLength is the length below which Mask ignores values, so short values don't mask common words
+const MinMaskLength = 4
+
+var masked struct {
+	sync.RWMutex
+	values   []string
+	replacer *strings.Replacer
+}
+
+// Mask replaces values with *** in every command line, command output and JSON line logged by this module,
+// e.g. for passwords resolved by the secrets package
+func Mask(values ...string) {
+	masked.Lock()
+	defer masked.Unlock()
+	for _, value := range values {
+		if len(value) >= MinMaskLength && !slices.Contains(masked.values, value) {
+			masked.values = append(masked.values, value, "***")
+		}
+	}
+	if len(masked.values) > 0 {
+		masked.replacer = strings.NewReplacer(masked.values...)
+	}
+}
+
+// Redact returns s with every masked value replaced by ***
+func Redact(s string) string {
+	masked.RLock()
+	defer masked.RUnlock()
+	if masked.replacer == nil {
+		return s
+	}
+	return masked.replacer.Replace(s)
+}
+
+// Logger returns the commons logger for module, adding module and resource fields in JSON mode
+func Logger(module, resource string) logger.Logger {
+	l := logger.GetLogger(module).Named(resource)
+	if JSON() {
+		return l.WithValues("module", module, "resource", resource)
+	}
+	return l
+}
@@ -0,0 +1,36 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"github.com/flanksource/commons/logger"
+)
+
+func TestLog(t *testing.T) {
+	var buf bytes.Buffer
+	old := logger.GetOutput()
+	logger.SetOutput(&buf)
+	defer logger.SetOutput(old)
+
+	Log(slog.LevelInfo, "command", "kubectl", "executing", "command", "kubectl get pods")
+
+	var line map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &line); err != nil {
+		t.Fatalf("expected a JSON line, got %q: %v", buf.String(), err)
+	}
+	for key, want := range map[string]string{"msg": "executing", "module": "command", "resource": "kubectl", "command": "kubectl get pods"} {
+		if line[key] != want {
+			t.Errorf("expected %s=%q, got %v", key, want, line[key])
+		}
+	}
+}
+
+func TestRedact(t *testing.T) {
+	Mask("s3cr3t-password", "abc")
+	if got := Redact("sqlcmd -P s3cr3t-password -Q 'select abc'"); got != "sqlcmd -P *** -Q 'select abc'" {
+		t.Errorf("expected the password to be masked, got %q", got)
+	}
+}
@@ -0,0 +1,262 @@
+// Package logs tails the logs of several pods and containers concurrently into one searchable buffer, so
+// tests can wait for or forbid log lines across every component of the system under test:
+//
+//	agg := logs.New()
+//	defer agg.Stop()
+//	agg.Pods("default", "app=api")
+//	agg.Container("worker")
+//	_, err := agg.EventuallyContains(ctx, `consumed \d+ messages`, time.Minute)
+//	err = agg.NeverContains("panic")
+//
+// suite.DumpLogs writes the aggregate to the artifacts of failed specs.
+package logs
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/flanksource/commons-test/cleanup"
+)
+
+// MaxLines bounds the lines kept by an Aggregator, the oldest are dropped first
+var MaxLines = 100000
+
+// Line is a log line and the pod, container or command it came from
+type Line struct {
+	Source string
+	Time   time.Time
+	Text   string
+}
+
+func (l Line) String() string {
+	return fmt.Sprintf("[%s] %s", l.Source, l.Text)
+}
+
+// Aggregator collects the lines of every source it follows until stopped
+type Aggregator struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	mu         sync.Mutex
+	lines      []Line
+	dropped    int
+	changed    chan struct{}
+	errs       []error
+	unregister func()
+}
+
+// New returns an empty aggregator, stopped by Stop or cleanup.Run
+func New() *Aggregator {
+	ctx, cancel := context.WithCancel(context.Background())
+	a := &Aggregator{ctx: ctx, cancel: cancel, changed: make(chan struct{})}
+	a.unregister = cleanup.Register("log aggregator", func(context.Context) error {
+		a.stop()
+		return nil
+	})
+	return a
+}
+
+// Add appends a line from source
+func (a *Aggregator) Add(source, text string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.lines = append(a.lines, Line{Source: source, Time: time.Now(), Text: text})
+	if over := len(a.lines) - MaxLines; over > 0 {
+		a.lines = append(a.lines[:0], a.lines[over:]...)
+		a.dropped += over
+	}
+	close(a.changed)
+	a.changed = make(chan struct{})
+}
+
+// Follow adds each line read from r as source until r is exhausted, closing it when the aggregator stops
+func (a *Aggregator) Follow(source string, r io.ReadCloser) {
+	a.wg.Add(1)
+	stop := context.AfterFunc(a.ctx, func() { r.Close() })
+	go func() {
+		defer a.wg.Done()
+		defer stop()
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			if a.ctx.Err() != nil {
+				return
+			}
+			a.Add(source, strings.TrimSuffix(scanner.Text(), "\r"))
+		}
+		if err := scanner.Err(); err != nil {
+			a.fail(fmt.Errorf("failed to read logs of %s: %w", source, err))
+		}
+	}()
+}
+
+// fail records an error of a source, unless the aggregator was stopped
+func (a *Aggregator) fail(err error) {
+	if a.ctx.Err() != nil {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.errs = append(a.errs, err)
+}
+
+// Err returns the errors of the sources that ended before Stop, e.g. a pod that could not be found
+func (a *Aggregator) Err() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return errors.Join(a.errs...)
+}
+
+// Stop stops following every source and waits for them to end
+func (a *Aggregator) Stop() {
+	if a.unregister != nil {
+		a.unregister()
+		a.unregister = nil
+	}
+	a.stop()
+}
+
+func (a *Aggregator) stop() {
+	a.cancel()
+	a.wg.Wait()
+}
+
+// Lines returns the lines collected so far, of every source when none are given
+func (a *Aggregator) Lines(sources ...string) []Line {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	var lines []Line
+	for _, line := range a.lines {
+		if len(sources) == 0 || matchesSource(line.Source, sources) {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// matchesSource returns true when source is one of sources, or a pod container of one of them
+func matchesSource(source string, sources []string) bool {
+	for _, s := range sources {
+		if source == s || strings.HasPrefix(source, s+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// Find returns the lines matching the regular expression pattern
+func (a *Aggregator) Find(pattern string) ([]Line, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern %s: %w", pattern, err)
+	}
+	return a.find(re, 0), nil
+}
+
+func (a *Aggregator) find(re *regexp.Regexp, from int) []Line {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	var lines []Line
+	for _, line := range a.lines[max(from-a.dropped, 0):] {
+		if re.MatchString(line.Text) {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// Contains returns true when a line matches the regular expression pattern
+func (a *Aggregator) Contains(pattern string) bool {
+	lines, err := a.Find(pattern)
+	return err == nil && len(lines) > 0
+}
+
+// EventuallyContains waits for a line matching the regular expression pattern, including the lines
+// already collected, and returns the first match
+func (a *Aggregator) EventuallyContains(ctx context.Context, pattern string, timeout time.Duration) (Line, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return Line{}, fmt.Errorf("invalid pattern %s: %w", pattern, err)
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	from := 0
+	for {
+		a.mu.Lock()
+		changed, total := a.changed, a.dropped+len(a.lines)
+		a.mu.Unlock()
+		if lines := a.find(re, from); len(lines) > 0 {
+			return lines[0], nil
+		}
+		from = total
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return Line{}, fmt.Errorf("no log line matched %q within %v: %w", pattern, timeout, errors.Join(ctx.Err(), a.Err()))
+		}
+	}
+}
+
+// NeverContains returns an error listing the lines collected so far that match the regular expression
+// pattern, e.g. panic
+func (a *Aggregator) NeverContains(pattern string) error {
+	lines, err := a.Find(pattern)
+	if err != nil {
+		return err
+	}
+	if len(lines) == 0 {
+		return nil
+	}
+	var matches []string
+	for _, line := range lines {
+		matches = append(matches, line.String())
+	}
+	return fmt.Errorf("%d log lines matched %q:\n  %s", len(lines), pattern, strings.Join(matches, "\n  "))
+}
+
+// String returns every line collected, as written by WriteTo
+func (a *Aggregator) String() string {
+	var b strings.Builder
+	_, _ = a.WriteTo(&b)
+	return b.String()
+}
+
+// WriteTo writes every line collected, prefixed with its time and source
+func (a *Aggregator) WriteTo(w io.Writer) (int64, error) {
+	var total int64
+	for _, line := range a.Lines() {
+		n, err := fmt.Fprintf(w, "%s %s\n", line.Time.Format("15:04:05.000"), line)
+		total += int64(n)
+		if err != nil {
+			return total, err
+		}
+	}
+	return total, nil
+}
+
+// WriteFile writes every line collected to path
+func (a *Aggregator) WriteFile(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create log directory: %w", err)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", path, err)
+	}
+	defer f.Close()
+	if _, err := a.WriteTo(f); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return f.Close()
+}
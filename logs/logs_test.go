@@ -0,0 +1,66 @@
+package logs
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAggregator(t *testing.T) {
+	agg := New()
+	defer agg.Stop()
+
+	r, w := io.Pipe()
+	agg.Follow("default/api/app", r)
+	agg.Add("worker", "started")
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		_, _ = io.WriteString(w, "listening on :8080\nconsumed 12 messages\n")
+	}()
+	line, err := agg.EventuallyContains(context.Background(), `consumed \d+ messages`, 5*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if line.Source != "default/api/app" {
+		t.Errorf("source = %s", line.Source)
+	}
+	if _, err := agg.EventuallyContains(context.Background(), "never logged", 100*time.Millisecond); err == nil {
+		t.Error("expected a timeout")
+	}
+
+	if err := agg.NeverContains("panic"); err != nil {
+		t.Error(err)
+	}
+	agg.Add("worker", "panic: runtime error")
+	if err := agg.NeverContains("panic"); err == nil || !strings.Contains(err.Error(), "[worker] panic: runtime error") {
+		t.Errorf("NeverContains = %v", err)
+	}
+	if lines := agg.Lines("default/api"); len(lines) != 2 {
+		t.Errorf("Lines(default/api) = %v", lines)
+	}
+
+	// Stop closes the pipe, ending the follower
+	agg.Stop()
+	if _, err := w.Write([]byte("late\n")); err == nil {
+		t.Error("expected the reader to be closed")
+	}
+}
+
+func TestMaxLines(t *testing.T) {
+	defer func(n int) { MaxLines = n }(MaxLines)
+	MaxLines = 2
+	agg := New()
+	defer agg.Stop()
+	for _, text := range []string{"a", "b", "c"} {
+		agg.Add("test", text)
+	}
+	if lines := agg.Lines(); len(lines) != 2 || lines[0].Text != "b" {
+		t.Errorf("Lines() = %v", lines)
+	}
+	if _, err := agg.EventuallyContains(context.Background(), "c", time.Second); err != nil {
+		t.Error(err)
+	}
+}
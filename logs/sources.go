@@ -0,0 +1,54 @@
+package logs
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/flanksource/commons-test/command"
+)
+
+// Command follows the stdout and stderr of a long running command, e.g. a log tailer, as source
+func (a *Aggregator) Command(source, name string, args ...string) {
+	a.command(func(line string) { a.Add(source, line) }, source, name, args...)
+}
+
+// Container follows the logs of a docker container (ID or name)
+func (a *Aggregator) Container(container string) {
+	a.Command(container, "docker", "logs", "--follow", "--tail", "all", container)
+}
+
+// Pod follows the logs of every container of a pod, the lines of each container coming from
+// <namespace>/<pod>/<container>
+func (a *Aggregator) Pod(namespace, pod string) {
+	a.kubectlLogs(namespace, "pod/"+pod)
+}
+
+// Pods follows the logs of every container of the pods matching selector when called. Pods created
+// afterwards are not followed.
+func (a *Aggregator) Pods(namespace, selector string) {
+	a.kubectlLogs(namespace, "--selector", selector, "--max-log-requests", "50")
+}
+
+func (a *Aggregator) kubectlLogs(namespace string, target ...string) {
+	args := append([]string{"logs", "--follow", "--all-containers", "--prefix", "--namespace", namespace}, target...)
+	source := namespace + "/" + strings.Join(target, " ")
+	a.command(func(line string) {
+		// Lines are prefixed with [pod/<pod>/<container>]
+		if prefix, text, ok := strings.Cut(line, "] "); ok && strings.HasPrefix(prefix, "[pod/") {
+			a.Add(namespace+"/"+strings.TrimPrefix(prefix, "[pod/"), text)
+			return
+		}
+		a.Add(source, line)
+	}, source, "kubectl", args...)
+}
+
+func (a *Aggregator) command(onLine func(line string), source, name string, args ...string) {
+	a.wg.Add(1)
+	go func() {
+		defer a.wg.Done()
+		result := command.NewCommandRunner(false).OnStdout(onLine).OnStderr(onLine).RunCommandQuietCtx(a.ctx, name, args...)
+		if result.Err != nil {
+			a.fail(fmt.Errorf("failed to follow logs of %s: %w", source, result.Err))
+		}
+	}()
+}
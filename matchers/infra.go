@@ -0,0 +1,133 @@
+package matchers
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/bsm/gomega/gcustom"
+)
+
+// readiness is implemented by helm.Pod, helm.Deployment and helm.StatefulSet
+type readiness interface {
+	IsReady() (bool, error)
+}
+
+// replicas is implemented by helm.Deployment and helm.StatefulSet
+type replicas interface {
+	GetReplicas() (int, error)
+}
+
+// helmStatus is implemented by helm.HelmChart
+type helmStatus interface {
+	Status() (string, error)
+}
+
+type podLogs interface {
+	GetLogs(lines ...int) string
+	Error() error
+}
+
+type containerLogs interface {
+	Logs(ctx context.Context, follow bool) (io.ReadCloser, error)
+}
+
+type healthChecker interface {
+	IsHealthy(ctx context.Context) (bool, error)
+}
+
+// BeReady succeeds when a pod, deployment or statefulset is ready, use it with Eventually to wait for a rollout
+func BeReady() gcustom.CustomGomegaMatcher {
+	return gcustom.MakeMatcher(func(actual readiness) (bool, error) {
+		return actual.IsReady()
+	}, "be ready")
+}
+
+// HaveReplicas succeeds when a deployment or statefulset has exactly n ready replicas
+func HaveReplicas(n int) gcustom.CustomGomegaMatcher {
+	return gcustom.MakeMatcher(func(actual replicas) (bool, error) {
+		ready, err := actual.GetReplicas()
+		if err != nil {
+			return false, err
+		}
+		if ready != n {
+			return false, fmt.Errorf("expected %d ready replicas, got %d", n, ready)
+		}
+		return true, nil
+	}, fmt.Sprintf("have %d ready replicas", n))
+}
+
+// HaveHelmStatus succeeds when a helm release has the status, e.g. "deployed"
+func HaveHelmStatus(status string) gcustom.CustomGomegaMatcher {
+	return gcustom.MakeMatcher(func(actual helmStatus) (bool, error) {
+		out, err := actual.Status()
+		if err != nil {
+			return false, err
+		}
+		observed := helmStatusLine.FindStringSubmatch(out)
+		if observed == nil {
+			return false, fmt.Errorf("no STATUS in helm status output:\n%s", out)
+		}
+		if observed[1] != status {
+			return false, fmt.Errorf("expected helm status %q, got %q", status, observed[1])
+		}
+		return true, nil
+	}, fmt.Sprintf("have helm status %q", status))
+}
+
+var helmStatusLine = regexp.MustCompile(`(?m)^STATUS:\s*(\S+)`)
+
+// ContainLogLine succeeds when a line of the logs matches pattern. Logs can be a string or []byte,
+// an io.Reader, a helm.Pod or a container.Container.
+func ContainLogLine(pattern string) gcustom.CustomGomegaMatcher {
+	re := regexp.MustCompile(pattern)
+	return gcustom.MakeMatcher(func(actual any) (bool, error) {
+		var logs string
+		switch v := actual.(type) {
+		case string:
+			logs = v
+		case []byte:
+			logs = string(v)
+		case io.Reader:
+			data, err := io.ReadAll(v)
+			if err != nil {
+				return false, err
+			}
+			logs = string(data)
+		case podLogs:
+			logs = v.GetLogs()
+			if err := v.Error(); err != nil {
+				return false, err
+			}
+		case containerLogs:
+			r, err := v.Logs(context.Background(), false)
+			if err != nil {
+				return false, err
+			}
+			defer r.Close()
+			data, err := io.ReadAll(r)
+			if err != nil {
+				return false, err
+			}
+			logs = string(data)
+		default:
+			return false, fmt.Errorf("ContainLogLine expects logs, a pod or a container, got %T", actual)
+		}
+
+		for _, line := range strings.Split(logs, "\n") {
+			if re.MatchString(strings.TrimSuffix(line, "\r")) {
+				return true, nil
+			}
+		}
+		return false, nil
+	}, fmt.Sprintf("contain a log line matching %q", pattern))
+}
+
+// BeHealthyMissionControl succeeds when mission-control answers /health
+func BeHealthyMissionControl() gcustom.CustomGomegaMatcher {
+	return gcustom.MakeMatcher(func(actual healthChecker) (bool, error) {
+		return actual.IsHealthy(context.Background())
+	}, "be a healthy mission-control")
+}
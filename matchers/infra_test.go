@@ -0,0 +1,97 @@
+package matchers
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/bsm/gomega/types"
+)
+
+type fakeResource struct {
+	ready    bool
+	replicas int
+	status   string
+	logs     string
+	err      error
+}
+
+func (f fakeResource) IsReady() (bool, error)      { return f.ready, f.err }
+func (f fakeResource) GetReplicas() (int, error)   { return f.replicas, f.err }
+func (f fakeResource) Status() (string, error)     { return f.status, f.err }
+func (f fakeResource) GetLogs(lines ...int) string { return f.logs }
+func (f fakeResource) Error() error                { return f.err }
+func (f fakeResource) IsHealthy(ctx context.Context) (bool, error) {
+	return f.ready, f.err
+}
+
+type fakeContainer struct {
+	logs string
+}
+
+func (f fakeContainer) Logs(ctx context.Context, follow bool) (io.ReadCloser, error) {
+	return io.NopCloser(strings.NewReader(f.logs)), nil
+}
+
+// expectMatch fails unless matcher matches actual as expected, wantErr expects the match to error instead
+func expectMatch(t *testing.T, matcher types.GomegaMatcher, actual any, expected, wantErr bool) {
+	t.Helper()
+	matched, err := matcher.Match(actual)
+	if wantErr {
+		if err == nil {
+			t.Errorf("expected an error matching %v", actual)
+		}
+		return
+	}
+	if err != nil {
+		t.Errorf("unexpected error matching %v: %v", actual, err)
+	}
+	if matched != expected {
+		t.Errorf("expected match of %v to be %v", actual, expected)
+	}
+}
+
+func TestBeReady(t *testing.T) {
+	expectMatch(t, BeReady(), fakeResource{ready: true}, true, false)
+	expectMatch(t, BeReady(), fakeResource{ready: false}, false, false)
+	expectMatch(t, BeReady(), fakeResource{err: errors.New("not found")}, false, true)
+}
+
+func TestHaveReplicas(t *testing.T) {
+	expectMatch(t, HaveReplicas(3), fakeResource{replicas: 3}, true, false)
+	expectMatch(t, HaveReplicas(3), fakeResource{replicas: 1}, false, true)
+	expectMatch(t, HaveReplicas(3), fakeResource{err: errors.New("not found")}, false, true)
+}
+
+func TestHaveHelmStatus(t *testing.T) {
+	deployed := "NAME: app\nLAST DEPLOYED: Mon Jan  1 00:00:00 2024\nSTATUS: deployed\nREVISION: 1\n"
+	expectMatch(t, HaveHelmStatus("deployed"), fakeResource{status: deployed}, true, false)
+	expectMatch(t, HaveHelmStatus("failed"), fakeResource{status: deployed}, false, true)
+	expectMatch(t, HaveHelmStatus("deployed"), fakeResource{status: "NAME: app\n"}, false, true)
+	expectMatch(t, HaveHelmStatus("deployed"), fakeResource{err: errors.New("release not found")}, false, true)
+}
+
+func TestContainLogLine(t *testing.T) {
+	logs := "starting\r\nlistening on :8080\r\n"
+	for _, actual := range []any{
+		logs,
+		[]byte(logs),
+		strings.NewReader(logs),
+		fakeResource{logs: logs},
+		fakeContainer{logs: logs},
+	} {
+		expectMatch(t, ContainLogLine(`^listening on :\d+$`), actual, true, false)
+		expectMatch(t, ContainLogLine(`panic`), actual, false, false)
+	}
+
+	expectMatch(t, ContainLogLine(`listening`), fakeResource{logs: logs, err: errors.New("pod not found")}, false, true)
+	expectMatch(t, ContainLogLine(`listening`), 42, false, true)
+}
+
+func TestBeHealthyMissionControl(t *testing.T) {
+	expectMatch(t, BeHealthyMissionControl(), fakeResource{ready: true}, true, false)
+	expectMatch(t, BeHealthyMissionControl(), fakeResource{ready: false}, false, false)
+	expectMatch(t, BeHealthyMissionControl(), fakeResource{err: errors.New("connection refused")}, false, true)
+}
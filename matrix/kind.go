@@ -0,0 +1,50 @@
+package matrix
+
+import (
+	"maps"
+
+	"github.com/flanksource/commons-db/context"
+
+	"github.com/flanksource/commons-test/helm"
+	"github.com/flanksource/commons-test/kind"
+	"github.com/flanksource/commons-test/names"
+)
+
+func (m *Matrix) kindCluster(nodeImage string) Cluster {
+	return kind.NewKind(names.New("matrix-" + nodeImage)).WithVersion(nodeImage)
+}
+
+func (m *Matrix) helmRelease(c Combination, namespace string) Release {
+	chart := helm.NewHelmChart(context.New(), m.chart).
+		Release(m.releaseName()).
+		Namespace(namespace).
+		Version(c.ChartVersion).
+		Values(deepCopy(m.values)).
+		WaitFor(m.timeout)
+	if m.repository != "" {
+		chart.Repository(m.repository, m.repositoryURL)
+	}
+	if c.AppVersion != "" {
+		chart.SetValue(m.appVersionValue, c.AppVersion)
+	}
+	return chart
+}
+
+func createNamespace(name string) (func(), error) {
+	ns := helm.NewNamespace(name).Create()
+	if err := ns.Error(); err != nil {
+		return nil, err
+	}
+	return func() { ns.Delete() }, nil
+}
+
+// deepCopy copies values so that SetValue does not modify the nested maps shared by every combination
+func deepCopy(values map[string]any) map[string]any {
+	c := maps.Clone(values)
+	for k, v := range c {
+		if nested, ok := v.(map[string]any); ok {
+			c[k] = deepCopy(nested)
+		}
+	}
+	return c
+}
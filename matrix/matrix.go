@@ -0,0 +1,299 @@
+// Package matrix installs a chart across combinations of chart versions, app versions and Kubernetes node
+// images, runs a verification against each and reports which combinations are compatible:
+//
+//	report := matrix.New("flanksource/canary-checker").
+//		Repository("flanksource", "https://flanksource.github.io/charts").
+//		ChartVersions("1.0.0", "1.1.0").
+//		AppVersions("v1.0.0", "v1.1.0").
+//		NodeImages("v1.29.4", "v1.30.0").
+//		Run(ctx, func(ctx context.Context, env matrix.Env) error {
+//			return helm.NewNamespace(env.Namespace).GetPod("app=canary-checker").WaitReady().Error()
+//		})
+//	fmt.Print(report)
+//
+// A kind cluster is created per node image and reused by every chart and app version, without node
+// images the current cluster is used.
+package matrix
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/flanksource/commons-test/cleanup"
+	"github.com/flanksource/commons-test/names"
+)
+
+// Combination is one cell of the matrix, empty fields use the chart's default
+type Combination struct {
+	ChartVersion string `json:"chartVersion,omitempty"`
+	AppVersion   string `json:"appVersion,omitempty"`
+	NodeImage    string `json:"nodeImage,omitempty"`
+}
+
+func (c Combination) String() string {
+	return fmt.Sprintf("chart=%s app=%s kubernetes=%s", orDefault(c.ChartVersion), orDefault(c.AppVersion), orDefault(c.NodeImage))
+}
+
+func orDefault(s string) string {
+	if s == "" {
+		return "default"
+	}
+	return s
+}
+
+// Cluster is created once per node image, implemented by kind.Kind
+type Cluster interface {
+	Create() error
+	Destroy() error
+	GetName() string
+}
+
+// Release is installed once per combination, implemented by helm.HelmChart
+type Release interface {
+	InstallOrUpgrade() error
+	Uninstall() error
+	GetReleaseName() string
+}
+
+// Env is the installed combination passed to the verification
+type Env struct {
+	Combination
+	// Cluster is nil when the current cluster is used
+	Cluster   Cluster
+	Release   Release
+	Namespace string
+}
+
+// Verify checks an installed combination
+type Verify func(ctx context.Context, env Env) error
+
+// Matrix is a fluent chart compatibility matrix
+type Matrix struct {
+	chart           string
+	repository      string
+	repositoryURL   string
+	release         string
+	namespace       string
+	values          map[string]any
+	appVersionValue string
+	timeout         time.Duration
+	chartVersions   []string
+	appVersions     []string
+	nodeImages      []string
+	exclude         func(Combination) bool
+	failFast        bool
+
+	// newCluster, newRelease and newNamespace are replaced in tests
+	newCluster   func(nodeImage string) Cluster
+	newRelease   func(c Combination, namespace string) Release
+	newNamespace func(name string) (remove func(), err error)
+}
+
+// New returns a matrix of chart, a chart path or a chart name in Repository
+func New(chart string) *Matrix {
+	m := &Matrix{
+		chart:           chart,
+		values:          map[string]any{},
+		appVersionValue: "image.tag",
+		timeout:         5 * time.Minute,
+	}
+	m.newCluster = m.kindCluster
+	m.newRelease = m.helmRelease
+	m.newNamespace = createNamespace
+	return m
+}
+
+// Repository sets the repository of the chart
+func (m *Matrix) Repository(name, url string) *Matrix {
+	m.repository, m.repositoryURL = name, url
+	return m
+}
+
+// Release sets the release name, generated from the chart name when empty
+func (m *Matrix) Release(name string) *Matrix {
+	m.release = name
+	return m
+}
+
+// Namespace sets the namespace the chart is installed in. By default a namespace is generated and removed
+// afterwards.
+func (m *Matrix) Namespace(namespace string) *Matrix {
+	m.namespace = namespace
+	return m
+}
+
+// Values sets the values of every combination
+func (m *Matrix) Values(values map[string]any) *Matrix {
+	m.values = values
+	return m
+}
+
+// AppVersionValue sets the value the app version is set to, image.tag by default
+func (m *Matrix) AppVersionValue(path string) *Matrix {
+	m.appVersionValue = path
+	return m
+}
+
+// Timeout bounds the install of each combination
+func (m *Matrix) Timeout(timeout time.Duration) *Matrix {
+	m.timeout = timeout
+	return m
+}
+
+// ChartVersions sets the chart versions to install
+func (m *Matrix) ChartVersions(versions ...string) *Matrix {
+	m.chartVersions = versions
+	return m
+}
+
+// AppVersions sets the app versions to install, see AppVersionValue
+func (m *Matrix) AppVersions(versions ...string) *Matrix {
+	m.appVersions = versions
+	return m
+}
+
+// NodeImages sets the kindest/node image tags to create clusters with, e.g. v1.30.0
+func (m *Matrix) NodeImages(tags ...string) *Matrix {
+	m.nodeImages = tags
+	return m
+}
+
+// Exclude skips the combinations fn returns true for, e.g. app versions a chart version does not support
+func (m *Matrix) Exclude(fn func(Combination) bool) *Matrix {
+	m.exclude = fn
+	return m
+}
+
+// FailFast skips the remaining combinations after the first failure
+func (m *Matrix) FailFast() *Matrix {
+	m.failFast = true
+	return m
+}
+
+// Combinations returns the combinations run, grouped by node image
+func (m *Matrix) Combinations() []Combination {
+	var combinations []Combination
+	for _, nodeImage := range orEmpty(m.nodeImages) {
+		for _, chartVersion := range orEmpty(m.chartVersions) {
+			for _, appVersion := range orEmpty(m.appVersions) {
+				c := Combination{ChartVersion: chartVersion, AppVersion: appVersion, NodeImage: nodeImage}
+				if m.exclude == nil || !m.exclude(c) {
+					combinations = append(combinations, c)
+				}
+			}
+		}
+	}
+	return combinations
+}
+
+func orEmpty(list []string) []string {
+	if len(list) == 0 {
+		return []string{""}
+	}
+	return list
+}
+
+// Run installs and verifies every combination in turn, uninstalling each afterwards. The clusters created
+// for node images are deleted once their combinations ran, unless KEEP_RESOURCES=true.
+func (m *Matrix) Run(ctx context.Context, verify Verify) *Report {
+	report := &Report{Chart: m.chart}
+	combinations := m.Combinations()
+	for i := 0; i < len(combinations); {
+		// Combinations sharing a node image are adjacent and share its cluster
+		j := i
+		for j < len(combinations) && combinations[j].NodeImage == combinations[i].NodeImage {
+			j++
+		}
+		report.Results = append(report.Results, m.runCluster(ctx, combinations[i:j], verify, report.Failed())...)
+		i = j
+	}
+	return report
+}
+
+func (m *Matrix) runCluster(ctx context.Context, combinations []Combination, verify Verify, failed bool) []Result {
+	results := make([]Result, len(combinations))
+	for i, c := range combinations {
+		results[i] = Result{Combination: c, Status: Skipped}
+	}
+	if failed && m.failFast {
+		return results
+	}
+
+	var cluster Cluster
+	if nodeImage := combinations[0].NodeImage; nodeImage != "" {
+		cluster = m.newCluster(nodeImage)
+		if err := cluster.Create(); err != nil {
+			for i := range results {
+				results[i].fail(fmt.Errorf("failed to create cluster for %s: %w", nodeImage, err))
+			}
+			return results
+		}
+		if !cleanup.Keep() {
+			defer func() { _ = cluster.Destroy() }()
+		}
+	}
+
+	// Generated namespaces are created per cluster and removed with it
+	namespace := m.namespace
+	if namespace == "" {
+		namespace = names.New("matrix")
+		remove, err := m.newNamespace(namespace)
+		if err != nil {
+			for i := range results {
+				results[i].fail(fmt.Errorf("failed to create namespace %s: %w", namespace, err))
+			}
+			return results
+		}
+		if !cleanup.Keep() {
+			defer remove()
+		}
+	}
+
+	for i, c := range combinations {
+		if failed && m.failFast {
+			break
+		}
+		results[i] = m.runCombination(ctx, c, cluster, namespace, verify)
+		failed = failed || results[i].Status == Failed
+	}
+	return results
+}
+
+func (m *Matrix) runCombination(ctx context.Context, c Combination, cluster Cluster, namespace string, verify Verify) (result Result) {
+	result = Result{Combination: c, Status: Passed}
+	start := time.Now()
+	defer func() {
+		// Failed gomega assertions in verify panic
+		if r := recover(); r != nil {
+			result.fail(fmt.Errorf("verification panicked: %v", r))
+		}
+		result.Duration = time.Since(start)
+	}()
+
+	release := m.newRelease(c, namespace)
+	if err := release.InstallOrUpgrade(); err != nil {
+		result.fail(fmt.Errorf("failed to install: %w", err))
+		return result
+	}
+	if !cleanup.Keep() {
+		defer func() {
+			if err := release.Uninstall(); err != nil && result.Status == Passed {
+				result.fail(fmt.Errorf("failed to uninstall: %w", err))
+			}
+		}()
+	}
+	if err := verify(ctx, Env{Combination: c, Cluster: cluster, Release: release, Namespace: namespace}); err != nil {
+		result.fail(err)
+	}
+	return result
+}
+
+// releaseName returns the release name, the base name of the chart by default
+func (m *Matrix) releaseName() string {
+	if m.release != "" {
+		return m.release
+	}
+	return names.Sanitize(m.chart[strings.LastIndex(m.chart, "/")+1:])
+}
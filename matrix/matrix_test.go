@@ -0,0 +1,89 @@
+package matrix
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+)
+
+type fake struct {
+	name   string
+	err    error
+	calls  *[]string
+	create bool
+}
+
+func (f *fake) Create() error           { *f.calls = append(*f.calls, "create "+f.name); return f.err }
+func (f *fake) Destroy() error          { *f.calls = append(*f.calls, "destroy "+f.name); return nil }
+func (f *fake) GetName() string         { return f.name }
+func (f *fake) InstallOrUpgrade() error { *f.calls = append(*f.calls, "install "+f.name); return f.err }
+func (f *fake) Uninstall() error        { *f.calls = append(*f.calls, "uninstall "+f.name); return nil }
+func (f *fake) GetReleaseName() string  { return f.name }
+
+func newFake(calls *[]string) *Matrix {
+	m := New("charts/app").
+		ChartVersions("1.0", "2.0").
+		AppVersions("v1", "v2").
+		NodeImages("v1.29", "v1.30").
+		Exclude(func(c Combination) bool { return c.ChartVersion == "1.0" && c.AppVersion == "v2" })
+	m.newCluster = func(nodeImage string) Cluster { return &fake{name: nodeImage, calls: calls} }
+	m.newRelease = func(c Combination, namespace string) Release {
+		var err error
+		if c.ChartVersion == "2.0" && c.NodeImage == "v1.29" && c.AppVersion == "v1" {
+			err = errors.New("unsupported")
+		}
+		return &fake{name: c.ChartVersion + "/" + c.AppVersion, err: err, calls: calls}
+	}
+	m.newNamespace = func(name string) (func(), error) { return func() {}, nil }
+	return m
+}
+
+func TestRun(t *testing.T) {
+	var calls []string
+	report := newFake(&calls).Run(context.Background(), func(ctx context.Context, env Env) error {
+		if env.AppVersion == "v2" && env.NodeImage == "v1.30" {
+			panic("assertion failed")
+		}
+		return nil
+	})
+
+	var got []string
+	for _, r := range report.Results {
+		got = append(got, fmt.Sprintf("%s %s %s %s", r.NodeImage, r.ChartVersion, r.AppVersion, r.Status))
+	}
+	want := []string{
+		"v1.29 1.0 v1 pass", "v1.29 2.0 v1 fail", "v1.29 2.0 v2 pass",
+		"v1.30 1.0 v1 pass", "v1.30 2.0 v1 pass", "v1.30 2.0 v2 fail",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("results = %v, want %v", got, want)
+	}
+	// One cluster per node image, created before and destroyed after its combinations
+	if calls[0] != "create v1.29" || calls[6] != "destroy v1.29" || calls[7] != "create v1.30" || calls[len(calls)-1] != "destroy v1.30" {
+		t.Errorf("calls = %v", calls)
+	}
+	if err := report.Err(); err == nil || !strings.Contains(err.Error(), "verification panicked: assertion failed") {
+		t.Errorf("Err() = %v", err)
+	}
+	if s := report.String(); !strings.Contains(s, "2.0    v1   v1.29       fail") {
+		t.Errorf("String() =\n%s", s)
+	}
+}
+
+func TestFailFast(t *testing.T) {
+	var calls []string
+	report := newFake(&calls).FailFast().Run(context.Background(), func(context.Context, Env) error { return nil })
+	var statuses []Status
+	for _, r := range report.Results {
+		statuses = append(statuses, r.Status)
+	}
+	if want := []Status{Passed, Failed, Skipped, Skipped, Skipped, Skipped}; !slices.Equal(statuses, want) {
+		t.Errorf("statuses = %v, want %v", statuses, want)
+	}
+	if slices.Contains(calls, "create v1.30") {
+		t.Errorf("expected no cluster after the failure, calls = %v", calls)
+	}
+}
@@ -0,0 +1,125 @@
+package matrix
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// Status is the outcome of a combination
+type Status string
+
+const (
+	Passed  Status = "pass"
+	Failed  Status = "fail"
+	Skipped Status = "skip"
+)
+
+// Result is the outcome of a combination
+type Result struct {
+	Combination
+	Status   Status        `json:"status"`
+	Duration time.Duration `json:"duration"`
+	Error    string        `json:"error,omitempty"`
+}
+
+func (r *Result) fail(err error) {
+	r.Status = Failed
+	r.Error = err.Error()
+}
+
+// Report is the compatibility report of a matrix run
+type Report struct {
+	Chart   string   `json:"chart"`
+	Results []Result `json:"results"`
+}
+
+// Failed returns true when any combination failed
+func (r *Report) Failed() bool {
+	for _, result := range r.Results {
+		if result.Status == Failed {
+			return true
+		}
+	}
+	return false
+}
+
+// Err returns the errors of the failed combinations
+func (r *Report) Err() error {
+	var errs []error
+	for _, result := range r.Results {
+		if result.Status == Failed {
+			errs = append(errs, fmt.Errorf("%s: %s", result.Combination, result.Error))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// String returns a table of the results
+func (r *Report) String() string {
+	var b strings.Builder
+	w := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "CHART\tAPP\tKUBERNETES\tRESULT\tDURATION\tERROR")
+	for _, result := range r.Results {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%v\t%s\n", orDefault(result.ChartVersion), orDefault(result.AppVersion),
+			orDefault(result.NodeImage), result.Status, result.Duration.Round(time.Second), firstLine(result.Error))
+	}
+	w.Flush()
+	return b.String()
+}
+
+// WriteMarkdown writes the results as a markdown table, e.g. for a CI job summary
+func (r *Report) WriteMarkdown(w io.Writer) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "### %s compatibility\n\n| Chart | App | Kubernetes | Result | Duration |\n|---|---|---|---|---|\n", r.Chart)
+	for _, result := range r.Results {
+		status := string(result.Status)
+		if result.Error != "" {
+			status += ": " + strings.ReplaceAll(firstLine(result.Error), "|", `\|`)
+		}
+		fmt.Fprintf(&b, "| %s | %s | %s | %s | %v |\n", orDefault(result.ChartVersion), orDefault(result.AppVersion),
+			orDefault(result.NodeImage), status, result.Duration.Round(time.Second))
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteJSON writes the report as JSON
+func (r *Report) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// WriteFile writes the report to path, as markdown when it ends in .md and JSON otherwise
+func (r *Report) WriteFile(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create report directory: %w", err)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create matrix report: %w", err)
+	}
+	defer f.Close()
+
+	if strings.EqualFold(filepath.Ext(path), ".md") {
+		err = r.WriteMarkdown(f)
+	} else {
+		err = r.WriteJSON(f)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write matrix report: %w", err)
+	}
+	return f.Close()
+}
+
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(s, "\n")
+	return line
+}
@@ -0,0 +1,153 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/flanksource/commons-test/wait"
+)
+
+// Expectation is a fluent assertion on the sum of the series with a name and labels
+type Expectation struct {
+	name   string
+	labels map[string]string
+	desc   string
+	cmp    func(value float64) bool
+}
+
+// Expect returns an expectation on the series called name, which only requires it to exist until a
+// comparison is set
+func Expect(name string) *Expectation {
+	return &Expectation{name: name, labels: map[string]string{}}
+}
+
+// WithLabel restricts the expectation to the series with label=value
+func (e *Expectation) WithLabel(label, value string) *Expectation {
+	e.labels[label] = value
+	return e
+}
+
+// ToEqual requires the value to equal expected
+func (e *Expectation) ToEqual(expected float64) *Expectation {
+	return e.compare(fmt.Sprintf("to equal %v", expected), func(v float64) bool { return v == expected })
+}
+
+// ToBeAtLeast requires the value to be at least minimum
+func (e *Expectation) ToBeAtLeast(minimum float64) *Expectation {
+	return e.compare(fmt.Sprintf("to be at least %v", minimum), func(v float64) bool { return v >= minimum })
+}
+
+// ToBeAtMost requires the value to be at most maximum
+func (e *Expectation) ToBeAtMost(maximum float64) *Expectation {
+	return e.compare(fmt.Sprintf("to be at most %v", maximum), func(v float64) bool { return v <= maximum })
+}
+
+// ToBeAbove requires the value to be greater than minimum
+func (e *Expectation) ToBeAbove(minimum float64) *Expectation {
+	return e.compare(fmt.Sprintf("to be above %v", minimum), func(v float64) bool { return v > minimum })
+}
+
+// ToBeBelow requires the value to be less than maximum
+func (e *Expectation) ToBeBelow(maximum float64) *Expectation {
+	return e.compare(fmt.Sprintf("to be below %v", maximum), func(v float64) bool { return v < maximum })
+}
+
+func (e *Expectation) compare(desc string, cmp func(float64) bool) *Expectation {
+	e.desc, e.cmp = desc, cmp
+	return e
+}
+
+func (e *Expectation) String() string {
+	s := Sample{Name: e.name, Labels: e.labels}.selector()
+	if e.desc == "" {
+		return s + " to exist"
+	}
+	return s + " " + e.desc
+}
+
+// Check returns an error describing the series found when families does not meet the expectation
+func (e *Expectation) Check(families Families) error {
+	samples := families.Samples(e.name, e.labels)
+	if len(samples) == 0 {
+		return fmt.Errorf("expected %s, but no series matched%s", e, e.similar(families))
+	}
+	if e.cmp != nil && !e.cmp(sum(samples)) {
+		return fmt.Errorf("expected %s, got %v from:\n  %s", e, sum(samples), join(samples))
+	}
+	return nil
+}
+
+// similar lists the series with the expected name but other labels
+func (e *Expectation) similar(families Families) string {
+	if samples := families.Samples(e.name, nil); len(samples) > 0 {
+		return ", found:\n  " + join(samples)
+	}
+	return ""
+}
+
+func join(samples []Sample) string {
+	var lines []string
+	for _, s := range samples {
+		lines = append(lines, s.String())
+	}
+	return strings.Join(lines, "\n  ")
+}
+
+// Match implements the gomega matcher interface for Families
+func (e *Expectation) Match(actual any) (bool, error) {
+	families, ok := actual.(Families)
+	if !ok {
+		return false, fmt.Errorf("metrics.Expect matches metrics.Families, got %T", actual)
+	}
+	return e.Check(families) == nil, nil
+}
+
+// FailureMessage implements the gomega matcher interface
+func (e *Expectation) FailureMessage(actual any) string {
+	if families, ok := actual.(Families); ok {
+		if err := e.Check(families); err != nil {
+			return err.Error()
+		}
+	}
+	return "expected " + e.String()
+}
+
+// NegatedFailureMessage implements the gomega matcher interface
+func (e *Expectation) NegatedFailureMessage(actual any) string {
+	return "expected not " + e.String()
+}
+
+// Source scrapes a metrics endpoint
+type Source func(ctx context.Context) (Families, error)
+
+// URL returns a source scraping url
+func URL(url string) Source {
+	return func(ctx context.Context) (Families, error) {
+		return Scrape(ctx, url)
+	}
+}
+
+// Poll returns the source as a function for gomega's Eventually
+func (s Source) Poll(ctx context.Context) func() (Families, error) {
+	return func() (Families, error) {
+		return s(ctx)
+	}
+}
+
+// WaitFor scrapes source until every expectation is met or timeout elapses
+func WaitFor(ctx context.Context, source Source, timeout time.Duration, expectations ...*Expectation) error {
+	return wait.For(ctx, time.Second, timeout, wait.NoError(func(ctx context.Context) error {
+		families, err := source(ctx)
+		if err != nil {
+			return err
+		}
+		var errs []error
+		for _, e := range expectations {
+			errs = append(errs, e.Check(families))
+		}
+		return errors.Join(errs...)
+	}))
+}
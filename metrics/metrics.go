@@ -0,0 +1,193 @@
+// Package metrics scrapes Prometheus /metrics endpoints of containers and pods and asserts on the values
+// of their series, e.g. for exporter tests:
+//
+//	source, stop, err := metrics.Service("monitoring", "queue-exporter", 9090, "/metrics")
+//	defer stop()
+//	err = metrics.WaitFor(ctx, source, time.Minute, metrics.Expect("queue_depth").WithLabel("queue", "foo").ToBeAtLeast(1))
+//
+// Expectations are also gomega matchers of scraped Families:
+//
+//	Eventually(source.Poll(ctx)).Should(metrics.Expect("queue_depth").WithLabel("queue", "foo").ToBeAtLeast(1))
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"maps"
+	"math"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+	"github.com/prometheus/common/model"
+)
+
+// Families is a scrape keyed by metric family name
+type Families map[string]*dto.MetricFamily
+
+// Sample is a single series of a scrape. Histograms and summaries are flattened into their _bucket,
+// _sum and _count series, with the le and quantile labels.
+type Sample struct {
+	Name   string
+	Labels map[string]string
+	Value  float64
+}
+
+func (s Sample) String() string {
+	var labels []string
+	for _, name := range slices.Sorted(maps.Keys(s.Labels)) {
+		labels = append(labels, fmt.Sprintf("%s=%q", name, s.Labels[name]))
+	}
+	if len(labels) == 0 {
+		return fmt.Sprintf("%s %v", s.Name, s.Value)
+	}
+	return fmt.Sprintf("%s{%s} %v", s.Name, strings.Join(labels, ","), s.Value)
+}
+
+// Parse parses the Prometheus text exposition format
+func Parse(r io.Reader) (Families, error) {
+	parser := expfmt.NewTextParser(model.UTF8Validation)
+	families, err := parser.TextToMetricFamilies(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse metrics: %w", err)
+	}
+	return families, nil
+}
+
+// Scrape fetches and parses the metrics at url
+func Scrape(ctx context.Context, url string) (Families, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid metrics url %s: %w", url, err)
+	}
+	req.Header.Set("Accept", "text/plain")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scrape %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to scrape %s: status %d", url, resp.StatusCode)
+	}
+	return Parse(resp.Body)
+}
+
+// Samples returns the series called name whose labels include labels
+func (f Families) Samples(name string, labels map[string]string) []Sample {
+	var samples []Sample
+	for _, family := range f.candidates(name) {
+		for _, s := range flatten(family) {
+			if s.Name == name && hasLabels(s.Labels, labels) {
+				samples = append(samples, s)
+			}
+		}
+	}
+	return samples
+}
+
+// Value returns the sum of the series called name with the labels given as name/value pairs
+func (f Families) Value(name string, labels ...string) (float64, error) {
+	want := map[string]string{}
+	for i := 0; i+1 < len(labels); i += 2 {
+		want[labels[i]] = labels[i+1]
+	}
+	samples := f.Samples(name, want)
+	if len(samples) == 0 {
+		return 0, fmt.Errorf("no series %s", Sample{Name: name, Labels: want}.selector())
+	}
+	return sum(samples), nil
+}
+
+// candidates returns the families that may contain the series name, including the family of a
+// histogram or summary series
+func (f Families) candidates(name string) []*dto.MetricFamily {
+	var families []*dto.MetricFamily
+	if family, ok := f[name]; ok {
+		families = append(families, family)
+	}
+	for _, suffix := range []string{"_bucket", "_sum", "_count"} {
+		if base, ok := strings.CutSuffix(name, suffix); ok {
+			if family, ok := f[base]; ok {
+				families = append(families, family)
+			}
+		}
+	}
+	return families
+}
+
+func flatten(family *dto.MetricFamily) []Sample {
+	name := family.GetName()
+	var samples []Sample
+	for _, m := range family.GetMetric() {
+		labels := map[string]string{}
+		for _, pair := range m.GetLabel() {
+			labels[pair.GetName()] = pair.GetValue()
+		}
+		with := func(name, value string) map[string]string {
+			l := maps.Clone(labels)
+			l[name] = value
+			return l
+		}
+		switch family.GetType() {
+		case dto.MetricType_COUNTER:
+			samples = append(samples, Sample{name, labels, m.GetCounter().GetValue()})
+		case dto.MetricType_GAUGE:
+			samples = append(samples, Sample{name, labels, m.GetGauge().GetValue()})
+		case dto.MetricType_SUMMARY:
+			summary := m.GetSummary()
+			for _, q := range summary.GetQuantile() {
+				samples = append(samples, Sample{name, with("quantile", formatFloat(q.GetQuantile())), q.GetValue()})
+			}
+			samples = append(samples,
+				Sample{name + "_sum", labels, summary.GetSampleSum()},
+				Sample{name + "_count", labels, float64(summary.GetSampleCount())})
+		case dto.MetricType_HISTOGRAM:
+			histogram := m.GetHistogram()
+			for _, b := range histogram.GetBucket() {
+				samples = append(samples, Sample{name + "_bucket", with("le", formatFloat(b.GetUpperBound())), float64(b.GetCumulativeCount())})
+			}
+			samples = append(samples,
+				Sample{name + "_sum", labels, histogram.GetSampleSum()},
+				Sample{name + "_count", labels, float64(histogram.GetSampleCount())})
+		default:
+			samples = append(samples, Sample{name, labels, m.GetUntyped().GetValue()})
+		}
+	}
+	return samples
+}
+
+func formatFloat(f float64) string {
+	if math.IsInf(f, 1) {
+		return "+Inf"
+	}
+	return fmt.Sprint(f)
+}
+
+func hasLabels(labels, want map[string]string) bool {
+	for name, value := range want {
+		if labels[name] != value {
+			return false
+		}
+	}
+	return true
+}
+
+func sum(samples []Sample) float64 {
+	var total float64
+	for _, s := range samples {
+		total += s.Value
+	}
+	return total
+}
+
+// selector formats the name and labels of the sample as a PromQL selector
+func (s Sample) selector() string {
+	str := s.String()
+	return str[:strings.LastIndex(str, " ")]
+}
@@ -0,0 +1,75 @@
+package metrics
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const exposition = `# TYPE queue_depth gauge
+queue_depth{queue="foo"} 3
+queue_depth{queue="bar"} 0
+# TYPE messages_total counter
+messages_total{queue="foo",status="ok"} 10
+messages_total{queue="foo",status="error"} 2
+# TYPE request_duration_seconds histogram
+request_duration_seconds_bucket{le="0.1"} 4
+request_duration_seconds_bucket{le="+Inf"} 5
+request_duration_seconds_sum 0.9
+request_duration_seconds_count 5
+`
+
+func TestExpect(t *testing.T) {
+	families, err := Parse(strings.NewReader(exposition))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, e := range []*Expectation{
+		Expect("queue_depth").WithLabel("queue", "foo").ToBeAtLeast(1),
+		Expect("queue_depth").WithLabel("queue", "bar").ToEqual(0),
+		Expect("messages_total").WithLabel("queue", "foo").ToEqual(12),
+		Expect("request_duration_seconds_count").ToEqual(5),
+		Expect("request_duration_seconds_bucket").WithLabel("le", "+Inf").ToBeAbove(4),
+		Expect("messages_total"),
+	} {
+		if err := e.Check(families); err != nil {
+			t.Errorf("%s: %v", e, err)
+		}
+	}
+
+	err = Expect("queue_depth").WithLabel("queue", "baz").ToBeAtLeast(1).Check(families)
+	if err == nil || !strings.Contains(err.Error(), `queue_depth{queue="foo"} 3`) {
+		t.Errorf("expected the existing series in the error, got %v", err)
+	}
+	if ok, _ := Expect("queue_depth").ToBeBelow(3).Match(families); ok {
+		t.Error("expected the sum of 3 not to be below 3")
+	}
+	if v, err := families.Value("messages_total", "status", "error"); err != nil || v != 2 {
+		t.Errorf("Value = %v, %v", v, err)
+	}
+}
+
+func TestWaitFor(t *testing.T) {
+	var scrapes atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ready := "0"
+		if scrapes.Add(1) > 1 {
+			ready = "1"
+		}
+		_, _ = io.WriteString(w, "# TYPE ready gauge\nready "+ready+"\n")
+	}))
+	defer server.Close()
+
+	if err := WaitFor(context.Background(), URL(server.URL), 10*time.Second, Expect("ready").ToEqual(1)); err != nil {
+		t.Fatal(err)
+	}
+	if n := scrapes.Load(); n < 2 {
+		t.Errorf("expected a second scrape, got %d", n)
+	}
+}
@@ -0,0 +1,232 @@
+// Package migrate tests a project's migrations against a fresh database of a Postgres or SQL Server
+// container: the schema after up must contain the expected tables, columns and indexes, running up again
+// must not change it and down must restore the schema found before up.
+//
+//	schema, err := migrate.New(postgres).
+//		Up(migrate.SQLFiles("migrations/*.up.sql")).
+//		Down(migrate.SQLFiles("migrations/*.down.sql")).
+//		Expect(expected).
+//		Idempotent().
+//		Run(ctx)
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/flanksource/commons-test/cleanup"
+	"github.com/flanksource/commons-test/command"
+	"github.com/flanksource/commons-test/container"
+	"github.com/flanksource/commons-test/logging"
+	"github.com/flanksource/commons-test/names"
+)
+
+// Server creates the databases migrations run against, implemented by container.PostgresContainer and
+// container.SQLServerContainer
+type Server interface {
+	DriverName() string
+	GetDatabaseConnectionString(database string) string
+	CreateDatabase(ctx context.Context, name string) error
+	DropDatabase(ctx context.Context, name string) error
+}
+
+// Database is the fresh database a Step migrates
+type Database struct {
+	Name   string
+	Driver string
+	DSN    string
+	DB     *sql.DB
+}
+
+// Step applies or reverts migrations
+type Step func(ctx context.Context, db *Database) error
+
+// SQL returns a step executing each script in order. SQL Server scripts are split on GO lines.
+func SQL(scripts ...string) Step {
+	return func(ctx context.Context, db *Database) error {
+		// Scripts run on a single connection so that session settings carry over
+		conn, err := db.DB.Conn(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to get connection: %w", err)
+		}
+		defer conn.Close()
+
+		for i, script := range scripts {
+			batches := []string{script}
+			if db.Driver == "sqlserver" {
+				batches = container.SplitSQLBatches(script)
+			}
+			for _, batch := range batches {
+				if _, err := conn.ExecContext(ctx, batch); err != nil {
+					return fmt.Errorf("script %d failed: %w", i+1, err)
+				}
+			}
+		}
+		return nil
+	}
+}
+
+// SQLFiles returns a step executing the files matching patterns, in lexical order within each pattern,
+// e.g. migrations/*.up.sql
+func SQLFiles(patterns ...string) Step {
+	return func(ctx context.Context, db *Database) error {
+		for _, pattern := range patterns {
+			files, err := filepath.Glob(pattern)
+			if err != nil {
+				return fmt.Errorf("invalid pattern %s: %w", pattern, err)
+			}
+			if len(files) == 0 {
+				return fmt.Errorf("no migrations match %s", pattern)
+			}
+			slices.Sort(files)
+			for _, file := range files {
+				data, err := os.ReadFile(file)
+				if err != nil {
+					return err
+				}
+				if err := SQL(string(data))(ctx, db); err != nil {
+					return fmt.Errorf("failed to apply %s: %w", file, err)
+				}
+			}
+		}
+		return nil
+	}
+}
+
+// DSNPlaceholder is replaced with the connection string in the arguments of Command
+const DSNPlaceholder = "$DATABASE_URL"
+
+// Command returns a step running a migration tool, with the connection string in the DATABASE_URL
+// environment variable and in place of $DATABASE_URL in args, e.g.
+//
+//	migrate.Command("migrate", "-path", "migrations", "-database", migrate.DSNPlaceholder, "up")
+func Command(name string, args ...string) Step {
+	return func(ctx context.Context, db *Database) error {
+		logging.Mask(db.DSN)
+		argv := make([]string, len(args))
+		for i, arg := range args {
+			argv[i] = strings.ReplaceAll(arg, DSNPlaceholder, db.DSN)
+		}
+		result := command.NewCommandRunner(false).WithEnv("DATABASE_URL", db.DSN).RunCommandCtx(ctx, name, argv...)
+		if result.Err != nil {
+			return fmt.Errorf("%s failed: %s", name, result.String())
+		}
+		return nil
+	}
+}
+
+// Test is a fluent migration test
+type Test struct {
+	server     Server
+	up, down   Step
+	expected   *Schema
+	idempotent bool
+}
+
+// New returns a test creating a fresh database on server
+func New(server Server) *Test {
+	return &Test{server: server}
+}
+
+// Up sets the step applying the migrations
+func (t *Test) Up(step Step) *Test {
+	t.up = step
+	return t
+}
+
+// Down sets the step reverting the migrations, checked to restore the schema found before Up
+func (t *Test) Down(step Step) *Test {
+	t.down = step
+	return t
+}
+
+// Expect requires the schema after Up to contain schema, see Schema.Missing
+func (t *Test) Expect(schema *Schema) *Test {
+	t.expected = schema
+	return t
+}
+
+// Idempotent requires a second Up to succeed without changing the schema
+func (t *Test) Idempotent() *Test {
+	t.idempotent = true
+	return t
+}
+
+// Run migrates a fresh database, dropped afterwards unless KEEP_RESOURCES=true, and returns the schema
+// after Up
+func (t *Test) Run(ctx context.Context) (*Schema, error) {
+	if t.up == nil {
+		return nil, fmt.Errorf("no up migration")
+	}
+
+	name := strings.ReplaceAll(names.New("migrate"), "-", "_")
+	if err := t.server.CreateDatabase(ctx, name); err != nil {
+		return nil, err
+	}
+	if !cleanup.Keep() {
+		defer t.server.DropDatabase(context.Background(), name)
+	}
+
+	db := &Database{Name: name, Driver: t.server.DriverName(), DSN: t.server.GetDatabaseConnectionString(name)}
+	conn, err := sql.Open(db.Driver, db.DSN)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database %s: %w", name, err)
+	}
+	defer conn.Close()
+	db.DB = conn
+
+	before, err := Inspect(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := t.up(ctx, db); err != nil {
+		return nil, fmt.Errorf("up failed: %w", err)
+	}
+	after, err := Inspect(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	if t.expected != nil {
+		if missing := after.Missing(t.expected); len(missing) > 0 {
+			return after, differences("schema after up does not match", missing)
+		}
+	}
+
+	if t.idempotent {
+		if err := t.up(ctx, db); err != nil {
+			return after, fmt.Errorf("second up failed: %w", err)
+		}
+		again, err := Inspect(ctx, db)
+		if err != nil {
+			return after, err
+		}
+		if diff := Diff(after, again); len(diff) > 0 {
+			return after, differences("second up changed the schema", diff)
+		}
+	}
+
+	if t.down != nil {
+		if err := t.down(ctx, db); err != nil {
+			return after, fmt.Errorf("down failed: %w", err)
+		}
+		restored, err := Inspect(ctx, db)
+		if err != nil {
+			return after, err
+		}
+		if diff := Diff(before, restored); len(diff) > 0 {
+			return after, differences("down did not restore the schema", diff)
+		}
+	}
+	return after, nil
+}
+
+func differences(msg string, diff []string) error {
+	return errors.New(msg + ":\n  " + strings.Join(diff, "\n  "))
+}
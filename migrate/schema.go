@@ -0,0 +1,161 @@
+package migrate
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+)
+
+// Column is the definition of a column, empty fields are not compared by Missing
+type Column struct {
+	// Type is the data type as reported by information_schema, e.g. integer or nvarchar
+	Type     string `json:"type,omitempty"`
+	Nullable *bool  `json:"nullable,omitempty"`
+}
+
+// Table is the definition of a table
+type Table struct {
+	Columns map[string]Column `json:"columns,omitempty"`
+	Indexes []string          `json:"indexes,omitempty"`
+}
+
+// Schema is the definition of the tables of a database, keyed by table name, qualified with the schema
+// outside of the default public (Postgres) and dbo (SQL Server) schemas
+type Schema struct {
+	Tables map[string]Table `json:"tables"`
+}
+
+// LoadSchema reads an expected schema from a YAML or JSON file, e.g.
+//
+//	tables:
+//	  users:
+//	    columns:
+//	      id: {type: integer, nullable: false}
+//	      email: {type: text}
+//	    indexes: [users_pkey, users_email_key]
+func LoadSchema(path string) (*Schema, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	var schema Schema
+	if err := yaml.UnmarshalStrict(data, &schema); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return &schema, nil
+}
+
+// Missing returns the tables, columns, column types and indexes of expected that the schema lacks. Tables
+// and columns not in expected are ignored.
+func (s *Schema) Missing(expected *Schema) []string {
+	var missing []string
+	for _, name := range slices.Sorted(maps.Keys(expected.Tables)) {
+		want := expected.Tables[name]
+		got, ok := s.Tables[name]
+		if !ok {
+			missing = append(missing, "missing table "+name)
+			continue
+		}
+		for _, column := range slices.Sorted(maps.Keys(want.Columns)) {
+			wantColumn := want.Columns[column]
+			gotColumn, ok := got.Columns[column]
+			switch {
+			case !ok:
+				missing = append(missing, fmt.Sprintf("missing column %s.%s", name, column))
+			case wantColumn.Type != "" && !strings.EqualFold(wantColumn.Type, gotColumn.Type):
+				missing = append(missing, fmt.Sprintf("column %s.%s is %s, expected %s", name, column, gotColumn.Type, wantColumn.Type))
+			case wantColumn.Nullable != nil && gotColumn.Nullable != nil && *wantColumn.Nullable != *gotColumn.Nullable:
+				missing = append(missing, fmt.Sprintf("column %s.%s has nullable=%v, expected %v", name, column, *gotColumn.Nullable, *wantColumn.Nullable))
+			}
+		}
+		for _, index := range want.Indexes {
+			if !slices.Contains(got.Indexes, index) {
+				missing = append(missing, fmt.Sprintf("missing index %s on %s", index, name))
+			}
+		}
+	}
+	return missing
+}
+
+// Diff returns the differences between two inspected schemas
+func Diff(before, after *Schema) []string {
+	var diff []string
+	for _, m := range after.Missing(before) {
+		diff = append(diff, strings.Replace(m, "missing", "removed", 1))
+	}
+	for _, m := range before.Missing(after) {
+		diff = append(diff, strings.Replace(m, "missing", "added", 1))
+	}
+	return diff
+}
+
+// Inspect reads the tables, columns and indexes of the database
+func Inspect(ctx context.Context, db *Database) (*Schema, error) {
+	schema := &Schema{Tables: map[string]Table{}}
+	defaultSchema, indexQuery := "public", postgresIndexes
+	if db.Driver == "sqlserver" {
+		defaultSchema, indexQuery = "dbo", sqlServerIndexes
+	}
+	qualify := func(tableSchema, table string) string {
+		if tableSchema == defaultSchema {
+			return table
+		}
+		return tableSchema + "." + table
+	}
+
+	rows, err := db.DB.QueryContext(ctx, `SELECT c.table_schema, c.table_name, c.column_name, c.data_type, c.is_nullable
+		FROM information_schema.columns c
+		JOIN information_schema.tables t ON t.table_schema = c.table_schema AND t.table_name = c.table_name
+		WHERE t.table_type = 'BASE TABLE' AND c.table_schema NOT IN ('pg_catalog', 'information_schema', 'sys')`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to inspect columns: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var tableSchema, table, column, dataType, nullable string
+		if err := rows.Scan(&tableSchema, &table, &column, &dataType, &nullable); err != nil {
+			return nil, err
+		}
+		name := qualify(tableSchema, table)
+		t := schema.Tables[name]
+		if t.Columns == nil {
+			t.Columns = map[string]Column{}
+		}
+		isNullable := nullable == "YES"
+		t.Columns[column] = Column{Type: dataType, Nullable: &isNullable}
+		schema.Tables[name] = t
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	indexes, err := db.DB.QueryContext(ctx, indexQuery)
+	if err != nil {
+		return nil, fmt.Errorf("failed to inspect indexes: %w", err)
+	}
+	defer indexes.Close()
+	for indexes.Next() {
+		var tableSchema, table, index string
+		if err := indexes.Scan(&tableSchema, &table, &index); err != nil {
+			return nil, err
+		}
+		name := qualify(tableSchema, table)
+		t := schema.Tables[name]
+		t.Indexes = append(t.Indexes, index)
+		schema.Tables[name] = t
+	}
+	return schema, indexes.Err()
+}
+
+const postgresIndexes = `SELECT schemaname, tablename, indexname FROM pg_indexes
+	WHERE schemaname NOT IN ('pg_catalog', 'information_schema') ORDER BY indexname`
+
+const sqlServerIndexes = `SELECT s.name, t.name, i.name FROM sys.indexes i
+	JOIN sys.tables t ON t.object_id = i.object_id
+	JOIN sys.schemas s ON s.schema_id = t.schema_id
+	WHERE i.name IS NOT NULL ORDER BY i.name`
@@ -0,0 +1,51 @@
+package migrate
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestMissing(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "schema.yaml")
+	expected := `tables:
+  users:
+    columns:
+      id: {type: INTEGER, nullable: false}
+      email: {}
+    indexes: [users_pkey, users_email_key]
+  audit.events:
+    columns:
+      id: {}
+`
+	if err := os.WriteFile(path, []byte(expected), 0644); err != nil {
+		t.Fatal(err)
+	}
+	want, err := LoadSchema(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nullable := true
+	got := &Schema{Tables: map[string]Table{
+		"users": {
+			Columns: map[string]Column{"id": {Type: "integer", Nullable: &nullable}, "name": {Type: "text"}},
+			Indexes: []string{"users_pkey"},
+		},
+	}}
+	missing := got.Missing(want)
+	if !slices.Equal(missing, []string{
+		"missing table audit.events",
+		"missing column users.email",
+		"column users.id has nullable=true, expected false",
+		"missing index users_email_key on users",
+	}) {
+		t.Errorf("unexpected differences %q", missing)
+	}
+
+	diff := Diff(&Schema{Tables: map[string]Table{}}, got)
+	if !slices.Equal(diff, []string{"added table users"}) {
+		t.Errorf("unexpected diff %q", diff)
+	}
+}
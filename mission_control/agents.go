@@ -0,0 +1,83 @@
+package mission_control
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+type Agent struct {
+	ID           string            `json:"id"`
+	Name         string            `json:"name"`
+	Hostname     string            `json:"hostname,omitempty"`
+	Description  string            `json:"description,omitempty"`
+	Properties   map[string]string `json:"properties,omitempty"`
+	LastSeen     *time.Time        `json:"last_seen,omitempty"`
+	LastReceived *time.Time        `json:"last_received,omitempty"`
+	CreatedAt    *time.Time        `json:"created_at,omitempty"`
+}
+
+// AgentCredentials are returned when an agent is registered
+type AgentCredentials struct {
+	ID          string `json:"id"`
+	Username    string `json:"username"`
+	AccessToken string `json:"access_token"`
+}
+
+// RegisterAgent registers an agent and returns the credentials it uses to push upstream
+func (mc *MissionControl) RegisterAgent(ctx context.Context, name string, properties map[string]string) (*AgentCredentials, error) {
+	r, err := mc.HTTP.R(ctx).
+		Header("Content-Type", "application/json").
+		Post("/agent/generate", map[string]any{"name": name, "properties": properties})
+	if err != nil {
+		return nil, err
+	}
+	if !r.IsOK() {
+		return nil, newAPIError("register agent "+name, r)
+	}
+
+	var credentials AgentCredentials
+	if err := r.Into(&credentials); err != nil {
+		return nil, err
+	}
+	return &credentials, nil
+}
+
+func (mc *MissionControl) GetAgent(ctx context.Context, name string) (*Agent, error) {
+	var agents []Agent
+	if err := mc.dbSelect(ctx, "get agent "+name, "agents", map[string]string{
+		"name":       "eq." + name,
+		"deleted_at": "is.null",
+	}, &agents); err != nil {
+		return nil, err
+	}
+	if len(agents) == 0 {
+		return nil, &APIError{Op: "get agent " + name, StatusCode: 404, Message: "agent not found"}
+	}
+	return &agents[0], nil
+}
+
+// WaitForAgentToPush waits until mission-control receives data from the agent after this call was made
+func (mc *MissionControl) WaitForAgentToPush(ctx context.Context, name string, timeout time.Duration) (*Agent, error) {
+	// Allow for clock skew between the test host and the database
+	since := time.Now().Add(-5 * time.Second)
+
+	var last *Agent
+	var lastErr error
+	err := poll(ctx, timeout, func(ctx context.Context) bool {
+		agent, err := mc.GetAgent(ctx, name)
+		lastErr = err
+		if err != nil {
+			return false
+		}
+		last = agent
+		return agent.LastReceived != nil && agent.LastReceived.After(since)
+	})
+	if err == nil {
+		return last, nil
+	}
+	if last == nil {
+		return nil, fmt.Errorf("agent %s not found after %v: %w", name, timeout, lastErr)
+	}
+	return last, fmt.Errorf("agent %s did not push within %v (last_received=%v, last_seen=%v)", name, timeout, last.LastReceived, last.LastSeen)
+}
@@ -0,0 +1,59 @@
+package mission_control
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+)
+
+// ArtifactFilter selects artifacts by the run action, check or change that produced them
+type ArtifactFilter struct {
+	PlaybookRunActionID string
+	CheckID             string
+	ConfigChangeID      string
+	Filename            string
+}
+
+func (mc *MissionControl) ListArtifacts(ctx context.Context, filter ArtifactFilter) ([]Artifact, error) {
+	filters := map[string]string{"order": "created_at.desc"}
+	if filter.PlaybookRunActionID != "" {
+		filters["playbook_run_action_id"] = "eq." + filter.PlaybookRunActionID
+	}
+	if filter.CheckID != "" {
+		filters["check_id"] = "eq." + filter.CheckID
+	}
+	if filter.ConfigChangeID != "" {
+		filters["config_change_id"] = "eq." + filter.ConfigChangeID
+	}
+	if filter.Filename != "" {
+		filters["filename"] = "eq." + filter.Filename
+	}
+
+	var artifacts []Artifact
+	if err := mc.dbSelect(ctx, "list artifacts", "artifacts", filters, &artifacts); err != nil {
+		return nil, err
+	}
+	return artifacts, nil
+}
+
+// DownloadArtifact streams the content of an artifact to w, returning the number of bytes
+// written and their hex encoded sha256 checksum for comparison with Artifact.Checksum
+func (mc *MissionControl) DownloadArtifact(ctx context.Context, id string, w io.Writer) (int64, string, error) {
+	r, err := mc.HTTP.R(ctx).Get("/artifacts/download/" + id)
+	if err != nil {
+		return 0, "", err
+	}
+	if !r.IsOK() {
+		return 0, "", newAPIError("download artifact "+id, r)
+	}
+	defer r.Body.Close()
+
+	hash := sha256.New()
+	n, err := io.Copy(io.MultiWriter(w, hash), r.Body)
+	if err != nil {
+		return n, "", fmt.Errorf("failed to download artifact %s: %w", id, err)
+	}
+	return n, hex.EncodeToString(hash.Sum(nil)), nil
+}
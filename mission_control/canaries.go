@@ -0,0 +1,143 @@
+package mission_control
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+type Canary struct {
+	ID        string          `json:"id,omitempty"`
+	Name      string          `json:"name"`
+	Namespace string          `json:"namespace"`
+	Spec      json.RawMessage `json:"spec"`
+	Source    string          `json:"source,omitempty"`
+	CreatedAt *time.Time      `json:"created_at,omitempty"`
+	DeletedAt *time.Time      `json:"deleted_at,omitempty"`
+}
+
+type Check struct {
+	ID       string `json:"id"`
+	CanaryID string `json:"canary_id"`
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Status   string `json:"status"`
+}
+
+type CheckStatus struct {
+	CheckID  string `json:"check_id"`
+	Status   bool   `json:"status"`
+	Invalid  bool   `json:"invalid"`
+	Time     string `json:"time"`
+	Duration int    `json:"duration"`
+	Message  string `json:"message,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// CreateCanary stores a canary spec, canary-checker picks it up on its next sync
+func (mc *MissionControl) CreateCanary(ctx context.Context, name, namespace string, spec any) (*Canary, error) {
+	raw, err := json.Marshal(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	r, err := mc.HTTP.R(ctx).
+		Header("Content-Type", "application/json").
+		Header("Prefer", "return=representation").
+		Post("/db/canaries", Canary{Name: name, Namespace: namespace, Spec: raw, Source: "UI"})
+	if err != nil {
+		return nil, err
+	}
+	if !r.IsOK() {
+		return nil, newAPIError("create canary "+name, r)
+	}
+
+	var canaries []Canary
+	if err := r.Into(&canaries); err != nil {
+		return nil, err
+	}
+	if len(canaries) == 0 {
+		return nil, &APIError{Op: "create canary " + name, StatusCode: r.StatusCode, Message: "no canary returned"}
+	}
+	return &canaries[0], nil
+}
+
+// DeleteCanary soft deletes a canary
+func (mc *MissionControl) DeleteCanary(ctx context.Context, id string) error {
+	r, err := mc.HTTP.R(ctx).
+		Header("Content-Type", "application/json").
+		QueryParam("id", "eq."+id).
+		Patch("/db/canaries", map[string]any{"deleted_at": time.Now().UTC()})
+	if err != nil {
+		return err
+	}
+	if !r.IsOK() {
+		return newAPIError("delete canary "+id, r)
+	}
+	return nil
+}
+
+// GetCanaryChecks returns the checks created for a canary
+func (mc *MissionControl) GetCanaryChecks(ctx context.Context, canaryID string) ([]Check, error) {
+	r, err := mc.HTTP.R(ctx).
+		QueryParam("canary_id", "eq."+canaryID).
+		QueryParam("deleted_at", "is.null").
+		Get("/db/checks")
+	if err != nil {
+		return nil, err
+	}
+	if !r.IsOK() {
+		return nil, newAPIError("get checks of canary "+canaryID, r)
+	}
+
+	var checks []Check
+	if err := r.Into(&checks); err != nil {
+		return nil, err
+	}
+	return checks, nil
+}
+
+// TriggerCanary runs all checks of a canary immediately
+func (mc *MissionControl) TriggerCanary(ctx context.Context, canaryID string) error {
+	r, err := mc.HTTP.R(ctx).Post("/canary/run/canary/"+canaryID, nil)
+	if err != nil {
+		return err
+	}
+	if !r.IsOK() {
+		return newAPIError("trigger canary "+canaryID, r)
+	}
+	return nil
+}
+
+// TriggerCheck runs a single check immediately
+func (mc *MissionControl) TriggerCheck(ctx context.Context, checkID string) error {
+	r, err := mc.HTTP.R(ctx).Post("/canary/run/check/"+checkID, nil)
+	if err != nil {
+		return err
+	}
+	if !r.IsOK() {
+		return newAPIError("trigger check "+checkID, r)
+	}
+	return nil
+}
+
+// GetCheckStatuses returns the results of a check since the given time, newest first
+func (mc *MissionControl) GetCheckStatuses(ctx context.Context, checkID string, since time.Time) ([]CheckStatus, error) {
+	r, err := mc.HTTP.R(ctx).
+		QueryParam("check_id", "eq."+checkID).
+		QueryParam("time", "gte."+since.UTC().Format(time.RFC3339)).
+		QueryParam("order", "time.desc").
+		Get("/db/check_statuses")
+	if err != nil {
+		return nil, err
+	}
+	if !r.IsOK() {
+		return nil, newAPIError("get statuses of check "+checkID, r)
+	}
+
+	var statuses []CheckStatus
+	if err := r.Into(&statuses); err != nil {
+		return nil, err
+	}
+	return statuses, nil
+}
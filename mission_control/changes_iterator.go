@@ -0,0 +1,86 @@
+package mission_control
+
+import "context"
+
+// DefaultChangesPageSize is used by the changes iterator when the request does not set a PageSize
+const DefaultChangesPageSize = 100
+
+// CatalogChangesIterator walks all pages of a catalog changes search
+type CatalogChangesIterator struct {
+	mc       *MissionControl
+	ctx      context.Context
+	req      CatalogChangesSearchRequest
+	maxItems int
+
+	page    []ConfigChangeRow
+	total   int64
+	fetched int
+	done    bool
+	err     error
+}
+
+// IterateCatalogChanges returns an iterator over every change matching req, starting at req.Page.
+// maxItems caps the number of changes returned in total, 0 means no cap.
+func (mc *MissionControl) IterateCatalogChanges(ctx context.Context, req CatalogChangesSearchRequest, maxItems int) *CatalogChangesIterator {
+	if req.PageSize <= 0 {
+		req.PageSize = DefaultChangesPageSize
+	}
+	if req.Page <= 0 {
+		req.Page = 1
+	}
+	return &CatalogChangesIterator{mc: mc, ctx: ctx, req: req, maxItems: maxItems}
+}
+
+// Next fetches the next page, returning false when there are no more changes or an error occurred
+func (it *CatalogChangesIterator) Next() bool {
+	if it.done {
+		return false
+	}
+
+	response, err := it.mc.SearchCatalogChanges(it.ctx, it.req)
+	if err != nil {
+		it.err = err
+		it.done = true
+		return false
+	}
+
+	it.total = response.Total
+	it.page = response.Changes
+	if it.maxItems > 0 && it.fetched+len(it.page) > it.maxItems {
+		it.page = it.page[:it.maxItems-it.fetched]
+	}
+	it.fetched += len(it.page)
+	it.req.Page++
+
+	// The total is omitted by some servers, a short page is the only reliable end marker then
+	if len(response.Changes) < it.req.PageSize ||
+		(it.total > 0 && int64(it.fetched) >= it.total) ||
+		(it.maxItems > 0 && it.fetched >= it.maxItems) {
+		it.done = true
+	}
+	return len(it.page) > 0
+}
+
+// Page returns the changes fetched by the last call to Next
+func (it *CatalogChangesIterator) Page() []ConfigChangeRow {
+	return it.page
+}
+
+// Total returns the total number of matching changes reported by the server, 0 when it omits it
+func (it *CatalogChangesIterator) Total() int64 {
+	return it.total
+}
+
+// Err returns the error that stopped the iteration, if any
+func (it *CatalogChangesIterator) Err() error {
+	return it.err
+}
+
+// All drains the iterator and returns every change
+func (it *CatalogChangesIterator) All() ([]ConfigChangeRow, error) {
+	var changes []ConfigChangeRow
+	for it.Next() {
+		changes = append(changes, it.Page()...)
+	}
+	return changes, it.Err()
+}
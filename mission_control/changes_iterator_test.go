@@ -0,0 +1,43 @@
+package mission_control_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	mc "github.com/flanksource/commons-test/mission_control"
+	"github.com/flanksource/commons-test/mission_control/fake"
+)
+
+func TestIterateCatalogChanges(t *testing.T) {
+	var changes []mc.ConfigChangeRow
+	for i := range 25 {
+		changes = append(changes, mc.ConfigChangeRow{ID: fmt.Sprint(i), ConfigID: "1", ChangeType: "diff"})
+	}
+
+	for name, server := range map[string]*fake.Server{
+		"with total":    fake.NewServer().WithChanges(changes...),
+		"without total": fake.NewServer().WithChanges(changes...).WithoutTotals(),
+	} {
+		t.Run(name, func(t *testing.T) {
+			defer server.Close()
+			client := server.Client()
+
+			all, err := client.IterateCatalogChanges(context.Background(), mc.CatalogChangesSearchRequest{PageSize: 10}, 0).All()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(all) != len(changes) {
+				t.Errorf("expected %d changes, got %d", len(changes), len(all))
+			}
+
+			capped, err := client.IterateCatalogChanges(context.Background(), mc.CatalogChangesSearchRequest{PageSize: 10}, 15).All()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(capped) != 15 {
+				t.Errorf("expected 15 changes, got %d", len(capped))
+			}
+		})
+	}
+}
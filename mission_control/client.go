@@ -0,0 +1,247 @@
+package mission_control
+
+import (
+	"bytes"
+	"io"
+	gohttp "net/http"
+	"net/http/httptrace"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/flanksource/commons/http"
+	"github.com/flanksource/commons/http/middlewares"
+)
+
+const (
+	// DefaultRetryAttempts is the number of attempts made for each request by default
+	DefaultRetryAttempts = 5
+	// DefaultRetryDelay is the initial backoff between attempts
+	DefaultRetryDelay = 500 * time.Millisecond
+)
+
+type authMode int
+
+const (
+	authNone authMode = iota
+	authBasic
+	authToken
+	authKratos
+)
+
+// authMode returns how requests are authenticated, safe to call while Login or Logout switch it
+func (mc *MissionControl) authMode() authMode {
+	return authMode(mc.auth.Load())
+}
+
+func (mc *MissionControl) setAuth(mode authMode) {
+	mc.auth.Store(int32(mode))
+}
+
+// Option configures a MissionControl client created by NewClient
+type Option func(*MissionControl)
+
+// WithBasicAuth authenticates every request with HTTP basic auth
+func WithBasicAuth(username, password string) Option {
+	return func(mc *MissionControl) {
+		mc.Username = username
+		mc.Password = password
+		mc.setAuth(authBasic)
+	}
+}
+
+// WithToken authenticates every request with a bearer access token or agent token
+func WithToken(token string) Option {
+	return func(mc *MissionControl) {
+		mc.Token = token
+		mc.setAuth(authToken)
+	}
+}
+
+// WithKratosLogin logs in through the Ory Kratos password flow on the first request and
+// attaches the resulting session to every request, logging in again when the session expires
+func WithKratosLogin(username, password string) Option {
+	return func(mc *MissionControl) {
+		mc.Username = username
+		mc.Password = password
+		mc.setAuth(authKratos)
+	}
+}
+
+// WithConfigDB sets the base URL of the config-db API, defaults to the mission-control URL
+func WithConfigDB(url string) Option {
+	return func(mc *MissionControl) {
+		mc.configDBURL = url
+	}
+}
+
+// WithRetry retries requests failing with a connection error, 429 or 5xx up to maxAttempts
+// times in total, backing off exponentially from baseDelay and honouring Retry-After on 429.
+// A maxAttempts of 1 or less disables retries.
+func WithRetry(maxAttempts int, baseDelay time.Duration) Option {
+	return func(mc *MissionControl) {
+		mc.retryAttempts = maxAttempts
+		mc.retryDelay = baseDelay
+	}
+}
+
+// WithNamespace records the namespace mission-control is installed in
+func WithNamespace(namespace string) Option {
+	return func(mc *MissionControl) {
+		mc.Namespace = namespace
+	}
+}
+
+// NewClient returns a MissionControl client for url with the HTTP and ConfigDB clients
+// sharing the same authentication
+func NewClient(url string, opts ...Option) (*MissionControl, error) {
+	mc := &MissionControl{
+		URL:            strings.TrimSuffix(url, "/"),
+		retryAttempts:  DefaultRetryAttempts,
+		retryDelay:     DefaultRetryDelay,
+		prometheusPath: DefaultPrometheusPath,
+	}
+	for _, opt := range opts {
+		opt(mc)
+	}
+	if mc.configDBURL == "" {
+		mc.configDBURL = mc.URL
+	}
+
+	mc.HTTP = mc.newHTTPClient(mc.URL).Use(mc.authMiddleware)
+	mc.ConfigDB = mc.newHTTPClient(mc.configDBURL).Use(mc.authMiddleware)
+	return mc, nil
+}
+
+// clone returns a client with the same settings and database as mc that authenticates with auth
+func (mc *MissionControl) clone(auth Option) *MissionControl {
+	opts := []Option{
+		WithConfigDB(mc.configDBURL),
+		WithNamespace(mc.Namespace),
+		WithRetry(mc.retryAttempts, mc.retryDelay),
+		WithPrometheusProxy(mc.prometheusPath),
+		auth,
+	}
+	if mc.debug {
+		opts = append(opts, WithDebugWriter(mc.debugWriter, mc.debugBodies))
+	}
+
+	// NewClient only fails for invalid options, none of which are possible here
+	client, _ := NewClient(mc.URL, opts...)
+	client.DB = mc.DB
+	return client
+}
+
+// newHTTPClient returns an unauthenticated client for baseURL using the configured retry policy
+func (mc *MissionControl) newHTTPClient(baseURL string) *http.Client {
+	client := http.NewClient().BaseURL(baseURL).Use(mc.retryMiddleware, mc.debugMiddleware)
+	// Spans are only exported once tracing.Setup has configured a provider
+	client.Trace(http.TraceConfig{SpanName: "mission-control", QueryParam: true, Timing: true})
+	return client
+}
+
+// retryMiddleware retries idempotent requests failing with a connection error, 429 or 5xx. Other
+// requests, e.g. the POST of CreateIncident, are only retried when the connection failed before the
+// request was written, as a server error can come after the write was committed. Retries happen at
+// the transport so the buffered body can be replayed, the http.Client retry strategy re-sends the
+// already drained body reader and every retried POST would go out empty.
+func (mc *MissionControl) retryMiddleware(next gohttp.RoundTripper) gohttp.RoundTripper {
+	if mc.retryAttempts <= 1 {
+		return next
+	}
+	strategy := http.RetryOnStatus(mc.retryAttempts, mc.retryDelay,
+		gohttp.StatusTooManyRequests,
+		gohttp.StatusInternalServerError,
+		gohttp.StatusBadGateway,
+		gohttp.StatusServiceUnavailable,
+		gohttp.StatusGatewayTimeout,
+	)
+	return middlewares.RoundTripperFunc(func(req *gohttp.Request) (*gohttp.Response, error) {
+		var body []byte
+		if req.Body != nil && req.Body != gohttp.NoBody {
+			var err error
+			body, err = io.ReadAll(req.Body)
+			req.Body.Close()
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		idempotent := slices.Contains([]string{gohttp.MethodGet, gohttp.MethodHead, gohttp.MethodOptions, gohttp.MethodPut, gohttp.MethodDelete}, req.Method)
+		for attempt := 0; ; attempt++ {
+			var written atomic.Bool
+			attemptReq := req.Clone(httptrace.WithClientTrace(req.Context(), &httptrace.ClientTrace{
+				WroteRequest: func(httptrace.WroteRequestInfo) { written.Store(true) },
+			}))
+			if body != nil {
+				attemptReq.Body = io.NopCloser(bytes.NewReader(body))
+				attemptReq.GetBody = func() (io.ReadCloser, error) {
+					return io.NopCloser(bytes.NewReader(body)), nil
+				}
+				attemptReq.ContentLength = int64(len(body))
+			}
+
+			resp, err := next.RoundTrip(attemptReq)
+			if !idempotent && (err == nil || written.Load()) {
+				return resp, err
+			}
+			retry, delay := strategy(&http.Response{Response: resp}, err, attempt)
+			if !retry {
+				return resp, err
+			}
+			if resp != nil {
+				resp.Body.Close()
+			}
+
+			select {
+			case <-req.Context().Done():
+				return nil, req.Context().Err()
+			case <-time.After(delay):
+			}
+		}
+	})
+}
+
+// authMiddleware attaches the configured credentials, logging in again through
+// Kratos once if the session has expired or the server rejects it
+func (mc *MissionControl) authMiddleware(next gohttp.RoundTripper) gohttp.RoundTripper {
+	return middlewares.RoundTripperFunc(func(req *gohttp.Request) (*gohttp.Response, error) {
+		switch mc.authMode() {
+		case authBasic:
+			req.SetBasicAuth(mc.Username, mc.Password)
+			return next.RoundTrip(req)
+		case authToken:
+			req.Header.Set("Authorization", "Bearer "+mc.Token)
+			return next.RoundTrip(req)
+		case authNone:
+			return next.RoundTrip(req)
+		}
+
+		if err := mc.attachSession(req); err != nil {
+			return nil, err
+		}
+
+		resp, err := next.RoundTrip(req)
+		replayable := req.GetBody != nil || req.Body == nil || req.Body == gohttp.NoBody
+		if err != nil || resp.StatusCode != gohttp.StatusUnauthorized || !replayable {
+			return resp, err
+		}
+
+		// The session was revoked or expired server side, log in again and replay once
+		resp.Body.Close()
+		if err := mc.kratosLogin(req.Context()); err != nil {
+			return nil, err
+		}
+		retry := req.Clone(req.Context())
+		if req.GetBody != nil {
+			if retry.Body, err = req.GetBody(); err != nil {
+				return nil, err
+			}
+		}
+		if err := mc.attachSession(retry); err != nil {
+			return nil, err
+		}
+		return next.RoundTrip(retry)
+	})
+}
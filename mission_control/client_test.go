@@ -0,0 +1,54 @@
+package mission_control_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	mc "github.com/flanksource/commons-test/mission_control"
+)
+
+func TestRetrySkipsNonIdempotentRequests(t *testing.T) {
+	// A 503 may come after the server committed the write, so the search POST is not retried
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	client, err := mc.NewClient(server.URL, mc.WithRetry(3, time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := client.QueryCatalog(context.Background(), mc.ResourceSelector{Name: "nginx"}); err == nil {
+		t.Error("expected the server error to be returned")
+	}
+	if attempts != 1 {
+		t.Errorf("expected 1 attempt, got %d", attempts)
+	}
+}
+
+func TestRetryGivesUp(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	client, err := mc.NewClient(server.URL, mc.WithRetry(2, time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if healthy, err := client.IsHealthy(context.Background()); err != nil || healthy {
+		t.Errorf("expected an unhealthy response after exhausting retries, got %v %v", healthy, err)
+	}
+	if attempts != 2 {
+		t.Errorf("expected 2 attempts, got %d", attempts)
+	}
+}
@@ -0,0 +1,77 @@
+package mission_control
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+type ConfigItem struct {
+	ID          string            `json:"id"`
+	ScraperID   string            `json:"scraper_id,omitempty"`
+	AgentID     string            `json:"agent_id,omitempty"`
+	ExternalID  []string          `json:"external_id,omitempty"`
+	Name        string            `json:"name"`
+	Type        string            `json:"type"`
+	ConfigClass string            `json:"config_class,omitempty"`
+	Tags        map[string]string `json:"tags,omitempty"`
+	Labels      map[string]string `json:"labels,omitempty"`
+	Config      map[string]any    `json:"config,omitempty"`
+	Health      string            `json:"health,omitempty"`
+	Status      string            `json:"status,omitempty"`
+	Ready       bool              `json:"ready"`
+	Description string            `json:"description,omitempty"`
+	ParentID    string            `json:"parent_id,omitempty"`
+	Path        string            `json:"path,omitempty"`
+	CreatedAt   *time.Time        `json:"created_at,omitempty"`
+	UpdatedAt   *time.Time        `json:"updated_at,omitempty"`
+	DeletedAt   *time.Time        `json:"deleted_at,omitempty"`
+}
+
+// Parents returns the IDs of the item's ancestors, from the root down to the direct parent
+func (c *ConfigItem) Parents() []string {
+	if c.Path == "" {
+		return nil
+	}
+	var parents []string
+	for _, id := range strings.Split(c.Path, ".") {
+		if id != "" && id != c.ID {
+			parents = append(parents, id)
+		}
+	}
+	return parents
+}
+
+// Namespace returns the namespace tag of the item
+func (c *ConfigItem) Namespace() string {
+	return c.Tags["namespace"]
+}
+
+func (mc *MissionControl) GetConfigItem(ctx context.Context, id string) (*ConfigItem, error) {
+	return mc.getConfigItem(ctx, "get config item "+id, map[string]string{"id": "eq." + id})
+}
+
+// GetConfigItemByExternalID returns the item a scraper created for an external ID
+func (mc *MissionControl) GetConfigItemByExternalID(ctx context.Context, scraperID, externalID string) (*ConfigItem, error) {
+	return mc.getConfigItem(ctx, "get config item "+externalID, map[string]string{
+		"scraper_id":  "eq." + scraperID,
+		"external_id": "cs.{" + quotePostgrestArrayValue(externalID) + "}",
+		"deleted_at":  "is.null",
+	})
+}
+
+func (mc *MissionControl) getConfigItem(ctx context.Context, op string, filters map[string]string) (*ConfigItem, error) {
+	var items []ConfigItem
+	if err := mc.dbSelect(ctx, op, "config_items", filters, &items); err != nil {
+		return nil, err
+	}
+	if len(items) == 0 {
+		return nil, &APIError{Op: op, StatusCode: 404, Message: "config item not found"}
+	}
+	return &items[0], nil
+}
+
+// quotePostgrestArrayValue quotes a value for use inside a PostgREST array literal
+func quotePostgrestArrayValue(value string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(value) + `"`
+}
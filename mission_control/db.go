@@ -0,0 +1,157 @@
+package mission_control
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+)
+
+// ConnectDB opens a connection to the mission-control database and stores it in mc.DB
+func (mc *MissionControl) ConnectDB(ctx context.Context, dsn string) error {
+	db, err := sql.Open("pgx", dsn)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return fmt.Errorf("failed to connect to database: %w", err)
+	}
+	mc.DB = db
+	return nil
+}
+
+// WaitForMigrations waits until the schema migrations have been applied and tables
+// (config_items by default) exist
+func (mc *MissionControl) WaitForMigrations(ctx context.Context, timeout time.Duration, tables ...string) error {
+	if mc.DB == nil {
+		return fmt.Errorf("database not connected, call ConnectDB first")
+	}
+	if len(tables) == 0 {
+		tables = []string{"config_items"}
+	}
+
+	var pending string
+	err := poll(ctx, timeout, func(ctx context.Context) bool {
+		var applied int64
+		if err := mc.DB.QueryRowContext(ctx, "SELECT COUNT(*) FROM migration_logs").Scan(&applied); err != nil {
+			pending = "migration_logs: " + err.Error()
+			return false
+		}
+		if applied == 0 {
+			pending = "no migrations applied"
+			return false
+		}
+
+		for _, table := range tables {
+			var exists bool
+			if err := mc.DB.QueryRowContext(ctx, "SELECT to_regclass($1) IS NOT NULL", table).Scan(&exists); err != nil || !exists {
+				pending = "table " + table + " does not exist"
+				return false
+			}
+		}
+		return true
+	})
+	if err != nil {
+		return fmt.Errorf("migrations not complete after %v: %s", timeout, pending)
+	}
+	return nil
+}
+
+// QueryRowInto runs a query returning a single row and scans it into dest, which is either
+// a pointer to a struct (columns matched on db, json or lowercased field names) or a pointer to a scalar
+func (mc *MissionControl) QueryRowInto(ctx context.Context, dest any, query string, args ...any) error {
+	if mc.DB == nil {
+		return fmt.Errorf("database not connected, call ConnectDB first")
+	}
+
+	rows, err := mc.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		return sql.ErrNoRows
+	}
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+
+	v := reflect.ValueOf(dest)
+	if v.Kind() != reflect.Pointer || v.IsNil() {
+		return fmt.Errorf("dest must be a non-nil pointer, got %T", dest)
+	}
+	if v.Elem().Kind() != reflect.Struct || v.Elem().Type() == reflect.TypeOf(time.Time{}) {
+		return rows.Scan(dest)
+	}
+
+	fields := structFields(v.Elem())
+	targets := make([]any, len(columns))
+	for i, column := range columns {
+		if field, ok := fields[strings.ToLower(column)]; ok {
+			targets[i] = field.Addr().Interface()
+		} else {
+			targets[i] = new(any)
+		}
+	}
+	return rows.Scan(targets...)
+}
+
+// CountRows returns the number of rows in table matching where (e.g. "type = $1"), an empty where counts all rows
+func (mc *MissionControl) CountRows(ctx context.Context, table, where string, args ...any) (int64, error) {
+	if mc.DB == nil {
+		return 0, fmt.Errorf("database not connected, call ConnectDB first")
+	}
+
+	query := "SELECT COUNT(*) FROM " + quoteIdentifier(table)
+	if where != "" {
+		query += " WHERE " + where
+	}
+
+	var count int64
+	if err := mc.DB.QueryRowContext(ctx, query, args...).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count %s: %w", table, err)
+	}
+	return count, nil
+}
+
+// structFields maps column names to the settable fields of a struct
+func structFields(v reflect.Value) map[string]reflect.Value {
+	fields := map[string]reflect.Value{}
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+
+		name := strings.ToLower(f.Name)
+		for _, tag := range []string{"db", "json"} {
+			if value := strings.Split(f.Tag.Get(tag), ",")[0]; value != "" && value != "-" {
+				name = value
+				break
+			}
+		}
+		fields[name] = v.Field(i)
+	}
+	return fields
+}
+
+// quoteIdentifier quotes a possibly schema qualified table name
+func quoteIdentifier(name string) string {
+	parts := strings.Split(name, ".")
+	for i, part := range parts {
+		parts[i] = `"` + strings.ReplaceAll(part, `"`, `""`) + `"`
+	}
+	return strings.Join(parts, ".")
+}
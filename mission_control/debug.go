@@ -0,0 +1,131 @@
+package mission_control
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	gohttp "net/http"
+	"strings"
+	"time"
+
+	"github.com/flanksource/commons/http/middlewares"
+	"github.com/flanksource/commons/logger"
+)
+
+// maxLoggedBody caps how much of a request or response body is logged
+const maxLoggedBody = 4096
+
+// redactedKeys are JSON body keys whose values are never logged
+var redactedKeys = []string{"password", "token", "secret", "session_token", "api_key", "access_token", "refresh_token"}
+
+// WithDebug logs method, URL, status and latency of every request, and the
+// request and response bodies with secrets redacted when bodies is true
+func WithDebug(bodies bool) Option {
+	return func(mc *MissionControl) {
+		mc.debug = true
+		mc.debugBodies = bodies
+	}
+}
+
+// WithDebugWriter sends debug logs to w (e.g. GinkgoWriter) instead of the package logger, implies WithDebug
+func WithDebugWriter(w io.Writer, bodies bool) Option {
+	return func(mc *MissionControl) {
+		mc.debug = true
+		mc.debugBodies = bodies
+		mc.debugWriter = w
+	}
+}
+
+func (mc *MissionControl) debugf(format string, args ...any) {
+	if mc.debugWriter != nil {
+		fmt.Fprintf(mc.debugWriter, format+"\n", args...)
+		return
+	}
+	logger.GetLogger("mission-control").Infof(format, args...)
+}
+
+// debugMiddleware logs each request and response when debug mode is enabled
+func (mc *MissionControl) debugMiddleware(next gohttp.RoundTripper) gohttp.RoundTripper {
+	return middlewares.RoundTripperFunc(func(req *gohttp.Request) (*gohttp.Response, error) {
+		if !mc.debug {
+			return next.RoundTrip(req)
+		}
+
+		if mc.debugBodies && req.GetBody != nil {
+			if body, err := req.GetBody(); err == nil {
+				data, _ := io.ReadAll(body)
+				body.Close()
+				if len(data) > 0 {
+					mc.debugf("--> %s %s %s", req.Method, req.URL, redactBody(data))
+				}
+			}
+		}
+
+		start := time.Now()
+		resp, err := next.RoundTrip(req)
+		latency := time.Since(start).Round(time.Millisecond)
+		if err != nil {
+			mc.debugf("<-- %s %s failed after %v: %v", req.Method, req.URL, latency, err)
+			return resp, err
+		}
+
+		// Streams never end, so their bodies cannot be buffered for logging
+		if !mc.debugBodies || strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream") {
+			mc.debugf("<-- %s %s %d (%v)", req.Method, req.URL, resp.StatusCode, latency)
+			return resp, nil
+		}
+
+		data, readErr := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		resp.Body = io.NopCloser(bytes.NewReader(data))
+		if readErr != nil {
+			return resp, readErr
+		}
+		mc.debugf("<-- %s %s %d (%v) %s", req.Method, req.URL, resp.StatusCode, latency, redactBody(data))
+		return resp, nil
+	})
+}
+
+// redactBody masks secret values in JSON bodies and truncates long bodies
+func redactBody(data []byte) string {
+	var value any
+	if err := json.Unmarshal(data, &value); err == nil {
+		redactValue(value)
+		if redacted, err := json.Marshal(value); err == nil {
+			data = redacted
+		}
+	}
+
+	if len(data) > maxLoggedBody {
+		return string(data[:maxLoggedBody]) + fmt.Sprintf("... (%d bytes)", len(data))
+	}
+	return string(data)
+}
+
+func redactValue(value any) {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, child := range v {
+			if isSecretKey(key) {
+				v[key] = "***"
+				continue
+			}
+			redactValue(child)
+		}
+	case []any:
+		for _, child := range v {
+			redactValue(child)
+		}
+	}
+}
+
+func isSecretKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, secret := range redactedKeys {
+		if key == secret {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,125 @@
+package mission_control
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	flanksourceCtx "github.com/flanksource/commons-db/context"
+
+	"github.com/flanksource/commons-test/helm"
+	"github.com/flanksource/commons-test/kind"
+)
+
+// ChartOptions configures the mission-control release installed by Deploy, zero values use the defaults
+type ChartOptions struct {
+	// Chart defaults to mission-control from the flanksource repository
+	Chart         string
+	Repository    string
+	RepositoryURL string
+	// Release and Namespace default to mission-control
+	Release   string
+	Namespace string
+	Values    map[string]any
+	// Selector matches the mission-control pod, defaults to app.kubernetes.io/name=mission-control
+	Selector string
+	// Port is the HTTP port of the mission-control pod, defaults to 8080
+	Port int
+	// Username and Password of the admin user, the password is set as the adminPassword chart value
+	Username string
+	Password string
+	// Kratos logs in through Kratos instead of basic auth
+	Kratos bool
+	// Timeout bounds the install and the wait for a healthy server, defaults to 10 minutes
+	Timeout time.Duration
+	// ClientOptions are passed to NewClient
+	ClientOptions []Option
+}
+
+func (o ChartOptions) withDefaults() ChartOptions {
+	if o.Chart == "" {
+		o.Chart = "mission-control"
+		if o.Repository == "" {
+			o.Repository = "flanksource"
+			o.RepositoryURL = "https://flanksource.github.io/charts"
+		}
+	}
+	if o.Release == "" {
+		o.Release = "mission-control"
+	}
+	if o.Namespace == "" {
+		o.Namespace = "mission-control"
+	}
+	if o.Selector == "" {
+		o.Selector = "app.kubernetes.io/name=mission-control"
+	}
+	if o.Port == 0 {
+		o.Port = 8080
+	}
+	if o.Username == "" {
+		o.Username = "admin@local"
+	}
+	if o.Password == "" {
+		o.Password = "admin"
+	}
+	if o.Timeout == 0 {
+		o.Timeout = 10 * time.Minute
+	}
+	return o
+}
+
+// Deploy installs or upgrades mission-control in the kind cluster, waits for it to become healthy
+// behind a port-forward and logs in. The returned func stops the port-forward.
+func Deploy(ctx context.Context, cluster *kind.Kind, opts ChartOptions) (*MissionControl, func(), error) {
+	opts = opts.withDefaults()
+
+	if err := cluster.Use().Error(); err != nil {
+		return nil, nil, fmt.Errorf("failed to use kind cluster %s: %w", cluster.Name, err)
+	}
+
+	chart := helm.NewHelmChart(flanksourceCtx.New(), opts.Chart).
+		Release(opts.Release).
+		Namespace(opts.Namespace).
+		Values(opts.Values).
+		SetValue("adminPassword", opts.Password).
+		WaitFor(opts.Timeout)
+	if opts.Repository != "" {
+		chart = chart.Repository(opts.Repository, opts.RepositoryURL)
+	}
+	if err := chart.InstallOrUpgrade(); err != nil {
+		return nil, nil, fmt.Errorf("failed to install %s: %w", opts.Release, err)
+	}
+
+	pod := chart.GetPod(opts.Selector)
+	if err := pod.WaitFor("condition=Ready", opts.Timeout).Error(); err != nil {
+		return nil, nil, fmt.Errorf("mission-control pod is not ready: %w", err)
+	}
+
+	clientOpts := []Option{WithNamespace(opts.Namespace)}
+	if opts.Kratos {
+		clientOpts = append(clientOpts, WithKratosLogin(opts.Username, opts.Password))
+	} else {
+		clientOpts = append(clientOpts, WithBasicAuth(opts.Username, opts.Password))
+	}
+	mc, stop, err := FromPortForward(pod, opts.Port, append(clientOpts, opts.ClientOptions...)...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := poll(ctx, opts.Timeout, func(ctx context.Context) bool {
+		healthy, _ := mc.IsHealthy(ctx)
+		return healthy
+	}); err != nil {
+		stop()
+		return nil, nil, fmt.Errorf("mission-control is not healthy after %v: %w", opts.Timeout, err)
+	}
+
+	if _, ok, err := mc.WhoAmI(ctx); err != nil || !ok {
+		stop()
+		if err == nil {
+			err = fmt.Errorf("whoami was rejected")
+		}
+		return nil, nil, fmt.Errorf("failed to log in as %s: %w", opts.Username, err)
+	}
+	return mc, stop, nil
+}
@@ -0,0 +1,94 @@
+package mission_control
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	gohttp "net/http"
+	"strings"
+
+	"github.com/flanksource/commons/http"
+)
+
+// APIError is returned when mission-control responds with a non-OK status
+type APIError struct {
+	// Op describes the client call that failed, e.g. "query catalog"
+	Op         string
+	StatusCode int
+	// Code is the machine readable error code from the response body, if any
+	Code      string
+	Message   string
+	RequestID string
+	Body      string
+}
+
+func (e *APIError) Error() string {
+	msg := e.Message
+	if msg == "" {
+		msg = e.Body
+	}
+	s := fmt.Sprintf("%s failed: %d %s", e.Op, e.StatusCode, gohttp.StatusText(e.StatusCode))
+	if e.Code != "" {
+		s += " (" + e.Code + ")"
+	}
+	if msg != "" {
+		s += ": " + msg
+	}
+	if e.RequestID != "" {
+		s += " [request-id " + e.RequestID + "]"
+	}
+	return s
+}
+
+// newAPIError builds an APIError from a non-OK response
+func newAPIError(op string, r *http.Response) error {
+	body, _ := r.AsString()
+	apiErr := &APIError{
+		Op:         op,
+		StatusCode: r.StatusCode,
+		Body:       strings.TrimSpace(body),
+		RequestID:  r.Header.Get("X-Request-Id"),
+	}
+	if apiErr.RequestID == "" {
+		apiErr.RequestID = r.Header.Get("X-Trace-Id")
+	}
+
+	// mission-control and config-db use {"error": "...", "message": "..."} with an optional code
+	var payload struct {
+		Error   string `json:"error"`
+		Message string `json:"message"`
+		Code    string `json:"code"`
+	}
+	if json.Unmarshal([]byte(body), &payload) == nil {
+		apiErr.Code = payload.Code
+		apiErr.Message = payload.Message
+		if apiErr.Message == "" {
+			apiErr.Message = payload.Error
+		}
+	}
+	return apiErr
+}
+
+// StatusCode returns the HTTP status of an APIError anywhere in err's chain, or 0
+func StatusCode(err error) int {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode
+	}
+	return 0
+}
+
+// IsNotFound returns true if err is an APIError with a 404 status
+func IsNotFound(err error) bool {
+	return StatusCode(err) == gohttp.StatusNotFound
+}
+
+// IsForbidden returns true if err is an APIError with a 403 status
+func IsForbidden(err error) bool {
+	return StatusCode(err) == gohttp.StatusForbidden
+}
+
+// IsUnauthorized returns true if err is an APIError with a 401 status
+func IsUnauthorized(err error) bool {
+	return StatusCode(err) == gohttp.StatusUnauthorized
+}
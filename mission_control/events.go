@@ -0,0 +1,89 @@
+package mission_control
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Event is a row of the event_queue table. Events are deleted once processed successfully,
+// failed events stay in the queue with Error set until they are retried or expire.
+type Event struct {
+	ID          string            `json:"id"`
+	Name        string            `json:"name"`
+	Properties  map[string]string `json:"properties,omitempty"`
+	Error       *string           `json:"error,omitempty"`
+	Attempts    int               `json:"attempts"`
+	Priority    int               `json:"priority"`
+	LastAttempt *time.Time        `json:"last_attempt,omitempty"`
+	CreatedAt   *time.Time        `json:"created_at,omitempty"`
+}
+
+// IsFailed returns true when the last processing attempt returned an error
+func (e Event) IsFailed() bool {
+	return e.Error != nil && *e.Error != ""
+}
+
+// GetEventQueueDepth returns the number of events in the queue (pending and failed), an empty name counts all events
+func (mc *MissionControl) GetEventQueueDepth(ctx context.Context, name string) (int, error) {
+	events, err := mc.listEvents(ctx, "get event queue depth", name, nil)
+	if err != nil {
+		return 0, err
+	}
+	return len(events), nil
+}
+
+// ListFailedEvents returns events whose last attempt failed, most recent first
+func (mc *MissionControl) ListFailedEvents(ctx context.Context) ([]Event, error) {
+	return mc.listEvents(ctx, "list failed events", "", map[string]string{
+		"error": "not.is.null",
+		"order": "last_attempt.desc",
+	})
+}
+
+// WaitForQueueDrain waits until no events named name are pending, an empty name waits for all events.
+// Returns an error listing the failures if any of the remaining events have failed.
+func (mc *MissionControl) WaitForQueueDrain(ctx context.Context, name string, timeout time.Duration) error {
+	var pending []Event
+	var lastErr error
+	err := poll(ctx, timeout, func(ctx context.Context) bool {
+		pending, lastErr = mc.listEvents(ctx, "wait for queue drain", name, map[string]string{"error": "is.null"})
+		return lastErr == nil && len(pending) == 0
+	})
+	if err != nil {
+		if lastErr != nil {
+			return fmt.Errorf("event queue %q did not drain within %v: %w", name, timeout, lastErr)
+		}
+		return fmt.Errorf("event queue %q did not drain within %v, %d events pending", name, timeout, len(pending))
+	}
+
+	failed, err := mc.listEvents(ctx, "wait for queue drain", name, map[string]string{"error": "not.is.null"})
+	if err != nil {
+		return err
+	}
+	if len(failed) > 0 {
+		var errors []string
+		for _, e := range failed {
+			errors = append(errors, fmt.Sprintf("%s(%s): %s", e.Name, e.ID, *e.Error))
+		}
+		return fmt.Errorf("event queue %q drained with %d failed events: %s", name, len(failed), strings.Join(errors, "; "))
+	}
+	return nil
+}
+
+func (mc *MissionControl) listEvents(ctx context.Context, op, name string, filters map[string]string) ([]Event, error) {
+	params := map[string]string{}
+	for k, v := range filters {
+		params[k] = v
+	}
+	if name != "" {
+		params["name"] = "eq." + name
+	}
+
+	var events []Event
+	if err := mc.dbSelect(ctx, op, "event_queue", params, &events); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
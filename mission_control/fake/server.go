@@ -0,0 +1,320 @@
+// Package fake provides an in-memory mission-control server for testing code that uses
+// the mission_control client without a cluster.
+package fake
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	mc "github.com/flanksource/commons-test/mission_control"
+)
+
+// Server is an httptest server implementing a subset of the mission-control API backed by fixtures
+type Server struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	mux      *http.ServeMux
+	healthy  bool
+	whoami   map[string]any
+	configs  []mc.SelectedResource
+	changes  []mc.ConfigChangeRow
+	requests []string
+	noTotals bool
+	users    map[string]string
+	sessions map[string]string
+}
+
+// NewServer starts a healthy server with an admin user and no fixtures, stop it with Close
+func NewServer() *Server {
+	s := &Server{
+		mux:      http.NewServeMux(),
+		healthy:  true,
+		users:    map[string]string{},
+		sessions: map[string]string{},
+		whoami: map[string]any{
+			"message": "success",
+			"payload": map[string]any{
+				"user": map[string]any{"id": "00000000-0000-0000-0000-000000000000", "name": "admin", "email": "admin@local"},
+			},
+		},
+	}
+	s.mux.HandleFunc("GET /health", s.health)
+	s.mux.HandleFunc("GET /auth/whoami", s.whoAmI)
+	s.mux.HandleFunc("POST /resources/search", s.searchResources)
+	s.mux.HandleFunc("POST /catalog/changes", s.searchChanges)
+	s.mux.HandleFunc("GET /kratos/self-service/login/api", s.loginFlow)
+	s.mux.HandleFunc("POST /kratos/self-service/login", s.login)
+	s.mux.HandleFunc("DELETE /kratos/self-service/logout/api", s.logout)
+
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.requests = append(s.requests, r.Method+" "+r.URL.Path)
+		s.mu.Unlock()
+		s.mux.ServeHTTP(w, r)
+	}))
+	return s
+}
+
+// Client returns a client for the server, with retries disabled so failures surface immediately
+func (s *Server) Client(opts ...mc.Option) *mc.MissionControl {
+	client, _ := mc.NewClient(s.URL, append([]mc.Option{mc.WithRetry(1, 0)}, opts...)...)
+	return client
+}
+
+// WithConfigs adds config items returned by /resources/search
+func (s *Server) WithConfigs(configs ...mc.SelectedResource) *Server {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.configs = append(s.configs, configs...)
+	return s
+}
+
+// WithChanges adds changes returned by /catalog/changes
+func (s *Server) WithChanges(changes ...mc.ConfigChangeRow) *Server {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.changes = append(s.changes, changes...)
+	return s
+}
+
+// WithUser lets username log in with password through the Kratos API login flow
+func (s *Server) WithUser(username, password string) *Server {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.users[username] = password
+	return s
+}
+
+// Sessions returns the username of every Kratos session token that is logged in
+func (s *Server) Sessions() map[string]string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return maps.Clone(s.sessions)
+}
+
+// WithoutTotals makes /catalog/changes omit the total, as servers do when counting is disabled
+func (s *Server) WithoutTotals() *Server {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.noTotals = true
+	return s
+}
+
+// WithWhoAmI replaces the /auth/whoami response, nil makes it return 401
+func (s *Server) WithWhoAmI(response map[string]any) *Server {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.whoami = response
+	return s
+}
+
+// SetHealthy controls whether /health returns 200 or 503
+func (s *Server) SetHealthy(healthy bool) *Server {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.healthy = healthy
+	return s
+}
+
+// Handle registers an additional handler, e.g. to fake an endpoint not implemented by the server
+func (s *Server) Handle(pattern string, handler http.HandlerFunc) *Server {
+	s.mux.HandleFunc(pattern, handler)
+	return s
+}
+
+// Requests returns the "METHOD /path" of every request received so far
+func (s *Server) Requests() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.requests...)
+}
+
+func (s *Server) health(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	healthy := s.healthy
+	s.mu.Unlock()
+
+	if !healthy {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]string{"error": "unhealthy"})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"message": "ok"})
+}
+
+func (s *Server) whoAmI(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	whoami := s.whoami
+	s.mu.Unlock()
+
+	if whoami == nil {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+		return
+	}
+	writeJSON(w, http.StatusOK, whoami)
+}
+
+func (s *Server) searchResources(w http.ResponseWriter, r *http.Request) {
+	var req mc.SearchResourcesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	response := mc.SearchResourcesResponse{Configs: []mc.SelectedResource{}}
+	for _, config := range s.configs {
+		for _, selector := range req.Configs {
+			if Matches(selector, config) {
+				response.Configs = append(response.Configs, config)
+				break
+			}
+		}
+	}
+	response.Total = len(response.Configs)
+	if req.Limit > 0 && len(response.Configs) > req.Limit {
+		response.Configs = response.Configs[:req.Limit]
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+func (s *Server) searchChanges(w http.ResponseWriter, r *http.Request) {
+	var req mc.CatalogChangesSearchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var matched []mc.ConfigChangeRow
+	for _, change := range s.changes {
+		if (req.CatalogID == "" || change.ConfigID == req.CatalogID) &&
+			(req.ChangeType == "" || change.ChangeType == req.ChangeType) &&
+			(req.ConfigType == "" || change.ConfigType == req.ConfigType) &&
+			(req.Severity == "" || change.Severity == req.Severity) &&
+			(req.Source == "" || change.Source == req.Source) {
+			matched = append(matched, change)
+		}
+	}
+
+	response := mc.CatalogChangesSearchResponse{Total: int64(len(matched)), Summary: map[string]int{}}
+	for _, change := range matched {
+		response.Summary[change.ChangeType]++
+	}
+
+	page, pageSize := max(req.Page, 1), req.PageSize
+	if pageSize <= 0 {
+		pageSize = 50
+	}
+	start := min((page-1)*pageSize, len(matched))
+	response.Changes = matched[start:min(start+pageSize, len(matched))]
+	if s.noTotals {
+		response.Total = 0
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+func (s *Server) loginFlow(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{
+		"id": "login-flow",
+		"ui": map[string]any{
+			"nodes": []map[string]any{{"attributes": map[string]any{"name": "csrf_token", "value": "csrf"}}},
+		},
+	})
+}
+
+func (s *Server) login(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Identifier string `json:"identifier"`
+		Password   string `json:"password"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if password, ok := s.users[req.Identifier]; !ok || password != req.Password {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid credentials"})
+		return
+	}
+	token := fmt.Sprintf("session-%d", len(s.requests))
+	s.sessions[token] = req.Identifier
+	writeJSON(w, http.StatusOK, map[string]any{
+		"session_token": token,
+		"session":       map[string]any{"expires_at": time.Now().Add(time.Hour)},
+	})
+}
+
+func (s *Server) logout(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		SessionToken string `json:"session_token"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.sessions[req.SessionToken]; !ok {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "no session"})
+		return
+	}
+	delete(s.sessions, req.SessionToken)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Matches returns true when config matches the selector's ID, name, namespace, types, labels, tags and
+// search. It approximates the server side matching well enough for fakes, Search only being a substring
+// of the name.
+func Matches(selector mc.ResourceSelector, config mc.SelectedResource) bool {
+	if selector.ID != "" && selector.ID != config.ID {
+		return false
+	}
+	if selector.Name != "" && selector.Name != config.Name {
+		return false
+	}
+	if selector.Namespace != "" && selector.Namespace != config.Namespace {
+		return false
+	}
+	if len(selector.Types) > 0 && !slices.Contains(selector.Types, config.Type) {
+		return false
+	}
+	for k, v := range selector.Labels {
+		if config.Labels[k] != v {
+			return false
+		}
+	}
+	if selector.TagSelector != "" {
+		for _, tag := range strings.Split(selector.TagSelector, ",") {
+			key, value, _ := strings.Cut(tag, "=")
+			if config.Tags[key] != value {
+				return false
+			}
+		}
+	}
+	if selector.Search != "" && !strings.Contains(config.Name, strings.Trim(selector.Search, "*")) {
+		return false
+	}
+	return true
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
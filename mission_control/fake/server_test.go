@@ -0,0 +1,76 @@
+package fake
+
+import (
+	"context"
+	"testing"
+
+	mc "github.com/flanksource/commons-test/mission_control"
+)
+
+func TestServer(t *testing.T) {
+	server := NewServer().
+		WithConfigs(
+			mc.SelectedResource{ID: "1", Name: "nginx", Namespace: "default", Type: "Kubernetes::Pod"},
+			mc.SelectedResource{ID: "2", Name: "redis", Namespace: "cache", Type: "Kubernetes::Pod"},
+		).
+		WithChanges(
+			mc.ConfigChangeRow{ID: "a", ConfigID: "1", ChangeType: "diff"},
+			mc.ConfigChangeRow{ID: "b", ConfigID: "1", ChangeType: "Pulled"},
+			mc.ConfigChangeRow{ID: "c", ConfigID: "2", ChangeType: "diff"},
+		)
+	defer server.Close()
+
+	ctx := context.Background()
+	client := server.Client()
+
+	t.Run("Health", func(t *testing.T) {
+		if healthy, err := client.IsHealthy(ctx); err != nil || !healthy {
+			t.Fatalf("expected healthy, got %v %v", healthy, err)
+		}
+		server.SetHealthy(false)
+		defer server.SetHealthy(true)
+		if healthy, _ := client.IsHealthy(ctx); healthy {
+			t.Error("expected unhealthy")
+		}
+	})
+
+	t.Run("WhoAmI", func(t *testing.T) {
+		if _, ok, err := client.WhoAmI(ctx); err != nil || !ok {
+			t.Fatalf("expected whoami to succeed, got %v %v", ok, err)
+		}
+	})
+
+	t.Run("QueryCatalog", func(t *testing.T) {
+		configs, err := client.QueryCatalog(ctx, mc.ResourceSelector{Namespace: "cache"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(configs) != 1 || configs[0].Name != "redis" {
+			t.Errorf("expected redis, got %+v", configs)
+		}
+	})
+
+	t.Run("SearchResources", func(t *testing.T) {
+		response, err := client.SearchResources(ctx, mc.ResourceSelector{Types: []string{"Kubernetes::Pod"}}, mc.SearchLimit(1))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if response.Total != 2 || len(response.Configs) != 1 {
+			t.Errorf("expected 1 of 2 configs, got %d of %d", len(response.Configs), response.Total)
+		}
+	})
+
+	t.Run("SearchCatalogChanges", func(t *testing.T) {
+		response, err := client.SearchCatalogChanges(ctx, mc.CatalogChangesSearchRequest{CatalogID: "1", PageSize: 1})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if response.Total != 2 || len(response.Changes) != 1 {
+			t.Errorf("expected 1 of 2 changes, got %d of %d", len(response.Changes), response.Total)
+		}
+	})
+
+	if len(server.Requests()) == 0 {
+		t.Error("expected requests to be recorded")
+	}
+}
@@ -0,0 +1,179 @@
+package mission_control
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/flanksource/clicky/exec"
+	"sigs.k8s.io/yaml"
+
+	"github.com/flanksource/commons-test/names"
+)
+
+// Flux kinds accepted by WaitForReconcile
+const (
+	FluxGitRepository  = "gitrepositories.source.toolkit.fluxcd.io"
+	FluxHelmRepository = "helmrepositories.source.toolkit.fluxcd.io"
+	FluxKustomization  = "kustomizations.kustomize.toolkit.fluxcd.io"
+	FluxHelmRelease    = "helmreleases.helm.toolkit.fluxcd.io"
+)
+
+// GitRepository is a Flux source pointing at a git repository
+type GitRepository struct {
+	Name      string
+	Namespace string
+	URL       string
+	// Branch defaults to main
+	Branch string
+	// SecretRef names a secret with git credentials
+	SecretRef string
+	// Interval defaults to 1m
+	Interval time.Duration
+}
+
+// Kustomization applies a path of a GitRepository
+type Kustomization struct {
+	Name            string
+	Namespace       string
+	GitRepository   string
+	Path            string
+	TargetNamespace string
+	Prune           bool
+	Interval        time.Duration
+}
+
+// HelmRelease installs a chart from a HelmRepository or GitRepository source
+type HelmRelease struct {
+	Name      string
+	Namespace string
+	Chart     string
+	Version   string
+	// SourceKind is HelmRepository or GitRepository
+	SourceKind string
+	SourceName string
+	Values     map[string]any
+	Interval   time.Duration
+}
+
+// ApplyGitRepository creates or updates a Flux GitRepository
+func ApplyGitRepository(ctx context.Context, repo GitRepository) error {
+	spec := map[string]any{
+		"url":      repo.URL,
+		"interval": interval(repo.Interval),
+		"ref":      map[string]any{"branch": defaultString(repo.Branch, "main")},
+	}
+	if repo.SecretRef != "" {
+		spec["secretRef"] = map[string]any{"name": repo.SecretRef}
+	}
+	return applyManifest(ctx, "source.toolkit.fluxcd.io/v1", "GitRepository", repo.Name, repo.Namespace, spec)
+}
+
+// ApplyKustomization creates or updates a Flux Kustomization sourced from a GitRepository in the same namespace
+func ApplyKustomization(ctx context.Context, k Kustomization) error {
+	spec := map[string]any{
+		"interval": interval(k.Interval),
+		"path":     defaultString(k.Path, "./"),
+		"prune":    k.Prune,
+		"sourceRef": map[string]any{
+			"kind": "GitRepository",
+			"name": k.GitRepository,
+		},
+	}
+	if k.TargetNamespace != "" {
+		spec["targetNamespace"] = k.TargetNamespace
+	}
+	return applyManifest(ctx, "kustomize.toolkit.fluxcd.io/v1", "Kustomization", k.Name, k.Namespace, spec)
+}
+
+// ApplyHelmRelease creates or updates a Flux HelmRelease
+func ApplyHelmRelease(ctx context.Context, release HelmRelease) error {
+	chart := map[string]any{
+		"chart": release.Chart,
+		"sourceRef": map[string]any{
+			"kind": defaultString(release.SourceKind, "HelmRepository"),
+			"name": release.SourceName,
+		},
+	}
+	if release.Version != "" {
+		chart["version"] = release.Version
+	}
+	spec := map[string]any{
+		"interval": interval(release.Interval),
+		"chart":    map[string]any{"spec": chart},
+	}
+	if len(release.Values) > 0 {
+		spec["values"] = release.Values
+	}
+	return applyManifest(ctx, "helm.toolkit.fluxcd.io/v2", "HelmRelease", release.Name, release.Namespace, spec)
+}
+
+// WaitForReconcile waits for a Flux resource (e.g. FluxKustomization) to report Ready
+func WaitForReconcile(ctx context.Context, kind, namespace, name string, timeout time.Duration) error {
+	result, err := kubectl(exec.WithContext(ctx), "wait", kind+"/"+name, "-n", namespace,
+		"--for=condition=Ready", "--timeout="+timeout.String())
+	if err == nil {
+		return nil
+	}
+
+	// Include the Ready condition message, which explains why the reconcile failed
+	status, _ := kubectl(exec.WithContext(ctx), "get", kind+"/"+name, "-n", namespace,
+		"-o", `jsonpath={.status.conditions[?(@.type=="Ready")].message}`)
+	message := ""
+	if status != nil {
+		message = strings.TrimSpace(status.Stdout)
+	}
+	if message == "" && result != nil {
+		message = strings.TrimSpace(result.Stderr)
+	}
+	return fmt.Errorf("%s %s/%s not reconciled after %v: %s", kind, namespace, name, timeout, message)
+}
+
+func applyManifest(ctx context.Context, apiVersion, kind, name, namespace string, spec map[string]any) error {
+	data, err := yaml.Marshal(map[string]any{
+		"apiVersion": apiVersion,
+		"kind":       kind,
+		"metadata":   map[string]any{"name": name, "namespace": defaultString(namespace, "default")},
+		"spec":       spec,
+	})
+	if err != nil {
+		return err
+	}
+
+	file, err := os.CreateTemp("", names.TempPrefix("gitops")+"*.yaml")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return err
+	}
+	file.Close()
+
+	result, err := kubectl(exec.WithContext(ctx), "apply", "-f", file.Name())
+	if err != nil {
+		stderr := ""
+		if result != nil {
+			stderr = result.Stderr
+		}
+		return fmt.Errorf("failed to apply %s %s: %w %s", kind, name, err, stderr)
+	}
+	return nil
+}
+
+func interval(d time.Duration) string {
+	if d == 0 {
+		return "1m"
+	}
+	return d.String()
+}
+
+func defaultString(value, def string) string {
+	if value == "" {
+		return def
+	}
+	return value
+}
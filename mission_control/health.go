@@ -0,0 +1,171 @@
+package mission_control
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Subsystems checked by WaitUntilHealthy
+const (
+	HealthHTTP     = "http"
+	HealthDB       = "db"
+	HealthJobs     = "jobs"
+	HealthCron     = "cron"
+	HealthUpstream = "upstream"
+)
+
+// DefaultHealthComponents are checked when WaitUntilHealthy is not given any components
+var DefaultHealthComponents = []string{HealthHTTP, HealthDB, HealthJobs, HealthCron}
+
+// CronStaleAfter is how long the cron component may go without starting a scheduled job before it is failing
+var CronStaleAfter = 10 * time.Minute
+
+var healthComponents = []string{HealthHTTP, HealthDB, HealthJobs, HealthCron, HealthUpstream}
+
+type ComponentHealth struct {
+	Healthy   bool      `json:"healthy"`
+	Message   string    `json:"message,omitempty"`
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+// HealthReport is the last known state of each subsystem
+type HealthReport struct {
+	Components map[string]ComponentHealth `json:"components"`
+}
+
+// Healthy returns true when every component is healthy
+func (r *HealthReport) Healthy() bool {
+	for _, c := range r.Components {
+		if !c.Healthy {
+			return false
+		}
+	}
+	return true
+}
+
+// Failing returns the names of unhealthy components
+func (r *HealthReport) Failing() []string {
+	var failing []string
+	for name, c := range r.Components {
+		if !c.Healthy {
+			failing = append(failing, name)
+		}
+	}
+	sort.Strings(failing)
+	return failing
+}
+
+func (r *HealthReport) String() string {
+	var names []string
+	for name := range r.Components {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var parts []string
+	for _, name := range names {
+		c := r.Components[name]
+		status := "ok"
+		if !c.Healthy {
+			status = "failing: " + c.Message
+		}
+		parts = append(parts, fmt.Sprintf("%s=%s", name, status))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// WaitUntilHealthy polls the given components (DefaultHealthComponents when empty) until all are healthy
+// in the same poll. On timeout the error describes which components were still failing and why.
+func (mc *MissionControl) WaitUntilHealthy(ctx context.Context, timeout time.Duration, components ...string) (*HealthReport, error) {
+	if len(components) == 0 {
+		components = DefaultHealthComponents
+	}
+	for _, name := range components {
+		if !slices.Contains(healthComponents, name) {
+			return nil, fmt.Errorf("unknown health component %q, expected one of %s", name, strings.Join(healthComponents, ", "))
+		}
+	}
+
+	report := &HealthReport{Components: map[string]ComponentHealth{}}
+	err := poll(ctx, timeout, func(ctx context.Context) bool {
+		for _, name := range components {
+			err := mc.checkComponent(ctx, name)
+			c := ComponentHealth{Healthy: err == nil, CheckedAt: time.Now()}
+			if err != nil {
+				c.Message = err.Error()
+			}
+			report.Components[name] = c
+		}
+		return report.Healthy()
+	})
+	if err != nil {
+		return report, fmt.Errorf("mission-control not healthy after %v: %s", timeout, report)
+	}
+	return report, nil
+}
+
+func (mc *MissionControl) checkComponent(ctx context.Context, name string) error {
+	switch name {
+	case HealthHTTP:
+		r, err := mc.HTTP.R(ctx).Get("/health")
+		if err != nil {
+			return err
+		}
+		if !r.IsOK() {
+			return newAPIError("health", r)
+		}
+		return nil
+
+	case HealthDB:
+		var rows []map[string]any
+		return mc.dbSelect(ctx, "query database", "config_items", map[string]string{"select": "id", "limit": "1"}, &rows)
+
+	case HealthJobs:
+		var jobs []struct {
+			Name   string `json:"name"`
+			Status string `json:"status"`
+		}
+		if err := mc.dbSelect(ctx, "query job history", "job_history", map[string]string{
+			"select": "name,status",
+			"order":  "time_start.desc",
+			"limit":  "1",
+		}, &jobs); err != nil {
+			return err
+		}
+		if len(jobs) == 0 {
+			return fmt.Errorf("no jobs have run yet")
+		}
+		return nil
+
+	case HealthCron:
+		var jobs []struct {
+			Name string `json:"name"`
+		}
+		if err := mc.dbSelect(ctx, "query job history", "job_history", map[string]string{
+			"select":     "name",
+			"time_start": "gte." + time.Now().Add(-CronStaleAfter).UTC().Format(time.RFC3339),
+			"limit":      "1",
+		}, &jobs); err != nil {
+			return err
+		}
+		if len(jobs) == 0 {
+			return fmt.Errorf("no scheduled job started in the last %v", CronStaleAfter)
+		}
+		return nil
+
+	case HealthUpstream:
+		r, err := mc.HTTP.R(ctx).Get("/upstream/ping")
+		if err != nil {
+			return err
+		}
+		if !r.IsOK() {
+			return newAPIError("ping upstream", r)
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown component %q", name)
+}
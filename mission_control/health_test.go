@@ -0,0 +1,72 @@
+package mission_control_test
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	mc "github.com/flanksource/commons-test/mission_control"
+	"github.com/flanksource/commons-test/mission_control/fake"
+)
+
+func TestWaitUntilHealthy(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("healthy", func(t *testing.T) {
+		server := fake.NewServer().
+			Handle("GET /db/config_items", jsonHandler(`[{"id": "1"}]`)).
+			Handle("GET /db/job_history", jsonHandler(`[{"name": "SyncCheckStatuses", "status": "SUCCESS"}]`))
+		defer server.Close()
+
+		report, err := server.Client().WaitUntilHealthy(ctx, time.Second)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(report.Components) != len(mc.DefaultHealthComponents) || !report.Healthy() {
+			t.Errorf("expected every default component to be healthy, got %s", report)
+		}
+	})
+
+	t.Run("unknown component", func(t *testing.T) {
+		server := fake.NewServer()
+		defer server.Close()
+
+		start := time.Now()
+		if _, err := server.Client().WaitUntilHealthy(ctx, 5*time.Second, "scheduler"); err == nil {
+			t.Error("expected an unknown component to be rejected")
+		}
+		if time.Since(start) > time.Second {
+			t.Errorf("expected an unknown component to fail immediately, took %v", time.Since(start))
+		}
+	})
+
+	t.Run("components are re-checked", func(t *testing.T) {
+		// The first job history query succeeds and every later one finds no jobs
+		var queries atomic.Int32
+		server := fake.NewServer().Handle("GET /db/job_history", func(w http.ResponseWriter, r *http.Request) {
+			if queries.Add(1) == 1 {
+				jsonHandler(`[{"name": "SyncCheckStatuses"}]`)(w, r)
+				return
+			}
+			jsonHandler(`[]`)(w, r)
+		})
+		defer server.Close()
+
+		report, err := server.Client().WaitUntilHealthy(ctx, time.Second, mc.HealthJobs, mc.HealthCron)
+		if err == nil {
+			t.Fatal("expected a timeout")
+		}
+		if failing := report.Failing(); len(failing) != 2 {
+			t.Errorf("expected jobs and cron to be failing in the last poll, got %s", report)
+		}
+	})
+}
+
+func jsonHandler(body string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(body))
+	}
+}
@@ -0,0 +1,108 @@
+package mission_control
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Incident struct {
+	ID           string     `json:"id,omitempty"`
+	Title        string     `json:"title"`
+	Description  string     `json:"description,omitempty"`
+	Type         string     `json:"type,omitempty"`
+	Status       string     `json:"status,omitempty"`
+	Severity     string     `json:"severity,omitempty"`
+	CommanderID  string     `json:"commander_id,omitempty"`
+	CreatedBy    string     `json:"created_by,omitempty"`
+	CreatedAt    *time.Time `json:"created_at,omitempty"`
+	Acknowledged *time.Time `json:"acknowledged,omitempty"`
+	Resolved     *time.Time `json:"resolved,omitempty"`
+	Closed       *time.Time `json:"closed,omitempty"`
+}
+
+type Responder struct {
+	ID         string          `json:"id,omitempty"`
+	IncidentID string          `json:"incident_id"`
+	Type       string          `json:"type"`
+	PersonID   string          `json:"person_id,omitempty"`
+	TeamID     string          `json:"team_id,omitempty"`
+	Properties json.RawMessage `json:"properties,omitempty"`
+	CreatedBy  string          `json:"created_by,omitempty"`
+}
+
+// IncidentFilter narrows ListIncidents, empty fields are ignored
+type IncidentFilter struct {
+	Statuses   []string
+	Severities []string
+	Types      []string
+	Since      time.Time
+	Limit      int
+}
+
+func (mc *MissionControl) CreateIncident(ctx context.Context, incident Incident) (*Incident, error) {
+	if incident.Status == "" {
+		incident.Status = "open"
+	}
+
+	var created []Incident
+	if err := mc.dbInsert(ctx, "create incident "+incident.Title, "incidents", incident, &created); err != nil {
+		return nil, err
+	}
+	if len(created) == 0 {
+		return nil, &APIError{Op: "create incident " + incident.Title, Message: "no incident returned"}
+	}
+	return &created[0], nil
+}
+
+// AddResponder adds a person or team as a responder to an incident
+func (mc *MissionControl) AddResponder(ctx context.Context, incidentID string, responder Responder) (*Responder, error) {
+	responder.IncidentID = incidentID
+
+	var created []Responder
+	if err := mc.dbInsert(ctx, "add responder to incident "+incidentID, "responders", responder, &created); err != nil {
+		return nil, err
+	}
+	if len(created) == 0 {
+		return nil, &APIError{Op: "add responder to incident " + incidentID, Message: "no responder returned"}
+	}
+	return &created[0], nil
+}
+
+// UpdateIncidentStatus moves an incident to a new status, e.g. "investigating", "mitigated", "resolved", "closed"
+func (mc *MissionControl) UpdateIncidentStatus(ctx context.Context, incidentID, status string) error {
+	update := map[string]any{"status": status}
+	switch status {
+	case "resolved":
+		update["resolved"] = time.Now().UTC()
+	case "closed":
+		update["closed"] = time.Now().UTC()
+	}
+	return mc.dbUpdate(ctx, "update status of incident "+incidentID, "incidents", map[string]string{"id": "eq." + incidentID}, update)
+}
+
+// ListIncidents returns incidents matching filter, newest first
+func (mc *MissionControl) ListIncidents(ctx context.Context, filter IncidentFilter) ([]Incident, error) {
+	filters := map[string]string{"order": "created_at.desc"}
+	if len(filter.Statuses) > 0 {
+		filters["status"] = "in.(" + strings.Join(filter.Statuses, ",") + ")"
+	}
+	if len(filter.Severities) > 0 {
+		filters["severity"] = "in.(" + strings.Join(filter.Severities, ",") + ")"
+	}
+	if len(filter.Types) > 0 {
+		filters["type"] = "in.(" + strings.Join(filter.Types, ",") + ")"
+	}
+	if !filter.Since.IsZero() {
+		filters["created_at"] = "gte." + filter.Since.UTC().Format(time.RFC3339)
+	}
+	if filter.Limit > 0 {
+		filters["limit"] = strconv.Itoa(filter.Limit)
+	}
+
+	var incidents []Incident
+	err := mc.dbSelect(ctx, "list incidents", "incidents", filters, &incidents)
+	return incidents, err
+}
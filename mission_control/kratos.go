@@ -0,0 +1,225 @@
+package mission_control
+
+import (
+	"context"
+	"fmt"
+	gohttp "net/http"
+	"sync"
+	"time"
+)
+
+// kratosSessionCookie is set instead of a session token when Kratos runs a browser flow
+const kratosSessionCookie = "ory_kratos_session"
+
+type kratosSession struct {
+	sync.Mutex
+	token     string
+	cookie    *gohttp.Cookie
+	expiresAt time.Time
+}
+
+func (s *kratosSession) valid() bool {
+	return (s.token != "" || s.cookie != nil) && time.Until(s.expiresAt) > time.Minute
+}
+
+// Login logs in through Kratos and attaches the session to every subsequent request of the
+// HTTP and ConfigDB clients, replacing any other authentication
+func (mc *MissionControl) Login(ctx context.Context, username, password string) error {
+	mc.session.Lock()
+	mc.Username = username
+	mc.Password = password
+	mc.session.token, mc.session.cookie = "", nil
+	mc.session.Unlock()
+	mc.setAuth(authKratos)
+	return mc.kratosLogin(ctx)
+}
+
+// RefreshSession re-authenticates the current Kratos session, extending its expiry
+func (mc *MissionControl) RefreshSession(ctx context.Context) error {
+	if mc.authMode() != authKratos {
+		return fmt.Errorf("client is not using a Kratos session")
+	}
+	return mc.submitLogin(ctx, true)
+}
+
+// SessionExpiresAt returns when the current Kratos session expires, zero when there is no session
+func (mc *MissionControl) SessionExpiresAt() time.Time {
+	mc.session.Lock()
+	defer mc.session.Unlock()
+	return mc.session.expiresAt
+}
+
+// Logout revokes the Kratos session. Subsequent requests are unauthenticated until Login is called again.
+func (mc *MissionControl) Logout(ctx context.Context) error {
+	mc.session.Lock()
+	token, cookie, username := mc.session.token, mc.session.cookie, mc.Username
+	mc.session.token, mc.session.cookie, mc.session.expiresAt = "", nil, time.Time{}
+	mc.session.Unlock()
+	mc.setAuth(authNone)
+
+	client := mc.newHTTPClient(mc.URL + "/kratos")
+	if token != "" {
+		req := client.R(ctx).Header("Content-Type", "application/json")
+		if err := req.Body(map[string]string{"session_token": token}); err != nil {
+			return err
+		}
+		r, err := req.Delete("/self-service/logout/api")
+		if err != nil {
+			return fmt.Errorf("failed to logout %s: %w", username, err)
+		}
+		if !r.IsOK() {
+			return newAPIError("logout "+username, r)
+		}
+		return nil
+	}
+	if cookie == nil {
+		return nil
+	}
+
+	// Browser sessions are revoked by following the logout URL of a logout flow
+	r, err := client.R(ctx).Header("Cookie", cookie.String()).Get("/self-service/logout/browser")
+	if err != nil {
+		return fmt.Errorf("failed to create logout flow: %w", err)
+	}
+	if !r.IsOK() {
+		return newAPIError("create logout flow", r)
+	}
+	var flow struct {
+		LogoutToken string `json:"logout_token"`
+	}
+	if err := r.Into(&flow); err != nil {
+		return err
+	}
+	r, err = client.R(ctx).
+		Header("Cookie", cookie.String()).
+		Header("Accept", "application/json").
+		QueryParam("token", flow.LogoutToken).
+		Get("/self-service/logout")
+	if err != nil {
+		return fmt.Errorf("failed to logout %s: %w", username, err)
+	}
+	if !r.IsOK() {
+		return newAPIError("logout "+username, r)
+	}
+	return nil
+}
+
+// attachSession adds the Kratos session to req, logging in if it is missing or about to expire
+func (mc *MissionControl) attachSession(req *gohttp.Request) error {
+	mc.session.Lock()
+	valid := mc.session.valid()
+	mc.session.Unlock()
+
+	if !valid {
+		if err := mc.kratosLogin(req.Context()); err != nil {
+			return err
+		}
+	}
+
+	mc.session.Lock()
+	defer mc.session.Unlock()
+	if mc.session.token != "" {
+		req.Header.Set("Authorization", "Bearer "+mc.session.token)
+	} else if mc.session.cookie != nil {
+		req.Header.Set("Cookie", mc.session.cookie.String())
+	}
+	return nil
+}
+
+func (mc *MissionControl) kratosLogin(ctx context.Context) error {
+	return mc.submitLogin(ctx, false)
+}
+
+// submitLogin performs the Kratos API (non-browser) password login flow, sending the CSRF token
+// when the flow has one. A refresh re-authenticates the current session instead of creating a new one.
+func (mc *MissionControl) submitLogin(ctx context.Context, refresh bool) error {
+	client := mc.newHTTPClient(mc.URL + "/kratos")
+
+	mc.session.Lock()
+	current := mc.session.token
+	username, password := mc.Username, mc.Password
+	mc.session.Unlock()
+
+	req := client.R(ctx).Header("Accept", "application/json")
+	if refresh {
+		req = req.QueryParam("refresh", "true")
+		if current != "" {
+			req = req.Header("X-Session-Token", current)
+		}
+	}
+	r, err := req.Get("/self-service/login/api")
+	if err != nil {
+		return fmt.Errorf("failed to create login flow: %w", err)
+	}
+	if !r.IsOK() {
+		return newAPIError("create login flow", r)
+	}
+
+	var flow struct {
+		ID string `json:"id"`
+		UI struct {
+			Nodes []struct {
+				Attributes struct {
+					Name  string `json:"name"`
+					Value any    `json:"value"`
+				} `json:"attributes"`
+			} `json:"nodes"`
+		} `json:"ui"`
+	}
+	if err := r.Into(&flow); err != nil {
+		return err
+	}
+
+	body := map[string]string{
+		"method":     "password",
+		"identifier": username,
+		"password":   password,
+	}
+	for _, node := range flow.UI.Nodes {
+		if csrf, ok := node.Attributes.Value.(string); ok && node.Attributes.Name == "csrf_token" && csrf != "" {
+			body["csrf_token"] = csrf
+		}
+	}
+
+	req = client.R(ctx).
+		Header("Content-Type", "application/json").
+		Header("Accept", "application/json").
+		QueryParam("flow", flow.ID)
+	if refresh && current != "" {
+		req = req.Header("X-Session-Token", current)
+	}
+	r, err = req.Post("/self-service/login", body)
+	if err != nil {
+		return fmt.Errorf("failed to login as %s: %w", username, err)
+	}
+	if !r.IsOK() {
+		return newAPIError("login as "+username, r)
+	}
+
+	var login struct {
+		SessionToken string `json:"session_token"`
+		Session      struct {
+			ExpiresAt time.Time `json:"expires_at"`
+		} `json:"session"`
+	}
+	if err := r.Into(&login); err != nil {
+		return err
+	}
+
+	mc.session.Lock()
+	defer mc.session.Unlock()
+	if login.SessionToken != "" {
+		mc.session.token = login.SessionToken
+	} else if !refresh || current == "" {
+		for _, cookie := range r.Cookies() {
+			if cookie.Name == kratosSessionCookie {
+				mc.session.cookie = cookie
+			}
+		}
+		if mc.session.cookie == nil {
+			return fmt.Errorf("login as %s returned neither a session token nor a session cookie", username)
+		}
+	}
+	mc.session.expiresAt = login.Session.ExpiresAt
+	return nil
+}
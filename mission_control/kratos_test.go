@@ -0,0 +1,35 @@
+package mission_control_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/flanksource/commons-test/mission_control/fake"
+)
+
+func TestKratosLogin(t *testing.T) {
+	server := fake.NewServer().WithUser("admin@local", "admin")
+	defer server.Close()
+	ctx := context.Background()
+	client := server.Client()
+
+	if err := client.Login(ctx, "admin@local", "wrong"); err == nil {
+		t.Error("expected login with a wrong password to fail")
+	}
+	if err := client.Login(ctx, "admin@local", "admin"); err != nil {
+		t.Fatal(err)
+	}
+	if client.SessionExpiresAt().IsZero() {
+		t.Error("expected the session expiry to be recorded")
+	}
+	if sessions := server.Sessions(); len(sessions) != 1 {
+		t.Errorf("expected 1 session, got %v", sessions)
+	}
+
+	if err := client.Logout(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if sessions := server.Sessions(); len(sessions) != 0 {
+		t.Errorf("expected the session to be revoked, got %v", sessions)
+	}
+}
@@ -0,0 +1,78 @@
+package mission_control
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// LogSelector identifies the component or config item whose logs are searched
+type LogSelector struct {
+	ID     string            `json:"id"`
+	Type   string            `json:"type,omitempty"`
+	Labels map[string]string `json:"labels,omitempty"`
+	Limit  int               `json:"limit,omitempty"`
+}
+
+// LogLine is a single log entry returned by the logs proxy
+type LogLine struct {
+	ID      string            `json:"id,omitempty"`
+	Time    time.Time         `json:"timestamp"`
+	Message string            `json:"message"`
+	Host    string            `json:"host,omitempty"`
+	Labels  map[string]string `json:"labels,omitempty"`
+}
+
+type LogsResponse struct {
+	Total    int       `json:"total,omitempty"`
+	Results  []LogLine `json:"results,omitempty"`
+	NextPage string    `json:"nextPage,omitempty"`
+}
+
+// Contains returns the lines whose message contains text
+func (r *LogsResponse) Contains(text string) []LogLine {
+	var lines []LogLine
+	for _, line := range r.Results {
+		if strings.Contains(line.Message, text) {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// SearchLogs queries the logs of a component or config item through mission-control's logs proxy
+func (mc *MissionControl) SearchLogs(ctx context.Context, selector LogSelector, query string, since time.Duration) (*LogsResponse, error) {
+	body := map[string]any{
+		"id":    selector.ID,
+		"query": query,
+		"end":   time.Now().UTC().Format(time.RFC3339),
+	}
+	if since > 0 {
+		body["start"] = time.Now().Add(-since).UTC().Format(time.RFC3339)
+	}
+	if selector.Type != "" {
+		body["type"] = selector.Type
+	}
+	if len(selector.Labels) > 0 {
+		body["labels"] = selector.Labels
+	}
+	if selector.Limit > 0 {
+		body["limit"] = selector.Limit
+	}
+
+	r, err := mc.HTTP.R(ctx).
+		Header("Content-Type", "application/json").
+		Post("/logs", body)
+	if err != nil {
+		return nil, err
+	}
+	if !r.IsOK() {
+		return nil, newAPIError("search logs of "+selector.ID, r)
+	}
+
+	var response LogsResponse
+	if err := r.Into(&response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
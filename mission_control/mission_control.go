@@ -5,6 +5,9 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"io"
+	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/flanksource/commons/http"
@@ -18,11 +21,36 @@ type MissionControl struct {
 	Username  string
 	Password  string
 	Namespace string
+	Token     string
 	DB        *sql.DB
+
+	auth           atomic.Int32 // authMode, switched by Login and Logout while requests are in flight
+	configDBURL    string
+	session        kratosSession
+	retryAttempts  int
+	retryDelay     time.Duration
+	prometheusPath string
+	debug          bool
+	debugBodies    bool
+	debugWriter    io.Writer
+}
+
+// MissionControlAPI is the subset of the client used by suite helpers, implemented by MissionControl
+// and testingfakes.MissionControl
+type MissionControlAPI interface {
+	IsHealthy(ctx context.Context) (bool, error)
+	WhoAmI(ctx context.Context) (map[string]any, bool, error)
+	SearchResources(ctx context.Context, selector ResourceSelector, opts ...SearchOption) (*SearchResourcesResponse, error)
+	QueryCatalog(ctx context.Context, selector ResourceSelector, opts ...SearchOption) ([]SelectedResource, error)
+	SearchCatalog(ctx context.Context, search string, opts ...SearchOption) ([]SelectedResource, error)
+	SearchCatalogChanges(ctx context.Context, req CatalogChangesSearchRequest) (*CatalogChangesSearchResponse, error)
+	Push(ctx context.Context, data PushData) error
 }
 
-func (mc *MissionControl) POST(path string, body any) (*http.Response, error) {
-	return mc.HTTP.R(context.TODO()).Post(path, body)
+var _ MissionControlAPI = (*MissionControl)(nil)
+
+func (mc *MissionControl) POST(ctx context.Context, path string, body any) (*http.Response, error) {
+	return mc.HTTP.R(ctx).Post(path, body)
 }
 
 type Scraper struct {
@@ -44,24 +72,6 @@ func (mc *MissionControl) GetScraper(id string) *Scraper {
 	}
 }
 
-type ScrapeResult struct {
-	Errors  []string       `json:"errors"`
-	Summary map[string]any `json:"scrape_summary"`
-}
-
-func (s *Scraper) Run() (*ScrapeResult, error) {
-	r, err := s.mc.ConfigDB.R(context.Background()).Post("/run/"+s.Id, map[string]string{"scraper": s.Name})
-	if err != nil {
-		return nil, err
-	}
-	result := &ScrapeResult{}
-	body, err := r.AsString()
-	if err != nil {
-		return nil, err
-	}
-	return result, json.Unmarshal([]byte(body), result)
-}
-
 type ResourceSelector struct {
 	ID            string            `json:"id,omitempty"`
 	Name          string            `json:"name,omitempty"`
@@ -70,11 +80,14 @@ type ResourceSelector struct {
 	Statuses      []string          `json:"statuses,omitempty"`
 	Labels        map[string]string `json:"labels,omitempty"`
 	FieldSelector string            `json:"field_selector,omitempty"`
+	TagSelector   string            `json:"tagSelector,omitempty"`
+	Health        string            `json:"health,omitempty"`
 	Search        string            `json:"search,omitempty"`
 }
 
 type SearchResourcesRequest struct {
 	Limit   int                `json:"limit,omitempty"`
+	SortBy  string             `json:"sort_by,omitempty"`
 	Configs []ResourceSelector `json:"configs,omitempty"`
 }
 
@@ -87,37 +100,115 @@ type SelectedResource struct {
 	Config     string            `json:"config,omitempty"`
 	Labels     map[string]string `json:"labels,omitempty"`
 	Properties map[string]string `json:"properties,omitempty"`
+
+	// parsed caches the result of ConfigAsMap for the Config it was parsed from
+	parsed     map[string]any
+	parsedFrom string
+}
+
+// ConfigAsMap returns Config parsed as a JSON object, the result is cached until Config changes
+func (r *SelectedResource) ConfigAsMap() (map[string]any, error) {
+	if r.parsed != nil && r.parsedFrom == r.Config {
+		return r.parsed, nil
+	}
+	if r.Config == "" {
+		return map[string]any{}, nil
+	}
+
+	var config map[string]any
+	if err := json.Unmarshal([]byte(r.Config), &config); err != nil {
+		return nil, fmt.Errorf("failed to parse config of %s/%s: %w", r.Type, r.Name, err)
+	}
+	r.parsed, r.parsedFrom = config, r.Config
+	return config, nil
+}
+
+// ConfigInto unmarshals Config into dest, e.g. a corev1.Pod
+func (r *SelectedResource) ConfigInto(dest any) error {
+	if err := json.Unmarshal([]byte(r.Config), dest); err != nil {
+		return fmt.Errorf("failed to parse config of %s/%s: %w", r.Type, r.Name, err)
+	}
+	return nil
 }
 
 type SearchResourcesResponse struct {
+	// Total is the number of matching configs before the limit is applied
+	Total   int                `json:"total,omitempty"`
 	Configs []SelectedResource `json:"configs"`
 }
 
-func (mc *MissionControl) QueryCatalog(selector ResourceSelector) ([]SelectedResource, error) {
-	req := SearchResourcesRequest{
-		Configs: []ResourceSelector{selector},
+// SearchOption customizes a catalog search
+type SearchOption func(req *SearchResourcesRequest, selector *ResourceSelector)
+
+// SearchLimit caps the number of configs returned
+func SearchLimit(limit int) SearchOption {
+	return func(req *SearchResourcesRequest, _ *ResourceSelector) {
+		req.Limit = limit
+	}
+}
+
+// SearchSortBy orders results by field, prefix the field with - to sort descending
+func SearchSortBy(field string) SearchOption {
+	return func(req *SearchResourcesRequest, _ *ResourceSelector) {
+		req.SortBy = field
+	}
+}
+
+// SearchTag only matches configs tagged with key=value, can be repeated
+func SearchTag(key, value string) SearchOption {
+	return func(_ *SearchResourcesRequest, selector *ResourceSelector) {
+		if selector.TagSelector != "" {
+			selector.TagSelector += ","
+		}
+		selector.TagSelector += key + "=" + value
+	}
+}
+
+// SearchHealth only matches configs with one of the given health states, e.g. healthy, warning, unhealthy
+func SearchHealth(health ...string) SearchOption {
+	return func(_ *SearchResourcesRequest, selector *ResourceSelector) {
+		selector.Health = strings.Join(health, ",")
+	}
+}
+
+// SearchResources queries the catalog and returns the matching configs with the total count
+func (mc *MissionControl) SearchResources(ctx context.Context, selector ResourceSelector, opts ...SearchOption) (*SearchResourcesResponse, error) {
+	req := SearchResourcesRequest{}
+	for _, opt := range opts {
+		opt(&req, &selector)
 	}
+	req.Configs = []ResourceSelector{selector}
 
-	r, err := mc.HTTP.R(context.TODO()).Post("/resources/search", req)
+	r, err := mc.HTTP.R(ctx).Post("/resources/search", req)
 	if err != nil {
 		return nil, err
 	}
 
 	if !r.IsOK() {
-		body, _ := r.AsString()
-		return nil, fmt.Errorf("query catalog failed: %s", body)
+		return nil, newAPIError("query catalog", r)
 	}
 
 	var response SearchResourcesResponse
 	if err := r.Into(&response); err != nil {
 		return nil, err
 	}
+	if response.Total == 0 {
+		response.Total = len(response.Configs)
+	}
 
+	return &response, nil
+}
+
+func (mc *MissionControl) QueryCatalog(ctx context.Context, selector ResourceSelector, opts ...SearchOption) ([]SelectedResource, error) {
+	response, err := mc.SearchResources(ctx, selector, opts...)
+	if err != nil {
+		return nil, err
+	}
 	return response.Configs, nil
 }
 
-func (mc *MissionControl) SearchCatalog(search string) ([]SelectedResource, error) {
-	return mc.QueryCatalog(ResourceSelector{Search: search})
+func (mc *MissionControl) SearchCatalog(ctx context.Context, search string, opts ...SearchOption) ([]SelectedResource, error) {
+	return mc.QueryCatalog(ctx, ResourceSelector{Search: search}, opts...)
 }
 
 type CatalogChangesSearchRequest struct {
@@ -182,15 +273,14 @@ type CatalogChangesSearchResponse struct {
 	Changes []ConfigChangeRow `json:"changes,omitempty"`
 }
 
-func (mc *MissionControl) SearchCatalogChanges(req CatalogChangesSearchRequest) (*CatalogChangesSearchResponse, error) {
-	r, err := mc.HTTP.R(context.TODO()).Header("content-type", "application/json").Post("/catalog/changes", req)
+func (mc *MissionControl) SearchCatalogChanges(ctx context.Context, req CatalogChangesSearchRequest) (*CatalogChangesSearchResponse, error) {
+	r, err := mc.HTTP.R(ctx).Header("content-type", "application/json").Post("/catalog/changes", req)
 	if err != nil {
 		return nil, err
 	}
 
 	if !r.IsOK() {
-		body, _ := r.AsString()
-		return nil, fmt.Errorf("query catalog failed: %s", body)
+		return nil, newAPIError("search catalog changes", r)
 	}
 
 	var response CatalogChangesSearchResponse
@@ -202,8 +292,8 @@ func (mc *MissionControl) SearchCatalogChanges(req CatalogChangesSearchRequest)
 
 }
 
-func (mc *MissionControl) IsHealthy() (bool, error) {
-	r, err := mc.HTTP.R(context.TODO()).Get("/health")
+func (mc *MissionControl) IsHealthy(ctx context.Context) (bool, error) {
+	r, err := mc.HTTP.R(ctx).Get("/health")
 	if err != nil {
 		return false, err
 	}
@@ -211,8 +301,8 @@ func (mc *MissionControl) IsHealthy() (bool, error) {
 	return r.IsOK(), nil
 }
 
-func (mc *MissionControl) WhoAmI() (map[string]any, bool, error) {
-	r, err := mc.HTTP.R(context.TODO()).Get("/auth/whoami")
+func (mc *MissionControl) WhoAmI(ctx context.Context) (map[string]any, bool, error) {
+	r, err := mc.HTTP.R(ctx).Get("/auth/whoami")
 	if err != nil {
 		return nil, false, err
 	}
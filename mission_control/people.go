@@ -0,0 +1,102 @@
+package mission_control
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+type Person struct {
+	ID        string     `json:"id,omitempty"`
+	Name      string     `json:"name"`
+	Email     string     `json:"email"`
+	Avatar    string     `json:"avatar,omitempty"`
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+}
+
+type Team struct {
+	ID        string     `json:"id,omitempty"`
+	Name      string     `json:"name"`
+	Icon      string     `json:"icon,omitempty"`
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+}
+
+// CreatePerson creates a person and assigns them role, an empty role skips the assignment
+func (mc *MissionControl) CreatePerson(ctx context.Context, email, role string) (*Person, error) {
+	name := email
+	if i := strings.Index(email, "@"); i > 0 {
+		name = email[:i]
+	}
+
+	var created []Person
+	if err := mc.dbInsert(ctx, "create person "+email, "people", Person{Name: name, Email: email}, &created); err != nil {
+		return nil, err
+	}
+	if len(created) == 0 {
+		return nil, &APIError{Op: "create person " + email, Message: "no person returned"}
+	}
+
+	if role != "" {
+		if err := mc.AssignRole(ctx, created[0].ID, role); err != nil {
+			return nil, err
+		}
+	}
+	return &created[0], nil
+}
+
+// GetPersonByEmail returns the person with the given email
+func (mc *MissionControl) GetPersonByEmail(ctx context.Context, email string) (*Person, error) {
+	var people []Person
+	if err := mc.dbSelect(ctx, "get person "+email, "people", map[string]string{"email": "eq." + email}, &people); err != nil {
+		return nil, err
+	}
+	if len(people) == 0 {
+		return nil, &APIError{Op: "get person " + email, StatusCode: 404, Message: "person not found"}
+	}
+	return &people[0], nil
+}
+
+// CreateTeam creates a team with the given person IDs as members
+func (mc *MissionControl) CreateTeam(ctx context.Context, name string, members ...string) (*Team, error) {
+	var created []Team
+	if err := mc.dbInsert(ctx, "create team "+name, "teams", Team{Name: name}, &created); err != nil {
+		return nil, err
+	}
+	if len(created) == 0 {
+		return nil, &APIError{Op: "create team " + name, Message: "no team returned"}
+	}
+
+	if len(members) > 0 {
+		var rows []map[string]string
+		for _, personID := range members {
+			rows = append(rows, map[string]string{"team_id": created[0].ID, "person_id": personID})
+		}
+		if err := mc.dbInsert(ctx, "add members to team "+name, "team_members", rows, nil); err != nil {
+			return nil, err
+		}
+	}
+	return &created[0], nil
+}
+
+// AssignRole sets the RBAC role (e.g. admin, editor, viewer) of a person or team
+func (mc *MissionControl) AssignRole(ctx context.Context, subject, role string) error {
+	r, err := mc.HTTP.R(ctx).
+		Header("Content-Type", "application/json").
+		Post("/rbac/"+subject+"/update_role", map[string]string{"role": role})
+	if err != nil {
+		return err
+	}
+	if !r.IsOK() {
+		return newAPIError("assign role "+role+" to "+subject, r)
+	}
+	return nil
+}
+
+// AsUser returns a copy of the client that authenticates as another user. The client logs in
+// through Kratos when mc does, and uses basic auth otherwise.
+func (mc *MissionControl) AsUser(email, password string) *MissionControl {
+	if mc.authMode() == authKratos {
+		return mc.clone(WithKratosLogin(email, password))
+	}
+	return mc.clone(WithBasicAuth(email, password))
+}
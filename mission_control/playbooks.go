@@ -0,0 +1,184 @@
+package mission_control
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Playbook run and action statuses that will not change any further
+var playbookTerminalStatuses = map[string]bool{
+	"completed": true,
+	"failed":    true,
+	"cancelled": true,
+	"timed_out": true,
+	"skipped":   true,
+}
+
+type Playbook struct {
+	ID          string          `json:"id"`
+	Name        string          `json:"name"`
+	Namespace   string          `json:"namespace,omitempty"`
+	Title       string          `json:"title,omitempty"`
+	Description string          `json:"description,omitempty"`
+	Spec        json.RawMessage `json:"spec,omitempty"`
+}
+
+// PlaybookParams selects the resource a playbook runs against and its parameters
+type PlaybookParams struct {
+	ConfigID    string         `json:"config_id,omitempty"`
+	ComponentID string         `json:"component_id,omitempty"`
+	CheckID     string         `json:"check_id,omitempty"`
+	Params      map[string]any `json:"params,omitempty"`
+}
+
+type PlaybookRun struct {
+	ID         string         `json:"id"`
+	PlaybookID string         `json:"playbook_id"`
+	Status     string         `json:"status"`
+	Error      string         `json:"error,omitempty"`
+	Parameters map[string]any `json:"parameters,omitempty"`
+	CreatedAt  *time.Time     `json:"created_at,omitempty"`
+	StartTime  *time.Time     `json:"start_time,omitempty"`
+	EndTime    *time.Time     `json:"end_time,omitempty"`
+}
+
+type PlaybookRunAction struct {
+	ID        string         `json:"id"`
+	Name      string         `json:"name"`
+	Status    string         `json:"status"`
+	Result    map[string]any `json:"result,omitempty"`
+	Error     string         `json:"error,omitempty"`
+	StartTime *time.Time     `json:"start_time,omitempty"`
+	EndTime   *time.Time     `json:"end_time,omitempty"`
+	Artifacts []Artifact     `json:"artifacts,omitempty"`
+}
+
+type Artifact struct {
+	ID                  string     `json:"id"`
+	PlaybookRunActionID string     `json:"playbook_run_action_id,omitempty"`
+	CheckID             string     `json:"check_id,omitempty"`
+	ConfigChangeID      string     `json:"config_change_id,omitempty"`
+	Filename            string     `json:"filename"`
+	Path                string     `json:"path,omitempty"`
+	ContentType         string     `json:"content_type,omitempty"`
+	Size                int64      `json:"size"`
+	Checksum            string     `json:"checksum,omitempty"`
+	CreatedAt           *time.Time `json:"created_at,omitempty"`
+}
+
+// PlaybookRunDetails is a run together with its actions
+type PlaybookRunDetails struct {
+	PlaybookRun
+	Actions []PlaybookRunAction `json:"actions"`
+}
+
+// IsDone returns true once the run has reached a terminal status
+func (r *PlaybookRunDetails) IsDone() bool {
+	return playbookTerminalStatuses[r.Status]
+}
+
+// Artifacts returns the artifacts produced by all actions of the run
+func (r *PlaybookRunDetails) Artifacts() []Artifact {
+	var artifacts []Artifact
+	for _, action := range r.Actions {
+		artifacts = append(artifacts, action.Artifacts...)
+	}
+	return artifacts
+}
+
+// FailedActions returns the actions that did not complete successfully
+func (r *PlaybookRunDetails) FailedActions() []PlaybookRunAction {
+	var failed []PlaybookRunAction
+	for _, action := range r.Actions {
+		if action.Status == "failed" {
+			failed = append(failed, action)
+		}
+	}
+	return failed
+}
+
+func (mc *MissionControl) ListPlaybooks(ctx context.Context) ([]Playbook, error) {
+	r, err := mc.HTTP.R(ctx).
+		QueryParam("select", "id,name,namespace,title,description,spec").
+		QueryParam("deleted_at", "is.null").
+		Get("/db/playbooks")
+	if err != nil {
+		return nil, err
+	}
+	if !r.IsOK() {
+		return nil, newAPIError("list playbooks", r)
+	}
+
+	var playbooks []Playbook
+	if err := r.Into(&playbooks); err != nil {
+		return nil, err
+	}
+	return playbooks, nil
+}
+
+// RunPlaybook submits a playbook run and returns the run ID
+func (mc *MissionControl) RunPlaybook(ctx context.Context, playbookID string, params PlaybookParams) (string, error) {
+	body := struct {
+		ID string `json:"id"`
+		PlaybookParams
+	}{ID: playbookID, PlaybookParams: params}
+
+	r, err := mc.HTTP.R(ctx).Header("Content-Type", "application/json").Post("/playbook/run", body)
+	if err != nil {
+		return "", err
+	}
+	if !r.IsOK() {
+		return "", newAPIError("run playbook "+playbookID, r)
+	}
+
+	var response struct {
+		RunID string `json:"run_id"`
+	}
+	if err := r.Into(&response); err != nil {
+		return "", err
+	}
+	return response.RunID, nil
+}
+
+func (mc *MissionControl) GetPlaybookRun(ctx context.Context, runID string) (*PlaybookRunDetails, error) {
+	r, err := mc.HTTP.R(ctx).Get("/playbook/run/" + runID)
+	if err != nil {
+		return nil, err
+	}
+	if !r.IsOK() {
+		return nil, newAPIError("get playbook run "+runID, r)
+	}
+
+	var response struct {
+		Run     PlaybookRun         `json:"run"`
+		Actions []PlaybookRunAction `json:"actions"`
+	}
+	if err := r.Into(&response); err != nil {
+		return nil, err
+	}
+	return &PlaybookRunDetails{PlaybookRun: response.Run, Actions: response.Actions}, nil
+}
+
+// WaitForPlaybookRun polls a run until it reaches a terminal status or timeout expires
+func (mc *MissionControl) WaitForPlaybookRun(ctx context.Context, runID string, timeout time.Duration) (*PlaybookRunDetails, error) {
+	var last *PlaybookRunDetails
+	var lastErr error
+	err := poll(ctx, timeout, func(ctx context.Context) bool {
+		run, err := mc.GetPlaybookRun(ctx, runID)
+		lastErr = err
+		if err != nil {
+			return false
+		}
+		last = run
+		return run.IsDone()
+	})
+	if err == nil {
+		return last, nil
+	}
+	if last != nil {
+		return last, fmt.Errorf("playbook run %s still %s after %v", runID, last.Status, timeout)
+	}
+	return nil, fmt.Errorf("playbook run %s not found after %v: %w", runID, timeout, lastErr)
+}
@@ -0,0 +1,90 @@
+package mission_control
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/flanksource/clicky"
+	"github.com/flanksource/clicky/exec"
+	"github.com/flanksource/commons/logger"
+
+	"github.com/flanksource/commons-test/helm"
+	"github.com/flanksource/commons-test/ports"
+	"github.com/flanksource/commons-test/wait"
+)
+
+var kubectl = clicky.Exec("kubectl").AsWrapper()
+
+// FromPortForward returns a client for mission-control running in pod, reached through a
+// kubectl port-forward to port that is restarted whenever it drops. The returned func stops the forward.
+func FromPortForward(pod *helm.Pod, port int, opts ...Option) (*MissionControl, func(), error) {
+	name := pod.GetName()
+	if name == "" {
+		return nil, nil, fmt.Errorf("failed to resolve pod name: %w", pod.Error())
+	}
+	return fromPortForward(pod.Namespace, "pod/"+name, port, opts...)
+}
+
+// FromService returns a client for mission-control behind a service, reached through a
+// kubectl port-forward to port that is restarted whenever it drops. The returned func stops the forward.
+func FromService(namespace, service string, port int, opts ...Option) (*MissionControl, func(), error) {
+	return fromPortForward(namespace, "svc/"+service, port, opts...)
+}
+
+func fromPortForward(namespace, target string, port int, opts ...Option) (*MissionControl, func(), error) {
+	localPort, stop, err := startPortForward(namespace, target, port)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	mc, err := NewClient(fmt.Sprintf("http://localhost:%d", localPort), append([]Option{WithNamespace(namespace)}, opts...)...)
+	if err != nil {
+		stop()
+		return nil, nil, err
+	}
+	return mc, stop, nil
+}
+
+// startPortForward keeps a kubectl port-forward to target running on a fixed local port until stop is called
+func startPortForward(namespace, target string, port int) (int, func(), error) {
+	localPort, err := ports.Get()
+	if err != nil {
+		return 0, nil, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stop := func() {
+		cancel()
+		ports.Release(localPort)
+	}
+	go func() {
+		for {
+			_, err := kubectl(exec.WithContext(ctx), "port-forward", "-n", namespace, target, fmt.Sprintf("%d:%d", localPort, port))
+			if ctx.Err() != nil {
+				return
+			}
+			logger.Warnf("port-forward to %s/%s:%d exited, reconnecting: %v", namespace, target, port, err)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(time.Second):
+			}
+		}
+	}()
+
+	err = wait.For(ctx, 100*time.Millisecond, 30*time.Second, wait.NoError(func(ctx context.Context) error {
+		conn, err := net.DialTimeout("tcp", fmt.Sprintf("localhost:%d", localPort), 500*time.Millisecond)
+		if err == nil {
+			conn.Close()
+		}
+		return err
+	}))
+	if err != nil {
+		stop()
+		return 0, nil, fmt.Errorf("failed to port-forward to %s/%s:%d: %w", namespace, target, port, err)
+	}
+	return localPort, stop, nil
+}
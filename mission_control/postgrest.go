@@ -0,0 +1,59 @@
+package mission_control
+
+import (
+	"context"
+)
+
+// dbSelect queries a PostgREST table exposed under /db, filters use PostgREST syntax e.g. {"id": "eq.123"}
+func (mc *MissionControl) dbSelect(ctx context.Context, op, table string, filters map[string]string, out any) error {
+	req := mc.HTTP.R(ctx)
+	for key, value := range filters {
+		req = req.QueryParam(key, value)
+	}
+
+	r, err := req.Get("/db/" + table)
+	if err != nil {
+		return err
+	}
+	if !r.IsOK() {
+		return newAPIError(op, r)
+	}
+	return r.Into(out)
+}
+
+// dbInsert inserts rows into a PostgREST table, decoding the created rows into out when it is not nil
+func (mc *MissionControl) dbInsert(ctx context.Context, op, table string, body any, out any) error {
+	req := mc.HTTP.R(ctx).Header("Content-Type", "application/json")
+	if out != nil {
+		req = req.Header("Prefer", "return=representation")
+	}
+
+	r, err := req.Post("/db/"+table, body)
+	if err != nil {
+		return err
+	}
+	if !r.IsOK() {
+		return newAPIError(op, r)
+	}
+	if out == nil {
+		return nil
+	}
+	return r.Into(out)
+}
+
+// dbUpdate patches the rows of a PostgREST table matching filters
+func (mc *MissionControl) dbUpdate(ctx context.Context, op, table string, filters map[string]string, body any) error {
+	req := mc.HTTP.R(ctx).Header("Content-Type", "application/json")
+	for key, value := range filters {
+		req = req.QueryParam(key, value)
+	}
+
+	r, err := req.Patch("/db/"+table, body)
+	if err != nil {
+		return err
+	}
+	if !r.IsOK() {
+		return newAPIError(op, r)
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+package mission_control
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// DefaultPrometheusPath is the prefix of mission-control's Prometheus proxy
+const DefaultPrometheusPath = "/prometheus"
+
+// WithPrometheusProxy sets the path of the Prometheus API proxy, defaults to DefaultPrometheusPath
+func WithPrometheusProxy(path string) Option {
+	return func(mc *MissionControl) {
+		if path != "" {
+			mc.prometheusPath = path
+		}
+	}
+}
+
+// Sample is a single value returned from a PromQL query
+type Sample struct {
+	Metric    map[string]string
+	Value     float64
+	Timestamp time.Time
+}
+
+// QueryPrometheus evaluates an instant PromQL query at the given time (now when zero) through the metrics proxy
+func (mc *MissionControl) QueryPrometheus(ctx context.Context, promql string, at time.Time) ([]Sample, error) {
+	req := mc.HTTP.R(ctx).QueryParam("query", promql)
+	if !at.IsZero() {
+		req = req.QueryParam("time", strconv.FormatFloat(float64(at.UnixMilli())/1000, 'f', 3, 64))
+	}
+
+	r, err := req.Get(mc.prometheusPath + "/api/v1/query")
+	if err != nil {
+		return nil, err
+	}
+	if !r.IsOK() {
+		return nil, newAPIError("query prometheus "+promql, r)
+	}
+
+	var response struct {
+		Status string `json:"status"`
+		Error  string `json:"error"`
+		Data   struct {
+			ResultType string          `json:"resultType"`
+			Result     json.RawMessage `json:"result"`
+		} `json:"data"`
+	}
+	if err := r.Into(&response); err != nil {
+		return nil, err
+	}
+	if response.Status != "success" {
+		return nil, fmt.Errorf("query %q failed: %s", promql, response.Error)
+	}
+
+	switch response.Data.ResultType {
+	case "scalar":
+		var value []any
+		if err := json.Unmarshal(response.Data.Result, &value); err != nil {
+			return nil, err
+		}
+		sample, err := parseSample(nil, value)
+		if err != nil {
+			return nil, err
+		}
+		return []Sample{sample}, nil
+
+	case "vector":
+		var series []struct {
+			Metric map[string]string `json:"metric"`
+			Value  []any             `json:"value"`
+		}
+		if err := json.Unmarshal(response.Data.Result, &series); err != nil {
+			return nil, err
+		}
+		var samples []Sample
+		for _, s := range series {
+			sample, err := parseSample(s.Metric, s.Value)
+			if err != nil {
+				return nil, err
+			}
+			samples = append(samples, sample)
+		}
+		return samples, nil
+	}
+	return nil, fmt.Errorf("unsupported result type: %s", response.Data.ResultType)
+}
+
+// parseSample parses a [<unix_time>, "<value>"] pair
+func parseSample(metric map[string]string, pair []any) (Sample, error) {
+	if len(pair) != 2 {
+		return Sample{}, fmt.Errorf("unexpected sample format: %v", pair)
+	}
+	ts, ok := pair[0].(float64)
+	if !ok {
+		return Sample{}, fmt.Errorf("unexpected sample timestamp: %v", pair[0])
+	}
+	raw, ok := pair[1].(string)
+	if !ok {
+		return Sample{}, fmt.Errorf("unexpected sample value: %v", pair[1])
+	}
+	value, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		return Sample{}, err
+	}
+	return Sample{
+		Metric:    metric,
+		Value:     value,
+		Timestamp: time.UnixMilli(int64(ts * 1000)),
+	}, nil
+}
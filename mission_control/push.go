@@ -0,0 +1,65 @@
+package mission_control
+
+import (
+	"context"
+)
+
+// PushData is the payload an agent sends upstream to /upstream/push
+type PushData struct {
+	AgentName           string            `json:"agent_name,omitempty"`
+	Topologies          []map[string]any  `json:"topologies,omitempty"`
+	Components          []map[string]any  `json:"components,omitempty"`
+	Canaries            []Canary          `json:"canaries,omitempty"`
+	Checks              []Check           `json:"checks,omitempty"`
+	CheckStatuses       []CheckStatus     `json:"check_statuses,omitempty"`
+	ConfigScrapers      []map[string]any  `json:"config_scrapers,omitempty"`
+	ConfigItems         []ConfigItem      `json:"config_items,omitempty"`
+	ConfigChanges       []ConfigChangeRow `json:"config_changes,omitempty"`
+	ConfigRelationships []map[string]any  `json:"config_relationships,omitempty"`
+}
+
+// AsAgent returns a copy of the client that authenticates with an agent's credentials, as a real agent pushing upstream does
+func (mc *MissionControl) AsAgent(credentials *AgentCredentials) *MissionControl {
+	return mc.clone(WithBasicAuth(credentials.Username, credentials.AccessToken))
+}
+
+// Push sends data upstream as an agent would
+func (mc *MissionControl) Push(ctx context.Context, data PushData) error {
+	r, err := mc.HTTP.R(ctx).
+		Header("Content-Type", "application/json").
+		Post("/upstream/push", data)
+	if err != nil {
+		return err
+	}
+	if !r.IsOK() {
+		return newAPIError("push from agent "+data.AgentName, r)
+	}
+	return nil
+}
+
+// PushTopology pushes a topology and its components on behalf of agent
+func (mc *MissionControl) PushTopology(ctx context.Context, agent string, topology map[string]any, components []map[string]any) error {
+	return mc.Push(ctx, PushData{
+		AgentName:  agent,
+		Topologies: []map[string]any{topology},
+		Components: components,
+	})
+}
+
+// PushConfigResults pushes scraped config items and changes on behalf of agent
+func (mc *MissionControl) PushConfigResults(ctx context.Context, agent string, items []ConfigItem, changes []ConfigChangeRow) error {
+	return mc.Push(ctx, PushData{
+		AgentName:     agent,
+		ConfigItems:   items,
+		ConfigChanges: changes,
+	})
+}
+
+// PushCheckStatuses pushes checks and their results on behalf of agent
+func (mc *MissionControl) PushCheckStatuses(ctx context.Context, agent string, checks []Check, statuses []CheckStatus) error {
+	return mc.Push(ctx, PushData{
+		AgentName:     agent,
+		Checks:        checks,
+		CheckStatuses: statuses,
+	})
+}
@@ -0,0 +1,39 @@
+package mission_control_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	mc "github.com/flanksource/commons-test/mission_control"
+	"github.com/flanksource/commons-test/mission_control/fake"
+)
+
+func TestPushAsAgent(t *testing.T) {
+	var pushed mc.PushData
+	server := fake.NewServer().Handle("POST /upstream/push", func(w http.ResponseWriter, r *http.Request) {
+		if username, password, ok := r.BasicAuth(); !ok || username != "agent-1" || password != "token" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		if err := json.NewDecoder(r.Body).Decode(&pushed); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		}
+	})
+	defer server.Close()
+
+	agent := server.Client().AsAgent(&mc.AgentCredentials{Username: "agent-1", AccessToken: "token"})
+	err := agent.PushConfigResults(context.Background(), "agent-1",
+		[]mc.ConfigItem{{ID: "1"}}, []mc.ConfigChangeRow{{ID: "a", ConfigID: "1"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pushed.AgentName != "agent-1" || len(pushed.ConfigItems) != 1 || len(pushed.ConfigChanges) != 1 {
+		t.Errorf("unexpected push %+v", pushed)
+	}
+
+	if err := server.Client().Push(context.Background(), mc.PushData{AgentName: "agent-1"}); err == nil {
+		t.Error("expected an unauthenticated push to fail")
+	}
+}
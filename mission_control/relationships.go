@@ -0,0 +1,106 @@
+package mission_control
+
+import "context"
+
+type RelationDirection string
+
+const (
+	RelationIncoming RelationDirection = "incoming"
+	RelationOutgoing RelationDirection = "outgoing"
+	RelationAll      RelationDirection = "all"
+)
+
+// RelatedConfig is a config item reachable from another through parent/child (hard)
+// or config_relationships (soft) links
+type RelatedConfig struct {
+	ID         string            `json:"id"`
+	Name       string            `json:"name"`
+	Type       string            `json:"type"`
+	Tags       map[string]string `json:"tags,omitempty"`
+	Health     string            `json:"health,omitempty"`
+	Status     string            `json:"status,omitempty"`
+	Path       string            `json:"path,omitempty"`
+	Direction  string            `json:"direction"`
+	Depth      int               `json:"depth"`
+	Relation   string            `json:"relation"`
+	RelatedIDs []string          `json:"related_ids,omitempty"`
+}
+
+// IsHard returns true for parent/child relationships
+func (r RelatedConfig) IsHard() bool {
+	return r.Relation == "hard"
+}
+
+// RelatedConfigs is the relationship graph around a config item
+type RelatedConfigs []RelatedConfig
+
+// Hard returns only parent/child relationships
+func (r RelatedConfigs) Hard() RelatedConfigs {
+	return r.filter(func(c RelatedConfig) bool { return c.IsHard() })
+}
+
+// Soft returns only relationships created by scraper relationship rules
+func (r RelatedConfigs) Soft() RelatedConfigs {
+	return r.filter(func(c RelatedConfig) bool { return !c.IsHard() })
+}
+
+// Incoming returns configs that point at the root config
+func (r RelatedConfigs) Incoming() RelatedConfigs {
+	return r.filter(func(c RelatedConfig) bool { return c.Direction == string(RelationIncoming) })
+}
+
+// Outgoing returns configs the root config points at
+func (r RelatedConfigs) Outgoing() RelatedConfigs {
+	return r.filter(func(c RelatedConfig) bool { return c.Direction == string(RelationOutgoing) })
+}
+
+// IDs returns the IDs of all related configs
+func (r RelatedConfigs) IDs() []string {
+	ids := make([]string, 0, len(r))
+	for _, c := range r {
+		ids = append(ids, c.ID)
+	}
+	return ids
+}
+
+func (r RelatedConfigs) filter(fn func(RelatedConfig) bool) RelatedConfigs {
+	var out RelatedConfigs
+	for _, c := range r {
+		if fn(c) {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// GetRelatedConfigs returns configs related to id up to depth hops away in the given direction
+func (mc *MissionControl) GetRelatedConfigs(ctx context.Context, id string, direction RelationDirection, depth int) (RelatedConfigs, error) {
+	if direction == "" {
+		direction = RelationAll
+	}
+	if depth <= 0 {
+		depth = 5
+	}
+
+	r, err := mc.HTTP.R(ctx).
+		Header("Content-Type", "application/json").
+		Post("/db/rpc/related_configs_recursive", map[string]any{
+			"config_id":         id,
+			"type_filter":       direction,
+			"max_depth":         depth,
+			"incoming_relation": "both",
+			"outgoing_relation": "both",
+		})
+	if err != nil {
+		return nil, err
+	}
+	if !r.IsOK() {
+		return nil, newAPIError("get related configs of "+id, r)
+	}
+
+	var related RelatedConfigs
+	if err := r.Into(&related); err != nil {
+		return nil, err
+	}
+	return related, nil
+}
@@ -0,0 +1,98 @@
+package mission_control
+
+import (
+	"errors"
+	"io"
+	gohttp "net/http"
+	"net/http/httptrace"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/flanksource/commons/http/middlewares"
+)
+
+func TestRetryMiddleware(t *testing.T) {
+	refused := errors.New("connection refused")
+	for name, tc := range map[string]struct {
+		method string
+		// respond returns the status or error of each attempt, calling trace.WroteRequest once it is sent
+		respond  func(attempt int, trace *httptrace.ClientTrace) (int, error)
+		attempts int
+	}{
+		"GET is retried on 503": {
+			method: gohttp.MethodGet,
+			respond: func(attempt int, _ *httptrace.ClientTrace) (int, error) {
+				if attempt == 1 {
+					return gohttp.StatusServiceUnavailable, nil
+				}
+				return gohttp.StatusOK, nil
+			},
+			attempts: 2,
+		},
+		"PUT is retried with its body": {
+			method: gohttp.MethodPut,
+			respond: func(attempt int, _ *httptrace.ClientTrace) (int, error) {
+				if attempt == 1 {
+					return gohttp.StatusBadGateway, nil
+				}
+				return gohttp.StatusOK, nil
+			},
+			attempts: 2,
+		},
+		"POST is not retried on 503": {
+			method: gohttp.MethodPost,
+			respond: func(int, *httptrace.ClientTrace) (int, error) {
+				return gohttp.StatusServiceUnavailable, nil
+			},
+			attempts: 1,
+		},
+		"POST is retried when the connection failed before the request was written": {
+			method: gohttp.MethodPost,
+			respond: func(attempt int, _ *httptrace.ClientTrace) (int, error) {
+				if attempt == 1 {
+					return 0, refused
+				}
+				return gohttp.StatusOK, nil
+			},
+			attempts: 2,
+		},
+		"POST is not retried when the connection failed after the request was written": {
+			method: gohttp.MethodPost,
+			respond: func(_ int, trace *httptrace.ClientTrace) (int, error) {
+				trace.WroteRequest(httptrace.WroteRequestInfo{})
+				return 0, refused
+			},
+			attempts: 1,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			var bodies []string
+			next := middlewares.RoundTripperFunc(func(req *gohttp.Request) (*gohttp.Response, error) {
+				data, _ := io.ReadAll(req.Body)
+				bodies = append(bodies, string(data))
+				status, err := tc.respond(len(bodies), httptrace.ContextClientTrace(req.Context()))
+				if err != nil {
+					return nil, err
+				}
+				return &gohttp.Response{StatusCode: status, Body: io.NopCloser(strings.NewReader(""))}, nil
+			})
+
+			mc := &MissionControl{retryAttempts: 3, retryDelay: time.Millisecond}
+			req, err := gohttp.NewRequest(tc.method, "http://mission-control/resources", strings.NewReader(`{"name":"nginx"}`))
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, _ = mc.retryMiddleware(next).RoundTrip(req)
+
+			if len(bodies) != tc.attempts {
+				t.Fatalf("expected %d attempts, got %d", tc.attempts, len(bodies))
+			}
+			for _, body := range bodies {
+				if body != `{"name":"nginx"}` {
+					t.Errorf("expected every attempt to send the body, got %q", body)
+				}
+			}
+		})
+	}
+}
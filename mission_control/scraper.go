@@ -0,0 +1,186 @@
+package mission_control
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ConfigTypeSummary counts the changes a scrape made to a single config type
+type ConfigTypeSummary struct {
+	Added     int `json:"added,omitempty"`
+	Updated   int `json:"updated,omitempty"`
+	Unchanged int `json:"unchanged,omitempty"`
+	Deleted   int `json:"deleted,omitempty"`
+}
+
+// ScrapeSummary is the outcome of a completed scraper run
+type ScrapeSummary struct {
+	ConfigTypes map[string]ConfigTypeSummary
+	Errors      []string
+	Duration    time.Duration
+}
+
+// Totals sums the counts across all config types
+func (s *ScrapeSummary) Totals() ConfigTypeSummary {
+	var total ConfigTypeSummary
+	for _, t := range s.ConfigTypes {
+		total.Added += t.Added
+		total.Updated += t.Updated
+		total.Unchanged += t.Unchanged
+		total.Deleted += t.Deleted
+	}
+	return total
+}
+
+// HasErrors returns true if the scraper reported any error
+func (s *ScrapeSummary) HasErrors() bool {
+	return len(s.Errors) > 0
+}
+
+// MustHaveNoErrors panics if the scraper reported any error
+func (s *ScrapeSummary) MustHaveNoErrors() *ScrapeSummary {
+	if s.HasErrors() {
+		panic(fmt.Sprintf("scrape completed with %d errors:\n%s", len(s.Errors), strings.Join(s.Errors, "\n")))
+	}
+	return s
+}
+
+func (s *ScrapeSummary) String() string {
+	types := make([]string, 0, len(s.ConfigTypes))
+	for t := range s.ConfigTypes {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+
+	var sb strings.Builder
+	for _, t := range types {
+		c := s.ConfigTypes[t]
+		fmt.Fprintf(&sb, "%s: added=%d updated=%d unchanged=%d deleted=%d\n", t, c.Added, c.Updated, c.Unchanged, c.Deleted)
+	}
+	if len(s.Errors) > 0 {
+		fmt.Fprintf(&sb, "errors: %s\n", strings.Join(s.Errors, "; "))
+	}
+	return sb.String()
+}
+
+// jobHistory is a row of the job_history table
+type jobHistory struct {
+	ID         string          `json:"id"`
+	Status     string          `json:"status"`
+	Errors     []string        `json:"errors"`
+	Details    json.RawMessage `json:"details"`
+	DurationMs int64           `json:"duration_millis"`
+	CreatedAt  time.Time       `json:"created_at"`
+}
+
+var jobTerminalStatuses = map[string]bool{
+	"SUCCESS":  true,
+	"FAILED":   true,
+	"WARNING":  true,
+	"FINISHED": true,
+	"SKIPPED":  true,
+}
+
+// Run triggers the scraper and waits up to timeout for it to complete
+func (s *Scraper) Run(ctx context.Context, timeout time.Duration) (*ScrapeSummary, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	// Allow for clock skew between the test host and the database
+	since := start.Add(-5 * time.Second)
+
+	r, err := s.mc.ConfigDB.R(ctx).Post("/run/"+s.Id, map[string]string{"scraper": s.Name})
+	if err != nil {
+		return nil, err
+	}
+	if !r.IsOK() {
+		return nil, newAPIError("run scraper "+s.Id, r)
+	}
+
+	var response struct {
+		Errors  []string        `json:"errors"`
+		Summary json.RawMessage `json:"scrape_summary"`
+	}
+	if err := r.Into(&response); err != nil {
+		return nil, err
+	}
+
+	// Synchronous runs return the summary directly
+	if len(response.Summary) > 0 && string(response.Summary) != "null" {
+		summary, err := parseScrapeSummary(response.Summary)
+		if err != nil {
+			return nil, err
+		}
+		summary.Errors = append(summary.Errors, response.Errors...)
+		summary.Duration = time.Since(start)
+		return summary, nil
+	}
+
+	return s.waitForJob(ctx, since, timeout)
+}
+
+// waitForJob polls the job history until the scraper's run started after since completes
+func (s *Scraper) waitForJob(ctx context.Context, since time.Time, timeout time.Duration) (*ScrapeSummary, error) {
+	var last *jobHistory
+	err := poll(ctx, timeout, func(ctx context.Context) bool {
+		var jobs []jobHistory
+		err := s.mc.dbSelect(ctx, "get job history of scraper "+s.Id, "job_history", map[string]string{
+			"resource_id": "eq." + s.Id,
+			"created_at":  "gte." + since.UTC().Format(time.RFC3339),
+			"order":       "created_at.desc",
+			"limit":       "1",
+		}, &jobs)
+		if err != nil || len(jobs) == 0 {
+			return false
+		}
+		last = &jobs[0]
+		return jobTerminalStatuses[last.Status]
+	})
+	if err == nil {
+		return last.summary()
+	}
+	if last != nil {
+		return nil, fmt.Errorf("scraper %s still %s after %v", s.Id, last.Status, timeout)
+	}
+	return nil, fmt.Errorf("scraper %s did not start within %v", s.Id, timeout)
+}
+
+func (j *jobHistory) summary() (*ScrapeSummary, error) {
+	summary := &ScrapeSummary{}
+	var details struct {
+		Summary json.RawMessage `json:"scrape_summary"`
+	}
+	if len(j.Details) > 0 && json.Unmarshal(j.Details, &details) == nil && len(details.Summary) > 0 {
+		parsed, err := parseScrapeSummary(details.Summary)
+		if err != nil {
+			return nil, err
+		}
+		summary = parsed
+	}
+	summary.Errors = append(summary.Errors, j.Errors...)
+	summary.Duration = time.Duration(j.DurationMs) * time.Millisecond
+	return summary, nil
+}
+
+// parseScrapeSummary accepts both the {"config_types": {...}, "errors": [...]} form
+// and the older bare map of config type to counts
+func parseScrapeSummary(raw json.RawMessage) (*ScrapeSummary, error) {
+	var wrapped struct {
+		ConfigTypes map[string]ConfigTypeSummary `json:"config_types"`
+		Errors      []string                     `json:"errors"`
+	}
+	if err := json.Unmarshal(raw, &wrapped); err == nil && (wrapped.ConfigTypes != nil || wrapped.Errors != nil) {
+		return &ScrapeSummary{ConfigTypes: wrapped.ConfigTypes, Errors: wrapped.Errors}, nil
+	}
+
+	var types map[string]ConfigTypeSummary
+	if err := json.Unmarshal(raw, &types); err != nil {
+		return nil, fmt.Errorf("failed to parse scrape summary: %w", err)
+	}
+	return &ScrapeSummary{ConfigTypes: types}, nil
+}
@@ -0,0 +1,94 @@
+package mission_control
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// seedBatchSize is the number of rows inserted per request when seeding
+const seedBatchSize = 500
+
+// ConfigItemFixture describes a config item inserted by SeedConfigItems, a missing ID is generated
+type ConfigItemFixture struct {
+	ID          string            `json:"id"`
+	ScraperID   string            `json:"scraper_id,omitempty"`
+	ExternalID  []string          `json:"external_id,omitempty"`
+	Name        string            `json:"name"`
+	Type        string            `json:"type"`
+	ConfigClass string            `json:"config_class"`
+	Tags        map[string]string `json:"tags,omitempty"`
+	Labels      map[string]string `json:"labels,omitempty"`
+	Config      any               `json:"config,omitempty"`
+	Health      string            `json:"health,omitempty"`
+	Status      string            `json:"status,omitempty"`
+	ParentID    string            `json:"parent_id,omitempty"`
+}
+
+// ConfigChangeFixture describes a change inserted by SeedChanges, a missing ID is generated
+type ConfigChangeFixture struct {
+	ID         string         `json:"id"`
+	ConfigID   string         `json:"config_id"`
+	ChangeType string         `json:"change_type"`
+	Severity   string         `json:"severity,omitempty"`
+	Source     string         `json:"source"`
+	Summary    string         `json:"summary,omitempty"`
+	Details    map[string]any `json:"details,omitempty"`
+	CreatedAt  *time.Time     `json:"created_at,omitempty"`
+}
+
+// SeedConfigItems inserts config items directly into the catalog in batches, bypassing scrapers.
+// Returns the fixtures with their IDs filled in.
+func (mc *MissionControl) SeedConfigItems(ctx context.Context, items []ConfigItemFixture) ([]ConfigItemFixture, error) {
+	seeded := make([]ConfigItemFixture, len(items))
+	for i, item := range items {
+		if item.ID == "" {
+			item.ID = uuid.NewString()
+		}
+		if item.ConfigClass == "" {
+			item.ConfigClass = item.Type
+		}
+		if len(item.ExternalID) == 0 {
+			item.ExternalID = []string{item.ID}
+		}
+		seeded[i] = item
+	}
+
+	if err := seedBatches(ctx, mc, "config_items", seeded); err != nil {
+		return nil, err
+	}
+	return seeded, nil
+}
+
+// SeedChanges inserts config changes directly into the catalog in batches.
+// Returns the fixtures with their IDs filled in.
+func (mc *MissionControl) SeedChanges(ctx context.Context, changes []ConfigChangeFixture) ([]ConfigChangeFixture, error) {
+	seeded := make([]ConfigChangeFixture, len(changes))
+	for i, change := range changes {
+		if change.ID == "" {
+			change.ID = uuid.NewString()
+		}
+		if change.Source == "" {
+			change.Source = "test"
+		}
+		seeded[i] = change
+	}
+
+	if err := seedBatches(ctx, mc, "config_changes", seeded); err != nil {
+		return nil, err
+	}
+	return seeded, nil
+}
+
+func seedBatches[T any](ctx context.Context, mc *MissionControl, table string, rows []T) error {
+	for start := 0; start < len(rows); start += seedBatchSize {
+		end := min(start+seedBatchSize, len(rows))
+		op := fmt.Sprintf("seed %s %d-%d of %d", table, start+1, end, len(rows))
+		if err := mc.dbInsert(ctx, op, table, rows[start:end], nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
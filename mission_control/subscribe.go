@@ -0,0 +1,122 @@
+package mission_control
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// DefaultEventsPath is the server-sent events endpoint used when EventFilter.Path is empty
+const DefaultEventsPath = "/events"
+
+// EventFilter selects the server events delivered by SubscribeEvents
+type EventFilter struct {
+	// Path overrides the streaming endpoint, defaults to DefaultEventsPath
+	Path string
+	// Types limits the events to the given event names, e.g. config.changed
+	Types []string
+	// Params are sent as query parameters, e.g. {"config_id": "..."}
+	Params map[string]string
+}
+
+func (f EventFilter) matches(eventType string) bool {
+	if len(f.Types) == 0 {
+		return true
+	}
+	for _, t := range f.Types {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// ServerEvent is a single event received from the streaming endpoint
+type ServerEvent struct {
+	ID         string          `json:"id,omitempty"`
+	Type       string          `json:"type"`
+	Data       json.RawMessage `json:"data,omitempty"`
+	ReceivedAt time.Time       `json:"received_at"`
+}
+
+// Into decodes the event data into v
+func (e ServerEvent) Into(v any) error {
+	return json.Unmarshal(e.Data, v)
+}
+
+// SubscribeEvents opens a server-sent events stream and delivers matching events on the returned
+// channel, which is closed when ctx is cancelled or the server ends the stream.
+// The stream is subject to the client's request timeout.
+func (mc *MissionControl) SubscribeEvents(ctx context.Context, filter EventFilter) (<-chan ServerEvent, error) {
+	path := filter.Path
+	if path == "" {
+		path = DefaultEventsPath
+	}
+
+	req := mc.HTTP.R(ctx).
+		Header("Accept", "text/event-stream").
+		Header("Cache-Control", "no-cache")
+	if len(filter.Types) > 0 {
+		req = req.QueryParam("types", strings.Join(filter.Types, ","))
+	}
+	for k, v := range filter.Params {
+		req = req.QueryParam(k, v)
+	}
+
+	r, err := req.Get(path)
+	if err != nil {
+		return nil, err
+	}
+	if !r.IsOK() {
+		return nil, newAPIError("subscribe to events", r)
+	}
+
+	events := make(chan ServerEvent)
+	go func() {
+		defer close(events)
+		defer r.Body.Close()
+
+		scanner := bufio.NewScanner(r.Body)
+		scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+
+		var event ServerEvent
+		var data []string
+		for scanner.Scan() {
+			line := scanner.Text()
+			if line != "" {
+				field, value, _ := strings.Cut(line, ":")
+				value = strings.TrimPrefix(value, " ")
+				switch field {
+				case "id":
+					event.ID = value
+				case "event":
+					event.Type = value
+				case "data":
+					data = append(data, value)
+				}
+				continue
+			}
+
+			// A blank line dispatches the event, events without data are keep-alives
+			if len(data) > 0 {
+				if event.Type == "" {
+					event.Type = "message"
+				}
+				event.Data = json.RawMessage(strings.Join(data, "\n"))
+				event.ReceivedAt = time.Now()
+				if filter.matches(event.Type) {
+					select {
+					case events <- event:
+					case <-ctx.Done():
+						return
+					}
+				}
+			}
+			event = ServerEvent{}
+			data = nil
+		}
+	}()
+	return events, nil
+}
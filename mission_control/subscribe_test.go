@@ -0,0 +1,51 @@
+package mission_control_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	mc "github.com/flanksource/commons-test/mission_control"
+	"github.com/flanksource/commons-test/mission_control/fake"
+)
+
+func TestSubscribeEvents(t *testing.T) {
+	server := fake.NewServer().Handle("GET /events", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("types") != "config.changed" {
+			http.Error(w, "unexpected types "+r.URL.Query().Get("types"), http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, ": keep-alive\n\n")
+		fmt.Fprint(w, "event: config.created\ndata: {\"id\": \"0\"}\n\n")
+		fmt.Fprint(w, "id: 1\nevent: config.changed\ndata: {\"id\":\ndata: \"1\"}\n\n")
+		fmt.Fprint(w, "id: 2\nevent: config.changed\ndata: {\"id\": \"2\"}\n\n")
+	})
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	events, err := server.Client().SubscribeEvents(ctx, mc.EventFilter{Types: []string{"config.changed"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var ids []string
+	for event := range events {
+		var data struct {
+			ID string `json:"id"`
+		}
+		if err := event.Into(&data); err != nil {
+			t.Fatalf("failed to decode event %s: %v", event.Data, err)
+		}
+		if event.ID != data.ID {
+			t.Errorf("expected event id %s to match its data, got %s", event.ID, data.ID)
+		}
+		ids = append(ids, data.ID)
+	}
+	if len(ids) != 2 || ids[0] != "1" || ids[1] != "2" {
+		t.Errorf("expected config.changed events 1 and 2, got %v", ids)
+	}
+}
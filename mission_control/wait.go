@@ -0,0 +1,130 @@
+package mission_control
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/flanksource/commons-test/wait"
+)
+
+// poll calls fn with exponential backoff until it returns true, an error from ctx, or timeout expires
+func poll(ctx context.Context, timeout time.Duration, fn func(ctx context.Context) bool) error {
+	return wait.For(ctx, 500*time.Millisecond, timeout, func(ctx context.Context) (bool, error) {
+		return fn(ctx), nil
+	})
+}
+
+// WaitForConfigItem polls the catalog until at least one item matches selector
+func (mc *MissionControl) WaitForConfigItem(ctx context.Context, selector ResourceSelector, timeout time.Duration) ([]SelectedResource, error) {
+	var found []SelectedResource
+	var lastErr error
+	err := poll(ctx, timeout, func(ctx context.Context) bool {
+		found, lastErr = mc.QueryCatalog(ctx, selector)
+		return lastErr == nil && len(found) > 0
+	})
+	if err == nil {
+		return found, nil
+	}
+
+	msg := fmt.Sprintf("no config item matching %s after %v", describeSelector(selector), timeout)
+	if lastErr != nil {
+		return nil, fmt.Errorf("%s: %w", msg, lastErr)
+	}
+
+	// List items of the same type to help spot typos in names, namespaces or labels
+	if len(selector.Types) > 0 {
+		// The caller's context has expired, but its values still apply
+		listCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 10*time.Second)
+		defer cancel()
+		similar, err := mc.QueryCatalog(listCtx, ResourceSelector{Types: selector.Types})
+		if err == nil {
+			var names []string
+			for i, item := range similar {
+				if i == 10 {
+					names = append(names, fmt.Sprintf("... %d more", len(similar)-i))
+					break
+				}
+				names = append(names, fmt.Sprintf("%s/%s/%s", item.Type, item.Namespace, item.Name))
+			}
+			msg += fmt.Sprintf(", found %d items of type %s: [%s]", len(similar), strings.Join(selector.Types, ","), strings.Join(names, ", "))
+		}
+	}
+	return nil, fmt.Errorf("%s", msg)
+}
+
+// WaitForChange polls catalog changes until one matching req (and match, if not nil) appears
+func (mc *MissionControl) WaitForChange(ctx context.Context, req CatalogChangesSearchRequest, match func(ConfigChangeRow) bool, timeout time.Duration) (*ConfigChangeRow, error) {
+	var found *ConfigChangeRow
+	var last *CatalogChangesSearchResponse
+	var lastErr error
+	err := poll(ctx, timeout, func(ctx context.Context) bool {
+		last, lastErr = mc.SearchCatalogChanges(ctx, req)
+		if lastErr != nil {
+			return false
+		}
+		for i := range last.Changes {
+			if match == nil || match(last.Changes[i]) {
+				found = &last.Changes[i]
+				return true
+			}
+		}
+		return false
+	})
+	if err == nil {
+		return found, nil
+	}
+
+	msg := fmt.Sprintf("no change matching id=%q type=%q config_type=%q after %v", req.CatalogID, req.ChangeType, req.ConfigType, timeout)
+	if lastErr != nil {
+		return nil, fmt.Errorf("%s: %w", msg, lastErr)
+	}
+	if last != nil && len(last.Changes) > 0 {
+		var seen []string
+		for i, c := range last.Changes {
+			if i == 10 {
+				seen = append(seen, fmt.Sprintf("... %d more", len(last.Changes)-i))
+				break
+			}
+			seen = append(seen, fmt.Sprintf("%s on %s/%s", c.ChangeType, c.ConfigType, c.ConfigName))
+		}
+		msg += fmt.Sprintf(", found %d changes: [%s]", last.Total, strings.Join(seen, ", "))
+	}
+	return nil, fmt.Errorf("%s", msg)
+}
+
+func describeSelector(s ResourceSelector) string {
+	var parts []string
+	if s.ID != "" {
+		parts = append(parts, "id="+s.ID)
+	}
+	if s.Name != "" {
+		parts = append(parts, "name="+s.Name)
+	}
+	if s.Namespace != "" {
+		parts = append(parts, "namespace="+s.Namespace)
+	}
+	if len(s.Types) > 0 {
+		parts = append(parts, "types="+strings.Join(s.Types, ","))
+	}
+	if len(s.Statuses) > 0 {
+		parts = append(parts, "statuses="+strings.Join(s.Statuses, ","))
+	}
+	for k, v := range s.Labels {
+		parts = append(parts, fmt.Sprintf("labels.%s=%s", k, v))
+	}
+	if s.FieldSelector != "" {
+		parts = append(parts, "fields="+s.FieldSelector)
+	}
+	if s.TagSelector != "" {
+		parts = append(parts, "tags="+s.TagSelector)
+	}
+	if s.Health != "" {
+		parts = append(parts, "health="+s.Health)
+	}
+	if s.Search != "" {
+		parts = append(parts, "search="+s.Search)
+	}
+	return "{" + strings.Join(parts, " ") + "}"
+}
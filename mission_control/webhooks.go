@@ -0,0 +1,85 @@
+package mission_control
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"strings"
+)
+
+// WebhookSigner signs webhook payloads with an HMAC of the raw body
+type WebhookSigner struct {
+	// Header receives the signature, e.g. X-Hub-Signature-256
+	Header string
+	// Prefix is prepended to the hex digest, e.g. sha256=
+	Prefix string
+	Secret string
+	// Hash defaults to sha256.New
+	Hash func() hash.Hash
+}
+
+// GitHubWebhookSigner signs payloads the way GitHub does, in the X-Hub-Signature-256 header
+func GitHubWebhookSigner(secret string) *WebhookSigner {
+	return &WebhookSigner{Header: "X-Hub-Signature-256", Prefix: "sha256=", Secret: secret, Hash: sha256.New}
+}
+
+// GitHubLegacyWebhookSigner signs payloads with the deprecated SHA1 X-Hub-Signature header
+func GitHubLegacyWebhookSigner(secret string) *WebhookSigner {
+	return &WebhookSigner{Header: "X-Hub-Signature", Prefix: "sha1=", Secret: secret, Hash: sha1.New}
+}
+
+// Sign returns the signature header value for body
+func (s *WebhookSigner) Sign(body []byte) string {
+	fn := s.Hash
+	if fn == nil {
+		fn = sha256.New
+	}
+	mac := hmac.New(fn, []byte(s.Secret))
+	mac.Write(body)
+	return s.Prefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// TriggerWebhook posts payload to a webhook path (e.g. /playbook/webhook/my-hook), signing it when signer is not nil.
+// payload is sent as is when it is a string or []byte and JSON encoded otherwise.
+// Returns the response body, or an *APIError when the webhook is rejected.
+func (mc *MissionControl) TriggerWebhook(ctx context.Context, path string, payload any, headers map[string]string, signer *WebhookSigner) (string, error) {
+	var body []byte
+	switch v := payload.(type) {
+	case []byte:
+		body = v
+	case string:
+		body = []byte(v)
+	default:
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return "", fmt.Errorf("failed to marshal webhook payload: %w", err)
+		}
+		body = data
+	}
+
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+
+	req := mc.HTTP.R(ctx).Header("Content-Type", "application/json")
+	for k, v := range headers {
+		req = req.Header(k, v)
+	}
+	if signer != nil {
+		req = req.Header(signer.Header, signer.Sign(body))
+	}
+
+	r, err := req.Post(path, body)
+	if err != nil {
+		return "", err
+	}
+	if !r.IsOK() {
+		return "", newAPIError("trigger webhook "+path, r)
+	}
+	return r.AsString()
+}
@@ -0,0 +1,44 @@
+package mission_control_test
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"testing"
+
+	mc "github.com/flanksource/commons-test/mission_control"
+	"github.com/flanksource/commons-test/mission_control/fake"
+)
+
+func TestTriggerWebhook(t *testing.T) {
+	const secret = "webhook-secret"
+	server := fake.NewServer().Handle("POST /playbook/webhook/deploy", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write(body)
+		if !hmac.Equal([]byte(r.Header.Get("X-Hub-Signature-256")), []byte("sha256="+hex.EncodeToString(mac.Sum(nil)))) {
+			http.Error(w, "invalid signature", http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte("accepted " + string(body)))
+	})
+	defer server.Close()
+	ctx := context.Background()
+	client := server.Client()
+
+	response, err := client.TriggerWebhook(ctx, "playbook/webhook/deploy", map[string]string{"ref": "main"}, nil, mc.GitHubWebhookSigner(secret))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response != `accepted {"ref":"main"}` {
+		t.Errorf("unexpected response %q", response)
+	}
+
+	_, err = client.TriggerWebhook(ctx, "/playbook/webhook/deploy", `{"ref": "main"}`, nil, mc.GitHubWebhookSigner("wrong"))
+	if apiErr, ok := err.(*mc.APIError); !ok || apiErr.StatusCode != http.StatusUnauthorized {
+		t.Errorf("expected a 401 APIError for a wrong secret, got %v", err)
+	}
+}
@@ -0,0 +1,98 @@
+// Package names generates DNS-safe resource names that are unique per test run, so parallel and
+// repeated runs never collide and leftover containers, namespaces and releases can be traced to a run.
+package names
+
+import (
+	"crypto/rand"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+const (
+	// PrefixEnv sets the prefix of every name, e.g. a CI job or developer name
+	PrefixEnv = "TEST_NAME_PREFIX"
+	// RunIDEnv sets the run ID, e.g. so every parallel ginkgo process of a CI job shares one
+	RunIDEnv = "TEST_RUN_ID"
+
+	// RunLabel is the label (or docker label) identifying the run that created a resource
+	RunLabel = "commons-test/run-id"
+
+	// MaxLength is the longest DNS label
+	MaxLength = 63
+)
+
+const alphabet = "abcdefghijklmnopqrstuvwxyz0123456789"
+
+var (
+	mu     sync.Mutex
+	prefix = os.Getenv(PrefixEnv)
+	runID  = os.Getenv(RunIDEnv)
+
+	invalid = regexp.MustCompile(`[^a-z0-9-]+`)
+)
+
+// SetPrefix sets the prefix of generated names, overriding TEST_NAME_PREFIX
+func SetPrefix(p string) {
+	mu.Lock()
+	defer mu.Unlock()
+	prefix = p
+}
+
+// RunID returns the ID shared by every name generated in this run, from TEST_RUN_ID or random
+func RunID() string {
+	mu.Lock()
+	defer mu.Unlock()
+	if runID == "" {
+		runID = random(6)
+	}
+	return Sanitize(runID)
+}
+
+// New returns a name like <prefix>-<base>-<run id>-<suffix>, e.g. ci-activemq-k3x9qa-7fz2. The base is
+// shortened when needed so the name fits in a DNS label.
+func New(base string) string {
+	mu.Lock()
+	p := prefix
+	mu.Unlock()
+
+	suffix := RunID() + "-" + random(4)
+	head := Sanitize(base)
+	if p = Sanitize(p); p != "" {
+		head = Sanitize(p + "-" + head)
+	}
+	if room := MaxLength - len(suffix) - 1; len(head) > room {
+		head = strings.TrimRight(head[:room], "-")
+	}
+	if head == "" {
+		return suffix
+	}
+	return head + "-" + suffix
+}
+
+// TempPrefix returns the prefix of the temp files and directories called base that this run creates,
+// e.g. os.MkdirTemp("", TempPrefix("fixture")+"*"), so leak checks can tell them apart from those of
+// concurrent and earlier runs
+func TempPrefix(base string) string {
+	return base + "-" + RunID() + "-"
+}
+
+// Sanitize lowercases s and replaces characters not allowed in DNS labels with dashes
+func Sanitize(s string) string {
+	s = invalid.ReplaceAllString(strings.ToLower(s), "-")
+	s = strings.Trim(s, "-")
+	if len(s) > MaxLength {
+		s = strings.TrimRight(s[:MaxLength], "-")
+	}
+	return s
+}
+
+func random(n int) string {
+	b := make([]byte, n)
+	_, _ = rand.Read(b)
+	for i := range b {
+		b[i] = alphabet[int(b[i])%len(alphabet)]
+	}
+	return string(b)
+}
@@ -0,0 +1,31 @@
+package names
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+var dnsLabel = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]*[a-z0-9])?$`)
+
+func TestNew(t *testing.T) {
+	SetPrefix("CI Job")
+	defer SetPrefix("")
+
+	for _, base := range []string{"activemq", "My_Release.v2", "", strings.Repeat("x", 100)} {
+		name := New(base)
+		if !dnsLabel.MatchString(name) || len(name) > MaxLength {
+			t.Errorf("New(%q) = %q is not a DNS label", base, name)
+		}
+		if !strings.Contains(name, RunID()) {
+			t.Errorf("New(%q) = %q does not contain the run ID %s", base, name, RunID())
+		}
+	}
+
+	if name := New("activemq"); !strings.HasPrefix(name, "ci-job-activemq-") {
+		t.Errorf("expected the prefix and base in %q", name)
+	}
+	if New("activemq") == New("activemq") {
+		t.Error("expected unique names")
+	}
+}
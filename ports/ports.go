@@ -0,0 +1,90 @@
+// Package ports leases free host ports for port-forwards, containers and kind port mappings. A leased port
+// is never handed out again in this process until released, and with COMMONS_TEST_PORT_LOCKS it is also
+// locked host-wide, so parallel suites never race for the same port between probing and binding it.
+package ports
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+
+	"github.com/flanksource/commons-test/config"
+	"github.com/flanksource/commons-test/lock"
+)
+
+// MaxAttempts is how many candidate ports Get tries before giving up
+var MaxAttempts = 100
+
+var leases = struct {
+	sync.Mutex
+	locks map[int]*lock.Lock
+}{locks: map[int]*lock.Lock{}}
+
+// Get leases a free port, held until Release
+func Get() (int, error) {
+	leases.Lock()
+	defer leases.Unlock()
+
+	hostWide := config.Get().PortLocks
+	for range MaxAttempts {
+		port, err := free()
+		if err != nil {
+			return 0, fmt.Errorf("failed to get free port: %w", err)
+		}
+		if _, ok := leases.locks[port]; ok {
+			continue
+		}
+		var portLock *lock.Lock
+		if hostWide {
+			portLock = lock.New("port-" + strconv.Itoa(port))
+			if ok, err := portLock.TryLock(); err != nil {
+				return 0, err
+			} else if !ok {
+				continue
+			}
+		}
+		leases.locks[port] = portLock
+		return port, nil
+	}
+	return 0, fmt.Errorf("failed to get free port: every candidate was leased after %d attempts", MaxAttempts)
+}
+
+// MustGet leases a free port, panicking on failure
+func MustGet() int {
+	port, err := Get()
+	if err != nil {
+		panic(err)
+	}
+	return port
+}
+
+// Release returns leased ports to the pool
+func Release(ports ...int) {
+	leases.Lock()
+	defer leases.Unlock()
+	for _, port := range ports {
+		if portLock := leases.locks[port]; portLock != nil {
+			_ = portLock.Unlock()
+		}
+		delete(leases.locks, port)
+	}
+}
+
+// Leased returns whether port is currently leased by this process
+func Leased(port int) bool {
+	leases.Lock()
+	defer leases.Unlock()
+	_, ok := leases.locks[port]
+	return ok
+}
+
+// free asks the kernel for an unused port on all interfaces, as docker publishes on 0.0.0.0
+func free() (int, error) {
+	listener, err := net.Listen("tcp", ":0")
+	if err != nil {
+		return 0, err
+	}
+	defer listener.Close()
+	return listener.Addr().(*net.TCPAddr).Port, nil
+}
@@ -0,0 +1,36 @@
+package ports
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/flanksource/commons-test/config"
+	"github.com/flanksource/commons-test/lock"
+)
+
+func TestGet(t *testing.T) {
+	c := config.Default()
+	c.CacheDir = t.TempDir()
+	c.PortLocks = true
+	config.Set(c)
+	defer config.Set(nil)
+
+	seen := map[int]bool{}
+	for range 20 {
+		port := MustGet()
+		if seen[port] {
+			t.Fatalf("port %d was leased twice", port)
+		}
+		seen[port] = true
+	}
+
+	for port := range seen {
+		if ok, _ := lock.New("port-" + strconv.Itoa(port)).TryLock(); ok {
+			t.Errorf("expected port %d to be locked host-wide", port)
+		}
+		Release(port)
+		if Leased(port) {
+			t.Errorf("expected port %d to be released", port)
+		}
+	}
+}
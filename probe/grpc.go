@@ -0,0 +1,76 @@
+package probe
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+
+	"github.com/flanksource/commons-test/wait"
+)
+
+// GRPCProbe checks a server implementing the standard gRPC health service
+type GRPCProbe struct {
+	addr     string
+	service  string
+	timeout  time.Duration
+	interval time.Duration
+}
+
+// GRPCHealth returns a probe of the health of service at the host:port addr, an empty service being the
+// overall server health
+func GRPCHealth(addr, service string) *GRPCProbe {
+	return &GRPCProbe{addr: addr, service: service, timeout: 2 * time.Second, interval: DefaultInterval}
+}
+
+// WithTimeout sets the timeout of each attempt, 2s by default
+func (p *GRPCProbe) WithTimeout(timeout time.Duration) *GRPCProbe {
+	p.timeout = timeout
+	return p
+}
+
+// WithInterval sets the initial interval between attempts
+func (p *GRPCProbe) WithInterval(interval time.Duration) *GRPCProbe {
+	p.interval = interval
+	return p
+}
+
+// Check makes a single plaintext health check, returning the reported status and an error unless it is SERVING
+func (p *GRPCProbe) Check(ctx context.Context) (healthpb.HealthCheckResponse_ServingStatus, error) {
+	conn, err := grpc.NewClient(p.addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return healthpb.HealthCheckResponse_UNKNOWN, wait.Stop(fmt.Errorf("invalid probe of %s: %w", p.addr, err))
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+	resp, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{Service: p.service})
+	if err != nil {
+		return healthpb.HealthCheckResponse_UNKNOWN, err
+	}
+	if resp.Status != healthpb.HealthCheckResponse_SERVING {
+		return resp.Status, fmt.Errorf("%s service %q is %s", p.addr, p.service, resp.Status)
+	}
+	return resp.Status, nil
+}
+
+// WaitUntil retries Check with backoff until it succeeds or timeout expires, returning the last status
+func (p *GRPCProbe) WaitUntil(timeout time.Duration) (healthpb.HealthCheckResponse_ServingStatus, error) {
+	return p.WaitUntilCtx(context.Background(), timeout)
+}
+
+// WaitUntilCtx retries Check with backoff until it succeeds, timeout expires or ctx is done, returning the
+// last status
+func (p *GRPCProbe) WaitUntilCtx(ctx context.Context, timeout time.Duration) (healthpb.HealthCheckResponse_ServingStatus, error) {
+	status := healthpb.HealthCheckResponse_UNKNOWN
+	err := wait.For(ctx, p.interval, timeout, wait.NoError(func(ctx context.Context) error {
+		var err error
+		status, err = p.Check(ctx)
+		return err
+	}))
+	return status, err
+}
@@ -0,0 +1,155 @@
+// Package probe polls HTTP endpoints, TCP ports and gRPC health services until they are ready, replacing
+// hand-written retry loops in readiness checks and tests:
+//
+//	resp, err := probe.HTTP(url + "/healthz").ExpectStatus(200).ExpectBodyContains("ok").WaitUntil(time.Minute)
+package probe
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/flanksource/commons-test/wait"
+)
+
+// DefaultInterval is the initial interval between attempts, backing off as described in the wait package
+const DefaultInterval = 500 * time.Millisecond
+
+// MaxBody is the number of response body bytes read and matched
+const MaxBody = 1 << 20
+
+// Response is the outcome of a single attempt
+type Response struct {
+	StatusCode int
+	Header     http.Header
+	Body       string
+	Duration   time.Duration
+}
+
+// HTTPProbe is a fluent HTTP readiness check
+type HTTPProbe struct {
+	url      string
+	method   string
+	header   http.Header
+	statuses []int
+	contains []string
+	interval time.Duration
+	client   *http.Client
+}
+
+// HTTP returns a GET probe of url expecting a 2xx response
+func HTTP(url string) *HTTPProbe {
+	return &HTTPProbe{
+		url:      url,
+		method:   http.MethodGet,
+		header:   http.Header{},
+		interval: DefaultInterval,
+		client:   &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+// WithMethod sets the request method
+func (p *HTTPProbe) WithMethod(method string) *HTTPProbe {
+	p.method = method
+	return p
+}
+
+// WithHeader adds a request header
+func (p *HTTPProbe) WithHeader(key, value string) *HTTPProbe {
+	p.header.Add(key, value)
+	return p
+}
+
+// WithBasicAuth sets basic auth credentials
+func (p *HTTPProbe) WithBasicAuth(username, password string) *HTTPProbe {
+	req := http.Request{Header: http.Header{}}
+	req.SetBasicAuth(username, password)
+	p.header.Set("Authorization", req.Header.Get("Authorization"))
+	return p
+}
+
+// WithTimeout sets the timeout of each attempt, 5s by default
+func (p *HTTPProbe) WithTimeout(timeout time.Duration) *HTTPProbe {
+	p.client.Timeout = timeout
+	return p
+}
+
+// WithInterval sets the initial interval between attempts
+func (p *HTTPProbe) WithInterval(interval time.Duration) *HTTPProbe {
+	p.interval = interval
+	return p
+}
+
+// ExpectStatus accepts any of codes instead of any 2xx status
+func (p *HTTPProbe) ExpectStatus(codes ...int) *HTTPProbe {
+	p.statuses = append(p.statuses, codes...)
+	return p
+}
+
+// ExpectBodyContains requires the response body to contain s
+func (p *HTTPProbe) ExpectBodyContains(s string) *HTTPProbe {
+	p.contains = append(p.contains, s)
+	return p
+}
+
+// Check makes a single attempt, returning the response (nil when the request failed) and an error
+// describing the mismatch
+func (p *HTTPProbe) Check(ctx context.Context) (*Response, error) {
+	req, err := http.NewRequestWithContext(ctx, p.method, p.url, nil)
+	if err != nil {
+		return nil, wait.Stop(fmt.Errorf("invalid probe of %s: %w", p.url, err))
+	}
+	req.Header = p.header.Clone()
+
+	start := time.Now()
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, MaxBody))
+	response := &Response{StatusCode: resp.StatusCode, Header: resp.Header, Body: string(body), Duration: time.Since(start)}
+	if err != nil {
+		return response, fmt.Errorf("failed to read %s: %w", p.url, err)
+	}
+
+	if !p.statusOK(resp.StatusCode) {
+		return response, fmt.Errorf("%s %s returned %d", p.method, p.url, resp.StatusCode)
+	}
+	for _, s := range p.contains {
+		if !strings.Contains(response.Body, s) {
+			return response, fmt.Errorf("%s %s returned a body without %q", p.method, p.url, s)
+		}
+	}
+	return response, nil
+}
+
+func (p *HTTPProbe) statusOK(code int) bool {
+	if len(p.statuses) == 0 {
+		return code >= 200 && code < 300
+	}
+	return slices.Contains(p.statuses, code)
+}
+
+// WaitUntil retries Check with backoff until it succeeds or timeout expires, returning the last response
+func (p *HTTPProbe) WaitUntil(timeout time.Duration) (*Response, error) {
+	return p.WaitUntilCtx(context.Background(), timeout)
+}
+
+// WaitUntilCtx retries Check with backoff until it succeeds, timeout expires or ctx is done, returning the
+// last response
+func (p *HTTPProbe) WaitUntilCtx(ctx context.Context, timeout time.Duration) (*Response, error) {
+	var last *Response
+	err := wait.For(ctx, p.interval, timeout, wait.NoError(func(ctx context.Context) error {
+		resp, err := p.Check(ctx)
+		if resp != nil {
+			last = resp
+		}
+		return err
+	}))
+	return last, err
+}
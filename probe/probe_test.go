@@ -0,0 +1,56 @@
+package probe
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWaitUntil(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 || r.Header.Get("X-Test") != "yes" {
+			http.Error(w, "starting", http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("status: ok"))
+	}))
+	defer server.Close()
+
+	resp, err := HTTP(server.URL).WithHeader("X-Test", "yes").WithInterval(time.Millisecond).
+		ExpectStatus(http.StatusOK).ExpectBodyContains("ok").WaitUntil(5 * time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK || calls.Load() != 3 {
+		t.Errorf("expected success on the third attempt, got %d after %d", resp.StatusCode, calls.Load())
+	}
+
+	resp, err = HTTP(server.URL).WithInterval(time.Millisecond).ExpectBodyContains("ready").WaitUntil(50 * time.Millisecond)
+	if err == nil {
+		t.Fatal("expected a timeout")
+	}
+	if resp == nil || resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("expected the last response on failure, got %+v", resp)
+	}
+}
+
+func TestTCP(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := listener.Addr().String()
+
+	if err := TCP(addr).WithInterval(time.Millisecond).WaitUntil(time.Second); err != nil {
+		t.Fatal(err)
+	}
+
+	listener.Close()
+	if err := TCP(addr).WithInterval(time.Millisecond).WaitUntil(50 * time.Millisecond); err == nil {
+		t.Error("expected a closed port to time out")
+	}
+}
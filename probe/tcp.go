@@ -0,0 +1,53 @@
+package probe
+
+import (
+	"context"
+	"net"
+	"time"
+
+	"github.com/flanksource/commons-test/wait"
+)
+
+// TCPProbe checks that a port accepts connections, e.g. a broker or database
+type TCPProbe struct {
+	addr     string
+	timeout  time.Duration
+	interval time.Duration
+}
+
+// TCP returns a probe of the host:port addr
+func TCP(addr string) *TCPProbe {
+	return &TCPProbe{addr: addr, timeout: 2 * time.Second, interval: DefaultInterval}
+}
+
+// WithTimeout sets the dial timeout of each attempt, 2s by default
+func (p *TCPProbe) WithTimeout(timeout time.Duration) *TCPProbe {
+	p.timeout = timeout
+	return p
+}
+
+// WithInterval sets the initial interval between attempts
+func (p *TCPProbe) WithInterval(interval time.Duration) *TCPProbe {
+	p.interval = interval
+	return p
+}
+
+// Check makes a single connection attempt
+func (p *TCPProbe) Check(ctx context.Context) error {
+	dialer := net.Dialer{Timeout: p.timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", p.addr)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+// WaitUntil retries Check with backoff until it succeeds or timeout expires
+func (p *TCPProbe) WaitUntil(timeout time.Duration) error {
+	return p.WaitUntilCtx(context.Background(), timeout)
+}
+
+// WaitUntilCtx retries Check with backoff until it succeeds, timeout expires or ctx is done
+func (p *TCPProbe) WaitUntilCtx(ctx context.Context, timeout time.Duration) error {
+	return wait.For(ctx, p.interval, timeout, wait.NoError(p.Check))
+}
@@ -0,0 +1,177 @@
+// Package report records the infrastructure steps of a test run (cluster creation, image loads,
+// chart installs, container starts) and writes them as JSON or JUnit XML, so CI can track setup time
+// separately from test time.
+package report
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// FileEnv is the environment variable naming the file suite.ReportTimings writes the steps to,
+// as JUnit XML when it ends in .xml and JSON otherwise
+const FileEnv = "SETUP_REPORT"
+
+// Step categories recorded by this module
+const (
+	Cluster   = "cluster"
+	Image     = "image"
+	Chart     = "chart"
+	Container = "container"
+	Namespace = "namespace"
+)
+
+// Step is one recorded infrastructure step
+type Step struct {
+	Category string        `json:"category"`
+	Name     string        `json:"name"`
+	Start    time.Time     `json:"start"`
+	Duration time.Duration `json:"duration"`
+	Error    string        `json:"error,omitempty"`
+}
+
+// Failed returns true when the step returned an error
+func (s Step) Failed() bool {
+	return s.Error != ""
+}
+
+var steps struct {
+	sync.Mutex
+	list []Step
+}
+
+// Track runs fn and records it as a step, returning its error
+func Track(category, name string, fn func() error) error {
+	start := time.Now()
+	err := fn()
+	step := Step{Category: category, Name: name, Start: start, Duration: time.Since(start)}
+	if err != nil {
+		step.Error = err.Error()
+	}
+	steps.Lock()
+	steps.list = append(steps.list, step)
+	steps.Unlock()
+	return err
+}
+
+// Steps returns the recorded steps in the order they started
+func Steps() []Step {
+	steps.Lock()
+	defer steps.Unlock()
+	return append([]Step(nil), steps.list...)
+}
+
+// Reset clears the recorded steps
+func Reset() {
+	steps.Lock()
+	defer steps.Unlock()
+	steps.list = nil
+}
+
+// String returns a table of the recorded steps
+func String() string {
+	var b strings.Builder
+	var total time.Duration
+	w := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "DURATION\tCATEGORY\tSTEP\tERROR")
+	for _, step := range Steps() {
+		total += step.Duration
+		fmt.Fprintf(w, "%v\t%s\t%s\t%s\n", step.Duration.Round(time.Millisecond), step.Category, step.Name, step.Error)
+	}
+	w.Flush()
+	return fmt.Sprintf("Setup took %v\n%s", total.Round(time.Second), b.String())
+}
+
+// WriteJSON writes the recorded steps as a JSON array
+func WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(Steps())
+}
+
+type junitSuites struct {
+	XMLName xml.Name     `xml:"testsuites"`
+	Suites  []junitSuite `xml:"testsuite"`
+}
+
+type junitSuite struct {
+	Name     string      `xml:"name,attr"`
+	Tests    int         `xml:"tests,attr"`
+	Failures int         `xml:"failures,attr"`
+	Time     string      `xml:"time,attr"`
+	Cases    []junitCase `xml:"testcase"`
+}
+
+type junitCase struct {
+	ClassName string        `xml:"classname,attr"`
+	Name      string        `xml:"name,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// WriteJUnit writes the recorded steps as a JUnit test suite named suite, one test case per step
+func WriteJUnit(w io.Writer, suite string) error {
+	s := junitSuite{Name: suite}
+	var total time.Duration
+	for _, step := range Steps() {
+		c := junitCase{ClassName: step.Category, Name: step.Name, Time: seconds(step.Duration)}
+		if step.Failed() {
+			s.Failures++
+			c.Failure = &junitFailure{Message: step.Error, Text: step.Error}
+		}
+		total += step.Duration
+		s.Cases = append(s.Cases, c)
+	}
+	s.Tests = len(s.Cases)
+	s.Time = seconds(total)
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(junitSuites{Suites: []junitSuite{s}}); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// WriteFile writes the recorded steps to path, as JUnit XML when it ends in .xml and JSON otherwise
+func WriteFile(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create report directory: %w", err)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create setup report: %w", err)
+	}
+	defer f.Close()
+
+	if strings.EqualFold(filepath.Ext(path), ".xml") {
+		err = WriteJUnit(f, "setup")
+	} else {
+		err = WriteJSON(f)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write setup report: %w", err)
+	}
+	return f.Close()
+}
+
+func seconds(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
@@ -0,0 +1,37 @@
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestReport(t *testing.T) {
+	Reset()
+	_ = Track(Cluster, "e2e", func() error { return nil })
+	_ = Track(Chart, "default/podinfo", func() error { return errors.New("timed out waiting for the condition") })
+
+	var buf bytes.Buffer
+	if err := WriteJSON(&buf); err != nil {
+		t.Fatal(err)
+	}
+	var steps []Step
+	if err := json.Unmarshal(buf.Bytes(), &steps); err != nil {
+		t.Fatal(err)
+	}
+	if len(steps) != 2 || steps[0].Failed() || !steps[1].Failed() {
+		t.Errorf("unexpected steps: %+v", steps)
+	}
+
+	buf.Reset()
+	if err := WriteJUnit(&buf, "setup"); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{`tests="2"`, `failures="1"`, `classname="chart" name="default/podinfo"`, `message="timed out waiting for the condition"`} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("expected %s in\n%s", want, buf.String())
+		}
+	}
+}
@@ -0,0 +1,161 @@
+// Package retry retries flaky setup steps and counts how often each one needed more than one attempt, so
+// the flakiest infrastructure operations can be quantified and then fixed:
+//
+//	err := retry.Flaky("load image", 3, func() error { return cluster.Load(image) })
+//
+// suite.ReportFlakes tags the ginkgo report with the attempts and prints the statistics after the suite.
+package retry
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/flanksource/commons/logger"
+
+	"github.com/flanksource/commons-test/wait"
+)
+
+// FileEnv is the environment variable naming the JSON file suite.ReportFlakes writes the statistics to
+const FileEnv = "FLAKE_REPORT"
+
+// Backoff is the delay after the first failed attempt, doubled after each further one
+var Backoff = time.Second
+
+// OnComplete, when set, is called after every Flaky step with the attempts it took and its final error,
+// e.g. by suite.ReportFlakes to add a ginkgo report entry
+var OnComplete func(name string, attempts int, err error)
+
+// Stat aggregates the runs of the steps called name
+type Stat struct {
+	Name string `json:"name"`
+	// Runs is the number of calls to Flaky
+	Runs int `json:"runs"`
+	// Attempts is the number of calls to fn across every run
+	Attempts int `json:"attempts"`
+	// Flakes is the number of runs that failed at least once and then succeeded
+	Flakes int `json:"flakes"`
+	// Failures is the number of runs that failed every attempt
+	Failures int `json:"failures"`
+}
+
+var stats struct {
+	sync.Mutex
+	byName map[string]*Stat
+}
+
+// Flaky runs fn up to attempts times until it succeeds, backing off between attempts, and records the
+// outcome. Errors marked with wait.Stop are returned without retrying.
+func Flaky(name string, attempts int, fn func() error) error {
+	attempts = max(attempts, 1)
+	delay := Backoff
+	attempt := 1
+	var err error
+	for ; ; attempt++ {
+		if err = fn(); err == nil || attempt == attempts || wait.IsStop(err) {
+			break
+		}
+		logger.Warnf("%s failed (attempt %d/%d), retrying in %v: %v", name, attempt, attempts, delay, err)
+		time.Sleep(delay)
+		delay *= 2
+	}
+
+	record(name, attempt, err)
+	if OnComplete != nil {
+		OnComplete(name, attempt, err)
+	}
+	if err != nil {
+		return fmt.Errorf("%s failed after %d attempts: %w", name, attempt, err)
+	}
+	if attempt > 1 {
+		logger.Warnf("%s succeeded after %d attempts", name, attempt)
+	}
+	return nil
+}
+
+func record(name string, attempts int, err error) {
+	stats.Lock()
+	defer stats.Unlock()
+	if stats.byName == nil {
+		stats.byName = map[string]*Stat{}
+	}
+	stat := stats.byName[name]
+	if stat == nil {
+		stat = &Stat{Name: name}
+		stats.byName[name] = stat
+	}
+	stat.Runs++
+	stat.Attempts += attempts
+	switch {
+	case err != nil:
+		stat.Failures++
+	case attempts > 1:
+		stat.Flakes++
+	}
+}
+
+// Stats returns the statistics of every step, flakiest first
+func Stats() []Stat {
+	stats.Lock()
+	defer stats.Unlock()
+	var list []Stat
+	for _, stat := range stats.byName {
+		list = append(list, *stat)
+	}
+	slices.SortFunc(list, func(a, b Stat) int {
+		if a.Flakes+a.Failures != b.Flakes+b.Failures {
+			return (b.Flakes + b.Failures) - (a.Flakes + a.Failures)
+		}
+		return strings.Compare(a.Name, b.Name)
+	})
+	return list
+}
+
+// Reset clears the statistics
+func Reset() {
+	stats.Lock()
+	defer stats.Unlock()
+	stats.byName = nil
+}
+
+// String returns a table of the statistics
+func String() string {
+	var b strings.Builder
+	w := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "STEP\tRUNS\tATTEMPTS\tFLAKES\tFAILURES")
+	for _, stat := range Stats() {
+		fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%d\n", stat.Name, stat.Runs, stat.Attempts, stat.Flakes, stat.Failures)
+	}
+	w.Flush()
+	return b.String()
+}
+
+// WriteJSON writes the statistics as a JSON array
+func WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(Stats())
+}
+
+// WriteFile writes the statistics to path as JSON
+func WriteFile(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create flake report directory: %w", err)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create flake report: %w", err)
+	}
+	defer f.Close()
+	if err := WriteJSON(f); err != nil {
+		return fmt.Errorf("failed to write flake report: %w", err)
+	}
+	return f.Close()
+}
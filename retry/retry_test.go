@@ -0,0 +1,44 @@
+package retry
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/flanksource/commons-test/wait"
+)
+
+func TestFlaky(t *testing.T) {
+	Backoff = 0
+	Reset()
+	defer Reset()
+
+	calls := 0
+	if err := Flaky("install", 3, func() error {
+		if calls++; calls < 2 {
+			return errors.New("connection refused")
+		}
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := Flaky("install", 3, func() error { return nil }); err != nil {
+		t.Fatal(err)
+	}
+
+	calls = 0
+	if err := Flaky("pull", 3, func() error {
+		calls++
+		return wait.Stop(errors.New("manifest unknown"))
+	}); err == nil || calls != 1 {
+		t.Errorf("expected a permanent error without retries, got %v after %d calls", err, calls)
+	}
+
+	stats := Stats()
+	expected := []Stat{
+		{Name: "install", Runs: 2, Attempts: 3, Flakes: 1},
+		{Name: "pull", Runs: 1, Attempts: 1, Failures: 1},
+	}
+	if len(stats) != len(expected) || stats[0] != expected[0] || stats[1] != expected[1] {
+		t.Errorf("expected %+v, got %+v", expected, stats)
+	}
+}
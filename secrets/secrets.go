@@ -0,0 +1,111 @@
+// Package secrets resolves test credentials like "sqlserver.password" from environment variables,
+// sops-encrypted files or a Vault server. Every resolved value is masked in command lines, command output
+// and JSON logs.
+package secrets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/flanksource/commons-test/logging"
+)
+
+// ErrNotFound is returned when no provider has the key
+var ErrNotFound = errors.New("secret not found")
+
+// Provider looks up secrets by dotted key, e.g. sqlserver.password
+type Provider interface {
+	// Lookup returns the value of key, ok being false when the provider does not have it
+	Lookup(ctx context.Context, key string) (value string, ok bool, err error)
+}
+
+var providers struct {
+	sync.Mutex
+	list []Provider
+}
+
+func init() {
+	providers.list = []Provider{Env()}
+}
+
+// Use replaces the providers consulted by Get, in order
+func Use(list ...Provider) {
+	providers.Lock()
+	defer providers.Unlock()
+	providers.list = list
+}
+
+// Register adds p after the current providers, which are Env() by default
+func Register(p Provider) {
+	providers.Lock()
+	defer providers.Unlock()
+	providers.list = append(providers.list, p)
+}
+
+// Get returns the value of key from the first provider that has it
+func Get(key string) (string, error) {
+	return GetContext(context.Background(), key)
+}
+
+// GetOr returns the value of key, or def when no provider has it or the lookup fails
+func GetOr(key, def string) string {
+	value, err := Get(key)
+	if err != nil {
+		return def
+	}
+	return value
+}
+
+// GetContext returns the value of key from the first provider that has it
+func GetContext(ctx context.Context, key string) (string, error) {
+	providers.Lock()
+	list := append([]Provider(nil), providers.list...)
+	providers.Unlock()
+
+	for _, p := range list {
+		value, ok, err := p.Lookup(ctx, key)
+		if err != nil {
+			return "", fmt.Errorf("failed to look up %s: %w", key, err)
+		}
+		if ok {
+			logging.Mask(value)
+			return value, nil
+		}
+	}
+	return "", fmt.Errorf("%w: %s", ErrNotFound, key)
+}
+
+// EnvPrefix prefixes the environment variables read by Env, e.g. TEST_SECRET_SQLSERVER_PASSWORD for
+// sqlserver.password
+const EnvPrefix = "TEST_SECRET_"
+
+type envProvider struct{}
+
+// Env returns a provider reading EnvPrefix followed by the upper-cased key with dots and dashes as
+// underscores
+func Env() Provider {
+	return envProvider{}
+}
+
+func (envProvider) Lookup(_ context.Context, key string) (string, bool, error) {
+	value, ok := os.LookupEnv(EnvName(key))
+	return value, ok, nil
+}
+
+// EnvName returns the environment variable Env reads key from
+func EnvName(key string) string {
+	return EnvPrefix + strings.NewReplacer(".", "_", "-", "_").Replace(strings.ToUpper(key))
+}
+
+// split returns the path and field of a dotted key, e.g. db/sqlserver and password for db.sqlserver.password
+func split(key string) (string, string) {
+	i := strings.LastIndex(key, ".")
+	if i < 0 {
+		return "", key
+	}
+	return strings.ReplaceAll(key[:i], ".", "/"), key[i+1:]
+}
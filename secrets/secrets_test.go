@@ -0,0 +1,41 @@
+package secrets
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/flanksource/commons-test/logging"
+)
+
+func TestGet(t *testing.T) {
+	vault := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "root" || r.URL.Path != "/v1/secret/data/db/sqlserver" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`{"data": {"data": {"password": "vault-passw0rd"}}}`))
+	}))
+	defer vault.Close()
+
+	t.Setenv(EnvName("postgres.password"), "env-passw0rd")
+	Use(Env(), Vault(vault.URL, "root", ""))
+	defer Use(Env())
+
+	for key, want := range map[string]string{
+		"postgres.password":     "env-passw0rd",
+		"db.sqlserver.password": "vault-passw0rd",
+	} {
+		if got, err := Get(key); err != nil || got != want {
+			t.Errorf("Get(%q) = %q, %v, want %q", key, got, err, want)
+		}
+	}
+
+	if _, err := Get("db.sqlserver.username"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+	if got := logging.Redact("-P vault-passw0rd"); got != "-P ***" {
+		t.Errorf("expected resolved secrets to be masked, got %q", got)
+	}
+}
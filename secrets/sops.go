@@ -0,0 +1,64 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/flanksource/commons-test/command"
+)
+
+type sopsProvider struct {
+	path string
+
+	once   sync.Once
+	values map[string]string
+	err    error
+}
+
+// Sops returns a provider decrypting path with the sops binary on first use. Nested keys are joined with
+// dots, e.g. sqlserver.password for {sqlserver: {password: ...}}.
+func Sops(path string) Provider {
+	return &sopsProvider{path: path}
+}
+
+func (s *sopsProvider) Lookup(ctx context.Context, key string) (string, bool, error) {
+	s.once.Do(func() { s.values, s.err = s.decrypt(ctx) })
+	if s.err != nil {
+		return "", false, s.err
+	}
+	value, ok := s.values[key]
+	return value, ok, nil
+}
+
+func (s *sopsProvider) decrypt(ctx context.Context) (map[string]string, error) {
+	result := command.NewCommandRunner(false).RunCommandQuietCtx(ctx, "sops", "--decrypt", "--output-type", "json", s.path)
+	if result.Err != nil {
+		return nil, fmt.Errorf("failed to decrypt %s: %w %s", s.path, result.Err, result.Stderr)
+	}
+	var data map[string]any
+	if err := json.Unmarshal([]byte(result.Stdout), &data); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", s.path, err)
+	}
+	values := map[string]string{}
+	flatten("", data, values)
+	return values, nil
+}
+
+func flatten(prefix string, data map[string]any, values map[string]string) {
+	for k, v := range data {
+		key := k
+		if prefix != "" {
+			key = prefix + "." + k
+		}
+		switch v := v.(type) {
+		case map[string]any:
+			flatten(key, v, values)
+		case string:
+			values[key] = v
+		default:
+			values[key] = fmt.Sprint(v)
+		}
+	}
+}
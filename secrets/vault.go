@@ -0,0 +1,68 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// DefaultVaultMount is the KV v2 engine enabled by Vault dev servers
+const DefaultVaultMount = "secret"
+
+type vaultProvider struct {
+	address string
+	token   string
+	mount   string
+}
+
+// Vault returns a provider reading a KV v2 engine mounted at mount (DefaultVaultMount when empty), the key
+// db.sqlserver.password being the password field of the db/sqlserver secret, e.g. from a
+// container.VaultContainer
+func Vault(address, token, mount string) Provider {
+	if mount == "" {
+		mount = DefaultVaultMount
+	}
+	return &vaultProvider{address: strings.TrimSuffix(address, "/"), token: token, mount: mount}
+}
+
+func (v *vaultProvider) Lookup(ctx context.Context, key string) (string, bool, error) {
+	path, field := split(key)
+	if path == "" {
+		return "", false, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/v1/%s/data/%s", v.address, v.mount, path), nil)
+	if err != nil {
+		return "", false, err
+	}
+	req.Header.Set("X-Vault-Token", v.token)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", false, fmt.Errorf("failed to read %s from vault: %w", path, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return "", false, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", false, fmt.Errorf("failed to read %s from vault: status %d", path, resp.StatusCode)
+	}
+
+	var body struct {
+		Data struct {
+			Data map[string]any `json:"data"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", false, fmt.Errorf("failed to decode %s from vault: %w", path, err)
+	}
+	value, ok := body.Data.Data[field]
+	if !ok {
+		return "", false, nil
+	}
+	return fmt.Sprint(value), true, nil
+}
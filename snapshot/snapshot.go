@@ -0,0 +1,214 @@
+// Package snapshot compares rendered manifests, e.g. HelmChart.Template() output, against golden files.
+// Manifests are normalized first: documents are sorted by kind, namespace and name, keys are sorted and
+// volatile fields are stripped. Run the tests with UPDATE_SNAPSHOTS=true, or with -update when the suite
+// defines that flag, to rewrite the golden files.
+package snapshot
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/hexops/gotextdiff"
+	"github.com/hexops/gotextdiff/myers"
+	"sigs.k8s.io/yaml"
+)
+
+// UpdateEnv rewrites golden files when set to true
+const UpdateEnv = "UPDATE_SNAPSHOTS"
+
+// Dir is where golden files are read from and written to, relative to the package under test
+var Dir = filepath.Join("testdata", "snapshots")
+
+// Volatile are the fields stripped from every manifest, as paths of keys
+var Volatile = [][]string{
+	{"metadata", "creationTimestamp"},
+	{"metadata", "resourceVersion"},
+	{"metadata", "uid"},
+	{"metadata", "generation"},
+	{"metadata", "managedFields"},
+	{"metadata", "labels", "helm.sh/chart"},
+	{"spec", "template", "metadata", "labels", "helm.sh/chart"},
+	{"status"},
+}
+
+// checksumAnnotation matches annotations like checksum/config that change with any rendered value
+var checksumAnnotation = regexp.MustCompile(`^checksum/`)
+
+// updating reports whether golden files are rewritten, from UpdateEnv or an -update flag defined by the
+// suite. The flag is not defined here, as a suite defining its own would panic with "flag redefined".
+func updating() bool {
+	if os.Getenv(UpdateEnv) == "true" {
+		return true
+	}
+	f := flag.Lookup("update")
+	return f != nil && f.Value.String() == "true"
+}
+
+// Templater is implemented by helm.HelmChart
+type Templater interface {
+	Template() (string, error)
+}
+
+// TB is the subset of testing.TB used by Match, also implemented by ginkgo.GinkgoT()
+type TB interface {
+	Helper()
+	Errorf(format string, args ...any)
+	Fatalf(format string, args ...any)
+	Logf(format string, args ...any)
+}
+
+// Match fails t with a diff when the normalized manifests differ from the golden file Dir/name.yaml
+func Match(t TB, name, manifests string, ignore ...[]string) {
+	t.Helper()
+	diff, err := Compare(name, manifests, ignore...)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	if diff != "" {
+		t.Errorf("snapshot %s does not match, rerun with "+UpdateEnv+"=true to accept the change:\n%s", name, diff)
+	}
+}
+
+// MatchChart renders chart with Template and matches the manifests against the golden file Dir/name.yaml
+func MatchChart(t TB, name string, chart Templater, ignore ...[]string) {
+	t.Helper()
+	manifests, err := chart.Template()
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	Match(t, name, manifests, ignore...)
+}
+
+// Compare returns a unified diff between the golden file Dir/name.yaml and the normalized manifests,
+// empty when they match. When updating, the golden file is rewritten instead.
+func Compare(name, manifests string, ignore ...[]string) (string, error) {
+	actual, err := Normalize(manifests, ignore...)
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(Dir, name+".yaml")
+	if updating() {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return "", fmt.Errorf("failed to create snapshot directory: %w", err)
+		}
+		if err := os.WriteFile(path, []byte(actual), 0644); err != nil {
+			return "", fmt.Errorf("failed to update snapshot %s: %w", path, err)
+		}
+		return "", nil
+	}
+
+	expected, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", fmt.Errorf("snapshot %s does not exist, run with %s=true to create it", path, UpdateEnv)
+	} else if err != nil {
+		return "", fmt.Errorf("failed to read snapshot %s: %w", path, err)
+	}
+	edits := myers.ComputeEdits("", string(expected), actual)
+	if len(edits) == 0 {
+		return "", nil
+	}
+	return fmt.Sprint(gotextdiff.ToUnified(path, "actual", string(expected), edits)), nil
+}
+
+// Normalize parses multi-document YAML, strips Volatile, checksum annotations and the ignore paths,
+// and returns the documents sorted by kind, namespace and name with sorted keys
+func Normalize(manifests string, ignore ...[]string) (string, error) {
+	type document struct {
+		key  string
+		yaml []byte
+	}
+	var documents []document
+	for i, raw := range splitDocuments(manifests) {
+		var object map[string]any
+		if err := yaml.Unmarshal([]byte(raw), &object); err != nil {
+			return "", fmt.Errorf("failed to parse document %d: %w", i+1, err)
+		}
+		if len(object) == 0 {
+			continue
+		}
+		for _, path := range slices.Concat(Volatile, ignore) {
+			remove(object, path)
+		}
+		stripChecksums(object)
+
+		data, err := yaml.Marshal(object)
+		if err != nil {
+			return "", fmt.Errorf("failed to marshal document %d: %w", i+1, err)
+		}
+		metadata, _ := object["metadata"].(map[string]any)
+		documents = append(documents, document{
+			key:  fmt.Sprintf("%v/%v/%v", object["kind"], metadata["namespace"], metadata["name"]),
+			yaml: data,
+		})
+	}
+	sort.SliceStable(documents, func(i, j int) bool {
+		return documents[i].key < documents[j].key
+	})
+
+	var buf bytes.Buffer
+	for i, doc := range documents {
+		if i > 0 {
+			buf.WriteString("---\n")
+		}
+		buf.Write(doc.yaml)
+	}
+	return buf.String(), nil
+}
+
+var separator = regexp.MustCompile(`(?m)^---.*$`)
+
+func splitDocuments(manifests string) []string {
+	var documents []string
+	for _, doc := range separator.Split(manifests, -1) {
+		if strings.TrimSpace(doc) != "" {
+			documents = append(documents, doc)
+		}
+	}
+	return documents
+}
+
+func remove(object map[string]any, path []string) {
+	for i, key := range path {
+		if i == len(path)-1 {
+			delete(object, key)
+			return
+		}
+		next, ok := object[key].(map[string]any)
+		if !ok {
+			return
+		}
+		object = next
+	}
+}
+
+// stripChecksums removes checksum/* keys from every annotations map
+func stripChecksums(value any) {
+	switch v := value.(type) {
+	case map[string]any:
+		if annotations, ok := v["annotations"].(map[string]any); ok {
+			for key := range annotations {
+				if checksumAnnotation.MatchString(key) {
+					delete(annotations, key)
+				}
+			}
+			if len(annotations) == 0 {
+				delete(v, "annotations")
+			}
+		}
+		for _, child := range v {
+			stripChecksums(child)
+		}
+	case []any:
+		for _, child := range v {
+			stripChecksums(child)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package snapshot
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const rendered = `---
+# Source: app/templates/service.yaml
+apiVersion: v1
+kind: Service
+metadata:
+  name: app
+  labels:
+    helm.sh/chart: app-1.2.3
+spec:
+  ports:
+    - port: 80
+---
+# Source: app/templates/deployment.yaml
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+spec:
+  template:
+    metadata:
+      annotations:
+        checksum/config: 3f2a
+      labels:
+        app: app
+`
+
+func TestNormalize(t *testing.T) {
+	normalized, err := Normalize(rendered, []string{"spec", "ports"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	deployment, service := strings.Index(normalized, "kind: Deployment"), strings.Index(normalized, "kind: Service")
+	if deployment < 0 || service < deployment {
+		t.Errorf("expected the deployment before the service:\n%s", normalized)
+	}
+	for _, volatile := range []string{"helm.sh/chart", "checksum/config", "ports"} {
+		if strings.Contains(normalized, volatile) {
+			t.Errorf("expected %s to be stripped:\n%s", volatile, normalized)
+		}
+	}
+}
+
+func TestCompare(t *testing.T) {
+	defer func(dir string) { Dir = dir }(Dir)
+	Dir = t.TempDir()
+	if _, err := Compare("app", rendered); err == nil {
+		t.Fatal("expected a missing snapshot to fail")
+	}
+
+	t.Setenv(UpdateEnv, "true")
+	if _, err := Compare("app", rendered); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(Dir, "app.yaml")); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv(UpdateEnv, "false")
+	diff, err := Compare("app", strings.ReplaceAll(rendered, "port: 80", "port: 8080"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(diff, "-  - port: 80\n") || !strings.Contains(diff, "+  - port: 8080\n") {
+		t.Errorf("expected a diff of the port, got:\n%s", diff)
+	}
+}
+
+// Suites may define their own -update flag, which snapshot honours without defining it
+var update = flag.Bool("update", false, "update snapshot golden files")
+
+func TestUpdateFlag(t *testing.T) {
+	t.Setenv(UpdateEnv, "")
+	if updating() {
+		t.Fatal("expected golden files not to be updated by default")
+	}
+	if err := flag.Set("update", "true"); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { *update = false }()
+	if !updating() {
+		t.Error("expected the suite's -update flag to update golden files")
+	}
+}
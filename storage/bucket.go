@@ -0,0 +1,110 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"maps"
+	"mime"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/flanksource/commons-test/cleanup"
+	"github.com/flanksource/commons-test/names"
+)
+
+// Bucket is a bucket created for a test
+type Bucket struct {
+	Name   string
+	Client *Client
+
+	unregister func()
+}
+
+// NewBucket creates a bucket, named after the run when name is empty, and registers its removal with
+// cleanup
+func NewBucket(ctx context.Context, endpoint Endpoint, name string) (*Bucket, error) {
+	if name == "" {
+		name = names.New("bucket")
+	}
+	b := &Bucket{Name: name, Client: NewClient(endpoint)}
+	if err := b.Client.CreateBucket(ctx, name); err != nil {
+		return nil, err
+	}
+	b.unregister = cleanup.Register("bucket "+name, b.delete)
+	return b, nil
+}
+
+// Seed uploads every file under root of fsys, e.g. an embed.FS, keyed by its path relative to root
+func (b *Bucket) Seed(ctx context.Context, fsys fs.FS, root string) error {
+	root = path.Clean(root)
+	return fs.WalkDir(fsys, root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		data, err := fs.ReadFile(fsys, p)
+		if err != nil {
+			return err
+		}
+		key := p
+		if root != "." {
+			key = strings.TrimPrefix(p, root+"/")
+		}
+		return b.Client.PutObject(ctx, b.Name, key, data, mime.TypeByExtension(path.Ext(p)))
+	})
+}
+
+// Keys returns the keys of the objects in the bucket starting with prefix
+func (b *Bucket) Keys(ctx context.Context, prefix string) ([]string, error) {
+	return b.Client.ListObjects(ctx, b.Name, prefix)
+}
+
+// Env returns the variables configuring the AWS SDKs and CLI of the workload under test to use the bucket,
+// with S3_BUCKET naming it
+func (b *Bucket) Env() map[string]string {
+	endpoint := b.Client.Endpoint()
+	return map[string]string{
+		"AWS_ACCESS_KEY_ID":     endpoint.AccessKey,
+		"AWS_SECRET_ACCESS_KEY": endpoint.SecretKey,
+		"AWS_REGION":            endpoint.region(),
+		"AWS_DEFAULT_REGION":    endpoint.region(),
+		"AWS_ENDPOINT_URL":      endpoint.internalURL(),
+		"AWS_ENDPOINT_URL_S3":   endpoint.internalURL(),
+		"S3_BUCKET":             b.Name,
+	}
+}
+
+// EnvList returns Env as KEY=value pairs, for container.Config.Env
+func (b *Bucket) EnvList() []string {
+	env := b.Env()
+	var list []string
+	for _, key := range slices.Sorted(maps.Keys(env)) {
+		list = append(list, key+"="+env[key])
+	}
+	return list
+}
+
+// Delete removes every object and then the bucket
+func (b *Bucket) Delete(ctx context.Context) error {
+	if b.unregister != nil {
+		b.unregister()
+		b.unregister = nil
+	}
+	return b.delete(ctx)
+}
+
+func (b *Bucket) delete(ctx context.Context) error {
+	keys, err := b.Client.ListObjects(ctx, b.Name, "")
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, key := range keys {
+		errs = append(errs, b.Client.DeleteObject(ctx, b.Name, key))
+	}
+	if err := errors.Join(errs...); err != nil {
+		return err
+	}
+	return b.Client.DeleteBucket(ctx, b.Name)
+}
@@ -0,0 +1,273 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Client is a minimal S3 client using path-style requests signed with SigV4, enough to manage the buckets
+// and objects of a test
+type Client struct {
+	endpoint Endpoint
+	http     *http.Client
+	now      func() time.Time
+}
+
+// NewClient returns a client for endpoint
+func NewClient(endpoint Endpoint) *Client {
+	return &Client{
+		endpoint: endpoint,
+		http:     &http.Client{Timeout: 30 * time.Second},
+		now:      time.Now,
+	}
+}
+
+// Endpoint returns the endpoint of the client
+func (c *Client) Endpoint() Endpoint {
+	return c.endpoint
+}
+
+// CreateBucket creates a bucket, succeeding if it is already owned by the caller
+func (c *Client) CreateBucket(ctx context.Context, bucket string) error {
+	var body []byte
+	if c.endpoint.Region != "" && c.endpoint.Region != "us-east-1" {
+		body = fmt.Appendf(nil, `<CreateBucketConfiguration><LocationConstraint>%s</LocationConstraint></CreateBucketConfiguration>`, c.endpoint.Region)
+	}
+	_, err := c.do(ctx, http.MethodPut, bucket, "", nil, body, "")
+	if err != nil && !isCode(err, "BucketAlreadyOwnedByYou") {
+		return fmt.Errorf("failed to create bucket %s: %w", bucket, err)
+	}
+	return nil
+}
+
+// DeleteBucket deletes an empty bucket
+func (c *Client) DeleteBucket(ctx context.Context, bucket string) error {
+	if _, err := c.do(ctx, http.MethodDelete, bucket, "", nil, nil, ""); err != nil {
+		return fmt.Errorf("failed to delete bucket %s: %w", bucket, err)
+	}
+	return nil
+}
+
+// PutObject uploads an object
+func (c *Client) PutObject(ctx context.Context, bucket, key string, data []byte, contentType string) error {
+	if _, err := c.do(ctx, http.MethodPut, bucket, key, nil, data, contentType); err != nil {
+		return fmt.Errorf("failed to put %s/%s: %w", bucket, key, err)
+	}
+	return nil
+}
+
+// GetObject downloads an object
+func (c *Client) GetObject(ctx context.Context, bucket, key string) ([]byte, error) {
+	data, err := c.do(ctx, http.MethodGet, bucket, key, nil, nil, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s/%s: %w", bucket, key, err)
+	}
+	return data, nil
+}
+
+// DeleteObject deletes an object, succeeding if it does not exist
+func (c *Client) DeleteObject(ctx context.Context, bucket, key string) error {
+	if _, err := c.do(ctx, http.MethodDelete, bucket, key, nil, nil, ""); err != nil {
+		return fmt.Errorf("failed to delete %s/%s: %w", bucket, key, err)
+	}
+	return nil
+}
+
+// ListObjects returns the keys of the objects starting with prefix, sorted
+func (c *Client) ListObjects(ctx context.Context, bucket, prefix string) ([]string, error) {
+	var keys []string
+	token := ""
+	for {
+		query := url.Values{"list-type": {"2"}}
+		if prefix != "" {
+			query.Set("prefix", prefix)
+		}
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+		data, err := c.do(ctx, http.MethodGet, bucket, "", query, nil, "")
+		if err != nil {
+			return nil, fmt.Errorf("failed to list %s: %w", bucket, err)
+		}
+		var result struct {
+			Contents []struct {
+				Key string
+			}
+			IsTruncated           bool
+			NextContinuationToken string
+		}
+		if err := xml.Unmarshal(data, &result); err != nil {
+			return nil, fmt.Errorf("failed to parse listing of %s: %w", bucket, err)
+		}
+		for _, object := range result.Contents {
+			keys = append(keys, object.Key)
+		}
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			break
+		}
+		token = result.NextContinuationToken
+	}
+	slices.Sort(keys)
+	return keys, nil
+}
+
+// Error is an error response of the S3 API
+type Error struct {
+	Status  int
+	Code    string
+	Message string
+}
+
+func (e *Error) Error() string {
+	if e.Code == "" {
+		return fmt.Sprintf("status %d", e.Status)
+	}
+	return fmt.Sprintf("%s (status %d): %s", e.Code, e.Status, e.Message)
+}
+
+func isCode(err error, code string) bool {
+	var e *Error
+	return errors.As(err, &e) && e.Code == code
+}
+
+// do sends a signed request for the bucket, or the object key in it, and returns the response body
+func (c *Client) do(ctx context.Context, method, bucket, key string, query url.Values, body []byte, contentType string) ([]byte, error) {
+	path := "/" + uriEncode(bucket, true)
+	if key != "" {
+		path += "/" + uriEncode(key, false)
+	}
+	u, err := url.Parse(strings.TrimSuffix(c.endpoint.URL, "/") + path)
+	if err != nil {
+		return nil, fmt.Errorf("invalid endpoint %s: %w", c.endpoint.URL, err)
+	}
+	u.RawQuery = canonicalQuery(query)
+
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	c.sign(req, body)
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 300 {
+		e := &Error{Status: resp.StatusCode}
+		_ = xml.Unmarshal(data, e)
+		return nil, e
+	}
+	return data, nil
+}
+
+// sign adds the SigV4 Authorization header to req, signing the host, content type, range and x-amz-*
+// headers
+func (c *Client) sign(req *http.Request, body []byte) {
+	now := c.now().UTC()
+	date := now.Format("20060102")
+	payloadHash := sha256Hex(body)
+	req.Header.Set("X-Amz-Date", now.Format("20060102T150405Z"))
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		lower := strings.ToLower(name)
+		if strings.HasPrefix(lower, "x-amz-") || lower == "content-type" || lower == "range" {
+			headers[lower] = strings.TrimSpace(strings.Join(values, ","))
+		}
+	}
+	signed := slices.Sorted(maps.Keys(headers))
+	var canonicalHeaders strings.Builder
+	for _, name := range signed {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(signed, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	region := c.endpoint.region()
+	scope := date + "/" + region + "/s3/aws4_request"
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		now.Format("20060102T150405Z"),
+		scope,
+		sha256Hex([]byte(canonicalRequest)),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+c.endpoint.SecretKey), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		c.endpoint.AccessKey, scope, signedHeaders, signature))
+}
+
+// canonicalQuery encodes query sorted by key, as both sent and signed
+func canonicalQuery(query url.Values) string {
+	var params []string
+	for _, name := range slices.Sorted(maps.Keys(query)) {
+		values := slices.Clone(query[name])
+		slices.Sort(values)
+		for _, value := range values {
+			params = append(params, uriEncode(name, true)+"="+uriEncode(value, true))
+		}
+	}
+	return strings.Join(params, "&")
+}
+
+// uriEncode percent-encodes every byte but the unreserved characters, and slashes unless encodeSlash
+func uriEncode(s string, encodeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		ch := s[i]
+		switch {
+		case 'A' <= ch && ch <= 'Z', 'a' <= ch && ch <= 'z', '0' <= ch && ch <= '9',
+			ch == '-', ch == '_', ch == '.', ch == '~', ch == '/' && !encodeSlash:
+			b.WriteByte(ch)
+		default:
+			fmt.Fprintf(&b, "%%%02X", ch)
+		}
+	}
+	return b.String()
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
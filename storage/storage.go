@@ -0,0 +1,71 @@
+// Package storage provisions S3 buckets on a MinIO container or on LocalStack in a cluster, seeds them
+// with fixtures and removes them after the run:
+//
+//	//go:embed testdata/objects
+//	var objects embed.FS
+//
+//	bucket, err := storage.NewBucket(ctx, storage.MinIO(minio), "")
+//	err = bucket.Seed(ctx, objects, "testdata/objects")
+//	app := container.Config{Image: "app", Env: bucket.EnvList(), ExtraHosts: []string{container.DockerHost + ":host-gateway"}}
+package storage
+
+import (
+	"fmt"
+
+	"github.com/flanksource/commons-test/container"
+	"github.com/flanksource/commons-test/helm"
+)
+
+// Endpoint is an S3 compatible endpoint and its credentials
+type Endpoint struct {
+	// URL is reachable from the test process
+	URL string
+	// InternalURL is reachable from the containers or pods under test, URL when empty
+	InternalURL string
+	// Region defaults to us-east-1
+	Region    string
+	AccessKey string
+	SecretKey string
+}
+
+func (e Endpoint) region() string {
+	if e.Region == "" {
+		return "us-east-1"
+	}
+	return e.Region
+}
+
+func (e Endpoint) internalURL() string {
+	if e.InternalURL == "" {
+		return e.URL
+	}
+	return e.InternalURL
+}
+
+// MinIO returns the endpoint of a started MinIO container, reachable from other containers through
+// container.DockerHost
+func MinIO(m *container.MinIOContainer) Endpoint {
+	accessKey, secretKey := m.GetCredentials()
+	return Endpoint{
+		URL:         m.GetEndpoint(),
+		InternalURL: m.GetInternalEndpoint(),
+		AccessKey:   accessKey,
+		SecretKey:   secretKey,
+	}
+}
+
+// LocalStack returns the endpoint of the localstack service in namespace, e.g. installed by kind with
+// kind.ServiceLocalStack in default, through a port-forward. The returned function stops the forward.
+func LocalStack(namespace string) (Endpoint, func(), error) {
+	port, stop, err := helm.ForwardPort(namespace, "svc/localstack", 4566)
+	if err != nil {
+		return Endpoint{}, nil, fmt.Errorf("failed to forward LocalStack port: %w", err)
+	}
+	return Endpoint{
+		URL:         fmt.Sprintf("http://localhost:%d", port),
+		InternalURL: fmt.Sprintf("http://localstack.%s.svc.cluster.local:4566", namespace),
+		Region:      "us-east-1",
+		AccessKey:   "test",
+		SecretKey:   "test",
+	}, stop, nil
+}
@@ -0,0 +1,250 @@
+// Package suite wires kind clusters, helm charts, containers and namespaces into the ginkgo lifecycle,
+// so specs get cleanup, shared clusters and setup timings without copy-pasting suite_setup files.
+package suite
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/flanksource/commons/logger"
+	"github.com/onsi/ginkgo/v2"
+	"github.com/onsi/gomega"
+
+	"github.com/flanksource/commons-test/budget"
+	"github.com/flanksource/commons-test/cleanup"
+	"github.com/flanksource/commons-test/command"
+	"github.com/flanksource/commons-test/config"
+	"github.com/flanksource/commons-test/container"
+	"github.com/flanksource/commons-test/helm"
+	"github.com/flanksource/commons-test/kind"
+	"github.com/flanksource/commons-test/leak"
+	"github.com/flanksource/commons-test/lock"
+	"github.com/flanksource/commons-test/logs"
+	"github.com/flanksource/commons-test/names"
+	"github.com/flanksource/commons-test/report"
+	"github.com/flanksource/commons-test/retry"
+)
+
+// Time runs fn and records it as a setup step for ReportTimings, for setup that commons-test does not
+// record itself. It fails when fn exceeds the budget of the "setup" category, the context passed to fn
+// is cancelled when it does.
+func Time(name string, fn func(ctx context.Context) error) error {
+	return budget.Run(context.Background(), "setup", name, fn)
+}
+
+// Chart installs or upgrades chart and uninstalls it when the current spec or container ends
+func Chart[T helm.ChartInstaller](chart T) T {
+	ginkgo.GinkgoHelper()
+	err := chart.InstallOrUpgrade()
+	gomega.Expect(err).NotTo(gomega.HaveOccurred(), "failed to install %s", chart.GetReleaseName())
+	ginkgo.DeferCleanup(func() {
+		if !cleanup.Keep() {
+			gomega.Expect(chart.Uninstall()).To(gomega.Succeed())
+		}
+	})
+	return chart
+}
+
+// Namespace creates the namespace and deletes it when the current spec or container ends
+func Namespace(name string) *helm.Namespace {
+	ginkgo.GinkgoHelper()
+	ns := helm.NewNamespace(name)
+	err := ns.Create().Error()
+	gomega.Expect(err).NotTo(gomega.HaveOccurred(), "failed to create namespace %s", name)
+	ginkgo.DeferCleanup(func() {
+		if !cleanup.Keep() {
+			gomega.Expect(ns.Delete().Error()).To(gomega.Succeed())
+		}
+	})
+	return ns
+}
+
+// SpecLabel is the label holding the spec that an IsolatedNamespace belongs to
+const SpecLabel = "commons-test/spec"
+
+// IsolatedNamespace creates a uniquely named namespace for the current spec, labelled with the spec,
+// run and parallel process, and deletes it when the spec ends. Install charts with ns.Chart and find
+// pods with ns.GetPod so parallel specs sharing a cluster never touch each other's resources.
+func IsolatedNamespace() *helm.Namespace {
+	ginkgo.GinkgoHelper()
+	spec := ginkgo.CurrentSpecReport()
+	ns := Namespace(names.New(spec.LeafNodeText))
+	err := ns.Label(map[string]string{
+		SpecLabel:              names.Sanitize(spec.FullText()),
+		"commons-test/process": fmt.Sprint(ginkgo.GinkgoParallelProcess()),
+		names.RunLabel:         names.RunID(),
+	}).Error()
+	gomega.Expect(err).NotTo(gomega.HaveOccurred(), "failed to label namespace %s", ns.Name())
+	return ns
+}
+
+// Container starts the container and removes it when the current spec or container ends
+func Container[T container.NamedManager](ctx context.Context, c T) T {
+	ginkgo.GinkgoHelper()
+	err := c.Start(ctx)
+	gomega.Expect(err).NotTo(gomega.HaveOccurred(), "failed to start container %s", c.GetName())
+	ginkgo.DeferCleanup(func(ctx context.Context) {
+		if !cleanup.Keep() {
+			gomega.Expect(c.Cleanup(ctx)).To(gomega.Succeed())
+		}
+	})
+	return c
+}
+
+// Kind gets or creates the cluster and, when it didn't exist beforehand, deletes it when the current spec
+// or container ends
+func Kind[T kind.Cluster](k T) T {
+	ginkgo.GinkgoHelper()
+	existed := k.Exists()
+	err := k.Create()
+	gomega.Expect(err).NotTo(gomega.HaveOccurred(), "failed to create kind cluster %s", k.GetName())
+	if existed {
+		return k
+	}
+	ginkgo.DeferCleanup(func() {
+		if !cleanup.Keep() {
+			gomega.Expect(k.Destroy()).To(gomega.Succeed())
+		}
+	})
+	return k
+}
+
+// SharedKind registers a SynchronizedBeforeSuite that creates the cluster once, runs setup (e.g. to
+// install charts shared by every spec) on the first parallel process and switches every process to
+// the cluster, and a SynchronizedAfterSuite that deletes it once no other test package uses it. Creation,
+// setup and deletion hold the lock named after the cluster, so test packages sharing it don't mutate it
+// concurrently, and the packages using it are counted with lock.Ref. Call it at the top level of the suite:
+//
+//	var cluster = suite.SharedKind("e2e", func(k *kind.Kind) { ... })
+func SharedKind(name string, setup ...func(k *kind.Kind)) *kind.Kind {
+	k := kind.NewKind(name)
+	ginkgo.SynchronizedBeforeSuite(func(ctx ginkgo.SpecContext) {
+		err := lock.With(ctx, ClusterLock(name), func() error {
+			if err := k.GetOrCreate().Error(); err != nil {
+				return fmt.Errorf("failed to create kind cluster %s: %w", name, err)
+			}
+			if _, err := lock.Ref(ClusterLock(name)); err != nil {
+				return err
+			}
+			for _, fn := range setup {
+				fn(k)
+			}
+			return nil
+		})
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+	}, func() {
+		gomega.Expect(k.Use().Error()).To(gomega.Succeed(), "failed to use kind cluster %s", name)
+	})
+	ginkgo.SynchronizedAfterSuite(func() {}, func(ctx ginkgo.SpecContext) {
+		err := lock.With(ctx, ClusterLock(name), func() error {
+			users, err := lock.Unref(ClusterLock(name))
+			if err != nil {
+				return err
+			}
+			if users > 0 {
+				logger.Infof("Keeping kind cluster %s used by %d other test packages", name, users)
+				return nil
+			}
+			if cleanup.Keep() {
+				return nil
+			}
+			return k.Delete().Error()
+		})
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+	})
+	return k
+}
+
+// ClusterLock is the name of the lock held while mutating the shared cluster called name
+func ClusterLock(name string) string {
+	return "kind-" + name
+}
+
+// Exclusive runs fn holding the lock of the shared cluster called name, for cluster-mutating steps like
+// CRD installs that specs of other test packages must not run concurrently
+func Exclusive(ctx context.Context, name string, fn func() error) error {
+	return lock.With(ctx, ClusterLock(name), fn)
+}
+
+// ReportTimings registers a ReportAfterSuite that prints the setup steps and the slowest commands, and
+// writes the steps to the file named by report.FileEnv (JUnit XML for .xml, JSON otherwise) when set.
+// Only steps recorded on the first parallel process are included, which is where SharedKind sets up.
+func ReportTimings() bool {
+	ginkgo.ReportAfterSuite("setup timings", func(ginkgo.Report) {
+		if len(report.Steps()) == 0 {
+			return
+		}
+		fmt.Printf("%s\n%s", report.String(), command.Report(10))
+		if path := os.Getenv(report.FileEnv); path != "" {
+			gomega.Expect(report.WriteFile(path)).To(gomega.Succeed())
+		}
+	})
+	return true
+}
+
+// ReportFlakes adds a ginkgo report entry to the current node for every retry.Flaky step that needed more
+// than one attempt, and registers a ReportAfterSuite that prints the flake statistics and writes them to
+// the file named by retry.FileEnv when set. Like ReportTimings, only the first parallel process is reported.
+func ReportFlakes() bool {
+	retry.OnComplete = func(name string, attempts int, err error) {
+		if attempts > 1 {
+			ginkgo.AddReportEntry("flaky: "+name, attempts, ginkgo.ReportEntryVisibilityFailureOrVerbose)
+		}
+	}
+	ginkgo.ReportAfterSuite("flake statistics", func(ginkgo.Report) {
+		if len(retry.Stats()) == 0 {
+			return
+		}
+		fmt.Printf("Flaky steps\n%s", retry.String())
+		if path := os.Getenv(retry.FileEnv); path != "" {
+			gomega.Expect(retry.WriteFile(path)).To(gomega.Succeed())
+		}
+	})
+	return true
+}
+
+// CheckLeaks registers a ReportBeforeSuite that snapshots the resources of the run and a ReportAfterSuite
+// that runs the pending cleanups and fails the suite on leftovers when config.LeakCheck is fail (only
+// logging them when it is report). Like ReportTimings, only the first parallel process is checked.
+func CheckLeaks() bool {
+	var before *leak.Snapshot
+	ginkgo.ReportBeforeSuite(func(ginkgo.Report) {
+		if config.Get().LeakCheck != "" {
+			before = leak.Take(context.Background())
+		}
+	})
+	ginkgo.ReportAfterSuite("leak check", func(ginkgo.Report) {
+		if before == nil {
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), cleanup.Timeout)
+		defer cancel()
+		if err := cleanup.Run(ctx); err != nil {
+			ginkgo.GinkgoWriter.Printf("%v\n", err)
+		}
+		gomega.Expect(leak.Check(ctx, before)).To(gomega.Succeed())
+	})
+	return true
+}
+
+// DumpLogs registers a ginkgo ReportAfterEach that writes the lines collected by agg to logs.txt in the
+// artifacts folder of each failed spec under root, the configured artifacts directory when root is empty.
+func DumpLogs(agg *logs.Aggregator, root string) bool {
+	if root == "" {
+		root = config.Get().ArtifactsDir
+	}
+	ginkgo.ReportAfterEach(func(report ginkgo.SpecReport) {
+		if !report.Failed() {
+			return
+		}
+		path := filepath.Join(command.ArtifactsDir(root, report.FullText()), "logs.txt")
+		if err := agg.WriteFile(path); err != nil {
+			ginkgo.GinkgoWriter.Printf("failed to dump logs for %q: %v\n", report.FullText(), err)
+			return
+		}
+		ginkgo.GinkgoWriter.Printf("Logs for %q written to %s\n", report.FullText(), path)
+	})
+	return true
+}
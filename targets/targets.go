@@ -0,0 +1,53 @@
+// Package targets resolves localhost URLs for endpoints running in containers, pods and services, shared by
+// the load and metrics packages.
+package targets
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/flanksource/commons-test/helm"
+)
+
+// Ports resolves the host port of a container port, implemented by container.Container
+type Ports interface {
+	GetPort(port string) (string, error)
+}
+
+// Pod returns the URL of path on port of the pod through a port-forward, the returned function stops the
+// forward
+func Pod(pod *helm.Pod, port int, path string) (string, func(), error) {
+	name := pod.GetName()
+	if name == "" {
+		return "", nil, fmt.Errorf("failed to resolve pod name: %w", pod.Error())
+	}
+	return forward(pod.Namespace, "pod/"+name, port, path)
+}
+
+// Service returns the URL of path on port of the service through a port-forward, the returned function stops
+// the forward
+func Service(namespace, service string, port int, path string) (string, func(), error) {
+	return forward(namespace, "svc/"+service, port, path)
+}
+
+// Container returns the URL of path on the host port published for port of the container
+func Container(c Ports, port, path string) (string, error) {
+	hostPort, err := c.GetPort(port)
+	if err != nil {
+		return "", fmt.Errorf("failed to get host port for %s: %w", port, err)
+	}
+	return URL(hostPort, path), nil
+}
+
+// URL returns the URL of path on a localhost port
+func URL(port, path string) string {
+	return fmt.Sprintf("http://localhost:%s/%s", port, strings.TrimPrefix(path, "/"))
+}
+
+func forward(namespace, target string, port int, path string) (string, func(), error) {
+	localPort, stop, err := helm.ForwardPort(namespace, target, port)
+	if err != nil {
+		return "", nil, err
+	}
+	return URL(fmt.Sprint(localPort), path), stop, nil
+}
@@ -0,0 +1,41 @@
+package targets
+
+import (
+	"errors"
+	"testing"
+)
+
+type ports map[string]string
+
+func (p ports) GetPort(port string) (string, error) {
+	if hostPort, ok := p[port]; ok {
+		return hostPort, nil
+	}
+	return "", errors.New("port not published")
+}
+
+func TestContainer(t *testing.T) {
+	url, err := Container(ports{"8080": "32768"}, "8080", "/metrics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if url != "http://localhost:32768/metrics" {
+		t.Errorf("unexpected url %s", url)
+	}
+
+	if _, err := Container(ports{}, "8080", "metrics"); err == nil {
+		t.Error("expected an error for an unpublished port")
+	}
+}
+
+func TestURL(t *testing.T) {
+	for path, expected := range map[string]string{
+		"":         "http://localhost:80/",
+		"metrics":  "http://localhost:80/metrics",
+		"/metrics": "http://localhost:80/metrics",
+	} {
+		if url := URL("80", path); url != expected {
+			t.Errorf("URL(80, %q) = %s, expected %s", path, url, expected)
+		}
+	}
+}
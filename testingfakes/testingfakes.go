@@ -0,0 +1,353 @@
+// Package testingfakes provides in-memory implementations of helm.ChartInstaller, kind.Cluster,
+// container.NamedManager and mission_control.MissionControlAPI, so suite helpers can be unit tested without
+// docker, kind or helm installed. Every fake records the calls made to it and returns Err when set.
+package testingfakes
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/flanksource/commons-test/container"
+	"github.com/flanksource/commons-test/helm"
+	"github.com/flanksource/commons-test/kind"
+	mc "github.com/flanksource/commons-test/mission_control"
+	"github.com/flanksource/commons-test/mission_control/fake"
+)
+
+var (
+	_ helm.ChartInstaller    = (*Chart)(nil)
+	_ kind.Cluster           = (*Cluster)(nil)
+	_ container.NamedManager = (*Container)(nil)
+	_ mc.MissionControlAPI   = (*MissionControl)(nil)
+)
+
+// calls records the methods called on a fake
+type calls struct {
+	mu    sync.Mutex
+	calls []string
+}
+
+func (c *calls) record(format string, args ...any) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.calls = append(c.calls, fmt.Sprintf(format, args...))
+}
+
+// Calls returns the methods called so far, e.g. "InstallOrUpgrade" or "Load nginx:latest"
+func (c *calls) Calls() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]string(nil), c.calls...)
+}
+
+// Chart is a fake helm release
+type Chart struct {
+	calls
+	Release string
+	// Err is returned by InstallOrUpgrade and Uninstall
+	Err       error
+	Installed bool
+}
+
+// NewChart returns an uninstalled fake release
+func NewChart(release string) *Chart {
+	return &Chart{Release: release}
+}
+
+func (c *Chart) InstallOrUpgrade() error {
+	c.record("InstallOrUpgrade")
+	if c.Err != nil {
+		return c.Err
+	}
+	c.Installed = true
+	return nil
+}
+
+func (c *Chart) Uninstall() error {
+	c.record("Uninstall")
+	if c.Err != nil {
+		return c.Err
+	}
+	c.Installed = false
+	return nil
+}
+
+// Status returns helm status output with STATUS deployed or uninstalled
+func (c *Chart) Status() (string, error) {
+	c.record("Status")
+	if !c.Installed {
+		return "", fmt.Errorf("release: not found")
+	}
+	return fmt.Sprintf("NAME: %s\nSTATUS: deployed\n", c.Release), nil
+}
+
+func (c *Chart) GetReleaseName() string {
+	return c.Release
+}
+
+// Cluster is a fake kind cluster
+type Cluster struct {
+	calls
+	Name string
+	// Err is returned by Create, Destroy and Load
+	Err     error
+	Created bool
+	Images  []string
+}
+
+// NewCluster returns a fake cluster that does not exist yet
+func NewCluster(name string) *Cluster {
+	return &Cluster{Name: name}
+}
+
+func (c *Cluster) Create() error {
+	c.record("Create")
+	if c.Err != nil {
+		return c.Err
+	}
+	c.Created = true
+	return nil
+}
+
+func (c *Cluster) Destroy() error {
+	c.record("Destroy")
+	if c.Err != nil {
+		return c.Err
+	}
+	c.Created = false
+	c.Images = nil
+	return nil
+}
+
+func (c *Cluster) Load(image string) error {
+	c.record("Load %s", image)
+	if c.Err != nil {
+		return c.Err
+	}
+	if !c.Created {
+		return fmt.Errorf("cluster %s does not exist", c.Name)
+	}
+	c.Images = append(c.Images, image)
+	return nil
+}
+
+func (c *Cluster) Exists() bool {
+	return c.Created
+}
+
+func (c *Cluster) GetName() string {
+	return c.Name
+}
+
+// GetKubeconfig returns a placeholder kubeconfig with a kind-<name> context pointing at an unreachable
+// server
+func (c *Cluster) GetKubeconfig() (string, error) {
+	if !c.Created {
+		return "", fmt.Errorf("cluster %s does not exist", c.Name)
+	}
+	return fmt.Sprintf(`apiVersion: v1
+kind: Config
+current-context: kind-%[1]s
+clusters:
+- name: kind-%[1]s
+  cluster:
+    server: https://127.0.0.1:6443
+contexts:
+- name: kind-%[1]s
+  context:
+    cluster: kind-%[1]s
+    user: kind-%[1]s
+users:
+- name: kind-%[1]s
+  user:
+    token: fake
+`, c.Name), nil
+}
+
+// Container is a fake docker container
+type Container struct {
+	calls
+	ID   string
+	Name string
+	// Ports maps container ports to the host ports returned by GetPort
+	Ports map[string]string
+	// Output is returned by Logs
+	Output string
+	// ExecFunc answers Exec, which returns an empty string when it is nil
+	ExecFunc func(cmd []string) (string, error)
+	// Err is returned by Start, Stop and Cleanup
+	Err     error
+	Running bool
+}
+
+// NewContainer returns a stopped fake container
+func NewContainer(name string) *Container {
+	return &Container{ID: "fake-" + name, Name: name, Ports: map[string]string{}}
+}
+
+func (c *Container) Start(ctx context.Context) error {
+	c.record("Start")
+	if c.Err != nil {
+		return c.Err
+	}
+	c.Running = true
+	return nil
+}
+
+func (c *Container) Stop(ctx context.Context) error {
+	c.record("Stop")
+	if c.Err != nil {
+		return c.Err
+	}
+	c.Running = false
+	return nil
+}
+
+func (c *Container) Logs(ctx context.Context, follow bool) (io.ReadCloser, error) {
+	return io.NopCloser(strings.NewReader(c.Output)), nil
+}
+
+func (c *Container) Exec(ctx context.Context, cmd []string) (string, error) {
+	c.record("Exec %s", strings.Join(cmd, " "))
+	if !c.Running {
+		return "", fmt.Errorf("container %s is not running", c.Name)
+	}
+	if c.ExecFunc == nil {
+		return "", nil
+	}
+	return c.ExecFunc(cmd)
+}
+
+func (c *Container) IsRunning(ctx context.Context) (bool, error) {
+	return c.Running, nil
+}
+
+func (c *Container) GetPort(port string) (string, error) {
+	if hostPort, ok := c.Ports[port]; ok {
+		return hostPort, nil
+	}
+	return "", fmt.Errorf("port %s is not mapped", port)
+}
+
+func (c *Container) GetID() string {
+	return c.ID
+}
+
+func (c *Container) GetName() string {
+	return c.Name
+}
+
+func (c *Container) Cleanup(ctx context.Context) error {
+	c.record("Cleanup")
+	if c.Err != nil {
+		return c.Err
+	}
+	c.Running = false
+	return nil
+}
+
+// MissionControl is a fake mission-control client backed by in-memory configs and changes, matching
+// them like mission_control/fake.Server does
+type MissionControl struct {
+	calls
+	Healthy bool
+	// WhoAmIResponse is returned by WhoAmI, nil reports an unauthenticated client
+	WhoAmIResponse map[string]any
+	Configs        []mc.SelectedResource
+	Changes        []mc.ConfigChangeRow
+	// Pushed records the data passed to Push
+	Pushed []mc.PushData
+	// Err is returned by every method that makes a request
+	Err error
+}
+
+// NewMissionControl returns a healthy fake with an admin user and no fixtures
+func NewMissionControl() *MissionControl {
+	return &MissionControl{
+		Healthy:        true,
+		WhoAmIResponse: map[string]any{"message": "success", "payload": map[string]any{"user": map[string]any{"name": "admin"}}},
+	}
+}
+
+func (m *MissionControl) IsHealthy(ctx context.Context) (bool, error) {
+	m.record("IsHealthy")
+	if m.Err != nil {
+		return false, m.Err
+	}
+	return m.Healthy, nil
+}
+
+func (m *MissionControl) WhoAmI(ctx context.Context) (map[string]any, bool, error) {
+	m.record("WhoAmI")
+	if m.Err != nil {
+		return nil, false, m.Err
+	}
+	return m.WhoAmIResponse, m.WhoAmIResponse != nil, nil
+}
+
+func (m *MissionControl) SearchResources(ctx context.Context, selector mc.ResourceSelector, opts ...mc.SearchOption) (*mc.SearchResourcesResponse, error) {
+	m.record("SearchResources")
+	if m.Err != nil {
+		return nil, m.Err
+	}
+	req := mc.SearchResourcesRequest{}
+	for _, opt := range opts {
+		opt(&req, &selector)
+	}
+
+	response := mc.SearchResourcesResponse{Configs: []mc.SelectedResource{}}
+	for _, config := range m.Configs {
+		if fake.Matches(selector, config) {
+			response.Configs = append(response.Configs, config)
+		}
+	}
+	response.Total = len(response.Configs)
+	if req.Limit > 0 && len(response.Configs) > req.Limit {
+		response.Configs = response.Configs[:req.Limit]
+	}
+	return &response, nil
+}
+
+func (m *MissionControl) QueryCatalog(ctx context.Context, selector mc.ResourceSelector, opts ...mc.SearchOption) ([]mc.SelectedResource, error) {
+	response, err := m.SearchResources(ctx, selector, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return response.Configs, nil
+}
+
+func (m *MissionControl) SearchCatalog(ctx context.Context, search string, opts ...mc.SearchOption) ([]mc.SelectedResource, error) {
+	return m.QueryCatalog(ctx, mc.ResourceSelector{Search: search}, opts...)
+}
+
+func (m *MissionControl) SearchCatalogChanges(ctx context.Context, req mc.CatalogChangesSearchRequest) (*mc.CatalogChangesSearchResponse, error) {
+	m.record("SearchCatalogChanges")
+	if m.Err != nil {
+		return nil, m.Err
+	}
+	response := mc.CatalogChangesSearchResponse{Summary: map[string]int{}}
+	for _, change := range m.Changes {
+		if (req.CatalogID == "" || change.ConfigID == req.CatalogID) &&
+			(req.ChangeType == "" || change.ChangeType == req.ChangeType) &&
+			(req.ConfigType == "" || change.ConfigType == req.ConfigType) &&
+			(req.Severity == "" || change.Severity == req.Severity) &&
+			(req.Source == "" || change.Source == req.Source) {
+			response.Changes = append(response.Changes, change)
+			response.Summary[change.ChangeType]++
+		}
+	}
+	response.Total = int64(len(response.Changes))
+	return &response, nil
+}
+
+func (m *MissionControl) Push(ctx context.Context, data mc.PushData) error {
+	m.record("Push %s", data.AgentName)
+	if m.Err != nil {
+		return m.Err
+	}
+	m.Pushed = append(m.Pushed, data)
+	return nil
+}
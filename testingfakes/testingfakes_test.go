@@ -0,0 +1,55 @@
+package testingfakes
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	mc "github.com/flanksource/commons-test/mission_control"
+)
+
+func TestCluster(t *testing.T) {
+	cluster := NewCluster("e2e")
+	if err := cluster.Load("nginx:latest"); err == nil {
+		t.Error("expected Load to fail before Create")
+	}
+	if err := cluster.Create(); err != nil {
+		t.Fatal(err)
+	}
+	if err := cluster.Load("nginx:latest"); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"Load nginx:latest", "Create", "Load nginx:latest"}; !slices.Equal(cluster.Calls(), want) {
+		t.Errorf("expected calls %v, got %v", want, cluster.Calls())
+	}
+
+	cluster.Err = errors.New("docker is not running")
+	if err := cluster.Destroy(); err == nil || !cluster.Exists() {
+		t.Error("expected Destroy to fail and keep the cluster")
+	}
+}
+
+func TestMissionControl(t *testing.T) {
+	fake := NewMissionControl()
+	fake.Configs = []mc.SelectedResource{
+		{ID: "1", Name: "nginx", Type: "Kubernetes::Pod"},
+		{ID: "2", Name: "nginx", Type: "Kubernetes::Deployment"},
+	}
+
+	configs, err := fake.QueryCatalog(context.Background(), mc.ResourceSelector{Name: "nginx", Types: []string{"Kubernetes::Pod"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(configs) != 1 || configs[0].ID != "1" {
+		t.Errorf("expected only the pod, got %+v", configs)
+	}
+
+	response, err := fake.SearchResources(context.Background(), mc.ResourceSelector{Search: "nginx"}, mc.SearchLimit(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.Total != 2 || len(response.Configs) != 1 {
+		t.Errorf("expected 1 of 2 configs, got %d of %d", len(response.Configs), response.Total)
+	}
+}
@@ -0,0 +1,49 @@
+package tls
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/flanksource/commons-test/container"
+)
+
+// Secret returns a kubernetes.io/tls secret holding the certificate, key and CA, e.g. for an ingress
+func (c *Certificate) Secret(namespace, name string) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Type:       corev1.SecretTypeTLS,
+		Data: map[string][]byte{
+			corev1.TLSCertKey:       c.CertPEM,
+			corev1.TLSPrivateKeyKey: c.KeyPEM,
+			CAFile:                  c.CA.CertPEM,
+		},
+	}
+}
+
+// CreateSecret creates or replaces the TLS secret in namespace
+func (c *Certificate) CreateSecret(ctx context.Context, k8s kubernetes.Interface, namespace, name string) error {
+	secrets := k8s.CoreV1().Secrets(namespace)
+	secret := c.Secret(namespace, name)
+	_, err := secrets.Create(ctx, secret, metav1.CreateOptions{})
+	if apierrors.IsAlreadyExists(err) {
+		_, err = secrets.Update(ctx, secret, metav1.UpdateOptions{})
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create secret %s/%s: %w", namespace, name, err)
+	}
+	return nil
+}
+
+// Mount writes the certificate files to dir and returns a read-only bind mount of dir at target, e.g.
+// /etc/tls for a container expecting /etc/tls/tls.crt
+func (c *Certificate) Mount(dir, target string) (container.Mount, error) {
+	if err := c.WriteFiles(dir); err != nil {
+		return container.Mount{}, err
+	}
+	return container.Mount{Source: dir, Target: target, Type: "bind", ReadOnly: true}, nil
+}
@@ -0,0 +1,235 @@
+// Package tls generates a throwaway CA with server and client certificates for tests, and exposes them as
+// files, container mounts, Kubernetes TLS secrets and http.Clients trusting the CA:
+//
+//	ca, _ := tls.NewCA("test")
+//	server, _ := ca.Server("app.example.com")
+//	client := ca.HTTPClient()
+package tls
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	gotls "crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
+// Validity is the lifetime of generated certificates
+var Validity = 24 * time.Hour
+
+// File names written by WriteFiles, matching the keys of Kubernetes TLS secrets
+const (
+	CertFile = "tls.crt"
+	KeyFile  = "tls.key"
+	CAFile   = "ca.crt"
+)
+
+// DefaultHosts are added to the SANs of every server certificate, so services are reachable through
+// published container ports and port-forwards
+var DefaultHosts = []string{"localhost", "127.0.0.1", "::1"}
+
+// CA is a self-signed certificate authority
+type CA struct {
+	Cert    *x509.Certificate
+	Key     *ecdsa.PrivateKey
+	CertPEM []byte
+}
+
+// Certificate is a key pair signed by a CA
+type Certificate struct {
+	CA      *CA
+	Cert    *x509.Certificate
+	CertPEM []byte
+	KeyPEM  []byte
+}
+
+// NewCA generates a CA whose common name is name
+func NewCA(name string) (*CA, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate CA key: %w", err)
+	}
+	template, err := newTemplate(name)
+	if err != nil {
+		return nil, err
+	}
+	template.IsCA = true
+	template.BasicConstraintsValid = true
+	template.KeyUsage = x509.KeyUsageCertSign | x509.KeyUsageCRLSign | x509.KeyUsageDigitalSignature
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create CA certificate: %w", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, err
+	}
+	return &CA{Cert: cert, Key: key, CertPEM: encode("CERTIFICATE", der)}, nil
+}
+
+// Server issues a server certificate for hosts (DNS names or IPs, e.g. ingress hosts) and DefaultHosts
+func (ca *CA) Server(hosts ...string) (*Certificate, error) {
+	name := "localhost"
+	if len(hosts) > 0 {
+		name = hosts[0]
+	}
+	return ca.issue(name, slices.Concat(hosts, DefaultHosts), x509.ExtKeyUsageServerAuth)
+}
+
+// Client issues a client certificate for mutual TLS whose common name is name
+func (ca *CA) Client(name string) (*Certificate, error) {
+	return ca.issue(name, nil, x509.ExtKeyUsageClientAuth)
+}
+
+func (ca *CA) issue(name string, hosts []string, usage x509.ExtKeyUsage) (*Certificate, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate key for %s: %w", name, err)
+	}
+	template, err := newTemplate(name)
+	if err != nil {
+		return nil, err
+	}
+	template.KeyUsage = x509.KeyUsageDigitalSignature
+	template.ExtKeyUsage = []x509.ExtKeyUsage{usage}
+	seen := map[string]bool{}
+	for _, host := range hosts {
+		if seen[host] {
+			continue
+		}
+		seen[host] = true
+		if ip := net.ParseIP(host); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else {
+			template.DNSNames = append(template.DNSNames, host)
+		}
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.Cert, &key.PublicKey, ca.Key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create certificate for %s: %w", name, err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, err
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+	return &Certificate{
+		CA:      ca,
+		Cert:    cert,
+		CertPEM: encode("CERTIFICATE", der),
+		KeyPEM:  encode("EC PRIVATE KEY", keyDER),
+	}, nil
+}
+
+func newTemplate(name string) (*x509.Certificate, error) {
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate serial: %w", err)
+	}
+	return &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: name, Organization: []string{"commons-test"}},
+		NotBefore:    time.Now().Add(-time.Minute),
+		NotAfter:     time.Now().Add(Validity),
+	}, nil
+}
+
+func encode(kind string, der []byte) []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: kind, Bytes: der})
+}
+
+// Pool returns a cert pool containing only the CA
+func (ca *CA) Pool() *x509.CertPool {
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.Cert)
+	return pool
+}
+
+// TLSConfig returns a client config trusting the CA
+func (ca *CA) TLSConfig() *gotls.Config {
+	return &gotls.Config{RootCAs: ca.Pool(), MinVersion: gotls.VersionTLS12}
+}
+
+// HTTPClient returns a client trusting the CA
+func (ca *CA) HTTPClient() *http.Client {
+	return newHTTPClient(ca.TLSConfig())
+}
+
+// WriteFile writes the CA certificate to dir/ca.crt, returning its path
+func (ca *CA) WriteFile(dir string) (string, error) {
+	path := filepath.Join(dir, CAFile)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(path, ca.CertPEM, 0644); err != nil {
+		return "", fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return path, nil
+}
+
+// KeyPair returns the certificate and key for a tls.Config
+func (c *Certificate) KeyPair() (gotls.Certificate, error) {
+	return gotls.X509KeyPair(c.CertPEM, c.KeyPEM)
+}
+
+// ServerConfig returns a server config presenting the certificate, requiring client certificates signed
+// by the CA when mutual is true
+func (c *Certificate) ServerConfig(mutual bool) (*gotls.Config, error) {
+	pair, err := c.KeyPair()
+	if err != nil {
+		return nil, err
+	}
+	config := &gotls.Config{Certificates: []gotls.Certificate{pair}, MinVersion: gotls.VersionTLS12}
+	if mutual {
+		config.ClientCAs = c.CA.Pool()
+		config.ClientAuth = gotls.RequireAndVerifyClientCert
+	}
+	return config, nil
+}
+
+// HTTPClient returns a client trusting the CA and presenting the certificate, for mutual TLS
+func (c *Certificate) HTTPClient() (*http.Client, error) {
+	pair, err := c.KeyPair()
+	if err != nil {
+		return nil, err
+	}
+	config := c.CA.TLSConfig()
+	config.Certificates = []gotls.Certificate{pair}
+	return newHTTPClient(config), nil
+}
+
+// WriteFiles writes tls.crt, tls.key and ca.crt to dir, all world-readable so they can be mounted into
+// containers running as any user
+func (c *Certificate) WriteFiles(dir string) error {
+	if _, err := c.CA.WriteFile(dir); err != nil {
+		return err
+	}
+	for name, data := range map[string][]byte{CertFile: c.CertPEM, KeyFile: c.KeyPEM} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", path, err)
+		}
+	}
+	return nil
+}
+
+func newHTTPClient(config *gotls.Config) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = config
+	return &http.Client{Transport: transport, Timeout: 30 * time.Second}
+}
@@ -0,0 +1,51 @@
+package tls
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMutualTLS(t *testing.T) {
+	ca, err := NewCA("test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server, err := ca.Server("app.example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(server.Cert.DNSNames) != 2 || len(server.Cert.IPAddresses) != 2 {
+		t.Errorf("unexpected SANs %v %v", server.Cert.DNSNames, server.Cert.IPAddresses)
+	}
+
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.TLS.PeerCertificates[0].Subject.CommonName))
+	}))
+	if ts.TLS, err = server.ServerConfig(true); err != nil {
+		t.Fatal(err)
+	}
+	ts.StartTLS()
+	defer ts.Close()
+
+	if _, err := ca.HTTPClient().Get(ts.URL); err == nil {
+		t.Error("expected a client without a certificate to be rejected")
+	}
+
+	cert, err := ca.Client("tester")
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, err := cert.HTTPClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := client.Get(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected 200, got %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,105 @@
+// Package tracing records OpenTelemetry spans for command executions, helm/kubectl calls and
+// mission-control HTTP requests, and exports them over OTLP when an endpoint is configured, so slow
+// suites can be profiled in a trace viewer.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ScopeName is the instrumentation scope of every span recorded by commons-test
+const ScopeName = "github.com/flanksource/commons-test"
+
+// DefaultServiceName is the service.name of exported spans when OTEL_SERVICE_NAME is not set
+const DefaultServiceName = "commons-test"
+
+// Enabled returns true when an OTLP endpoint is configured with OTEL_EXPORTER_OTLP_ENDPOINT or
+// OTEL_EXPORTER_OTLP_TRACES_ENDPOINT
+func Enabled() bool {
+	return os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") != "" || os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT") != ""
+}
+
+// Setup installs a global tracer provider exporting to the configured OTLP endpoint, over gRPC when
+// OTEL_EXPORTER_OTLP_PROTOCOL=grpc and HTTP otherwise. The exporter reads the standard OTEL_EXPORTER_OTLP_*
+// variables. Call the returned func at the end of the run to flush buffered spans. Setup does nothing
+// when no endpoint is configured.
+//
+//	shutdown, err := tracing.Setup(ctx)
+//	Expect(err).NotTo(HaveOccurred())
+//	DeferCleanup(shutdown)
+func Setup(ctx context.Context) (shutdown func(context.Context) error, err error) {
+	noop := func(context.Context) error { return nil }
+	if !Enabled() {
+		return noop, nil
+	}
+
+	var client otlptrace.Client
+	if protocol := os.Getenv("OTEL_EXPORTER_OTLP_PROTOCOL"); protocol == "grpc" {
+		client = otlptracegrpc.NewClient()
+	} else {
+		client = otlptracehttp.NewClient()
+	}
+	exporter, err := otlptrace.New(ctx, client)
+	if err != nil {
+		return noop, fmt.Errorf("failed to create OTLP exporter: %w", err)
+	}
+
+	serviceName := os.Getenv("OTEL_SERVICE_NAME")
+	if serviceName == "" {
+		serviceName = DefaultServiceName
+	}
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(attribute.String("service.name", serviceName)))
+	if err != nil {
+		return noop, fmt.Errorf("failed to create trace resource: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(sdktrace.WithBatcher(exporter), sdktrace.WithResource(res))
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	return provider.Shutdown, nil
+}
+
+// Start starts a span as a child of any span in ctx
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return otel.Tracer(ScopeName).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// StartExec starts a span for executing name with args
+func StartExec(ctx context.Context, name string, args []string) (context.Context, trace.Span) {
+	return Start(ctx, "exec "+name,
+		attribute.String("process.executable.name", name),
+		attribute.String("process.command_line", strings.TrimSpace(name+" "+strings.Join(args, " "))),
+	)
+}
+
+// EndExec ends a span started with StartExec, recording the exit code and error
+func EndExec(span trace.Span, exitCode int, err error) {
+	span.SetAttributes(attribute.Int("process.exit.code", exitCode))
+	End(span, err)
+}
+
+// End ends the span, marking it failed when err is not nil
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,143 @@
+package tracing_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/flanksource/commons-test/command"
+	"github.com/flanksource/commons-test/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// record installs a global tracer provider exporting synchronously to memory for the duration of the test
+func record(t *testing.T) *tracetest.InMemoryExporter {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	t.Cleanup(func() {
+		otel.SetTracerProvider(previous)
+		_ = provider.Shutdown(context.Background())
+	})
+	return exporter
+}
+
+func attributes(span tracetest.SpanStub) map[attribute.Key]attribute.Value {
+	attrs := map[attribute.Key]attribute.Value{}
+	for _, kv := range span.Attributes {
+		attrs[kv.Key] = kv.Value
+	}
+	return attrs
+}
+
+func TestStart(t *testing.T) {
+	exporter := record(t)
+
+	ctx, parent := tracing.Start(context.Background(), "suite", attribute.String("suite", "e2e"))
+	_, child := tracing.Start(ctx, "step")
+	tracing.End(child, nil)
+	tracing.End(parent, errors.New("step failed"))
+
+	spans := exporter.GetSpans()
+	if len(spans) != 2 {
+		t.Fatalf("expected 2 spans, got %d", len(spans))
+	}
+	step, suite := spans[0], spans[1]
+	if step.Name != "step" || suite.Name != "suite" {
+		t.Fatalf("unexpected spans %s and %s", step.Name, suite.Name)
+	}
+	if step.Parent.SpanID() != suite.SpanContext.SpanID() || step.SpanContext.TraceID() != suite.SpanContext.TraceID() {
+		t.Error("expected step to be a child of suite")
+	}
+	if step.InstrumentationScope.Name != tracing.ScopeName {
+		t.Errorf("unexpected scope %s", step.InstrumentationScope.Name)
+	}
+	if attributes(suite)["suite"].AsString() != "e2e" {
+		t.Errorf("expected suite attribute, got %v", suite.Attributes)
+	}
+	if step.Status.Code != codes.Unset {
+		t.Errorf("expected step status to be unset, got %v", step.Status)
+	}
+	if suite.Status.Code != codes.Error || suite.Status.Description != "step failed" || len(suite.Events) != 1 {
+		t.Errorf("expected suite to record the error, got %v with %d events", suite.Status, len(suite.Events))
+	}
+}
+
+func TestStartExec(t *testing.T) {
+	exporter := record(t)
+
+	_, span := tracing.StartExec(context.Background(), "kubectl", []string{"get", "pods"})
+	tracing.EndExec(span, 1, errors.New("exit status 1"))
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("expected 1 span, got %d", len(spans))
+	}
+	attrs := attributes(spans[0])
+	if spans[0].Name != "exec kubectl" {
+		t.Errorf("unexpected span name %s", spans[0].Name)
+	}
+	if attrs["process.executable.name"].AsString() != "kubectl" {
+		t.Errorf("unexpected executable %v", attrs["process.executable.name"])
+	}
+	if attrs["process.command_line"].AsString() != "kubectl get pods" {
+		t.Errorf("unexpected command line %v", attrs["process.command_line"])
+	}
+	if attrs["process.exit.code"].AsInt64() != 1 {
+		t.Errorf("unexpected exit code %v", attrs["process.exit.code"])
+	}
+	if spans[0].Status.Code != codes.Error {
+		t.Errorf("expected an error status, got %v", spans[0].Status)
+	}
+}
+
+func TestCommandSpansNestUnderCaller(t *testing.T) {
+	exporter := record(t)
+
+	ctx, parent := tracing.Start(context.Background(), "test")
+	if result := command.NewCommandRunner(false).RunCommandQuietCtx(ctx, "echo", "hello"); result.Err != nil {
+		t.Fatal(result.Err)
+	}
+	tracing.End(parent, nil)
+
+	spans := exporter.GetSpans()
+	if len(spans) != 2 {
+		t.Fatalf("expected 2 spans, got %d", len(spans))
+	}
+	if spans[0].Name != "exec echo" || spans[0].Parent.SpanID() != spans[1].SpanContext.SpanID() {
+		t.Errorf("expected exec echo to be a child of test, got %s with parent %s", spans[0].Name, spans[0].Parent.SpanID())
+	}
+	if code := attributes(spans[0])["process.exit.code"].AsInt64(); code != 0 {
+		t.Errorf("unexpected exit code %d", code)
+	}
+}
+
+func TestSetupWithoutEndpoint(t *testing.T) {
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "")
+	t.Setenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", "")
+	if tracing.Enabled() {
+		t.Fatal("expected tracing to be disabled without an endpoint")
+	}
+
+	previous := otel.GetTracerProvider()
+	shutdown, err := tracing.Setup(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if otel.GetTracerProvider() != previous {
+		t.Error("expected Setup to leave the tracer provider alone without an endpoint")
+	}
+
+	t.Setenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", "http://localhost:4318")
+	if !tracing.Enabled() {
+		t.Error("expected tracing to be enabled with a traces endpoint")
+	}
+}
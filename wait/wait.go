@@ -0,0 +1,126 @@
+// Package wait polls conditions with exponential backoff and jitter, returning timeout errors that
+// describe the last state observed.
+package wait
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"time"
+)
+
+var (
+	// Factor multiplies the interval after each attempt
+	Factor = 2.0
+	// MaxInterval caps the interval between attempts, unless the initial interval is larger
+	MaxInterval = 10 * time.Second
+	// Jitter randomizes each interval by up to this fraction, so parallel waiters don't poll in lockstep
+	Jitter = 0.2
+)
+
+// Condition reports whether the awaited state has been reached. An error means it has not been
+// reached yet and describes what was observed instead, e.g. "2/3 replicas ready". Wrap an error
+// with Stop to give up immediately.
+type Condition func(ctx context.Context) (bool, error)
+
+// NoError returns a condition that is met once check succeeds
+func NoError(check func(ctx context.Context) error) Condition {
+	return func(ctx context.Context) (bool, error) {
+		if err := check(ctx); err != nil {
+			return false, err
+		}
+		return true, nil
+	}
+}
+
+type stopError struct {
+	err error
+}
+
+func (e stopError) Error() string { return e.err.Error() }
+func (e stopError) Unwrap() error { return e.err }
+
+// Stop marks err as permanent, e.g. when a container exited, so For returns it without retrying
+func Stop(err error) error {
+	return stopError{err: err}
+}
+
+// IsStop returns true when err was marked permanent with Stop
+func IsStop(err error) bool {
+	var stop stopError
+	return errors.As(err, &stop)
+}
+
+// TimeoutError is returned when the condition is not met in time
+type TimeoutError struct {
+	Timeout  time.Duration
+	Attempts int
+	// Last is the error returned by the last attempt, nil when the condition simply returned false
+	Last error
+	// Err is context.DeadlineExceeded, or the parent context's error when it was done first
+	Err error
+}
+
+func (e *TimeoutError) Error() string {
+	msg := fmt.Sprintf("timed out after %v (%d attempts)", e.Timeout, e.Attempts)
+	if !errors.Is(e.Err, context.DeadlineExceeded) {
+		msg = fmt.Sprintf("%v after %d attempts", e.Err, e.Attempts)
+	}
+	if e.Last != nil {
+		return msg + ": " + e.Last.Error()
+	}
+	return msg + ": condition not met"
+}
+
+func (e *TimeoutError) Unwrap() []error {
+	return []error{e.Err, e.Last}
+}
+
+// For calls condition until it returns true, starting with interval between attempts and backing off
+// exponentially, until timeout expires or ctx is done. The condition is called at least once.
+func For(ctx context.Context, interval, timeout time.Duration, condition Condition) error {
+	return poll(ctx, interval, timeout, Factor, condition)
+}
+
+// Every is like For but keeps a fixed interval between attempts, for cheap local checks such as
+// dialing a port where backing off would only delay noticing the condition is met
+func Every(ctx context.Context, interval, timeout time.Duration, condition Condition) error {
+	return poll(ctx, interval, timeout, 1, condition)
+}
+
+func poll(ctx context.Context, interval, timeout time.Duration, factor float64, condition Condition) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	maxInterval := max(interval, MaxInterval)
+	var last error
+	for attempt := 1; ; attempt++ {
+		done, err := condition(ctx)
+		if done {
+			return nil
+		}
+		var stop stopError
+		if errors.As(err, &stop) {
+			return stop.err
+		}
+		// Errors caused by the timeout itself hide the state observed before it
+		if err != nil && (ctx.Err() == nil || last == nil) {
+			last = err
+		}
+
+		select {
+		case <-ctx.Done():
+			return &TimeoutError{Timeout: timeout, Attempts: attempt, Last: last, Err: ctx.Err()}
+		case <-time.After(jitter(interval)):
+		}
+		interval = min(time.Duration(float64(interval)*factor), maxInterval)
+	}
+}
+
+func jitter(d time.Duration) time.Duration {
+	if Jitter <= 0 || d <= 0 {
+		return d
+	}
+	return time.Duration(float64(d) * (1 + Jitter*(2*rand.Float64()-1)))
+}
@@ -0,0 +1,67 @@
+package wait
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFor(t *testing.T) {
+	t.Run("met", func(t *testing.T) {
+		attempts := 0
+		err := For(context.Background(), time.Millisecond, time.Second, func(ctx context.Context) (bool, error) {
+			attempts++
+			return attempts == 3, nil
+		})
+		if err != nil || attempts != 3 {
+			t.Fatalf("expected success after 3 attempts, got %d: %v", attempts, err)
+		}
+	})
+
+	t.Run("timeout describes last state", func(t *testing.T) {
+		err := For(context.Background(), time.Millisecond, 50*time.Millisecond, NoError(func(ctx context.Context) error {
+			return fmt.Errorf("1/3 replicas ready")
+		}))
+		var timeout *TimeoutError
+		if !errors.As(err, &timeout) || !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("expected a timeout error, got %v", err)
+		}
+		if !strings.Contains(err.Error(), "1/3 replicas ready") {
+			t.Errorf("expected the last state in %q", err)
+		}
+	})
+
+	t.Run("stop", func(t *testing.T) {
+		exited := errors.New("container exited")
+		attempts := 0
+		err := For(context.Background(), time.Millisecond, time.Second, func(ctx context.Context) (bool, error) {
+			attempts++
+			return false, Stop(exited)
+		})
+		if err != exited || attempts != 1 {
+			t.Fatalf("expected to stop after 1 attempt, got %d: %v", attempts, err)
+		}
+	})
+}
+
+func TestEvery(t *testing.T) {
+	var last time.Time
+	var gaps []time.Duration
+	err := Every(context.Background(), 20*time.Millisecond, time.Second, func(ctx context.Context) (bool, error) {
+		if !last.IsZero() {
+			gaps = append(gaps, time.Since(last))
+		}
+		last = time.Now()
+		return len(gaps) == 4, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Backing off would make the last gap 8 times the first
+	if gaps[3] > 3*gaps[0] {
+		t.Errorf("expected a fixed interval, got %v", gaps)
+	}
+}